@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var agentsCmd = &cobra.Command{
+	Use:   "agents",
+	Short: "Show subagents installed from .viberules/agents",
+	Long: `Install the subagent definitions in .viberules/agents/*.md for every
+enabled target that supports them.
+
+Agent locations:
+- claude: .claude/agents/<name>.md
+
+Agents are installed when a target is added and removed with it. Run
+'viberules sync' after adding, editing or deleting an agent.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadInitializedConfig()
+		if err != nil {
+			return err
+		}
+		return showPromptDirs(config, "agents", core.GetAgentTargets())
+	},
+}
+
+// writeAgents installs the subagents of a target, if it reads any
+func writeAgents(config *Config, name string, overwrite bool) error {
+	dir, ok := core.FindAgentTarget(name)
+	if !ok || globalMode {
+		return nil
+	}
+	return writePromptDir(config, dir, overwrite)
+}
+
+// removeAgents removes the subagents installed for a target
+func removeAgents(config *Config, name string) error {
+	dir, ok := core.FindAgentTarget(name)
+	if !ok || globalMode {
+		return nil
+	}
+	return removePromptDir(config, dir)
+}
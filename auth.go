@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// HostAuth is where the credential of one host comes from. It is read from
+// the user config only, so a project can't send credentials elsewhere.
+type HostAuth struct {
+	Username     string `yaml:"username,omitempty"`      // sent with the token as basic auth, as some registries need
+	Token        string `yaml:"token,omitempty"`         // the token itself
+	TokenEnv     string `yaml:"token_env,omitempty"`     // environment variable holding the token, such as GITHUB_TOKEN
+	TokenCommand string `yaml:"token_command,omitempty"` // command printing the token, such as 'gh auth token'
+	Keychain     bool   `yaml:"keychain,omitempty"`      // read the token from the system keychain
+}
+
+// validate checks that the entry names exactly one source of the token
+func (a HostAuth) validate() error {
+	sources := 0
+	for _, set := range []bool{a.Token != "", a.TokenEnv != "", a.TokenCommand != "", a.Keychain} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("set one of token, token_env, token_command or keychain")
+	}
+	return nil
+}
+
+// credential returns the token the entry points at
+func (a HostAuth) credential(host string) (core.Credential, error) {
+	var credential core.Credential
+	var err error
+	switch {
+	case a.TokenEnv != "":
+		credential = core.Credential{Token: os.Getenv(a.TokenEnv), Source: a.TokenEnv}
+		if credential.Token == "" {
+			err = fmt.Errorf("auth.%s.token_env: %s is not set", host, a.TokenEnv)
+		}
+	case a.TokenCommand != "":
+		credential, err = core.CommandCredential(commandContext, a.TokenCommand)
+	case a.Keychain:
+		credential, err = core.KeychainCredential(commandContext, host)
+	default:
+		credential = core.Credential{Token: a.Token, Source: "auth." + host + ".token in the user config"}
+	}
+	credential.Username = a.Username
+	return credential, err
+}
+
+// credentials finds the credential for host: in VIBERULES_TOKEN_<HOST>, then
+// the auth entry of the user config, then the netrc file. Failures to read a
+// configured credential are warned about and fetching goes on without it.
+func credentials(host string) (core.Credential, error) {
+	if credential := core.EnvCredential(host); credential.Token != "" {
+		logger.Debug("using credential from the environment", "host", host, "variable", credential.Source)
+		return credential, nil
+	}
+	user, err := loadUserConfig()
+	if err != nil {
+		return core.Credential{}, err
+	}
+	if auth, ok := user.Auth[host]; ok {
+		credential, err := auth.credential(host)
+		logger.Debug("read credential from the user config", "host", host, "source", credential.Source, "found", credential.Token != "")
+		if err != nil {
+			ui.Warn("⚠️  No credential for %s: %v\n", host, err)
+		}
+		return credential, err
+	}
+	return core.NetrcCredential(host)
+}
+
+// newNetFetcher returns a fetcher that authenticates with credentials
+func newNetFetcher() *core.NetFetcher {
+	fetcher := core.NewNetFetcher()
+	fetcher.Credentials = credentials
+	fetcher.Logger = logger
+	return fetcher
+}
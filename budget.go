@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// maxBudgetSections limits how many sections a budget warning attributes
+const maxBudgetSections = 5
+
+// budgetWarnings reports targets whose effective rules exceed their token budget,
+// listing the largest sections so users know what to trim
+func budgetWarnings(config *Config) []string {
+	var warnings []string
+	for _, target := range config.Targets {
+		settings := config.TargetSettings[target]
+		budget := settings.TokenBudget
+		if budget <= 0 {
+			continue
+		}
+
+		// Measure the rules before FitBudget so truncation and splitting are reported
+		strategy := config.strategyFor(target)
+		// Pointer files import the rules file as is
+		pipeline := config.buildPipeline(target, strategy, false)
+		if strategy == core.StrategyPointer {
+			pipeline = nil
+		}
+		content, err := engine.TargetContent(target, pipeline)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("⚠️  %s: cannot measure token budget: %v\n", target, err))
+			continue
+		}
+		tokens := core.EstimateTokens(content)
+		if tokens <= budget {
+			continue
+		}
+
+		if strategy == core.StrategyGenerate {
+			switch settings.Overflow {
+			case core.OverflowTruncate:
+				warnings = append(warnings, fmt.Sprintf("✂️  %s: rules use ~%d tokens; truncated to the budget of %d, omitting: %s\n",
+					target, tokens, budget, strings.Join(core.OmittedSections(content, budget), ", ")))
+				continue
+			case core.OverflowSplit:
+				warnings = append(warnings, fmt.Sprintf("ℹ️  %s: rules use ~%d tokens; split by section into %d files for the budget of %d\n",
+					target, tokens, len(core.ChunkSections(content, budget)), budget))
+				continue
+			}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "⚠️  %s: rules use ~%d tokens, over the budget of %d by ~%d\n", target, tokens, budget, tokens-budget)
+		fmt.Fprintln(&b, "   Largest sections:")
+		sections := core.SectionSizes(content)
+		for i, section := range sections {
+			if i == maxBudgetSections {
+				fmt.Fprintf(&b, "     ... %d more\n", len(sections)-i)
+				break
+			}
+			fmt.Fprintf(&b, "     ~%6d  %s\n", section.Tokens, section.Heading)
+		}
+		warnings = append(warnings, b.String())
+	}
+	return warnings
+}
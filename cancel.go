@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// commandTimeout is set by --timeout; zero means no limit
+var commandTimeout time.Duration
+
+// commandContext is done when the command is interrupted or runs past
+// --timeout. Remote fetches stop when it is, and recursive runs skip the
+// projects that are left.
+var commandContext = context.Background()
+
+// cancelCommand releases the timer started for --timeout
+var cancelCommand context.CancelFunc = func() {}
+
+// startCommandContext derives the context of cmd from the one it was
+// executed with, adding the --timeout deadline
+func startCommandContext(cmd *cobra.Command) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if commandTimeout > 0 {
+		ctx, cancelCommand = context.WithTimeout(ctx, commandTimeout)
+		cmd.SetContext(ctx)
+	}
+	commandContext = ctx
+}
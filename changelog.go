@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog [version] [to-version]",
+	Short: "Summarize rule changes between versions",
+	Long: `Show which sections of the rules file were added, removed or changed in
+each version, newest first, with the lines that changed.
+
+Versions come from the version: field of the rules frontmatter in the
+project's git history, including uncommitted edits; 'viberules publish'
+bumps it. A version's rules are the last ones committed with it.
+
+With one version, only that version is shown. With two, the changes from
+the first to the second are shown together.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showChangelog(args)
+	},
+}
+
+// rulesRelease is the rules file as of one version
+type rulesRelease struct {
+	version string
+	date    string
+	content []byte
+}
+
+func showChangelog(args []string) error {
+	if _, err := loadInitializedConfig(); err != nil {
+		return err
+	}
+	releases, err := rulesReleases()
+	if err != nil {
+		return err
+	}
+	if len(releases) == 0 {
+		ui.Info("No versions yet: add version: to the frontmatter of %s\n", engine.RulesFile())
+		return nil
+	}
+
+	find := func(version string) (int, error) {
+		for i, release := range releases {
+			if release.version == version {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("version %s is not in the history of %s", version, engine.RulesFile())
+	}
+	switch len(args) {
+	case 0:
+		for i := len(releases) - 1; i >= 0; i-- {
+			printRelease(releases, i)
+		}
+	case 1:
+		i, err := find(args[0])
+		if err != nil {
+			return err
+		}
+		printRelease(releases, i)
+	case 2:
+		from, err := find(args[0])
+		if err != nil {
+			return err
+		}
+		to, err := find(args[1])
+		if err != nil {
+			return err
+		}
+		ui.Print("## %s → %s\n\n", releases[from].version, releases[to].version)
+		printChanges(core.CompareRules(releases[from].content, releases[to].content))
+	}
+	return nil
+}
+
+// rulesReleases returns the versions of the rules file, oldest first
+func rulesReleases() ([]rulesRelease, error) {
+	history, err := core.GitFileHistory(projectDir(), engine.RulesFile())
+	if err != nil {
+		return nil, fmt.Errorf("changelog needs the git history of %s: %w", engine.RulesFile(), err)
+	}
+	if current, err := engine.FS().ReadFile(engine.RulesFile()); err == nil {
+		if len(history) == 0 || !bytes.Equal(history[len(history)-1].Content, current) {
+			history = append(history, core.FileRevision{Date: "uncommitted", Content: current})
+		}
+	}
+
+	var releases []rulesRelease
+	index := make(map[string]int)
+	for _, revision := range history {
+		version := core.RulesVersion(revision.Content)
+		if version == "" {
+			continue
+		}
+		release := rulesRelease{version: version, date: revision.Date, content: revision.Content}
+		if i, ok := index[version]; ok {
+			releases[i] = release
+			continue
+		}
+		index[version] = len(releases)
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+// printRelease prints the changes of releases[i] since the version before it
+func printRelease(releases []rulesRelease, i int) {
+	ui.Print("## %s (%s)\n\n", releases[i].version, releases[i].date)
+	if i == 0 {
+		printChanges(core.CompareRules(nil, releases[i].content))
+		return
+	}
+	printChanges(core.CompareRules(releases[i-1].content, releases[i].content))
+}
+
+func printChanges(changes []core.SectionChange) {
+	if len(changes) == 0 {
+		ui.Print("No rule changes\n\n")
+		return
+	}
+	labels := map[string]string{"added": "Added:  ", "removed": "Removed:", "changed": "Changed:"}
+	for _, change := range changes {
+		heading := change.Heading
+		if heading == "" {
+			heading = "(before the first heading)"
+		}
+		ui.Print("%s %s\n", labels[change.Kind], heading)
+		for _, line := range change.Added {
+			ui.Print("    + %s\n", line)
+		}
+		for _, line := range change.Removed {
+			ui.Print("    - %s\n", line)
+		}
+	}
+	ui.Print("\n")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/sky1core/viberules/core"
+)
+
+// claudeSettingsTarget is the target whose project settings viberules shares
+const claudeSettingsTarget = "claude"
+
+// writeClaudeSettings writes the shared Claude Code settings for the claude target and records their checksums
+func writeClaudeSettings(config *Config, name string, overwrite bool) error {
+	if name != claudeSettingsTarget || globalMode {
+		return nil
+	}
+
+	written, err := engine.WriteClaudeSettings(config.Checksums, overwrite)
+	if err != nil {
+		return err
+	}
+	for _, key := range core.ManagedClaudeSettings(config.Checksums) {
+		delete(config.Checksums, key)
+	}
+	if len(written) > 0 && config.Checksums == nil {
+		config.Checksums = make(map[string]string)
+	}
+	for key, sum := range written {
+		config.Checksums[key] = sum
+	}
+	return nil
+}
+
+// removeClaudeSettings removes the shared settings written for the claude target
+func removeClaudeSettings(config *Config, name string) error {
+	if name != claudeSettingsTarget || globalMode {
+		return nil
+	}
+	if err := engine.RemoveClaudeSettings(config.Checksums); err != nil {
+		return err
+	}
+	for _, key := range core.ManagedClaudeSettings(config.Checksums) {
+		delete(config.Checksums, key)
+	}
+	return nil
+}
+
+// collectClaudeSettingsStatus reports the state of the shared Claude Code settings
+func collectClaudeSettingsStatus(config *Config) ([]targetOutputStatus, error) {
+	if !containsName(config.Targets, claudeSettingsTarget) || globalMode {
+		return nil, nil
+	}
+
+	statuses, err := engine.CheckClaudeSettings(config.Checksums)
+	if err != nil {
+		return nil, err
+	}
+	var all []targetOutputStatus
+	for _, status := range statuses {
+		all = append(all, targetOutputStatus{OutputStatus: status, Strategy: "settings"})
+	}
+	return all, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var commandsCmd = &cobra.Command{
+	Use:   "commands",
+	Short: "Show slash commands installed from .viberules/commands",
+	Long: `Install the prompts in .viberules/commands/*.md as custom slash commands
+of every enabled target that supports them.
+
+Command locations:
+- claude: .claude/commands/<name>.md
+- cursor: .cursor/commands/<name>.md
+- gemini: .gemini/commands/<name>.toml
+
+Commands are installed when a target is added and removed with it. Run
+'viberules sync' after adding, editing or deleting a command.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showCommands()
+	},
+}
+
+// writePromptDir installs the prompts of a target into dir and records their checksums
+func writePromptDir(config *Config, dir core.PromptDir, overwrite bool) error {
+	written, err := engine.WritePromptDir(dir, config.Checksums, overwrite)
+	if err != nil {
+		return err
+	}
+	for _, path := range dir.Managed(config.Checksums) {
+		delete(config.Checksums, path)
+	}
+	if len(written) > 0 && config.Checksums == nil {
+		config.Checksums = make(map[string]string)
+	}
+	for path, sum := range written {
+		config.Checksums[path] = sum
+	}
+	return nil
+}
+
+// removePromptDir removes the prompts installed into dir
+func removePromptDir(config *Config, dir core.PromptDir) error {
+	if err := engine.RemovePromptDir(dir, config.Checksums); err != nil {
+		return err
+	}
+	for _, path := range dir.Managed(config.Checksums) {
+		delete(config.Checksums, path)
+	}
+	return nil
+}
+
+// collectPromptDirStatus reports the state of the prompts in dirs of enabled targets
+func collectPromptDirStatus(config *Config, dirs []core.PromptDir, strategy string) ([]targetOutputStatus, error) {
+	if globalMode {
+		return nil, nil
+	}
+
+	var all []targetOutputStatus
+	for _, dir := range dirs {
+		if !containsName(config.Targets, dir.Name) {
+			continue
+		}
+		statuses, err := engine.CheckPromptDir(dir, config.Checksums)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			all = append(all, targetOutputStatus{OutputStatus: status, Strategy: strategy})
+		}
+	}
+	return all, nil
+}
+
+// writeCommands installs the slash commands of a target, if it reads any
+func writeCommands(config *Config, name string, overwrite bool) error {
+	dir, ok := core.FindCommandTarget(name)
+	if !ok || globalMode {
+		return nil
+	}
+	return writePromptDir(config, dir, overwrite)
+}
+
+// removeCommands removes the slash commands installed for a target
+func removeCommands(config *Config, name string) error {
+	dir, ok := core.FindCommandTarget(name)
+	if !ok || globalMode {
+		return nil
+	}
+	return removePromptDir(config, dir)
+}
+
+// showPromptDirs lists the prompts of a source directory and the targets they are installed for
+func showPromptDirs(config *Config, kind string, dirs []core.PromptDir) error {
+	source := dirs[0].Source
+	names, err := engine.PromptSources(dirs[0])
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		ui.Print("No %s found. Add markdown files to %s to install them.\n", kind, source)
+		return nil
+	}
+
+	ui.Print("%s%s:\n", strings.ToUpper(kind[:1]), kind[1:])
+	for _, name := range names {
+		ui.Print("  %s\n", name)
+	}
+
+	ui.Print("\nInstalled in:\n")
+	for _, dir := range dirs {
+		if containsName(config.Targets, dir.Name) {
+			ui.Print("  ✅ %s (%s)\n", dir.Dir, dir.Name)
+		} else {
+			ui.Print("  ⬜ %s (%s)\n", dir.Dir, dir.Name)
+		}
+	}
+	return nil
+}
+
+func showCommands() error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	return showPromptDirs(config, "commands", core.GetCommandTargets())
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set options in .viberules/.config.yaml",
+	Long: `Read and write configuration without editing YAML by hand.
+
+Keys follow the YAML layout, joined with dots:
+  mode, targets, link_strategy, source, banner, inherit, output, trash, offline,
+  cache_ttl, git,
+  target_settings.<target>.<option> (link_strategy, token_budget, overflow, output, ...),
+  variables.<name>, profile_dirs.<dir>, user_links.<path>, groups.<name>,
+  policy.sections, shared.repo, shared.branch, shared.path, pack_index
+
+Lists such as targets are written comma-separated (claude,codex).
+Values shown by get and list include user config and environment overrides.
+
+Examples:
+  viberules config get mode
+  viberules config set target_settings.claude.token_budget 4000
+  viberules config unset variables.team
+  viberules config list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listConfig()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print the value of a config key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return getConfig(args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a config key",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setConfig(args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Remove a config key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setConfig(args[0], "")
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every config key and value",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listConfig()
+	},
+}
+
+// internalConfigKeys are written by viberules itself and can't be set
+var internalConfigKeys = []string{"checksums"}
+
+func getConfig(key string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	value, err := configValue(reflect.ValueOf(config).Elem(), splitConfigKey(key))
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	// Sections print their keys, as list does
+	if isConfigSection(value) {
+		for _, line := range flattenConfig(key, value) {
+			ui.Data(line)
+		}
+		return nil
+	}
+	ui.Data(formatConfigValue(value))
+	return nil
+}
+
+func listConfig() error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	for _, line := range flattenConfig("", reflect.ValueOf(config).Elem()) {
+		ui.Data(line)
+	}
+	return nil
+}
+
+// setConfig sets key to raw, or removes it when raw is empty. Keys with a
+// dedicated command go through it, so switching mode still scans for secrets,
+// switching strategy still recreates outputs and a new source moves the rules file.
+func setConfig(key, raw string) error {
+	path := splitConfigKey(key)
+	if containsName(internalConfigKeys, path[0]) {
+		return fmt.Errorf("%s is managed by viberules and can't be set", path[0])
+	}
+
+	switch {
+	case key == "mode" && raw != "":
+		return setModeCommand(raw)
+	case key == "source":
+		return setRulesSource(raw)
+	case key == "link_strategy" && raw != "":
+		return setStrategyCommand(raw, "")
+	case len(path) == 3 && path[0] == "target_settings" && path[2] == "link_strategy":
+		if raw == "" {
+			raw = "default"
+		}
+		return setStrategyCommand(raw, path[1])
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	root := reflect.ValueOf(config).Elem()
+	if raw == "" {
+		err = unsetConfigValue(root, path)
+	} else {
+		err = setConfigValue(root, path, raw)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if err := config.validate(); err != nil {
+		return err
+	}
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	if raw == "" {
+		ui.Success("Removed %s\n", key)
+	} else {
+		ui.Success("Set %s to %s\n", key, raw)
+	}
+	ui.Info("ℹ️  Run 'viberules sync' to update outputs\n")
+	return nil
+}
+
+// splitConfigKey splits a dotted key. Map keys that contain dots (file paths
+// such as user_links.CLAUDE.md) keep the rest of the key.
+func splitConfigKey(key string) []string {
+	path := strings.Split(key, ".")
+	switch path[0] {
+	case "user_links", "profile_dirs", "checksums":
+		if len(path) > 2 {
+			return []string{path[0], strings.Join(path[1:], ".")}
+		}
+	}
+	return path
+}
+
+// configField returns the struct field of v named by a YAML key
+func configField(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("yaml"), ",")[0]; tag == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// configValue returns the value at path below v
+func configValue(v reflect.Value, path []string) (reflect.Value, error) {
+	for _, name := range path {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if v.IsNil() {
+				return reflect.Value{}, fmt.Errorf("not set")
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := configField(v, name)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("unknown key")
+			}
+			v = field
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(name))
+			if !v.IsValid() {
+				return reflect.Value{}, fmt.Errorf("not set")
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("unknown key")
+		}
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return reflect.Value{}, fmt.Errorf("not set")
+	}
+	return v, nil
+}
+
+// setConfigValue parses raw into the value at path below v, creating maps as needed
+func setConfigValue(v reflect.Value, path []string, raw string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setConfigValue(v.Elem(), path, raw)
+	}
+	if len(path) == 0 {
+		return parseConfigValue(v, raw)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := configField(v, path[0])
+		if !ok {
+			return fmt.Errorf("unknown key")
+		}
+		return setConfigValue(field, path[1:], raw)
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf(path[0])
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if current := v.MapIndex(key); current.IsValid() {
+			elem.Set(current)
+		}
+		if err := setConfigValue(elem, path[1:], raw); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	return fmt.Errorf("unknown key")
+}
+
+// unsetConfigValue clears the value at path below v. Map entries and
+// sections left empty are removed.
+func unsetConfigValue(v reflect.Value, path []string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if len(path) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		return unsetConfigValue(v.Elem(), path)
+	case reflect.Struct:
+		field, ok := configField(v, path[0])
+		if !ok {
+			return fmt.Errorf("unknown key")
+		}
+		if len(path) == 1 {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		return unsetConfigValue(field, path[1:])
+	case reflect.Map:
+		key := reflect.ValueOf(path[0])
+		current := v.MapIndex(key)
+		if !current.IsValid() {
+			return nil
+		}
+		if len(path) > 1 {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(current)
+			if err := unsetConfigValue(elem, path[1:]); err != nil {
+				return err
+			}
+			if !elem.IsZero() {
+				v.SetMapIndex(key, elem)
+				return nil
+			}
+		}
+		v.SetMapIndex(key, reflect.Value{})
+		if v.Len() == 0 {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown key")
+}
+
+// parseConfigValue parses raw into v according to its type
+func parseConfigValue(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can't be set from the command line")
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Interface:
+		// Free-form values such as frontmatter fields keep their YAML type
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+			return fmt.Errorf("invalid value %q: %w", raw, err)
+		}
+		if value == nil {
+			value = raw
+		}
+		v.Set(reflect.ValueOf(&value).Elem())
+	default:
+		return fmt.Errorf("is a section; set one of its keys")
+	}
+	return nil
+}
+
+// isConfigSection reports whether v holds keys of its own
+func isConfigSection(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
+}
+
+// formatConfigValue formats a single value as config get prints it
+func formatConfigValue(v reflect.Value) string {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		var items []string
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, formatConfigValue(v.Index(i)))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// flattenConfig lists the keys below v as key=value lines, skipping unset
+// options and values managed by viberules
+func flattenConfig(prefix string, v reflect.Value) []string {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var lines []string
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || tag == "" || tag == "-" || containsName(internalConfigKeys, join(tag)) {
+				continue
+			}
+			if v.Field(i).IsZero() {
+				continue
+			}
+			lines = append(lines, flattenConfig(join(tag), v.Field(i))...)
+		}
+	case reflect.Map:
+		var keys []string
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, flattenConfig(join(key), v.MapIndex(reflect.ValueOf(key)))...)
+		}
+	default:
+		lines = append(lines, prefix+"="+formatConfigValue(v))
+	}
+	return lines
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Commands read, modify and save .viberules/.config.yaml. An advisory lock on
+// the .viberules directory, taken when the config is loaded and released once
+// it is saved, keeps concurrent invocations from losing each other's changes.
+// A process that only reads the config holds the lock until it exits.
+
+// configLocks holds the locked .viberules directories by absolute path
+var configLocks = map[string]*os.File{}
+
+// configLockTimeout bounds how long a command waits for another one to finish
+const configLockTimeout = 10 * time.Second
+
+// errConfigLocked is returned by tryLockFile when another process holds the lock
+var errConfigLocked = errors.New("config is locked")
+
+// lockConfig locks the config of the current project. It is a no-op when the
+// lock is already held or the project has no .viberules directory yet.
+func lockConfig() error {
+	dir, err := engine.FS().Abs(".viberules")
+	if err != nil {
+		return fmt.Errorf("failed to resolve .viberules: %w", err)
+	}
+	if _, held := configLocks[dir]; held {
+		return nil
+	}
+
+	f, err := os.Open(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+
+	deadline := time.Now().Add(configLockTimeout)
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errConfigLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errConfigLocked) {
+				return fmt.Errorf("timed out waiting for another viberules process to release %s", dir)
+			}
+			return fmt.Errorf("failed to lock %s: %w", dir, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	configLocks[dir] = f
+	return nil
+}
+
+// unlockConfig releases the config lock of the current project, if held
+func unlockConfig() {
+	dir, err := engine.FS().Abs(".viberules")
+	if err != nil {
+		return
+	}
+	if f, held := configLocks[dir]; held {
+		f.Close() // closing the descriptor releases the lock
+		delete(configLocks, dir)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// tryLockFile is a no-op where flock is unavailable; viberules refuses to run there anyway
+func tryLockFile(f *os.File) error {
+	return nil
+}
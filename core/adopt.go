@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// AdoptableFiles are the rules files assistants read that init --adopt
+// imports, in the order their sections appear in the rules file
+var AdoptableFiles = []string{
+	"CLAUDE.md",
+	"AGENTS.md",
+	"GEMINI.md",
+	".cursorrules",
+	filepath.Join(".github", "copilot-instructions.md"),
+}
+
+// AdoptFiles appends the content of each regular file in paths to rules,
+// under a heading naming the file. Paragraphs already present in rules or an
+// earlier file are left out, and a file with nothing new but headings adds
+// no section. Symlinks are skipped: they point at rules kept elsewhere.
+// It returns the merged rules and the paths that were read.
+func (e *Engine) AdoptFiles(rules []byte, paths []string) ([]byte, []string, error) {
+	seen := make(map[string]bool)
+	for _, block := range markdownBlocks(string(rules)) {
+		seen[block] = true
+	}
+
+	var out bytes.Buffer
+	out.Write(rules)
+	var adopted []string
+	for _, path := range paths {
+		info, err := e.fs.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := e.fs.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		adopted = append(adopted, path)
+
+		var blocks []string
+		headingsOnly := true
+		for _, block := range markdownBlocks(string(e.StripBanner(content, ""))) {
+			// Headings repeat across files without being duplicates
+			if seen[block] && !isHeading(block) {
+				continue
+			}
+			seen[block] = true
+			blocks = append(blocks, block)
+			headingsOnly = headingsOnly && isHeading(block)
+		}
+		if headingsOnly {
+			continue
+		}
+
+		if out.Len() > 0 {
+			if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+				out.WriteString("\n")
+			}
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "## Imported from %s\n\n%s\n", filepath.ToSlash(path), strings.Join(blocks, "\n\n"))
+	}
+	return out.Bytes(), adopted, nil
+}
+
+// LinkToRules replaces a missing path with a symlink to the rules file
+func (e *Engine) LinkToRules(path string) error {
+	source, err := filepath.Rel(filepath.Dir(path), e.rulesFile)
+	if err != nil {
+		return err
+	}
+	return e.createScopedSymlink(source, path)
+}
+
+// markdownBlocks splits markdown into trimmed paragraphs separated by blank
+// lines, keeping fenced code blocks whole
+func markdownBlocks(content string) []string {
+	var blocks []string
+	var current []string
+	inFence := false
+	flush := func() {
+		if block := strings.TrimSpace(strings.Join(current, "\n")); block != "" {
+			blocks = append(blocks, block)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if trimmed == "" && !inFence {
+			flush()
+			continue
+		}
+		current = append(current, strings.TrimRight(line, " \t\r"))
+	}
+	flush()
+	return blocks
+}
+
+// isHeading reports whether a block is a single markdown heading
+func isHeading(block string) bool {
+	return strings.HasPrefix(block, "#") && !strings.Contains(block, "\n")
+}
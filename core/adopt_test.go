@@ -0,0 +1,54 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAdoptFiles(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	files := map[string]string{
+		"CLAUDE.md":    "# Rules\n\nUse tabs.\n\n```go\nfunc a() {\n\n}\n```\n",
+		"AGENTS.md":    "# Rules\n\nUse tabs.\n\nRun go test before committing.\n\nKeep functions short.\n",
+		".cursorrules": "# Rules\n\nUse tabs.\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	// Symlinks already point at rules kept somewhere else
+	if err := os.Symlink("CLAUDE.md", "GEMINI.md"); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	merged, adopted, err := e.AdoptFiles([]byte("# Project\n\nRun go test before committing.\n"), AdoptableFiles)
+	if err != nil {
+		t.Fatalf("AdoptFiles() failed: %v", err)
+	}
+	if want := []string{"CLAUDE.md", "AGENTS.md", ".cursorrules"}; !reflect.DeepEqual(adopted, want) {
+		t.Errorf("adopted = %v, want %v", adopted, want)
+	}
+	want := "# Project\n\nRun go test before committing.\n\n" +
+		"## Imported from CLAUDE.md\n\n# Rules\n\nUse tabs.\n\n```go\nfunc a() {\n\n}\n```\n\n" +
+		"## Imported from AGENTS.md\n\n# Rules\n\nKeep functions short.\n"
+	if string(merged) != want {
+		t.Errorf("AdoptFiles() =\n%s\nwant\n%s", merged, want)
+	}
+}
+
+func TestLinkToRules(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	path := filepath.Join(".github", "copilot-instructions.md")
+	if err := e.LinkToRules(path); err != nil {
+		t.Fatalf("LinkToRules() failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "rules" {
+		t.Errorf("%s = %q, %v, want the rules", path, content, err)
+	}
+}
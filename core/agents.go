@@ -0,0 +1,24 @@
+package core
+
+import "path/filepath"
+
+// AgentsDir holds shared subagent definitions, one markdown file per agent
+var AgentsDir = filepath.Join(".viberules", "agents")
+
+// GetAgentTargets returns where targets read project subagents
+func GetAgentTargets() []PromptDir {
+	return []PromptDir{
+		{Name: "claude", Source: AgentsDir, Dir: filepath.Join(".claude", "agents"), Ext: ".md", render: renderAgent},
+	}
+}
+
+// FindAgentTarget returns the subagent location of a target
+func FindAgentTarget(name string) (PromptDir, bool) {
+	return findPromptDir(GetAgentTargets(), name)
+}
+
+// renderAgent keeps the source as is: Claude reads the agent's frontmatter
+// (name, description, tools) and system prompt from the same markdown
+func renderAgent(content []byte) ([]byte, error) {
+	return content, nil
+}
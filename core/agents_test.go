@@ -0,0 +1,34 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAgents(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	agent := "---\nname: reviewer\ndescription: Reviews diffs\ntools: Read, Grep\n---\nYou review code.\n"
+	writeTestFile(t, filepath.Join(AgentsDir, "reviewer.md"), agent)
+	claude, _ := FindAgentTarget("claude")
+
+	recorded, err := e.WritePromptDir(claude, nil, false)
+	if err != nil {
+		t.Fatalf("WritePromptDir failed: %v", err)
+	}
+	path := filepath.Join(".claude", "agents", "reviewer.md")
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != agent {
+		t.Errorf("%s = %q, want the source agent", path, content)
+	}
+
+	if err := e.RemovePromptDir(claude, recorded); err != nil {
+		t.Fatalf("RemovePromptDir failed: %v", err)
+	}
+	if fileExistsForTest(path) {
+		t.Error("Installed agent should be removed")
+	}
+	if _, ok := FindAgentTarget("gemini"); ok {
+		t.Error("gemini has no subagents")
+	}
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"fmt"
+	"os"
+)
+
+// WriteFileAtomic replaces the file at path in f with content so readers see either
+// the old or the new file, never a partial write: on disk, content goes to a
+// temporary file in the same directory, which is then renamed over path.
+// An existing file keeps its permissions, and a read-only file is not replaced.
+func WriteFileAtomic(f FS, path string, content []byte, perm os.FileMode) error {
+	// Write through symlinks instead of replacing them
+	if resolved, err := f.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := f.Stat(path); err == nil {
+		if info.Mode().Perm()&0200 == 0 {
+			return fmt.Errorf("failed to write %s: %w", path, os.ErrPermission)
+		}
+		perm = info.Mode().Perm()
+	}
+
+	if r, ok := f.(replacer); ok {
+		return r.ReplaceFile(path, content, perm)
+	}
+	if err := f.WriteFile(path, content, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
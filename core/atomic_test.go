@@ -0,0 +1,59 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	e := New(OSFS{})
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if err := WriteFileAtomic(e.FS(), path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic(FS(), ) failed: %v", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+	if err := WriteFileAtomic(e.FS(), path, []byte("v2"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic(FS(), ) over existing file failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "v2" {
+		t.Errorf("content = %q, %v; want v2", content, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("permissions = %v, want the existing 0600", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	// Read-only files are not replaced
+	if err := os.Chmod(path, 0444); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+	if err := WriteFileAtomic(e.FS(), path, []byte("v3"), 0644); err == nil {
+		t.Error("WriteFileAtomic(FS(), ) should refuse to replace a read-only file")
+	}
+
+	// Symlinks are written through
+	link := filepath.Join(dir, "link.yaml")
+	target := filepath.Join(dir, "target.yaml")
+	writeTestFile(t, target, "old")
+	if err := os.Symlink("target.yaml", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := WriteFileAtomic(e.FS(), link, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic(FS(), ) through symlink failed: %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "new" {
+		t.Errorf("symlink target = %q, want new", content)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink should be kept")
+	}
+}
@@ -0,0 +1,275 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Credential authenticates requests to one host
+type Credential struct {
+	Username string // sent with Token as basic auth; empty sends Token as a bearer token
+	Token    string
+	Source   string // where the credential came from, for error messages
+}
+
+// Credentials returns the credential for a host, or a zero Credential when
+// there is none
+type Credentials func(host string) (Credential, error)
+
+// DefaultCredentials looks for a host's credential in its environment
+// variable (see TokenEnvName), then in the netrc file
+func DefaultCredentials(host string) (Credential, error) {
+	if credential := EnvCredential(host); credential.Token != "" {
+		return credential, nil
+	}
+	return NetrcCredential(host)
+}
+
+// TokenEnvName returns the environment variable holding the token of host:
+// VIBERULES_TOKEN_ followed by the host in upper case with every character
+// other than letters and digits replaced by _, as in VIBERULES_TOKEN_GITHUB_COM
+func TokenEnvName(host string) string {
+	name := []byte(strings.ToUpper(host))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	return "VIBERULES_TOKEN_" + string(name)
+}
+
+// EnvCredential returns the token in the environment variable of host
+func EnvCredential(host string) Credential {
+	name := TokenEnvName(host)
+	return Credential{Token: os.Getenv(name), Source: name}
+}
+
+// NetrcCredential returns the login and password for host, or the default
+// entry, in $NETRC or ~/.netrc (_netrc on Windows)
+func NetrcCredential(host string) (Credential, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Credential{}, nil
+		}
+		path = filepath.Join(home, ".netrc")
+		if runtime.GOOS == "windows" {
+			path = filepath.Join(home, "_netrc")
+		}
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Credential{}, nil
+	}
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var found, fallback Credential
+	var current *Credential
+	fields := strings.Fields(string(content))
+	for i := 0; i < len(fields); i++ {
+		value := ""
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		switch fields[i] {
+		case "machine":
+			current = nil
+			if value == host && found.Source == "" {
+				found.Source = path
+				current = &found
+			}
+			i++
+		case "default":
+			current = nil
+			if fallback.Source == "" {
+				fallback.Source = path
+				current = &fallback
+			}
+		case "login", "password", "account":
+			if current != nil && fields[i] == "login" {
+				current.Username = value
+			}
+			if current != nil && fields[i] == "password" {
+				current.Token = value
+			}
+			i++
+		case "macdef":
+			// Macros run to the end of the file in practice
+			i = len(fields)
+		}
+	}
+	if found.Token != "" {
+		return found, nil
+	}
+	if fallback.Token != "" {
+		return fallback, nil
+	}
+	return Credential{}, nil
+}
+
+// KeychainCredential reads the token of host from the system keychain: the
+// generic password with service viberules and the host as account on macOS,
+// or the secret with attributes service=viberules and host=<host> in the
+// Secret Service on Linux
+func KeychainCredential(ctx context.Context, host string) (Credential, error) {
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		args = []string{"security", "find-generic-password", "-s", "viberules", "-a", host, "-w"}
+	case "linux":
+		args = []string{"secret-tool", "lookup", "service", "viberules", "host", host}
+	default:
+		return Credential{}, fmt.Errorf("keychain credentials are not supported on %s; use token_command", runtime.GOOS)
+	}
+	token, err := runCredentialCommand(ctx, args)
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to read the token for %s from the keychain: %w", host, err)
+	}
+	return Credential{Token: token, Source: "keychain"}, nil
+}
+
+// CommandCredential runs command, split into words without a shell, and
+// returns its output as a token, as with 'gh auth token'
+func CommandCredential(ctx context.Context, command string) (Credential, error) {
+	token, err := runCredentialCommand(ctx, strings.Fields(command))
+	if err != nil {
+		return Credential{}, fmt.Errorf("token command '%s' failed: %w", command, err)
+	}
+	return Credential{Token: token, Source: "token command '" + command + "'"}, nil
+}
+
+func runCredentialCommand(ctx context.Context, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("no token in the output")
+	}
+	return token, nil
+}
+
+// apply authenticates req with the credential, if there is one
+func (c Credential) apply(req *http.Request) {
+	switch {
+	case c.Token == "":
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Token)
+	default:
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
+// gitEnv returns the environment for git commands that fetch from a remote:
+// they fail instead of prompting, ssh uses keys from the agent, and https
+// requests carry the credential. Tokens go in the environment rather than
+// the arguments, where other users could see them.
+func (c Credential) gitEnv() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" && os.Getenv("GIT_SSH") == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	if c.Token == "" {
+		return env
+	}
+	// Git hosts take tokens as the password of any user
+	username := c.Username
+	if username == "" {
+		username = "x-access-token"
+	}
+	header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+c.Token))
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	return append(env,
+		"GIT_CONFIG_COUNT="+strconv.Itoa(count+1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=http.extraHeader", count),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, header),
+	)
+}
+
+// gitHost returns the host of a git repository URL that takes tokens: https
+// URLs only, as ssh authenticates with the agent's keys
+func gitHost(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || u.Scheme != "https" {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// AuthHint tells how to give viberules access to host
+func AuthHint(host string) string {
+	return fmt.Sprintf("set %s, add auth.%s to the user config, or add the host to ~/.netrc", TokenEnvName(host), host)
+}
+
+// httpStatusError returns the error for an unsuccessful response to a request
+// for target, telling authentication failures and missing content apart
+func httpStatusError(target string, resp *http.Response, credential Credential) error {
+	host := resp.Request.URL.Hostname()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if credential.Token != "" {
+			return WithKind(ErrAuth, fmt.Errorf("%s: %s: access denied with the credential from %s", target, resp.Status, credential.Source))
+		}
+		return WithKind(ErrAuth, fmt.Errorf("%s: %s: %s needs credentials (%s)", target, resp.Status, host, AuthHint(host)))
+	case http.StatusNotFound:
+		return WithKind(ErrNotFound, fmt.Errorf("%s: %s", target, resp.Status))
+	}
+	return fmt.Errorf("%s: %s", target, resp.Status)
+}
+
+// Messages of git and ssh that mean the remote refused access or lacks the
+// content. Hosts answer "not found" for private repositories too, so those
+// mention access.
+var (
+	gitAuthMessages = []string{
+		"Authentication failed", "could not read Username", "could not read Password",
+		"terminal prompts disabled", "Permission denied (publickey", "Access denied", "returned error: 401",
+		"returned error: 403", "Host key verification failed",
+	}
+	gitNotFoundMessages = []string{
+		"Repository not found", "does not appear to be a git repository", "couldn't find remote ref",
+		"does not exist in", "exists on disk, but not in", "not our ref", "returned error: 404",
+	}
+)
+
+// classifyGitError marks a failed git command as an authentication failure or
+// missing content, judging by what git printed
+func classifyGitError(err error, msg string) error {
+	for _, pattern := range gitAuthMessages {
+		if strings.Contains(msg, pattern) {
+			return WithKind(ErrAuth, fmt.Errorf("%w (authentication failed: check that your SSH agent has a key for the host, or for https URLs a token is configured)", err))
+		}
+	}
+	for _, pattern := range gitNotFoundMessages {
+		if strings.Contains(msg, pattern) && pattern == "Repository not found" {
+			return WithKind(ErrNotFound, fmt.Errorf("%w (private repositories look missing without access)", err))
+		}
+		if strings.Contains(msg, pattern) {
+			return WithKind(ErrNotFound, err)
+		}
+	}
+	return err
+}
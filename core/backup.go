@@ -0,0 +1,74 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// BackupDir holds files viberules moved aside to put its outputs in their place
+var BackupDir = filepath.Join(".viberules", "backup")
+
+// NewBackupDir returns the directory files moved aside at now are kept in
+func NewBackupDir(now time.Time) string {
+	return filepath.Join(BackupDir, now.Format("20060102-150405"))
+}
+
+// UnmanagedOutputs returns the outputs of a target occupied by regular files
+// viberules did not create. Files with a recorded checksum are viberules
+// copies and are left to the link strategy.
+func (e *Engine) UnmanagedOutputs(targetName string, recorded map[string]string) ([]string, error) {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return nil, unknownTarget(targetName)
+	}
+	links, err := e.OutputLinks(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, link := range links {
+		path := filepath.Clean(link.Target)
+		info, err := e.fs.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() || recorded[path] != "" {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// BackupUnmanagedOutputs moves the files UnmanagedOutputs finds out of the
+// way of a target's outputs, into dir under their own paths. It returns the
+// paths that were moved.
+func (e *Engine) BackupUnmanagedOutputs(targetName string, recorded map[string]string, dir string) ([]string, error) {
+	paths, err := e.UnmanagedOutputs(targetName, recorded)
+	if err != nil {
+		return nil, err
+	}
+	var moved []string
+	for _, path := range paths {
+		if err := e.BackupFile(path, dir); err != nil {
+			return moved, err
+		}
+		moved = append(moved, path)
+	}
+	return moved, nil
+}
+
+// BackupFile moves path to the same relative path below dir
+func (e *Engine) BackupFile(path, dir string) error {
+	dest := filepath.Join(dir, path)
+	if err := e.fs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if _, err := e.fs.Lstat(dest); err == nil {
+		return fmt.Errorf("failed to back up %s: %s already exists", path, dest)
+	}
+	if err := e.fs.Rename(path, dest); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	e.observer.OnFileBackedUp(path, dest)
+	return nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBackupUnmanagedOutputs(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	if err := os.WriteFile("CLAUDE.md", []byte("# Hand-written\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+	dir := NewBackupDir(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+	if want := filepath.Join(".viberules", "backup", "20240501-123000"); dir != want {
+		t.Errorf("NewBackupDir() = %q, want %q", dir, want)
+	}
+
+	moved, err := e.BackupUnmanagedOutputs("claude", nil, dir)
+	if err != nil {
+		t.Fatalf("BackupUnmanagedOutputs() failed: %v", err)
+	}
+	if !reflect.DeepEqual(moved, []string{"CLAUDE.md"}) {
+		t.Errorf("moved = %v, want [CLAUDE.md]", moved)
+	}
+	if _, err := os.Lstat("CLAUDE.md"); !os.IsNotExist(err) {
+		t.Error("CLAUDE.md should have been moved")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "CLAUDE.md"))
+	if err != nil || string(content) != "# Hand-written\n" {
+		t.Errorf("backup content = %q, %v", content, err)
+	}
+
+	// Symlinks and recorded copies belong to viberules
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks() failed: %v", err)
+	}
+	if err := os.WriteFile("GEMINI.md", []byte("copy"), 0644); err != nil {
+		t.Fatalf("Failed to write GEMINI.md: %v", err)
+	}
+	recorded := map[string]string{"GEMINI.md": Checksum([]byte("copy"))}
+	for _, name := range []string{"claude", "gemini"} {
+		moved, err := e.BackupUnmanagedOutputs(name, recorded, dir)
+		if err != nil || len(moved) != 0 {
+			t.Errorf("BackupUnmanagedOutputs(%s) = %v, %v, want nothing moved", name, moved, err)
+		}
+	}
+
+	// An existing backup is never overwritten
+	if err := os.Remove("CLAUDE.md"); err != nil {
+		t.Fatalf("Failed to remove CLAUDE.md: %v", err)
+	}
+	if err := os.WriteFile("CLAUDE.md", []byte("# Second\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+	if _, err := e.BackupUnmanagedOutputs("claude", nil, dir); err == nil {
+		t.Error("BackupUnmanagedOutputs() replaced an existing backup")
+	}
+}
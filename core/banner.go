@@ -0,0 +1,68 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBanner returns the banner text used for "banner: default"
+func (e *Engine) DefaultBanner() string {
+	return fmt.Sprintf("GENERATED BY viberules — edit %s instead", filepath.ToSlash(e.rulesFile))
+}
+
+// bannerLine returns the markdown comment holding text
+func bannerLine(text string) []byte {
+	return []byte(fmt.Sprintf("<!-- %s -->\n", strings.TrimSpace(text)))
+}
+
+// splitFrontmatter returns a leading YAML frontmatter block and the rest of content
+func splitFrontmatter(content []byte) (frontmatter, body []byte) {
+	body = stripFrontmatter(content)
+	return content[:len(content)-len(body)], body
+}
+
+// AddBanner returns a transform that puts a banner comment at the top of the
+// output, after any frontmatter, so contributors edit the rules file instead.
+// "default" selects DefaultBanner(); an empty text adds nothing.
+func (e *Engine) AddBanner(text string) Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		if strings.TrimSpace(text) == "" {
+			return content, nil
+		}
+		if text == "default" {
+			text = e.DefaultBanner()
+		}
+		if strings.Contains(text, "-->") {
+			return nil, fmt.Errorf("banner must not contain \"-->\"")
+		}
+
+		frontmatter, body := splitFrontmatter(content)
+		banner := bannerLine(text)
+		if bytes.HasPrefix(body, banner) {
+			return content, nil
+		}
+
+		var out bytes.Buffer
+		out.Write(frontmatter)
+		out.Write(banner)
+		out.Write(body)
+		return out.Bytes(), nil
+	}
+}
+
+// StripBanner removes the banner AddBanner(text) or the default banner put at
+// the top of content, keeping any frontmatter
+func (e *Engine) StripBanner(content []byte, text string) []byte {
+	frontmatter, body := splitFrontmatter(content)
+	for _, banner := range []string{text, e.DefaultBanner()} {
+		if strings.TrimSpace(banner) == "" || banner == "default" {
+			continue
+		}
+		if line := bannerLine(banner); bytes.HasPrefix(body, line) {
+			return append(append([]byte(nil), frontmatter...), body[len(line):]...)
+		}
+	}
+	return content
+}
@@ -0,0 +1,40 @@
+package core
+
+import "testing"
+
+func TestAddBanner(t *testing.T) {
+	e := New(OSFS{})
+	banner := "<!-- " + e.DefaultBanner() + " -->\n"
+	tests := []struct {
+		name    string
+		text    string
+		content string
+		want    string
+	}{
+		{"off", "", "# Rules\n", "# Rules\n"},
+		{"default", "default", "# Rules\n", banner + "# Rules\n"},
+		{"custom", "Edit docs/ai.md", "# Rules\n", "<!-- Edit docs/ai.md -->\n# Rules\n"},
+		{"after frontmatter", "default", "---\nalwaysApply: true\n---\n# Rules\n", "---\nalwaysApply: true\n---\n" + banner + "# Rules\n"},
+		{"already present", "default", banner + "# Rules\n", banner + "# Rules\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.AddBanner(tt.text)(&RenderContext{Target: "claude"}, []byte(tt.content))
+			if err != nil {
+				t.Fatalf("AddBanner failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AddBanner = %q, want %q", got, tt.want)
+			}
+			if tt.text != "" {
+				if stripped := e.StripBanner(got, tt.text); string(stripped) != string(e.StripBanner([]byte(tt.content), tt.text)) {
+					t.Errorf("StripBanner = %q, want the content without banner", stripped)
+				}
+			}
+		})
+	}
+
+	if _, err := e.AddBanner("bad --> banner")(&RenderContext{}, []byte("# Rules\n")); err == nil {
+		t.Error("A banner closing the comment early should be rejected")
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken approximates how many characters make up one token for
+// English prose and code across common tokenizers
+const charsPerToken = 4
+
+// EstimateTokens approximates the number of tokens in content
+func EstimateTokens(content []byte) int {
+	return (utf8.RuneCount(content) + charsPerToken - 1) / charsPerToken
+}
+
+// SectionSize is the estimated size of a markdown section
+type SectionSize struct {
+	Heading string // heading line, or "(preamble)" for text before the first heading
+	Tokens  int
+}
+
+// SectionSizes splits content at markdown headings and returns the size of
+// each section, largest first. Headings inside fenced code blocks are ignored.
+func SectionSizes(content []byte) []SectionSize {
+	var sections []SectionSize
+	current := SectionSize{Heading: "(preamble)"}
+	var body strings.Builder
+	inFence := false
+
+	flush := func() {
+		current.Tokens = EstimateTokens([]byte(body.String()))
+		if current.Tokens > 0 {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(trimmed, "#") {
+			flush()
+			current = SectionSize{Heading: trimmed}
+		}
+		body.WriteString(line)
+	}
+	flush()
+
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Tokens > sections[j].Tokens })
+	return sections
+}
+
+// TargetContent returns the effective rules a target's primary output carries:
+// the rendered content for file-writing strategies, the rules file otherwise
+func (e *Engine) TargetContent(targetName string, pipeline Pipeline) ([]byte, error) {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return nil, unknownTarget(targetName)
+	}
+	if len(target.Links) == 0 {
+		return nil, nil
+	}
+	return e.RenderLink(pipeline, target.Name, target.Links[0])
+}
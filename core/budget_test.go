@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens([]byte("12345678")); got != 2 {
+		t.Errorf("EstimateTokens(8 chars) = %d, want 2", got)
+	}
+	if got := EstimateTokens([]byte("규칙")); got != 1 {
+		t.Errorf("EstimateTokens counts runes, got %d for 2 runes", got)
+	}
+}
+
+func TestSectionSizes(t *testing.T) {
+	content := "intro\n# Rules\n## Small\nshort\n## Large\n" + strings.Repeat("long line of rules\n", 10) +
+		"```sh\n# not a heading\n```\n"
+
+	sections := SectionSizes([]byte(content))
+	if len(sections) != 4 {
+		t.Fatalf("SectionSizes returned %d sections, want 4: %+v", len(sections), sections)
+	}
+	if sections[0].Heading != "## Large" {
+		t.Errorf("Largest section = %s, want ## Large", sections[0].Heading)
+	}
+
+	total := 0
+	for _, section := range sections {
+		total += section.Tokens
+	}
+	if estimate := EstimateTokens([]byte(content)); total < estimate-len(sections) || total > estimate+len(sections) {
+		t.Errorf("Section sizes add up to %d, want about %d", total, estimate)
+	}
+}
+
+func TestTargetContent(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	content, err := e.TargetContent("claude", Pipeline{e.AddTargetFiles(context.Background(), nil)})
+	if err != nil {
+		t.Fatalf("TargetContent failed: %v", err)
+	}
+	if string(content) != "# Rules\n" {
+		t.Errorf("TargetContent = %q, want rules content", content)
+	}
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheDir returns the directory name below the user cache directory
+// (~/.cache/viberules on Linux), or "" when there is none
+func cacheDir(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "viberules", name)
+}
+
+// ContentCache keeps fetched remote content on disk, named by its checksum.
+// Locked content is read from it instead of the network, so generation
+// works offline for everything fetched once.
+type ContentCache struct {
+	Dir string
+}
+
+// DefaultContentCache returns the cache in the user cache directory
+// (~/.cache/viberules/remote on Linux), or nil when there is none
+func DefaultContentCache() *ContentCache {
+	dir := cacheDir("remote")
+	if dir == "" {
+		return nil
+	}
+	return &ContentCache{Dir: dir}
+}
+
+// Get returns the content with checksum sum. Entries that don't match their
+// name, as after a partial write, count as missing.
+func (c *ContentCache) Get(sum string) ([]byte, bool) {
+	if c == nil || sum == "" {
+		return nil, false
+	}
+	content, err := os.ReadFile(filepath.Join(c.Dir, sum))
+	if err != nil || Checksum(content) != sum {
+		return nil, false
+	}
+	return content, true
+}
+
+// Put stores content. Failures only cost a later fetch, so they aren't fatal
+// and are returned for callers that care.
+func (c *ContentCache) Put(content []byte) error {
+	if c == nil {
+		return nil
+	}
+	sum := Checksum(content)
+	path := filepath.Join(c.Dir, sum)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return writeCacheEntry(c.Dir, path, content)
+}
+
+// writeCacheEntry writes an entry of the cache in dir that only the current
+// user can read, as cached content may come from private repositories.
+// Directories and entries left readable by earlier versions are tightened.
+func writeCacheEntry(dir, path string, content []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return fmt.Errorf("failed to restrict %s: %w", dir, err)
+	}
+	if err := WriteFileAtomic(OSFS{}, path, content, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// DefaultRefCacheDir is where CachingFetcher keeps its entries
+// (~/.cache/viberules/refs on Linux), or "" when there is no cache directory
+func DefaultRefCacheDir() string {
+	return cacheDir("refs")
+}
+
+// CachingFetcher remembers what Inner fetched for each reference, keyed by
+// its URL, path and ref, in Dir. Entries younger than TTL are reused without
+// asking Inner. Older ones are still used when Inner fails for reasons other
+// than access or missing content, so generation works on flaky networks.
+type CachingFetcher struct {
+	Inner   RevisionFetcher
+	Dir     string        // no caching when empty
+	TTL     time.Duration // no reuse of fresh entries when zero
+	Refresh bool          // always ask Inner, as after 'update --refresh'
+	Logger  *slog.Logger  // debug records of reuse; none when nil
+}
+
+// refEntry is a CachingFetcher entry
+type refEntry struct {
+	Ref      string    `json:"ref"`
+	Revision string    `json:"revision,omitempty"`
+	Fetched  time.Time `json:"fetched"`
+	Content  []byte    `json:"content"`
+}
+
+// Fetch implements Fetcher
+func (f *CachingFetcher) Fetch(ctx context.Context, ref RemoteRef) ([]byte, error) {
+	content, _, err := f.FetchRevision(ctx, ref)
+	return content, err
+}
+
+// FetchRevision implements RevisionFetcher
+func (f *CachingFetcher) FetchRevision(ctx context.Context, ref RemoteRef) ([]byte, string, error) {
+	if f.Dir == "" {
+		return f.Inner.FetchRevision(ctx, ref)
+	}
+	path := filepath.Join(f.Dir, Checksum([]byte(ref.String())))
+	entry, cached := readRefEntry(path, ref)
+	if cached && !f.Refresh && time.Since(entry.Fetched) < f.TTL {
+		logTo(f.Logger).Debug("using cached content", "ref", entry.Ref, "fetched", entry.Fetched)
+		return entry.Content, entry.Revision, nil
+	}
+
+	content, revision, err := f.Inner.FetchRevision(ctx, ref)
+	if err != nil {
+		if cached && !f.Refresh && !errors.Is(err, ErrAuth) && !errors.Is(err, ErrNotFound) && ctx.Err() == nil {
+			logTo(f.Logger).Debug("using expired cached content after a failed fetch", "ref", entry.Ref, "fetched", entry.Fetched, "error", err)
+			return entry.Content, entry.Revision, nil
+		}
+		return nil, "", err
+	}
+	if data, err := json.Marshal(refEntry{Ref: ref.String(), Revision: revision, Fetched: time.Now(), Content: content}); err == nil {
+		// Failing to cache only costs a later fetch
+		writeCacheEntry(f.Dir, path, data)
+	}
+	return content, revision, nil
+}
+
+// readRefEntry reads the entry at path, which must be for ref
+func readRefEntry(path string, ref RemoteRef) (refEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return refEntry{}, false
+	}
+	var entry refEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Ref != ref.String() {
+		return refEntry{}, false
+	}
+	return entry, true
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContentCache(t *testing.T) {
+	cache := &ContentCache{Dir: filepath.Join(t.TempDir(), "remote")}
+	content := []byte("# Shared\n")
+	sum := Checksum(content)
+
+	if _, ok := cache.Get(sum); ok {
+		t.Error("Get on an empty cache should miss")
+	}
+	if err := cache.Put(content); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if got, ok := cache.Get(sum); !ok || string(got) != string(content) {
+		t.Errorf("Get(%s) = %q, %v; want the content", sum, got, ok)
+	}
+	checkPrivate(t, cache.Dir, filepath.Join(cache.Dir, sum))
+
+	// A corrupted entry is a miss rather than wrong content
+	writeTestFile(t, filepath.Join(cache.Dir, sum), "# Other\n")
+	if _, ok := cache.Get(sum); ok {
+		t.Error("Get should miss entries that don't match their checksum")
+	}
+
+	var none *ContentCache
+	if err := none.Put(content); err != nil {
+		t.Errorf("Put on a nil cache = %v, want nil", err)
+	}
+	if _, ok := none.Get(sum); ok {
+		t.Error("Get on a nil cache should miss")
+	}
+}
+
+// checkPrivate fails the test unless dir and file are only accessible by
+// the current user
+func checkPrivate(t *testing.T, dir, file string) {
+	t.Helper()
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("%s should have mode 0700: %v, %v", dir, info, err)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("%s should have mode 0600: %v, %v", file, info, err)
+	}
+}
+
+func TestLockedFetcherOffline(t *testing.T) {
+	ref := RemoteRef{URL: "https://example.com/rules.md"}
+	other := RemoteRef{URL: "https://example.com/other.md"}
+	cache := &ContentCache{Dir: t.TempDir()}
+	lock := &Lockfile{}
+
+	inner := &fakeFetcher{content: map[string]string{ref.String(): "# Rules\n"}}
+	if _, err := (&LockedFetcher{Inner: inner, Lock: lock, Cache: cache}).Fetch(context.Background(), ref); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	offline := &NetFetcher{Offline: true}
+	content, err := (&LockedFetcher{Inner: offline, Lock: lock, Cache: cache}).Fetch(context.Background(), ref)
+	if err != nil || string(content) != "# Rules\n" {
+		t.Errorf("Offline Fetch of locked content = %q, %v; want it from the cache", content, err)
+	}
+	if _, err := (&LockedFetcher{Inner: offline, Lock: lock, Cache: cache}).Fetch(context.Background(), other); !errors.Is(err, ErrOffline) {
+		t.Errorf("Offline Fetch of unlocked content = %v, want ErrOffline", err)
+	}
+
+	if err := os.RemoveAll(cache.Dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&LockedFetcher{Inner: offline, Lock: lock, Cache: cache}).Fetch(context.Background(), ref); !errors.Is(err, ErrOffline) {
+		t.Errorf("Offline Fetch of uncached content = %v, want ErrOffline", err)
+	}
+}
+
+func TestCachingFetcher(t *testing.T) {
+	ref := RemoteRef{URL: "https://example.com/rules.md"}
+	inner := &fakeFetcher{content: map[string]string{ref.String(): "v1\n"}, revision: map[string]string{}}
+	fetcher := &CachingFetcher{Inner: inner, Dir: t.TempDir(), TTL: time.Hour}
+	fetch := func() string {
+		t.Helper()
+		content, err := fetcher.Fetch(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		return string(content)
+	}
+
+	fetch()
+	checkPrivate(t, fetcher.Dir, filepath.Join(fetcher.Dir, Checksum([]byte(ref.String()))))
+	inner.content[ref.String()] = "v2\n"
+	if got := fetch(); got != "v1\n" || len(inner.fetched) != 1 {
+		t.Errorf("Fetch within the TTL = %q after %d fetches, want the cached v1", got, len(inner.fetched))
+	}
+
+	fetcher.Refresh = true
+	if got := fetch(); got != "v2\n" {
+		t.Errorf("Fetch with Refresh = %q, want v2", got)
+	}
+
+	// Expired entries are fetched again, and still used when fetching fails
+	fetcher.Refresh = false
+	fetcher.TTL = 0
+	inner.content[ref.String()] = "v3\n"
+	if got := fetch(); got != "v3\n" {
+		t.Errorf("Fetch of an expired entry = %q, want v3", got)
+	}
+	delete(inner.content, ref.String())
+	if got := fetch(); got != "v3\n" {
+		t.Errorf("Fetch failing upstream = %q, want the cached v3", got)
+	}
+
+	// Missing content isn't hidden by the cache
+	fetcher.Inner = notFoundFetcher{}
+	if _, err := fetcher.Fetch(context.Background(), ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Fetch of removed content = %v, want ErrNotFound", err)
+	}
+}
+
+// notFoundFetcher reports every reference as missing
+type notFoundFetcher struct{}
+
+func (notFoundFetcher) Fetch(ctx context.Context, ref RemoteRef) ([]byte, error) {
+	return nil, WithKind(ErrNotFound, errors.New("gone"))
+}
+
+func (notFoundFetcher) FetchRevision(ctx context.Context, ref RemoteRef) ([]byte, string, error) {
+	return nil, "", WithKind(ErrNotFound, errors.New("gone"))
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// versionLine matches the version field of a frontmatter block
+var versionLine = regexp.MustCompile(`(?m)^version:.*$`)
+
+// versionNumbers matches the numeric components of a version, after an optional prefix such as v
+var versionNumbers = regexp.MustCompile(`^([^0-9]*)([0-9]+(?:\.[0-9]+)*)$`)
+
+// RulesVersion returns the version stamped in the frontmatter of a rules file,
+// or an empty string when there is none
+func RulesVersion(content []byte) string {
+	frontmatter, _ := splitFrontmatter(content)
+	if len(frontmatter) == 0 {
+		return ""
+	}
+	// A node keeps the version as written: 1.10 isn't the number 1.1
+	var fields struct {
+		Version yaml.Node `yaml:"version"`
+	}
+	block := bytes.TrimSuffix(bytes.TrimPrefix(frontmatter, []byte("---\n")), []byte("---\n"))
+	if yaml.Unmarshal(block, &fields) != nil || fields.Version.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return fields.Version.Value
+}
+
+// SetRulesVersion returns content with version stamped in its frontmatter,
+// keeping the other fields as they are written
+func SetRulesVersion(content []byte, version string) []byte {
+	line := "version: " + version
+	frontmatter, body := splitFrontmatter(content)
+	if len(frontmatter) == 0 {
+		return append([]byte("---\n"+line+"\n---\n"), content...)
+	}
+	if versionLine.Match(frontmatter) {
+		frontmatter = versionLine.ReplaceAllLiteral(frontmatter, []byte(line))
+	} else {
+		frontmatter = append([]byte("---\n"+line+"\n"), frontmatter[len("---\n"):]...)
+	}
+	return append(frontmatter, body...)
+}
+
+// BumpVersion increments a dotted version at level: major, minor or patch.
+// Missing components count as zero and those after the bumped one are reset,
+// so 1.4 bumped at patch level is 1.4.1 and v2.3.1 at minor level is v2.4.0.
+func BumpVersion(version, level string) (string, error) {
+	match := versionNumbers.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("cannot bump version %q: not a dotted number", version)
+	}
+	index := map[string]int{"major": 0, "minor": 1, "patch": 2}
+	at, ok := index[level]
+	if !ok {
+		return "", fmt.Errorf("invalid version bump %q (use major, minor or patch)", level)
+	}
+
+	parts := strings.Split(match[2], ".")
+	for len(parts) <= at {
+		parts = append(parts, "0")
+	}
+	n, _ := strconv.Atoi(parts[at])
+	parts[at] = strconv.Itoa(n + 1)
+	for i := at + 1; i < len(parts); i++ {
+		parts[i] = "0"
+	}
+	return match[1] + strings.Join(parts, "."), nil
+}
+
+// SectionChange is a section of a rules file that differs between two versions
+type SectionChange struct {
+	Heading string   // heading line; empty for the text before the first heading
+	Kind    string   // added, removed or changed
+	Added   []string // lines only the newer version has, for changed sections
+	Removed []string // lines only the older version has, for changed sections
+}
+
+// CompareRules lists the sections added, removed or changed from older to
+// newer, in the order of newer with removed sections last. Frontmatter is
+// left out.
+func CompareRules(older, newer []byte) []SectionChange {
+	oldSections := splitSections(string(stripFrontmatter(older)))
+	newSections := splitSections(string(stripFrontmatter(newer)))
+	oldIndex := indexSections(oldSections)
+	newIndex := indexSections(newSections)
+
+	var changes []SectionChange
+	for _, section := range newSections {
+		before, ok := oldIndex[section.key]
+		switch {
+		case !ok:
+			changes = append(changes, SectionChange{Heading: section.key, Kind: "added"})
+		case before.body != section.body:
+			added, removed := diffLines(before.body, section.body)
+			changes = append(changes, SectionChange{Heading: section.key, Kind: "changed", Added: added, Removed: removed})
+		}
+	}
+	for _, section := range oldSections {
+		if _, ok := newIndex[section.key]; !ok {
+			changes = append(changes, SectionChange{Heading: section.key, Kind: "removed"})
+		}
+	}
+	return changes
+}
+
+// diffLines returns the non-blank lines only in newer and only in older
+func diffLines(older, newer string) (added, removed []string) {
+	count := func(s string) map[string]int {
+		counts := make(map[string]int)
+		for _, line := range strings.Split(s, "\n") {
+			counts[line]++
+		}
+		return counts
+	}
+	oldCounts, newCounts := count(older), count(newer)
+	for _, line := range strings.Split(newer, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if oldCounts[line] > 0 {
+			oldCounts[line]--
+			continue
+		}
+		added = append(added, line)
+	}
+	for _, line := range strings.Split(older, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if newCounts[line] > 0 {
+			newCounts[line]--
+			continue
+		}
+		removed = append(removed, line)
+	}
+	return added, removed
+}
+
+// FileRevision is the content of a file at one commit
+type FileRevision struct {
+	Commit  string
+	Date    string // commit date, YYYY-MM-DD
+	Content []byte
+}
+
+// GitFileHistory returns the committed versions of the file at path, relative
+// to dir, oldest first
+func GitFileHistory(dir, path string) ([]FileRevision, error) {
+	out, err := runGit(dir, "log", "--reverse", "--format=%H %cs", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var revisions []FileRevision
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		commit, date, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		// Commits that deleted the file have no content to compare
+		content, err := runGit(dir, "show", commit+":./"+strings.TrimPrefix(path, "./"))
+		if err != nil {
+			continue
+		}
+		revisions = append(revisions, FileRevision{Commit: commit, Date: date, Content: content})
+	}
+	return revisions, nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRulesVersion(t *testing.T) {
+	tests := []struct {
+		rules string
+		want  string
+	}{
+		{"# Rules\n", ""},
+		{"---\nversion: 1.10\n---\n# Rules\n", "1.10"},
+		{"---\ndescription: x\nversion: v2.0.1\n---\n", "v2.0.1"},
+		{"---\nversion:\n  - 1\n---\n", ""},
+	}
+	for _, tt := range tests {
+		if got := RulesVersion([]byte(tt.rules)); got != tt.want {
+			t.Errorf("RulesVersion(%q) = %q, want %q", tt.rules, got, tt.want)
+		}
+	}
+
+	set := []struct {
+		rules string
+		want  string
+	}{
+		{"# Rules\n", "---\nversion: 1.1\n---\n# Rules\n"},
+		{"---\nversion: 1.0\ndescription: x\n---\n# Rules\n", "---\nversion: 1.1\ndescription: x\n---\n# Rules\n"},
+		{"---\ndescription: x\n---\n# Rules\n", "---\nversion: 1.1\ndescription: x\n---\n# Rules\n"},
+	}
+	for _, tt := range set {
+		if got := SetRulesVersion([]byte(tt.rules), "1.1"); string(got) != tt.want {
+			t.Errorf("SetRulesVersion(%q) = %q, want %q", tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version, level, want string
+	}{
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.4", "patch", "1.4.1"},
+		{"v2.3.1", "minor", "v2.4.0"},
+		{"3", "major", "4"},
+		{"1.9", "minor", "1.10"},
+	}
+	for _, tt := range tests {
+		if got, err := BumpVersion(tt.version, tt.level); err != nil || got != tt.want {
+			t.Errorf("BumpVersion(%q, %q) = %q, %v; want %q", tt.version, tt.level, got, err, tt.want)
+		}
+	}
+	if _, err := BumpVersion("next", "patch"); err == nil {
+		t.Error("BumpVersion should fail for a version without numbers")
+	}
+	if _, err := BumpVersion("1.0", "huge"); err == nil {
+		t.Error("BumpVersion should fail for an unknown level")
+	}
+}
+
+func TestCompareRules(t *testing.T) {
+	old := "---\nversion: 1.0\n---\n# Rules\n\n## Style\n\nUse tabs.\nKeep lines short.\n\n## Old\n\nGone.\n"
+	newer := "---\nversion: 1.1\n---\n# Rules\n\n## Style\n\nUse gofmt.\nKeep lines short.\n\n## Security\n\nNo secrets.\n"
+	changes := CompareRules([]byte(old), []byte(newer))
+	if len(changes) != 3 {
+		t.Fatalf("CompareRules() = %+v, want 3 changes", changes)
+	}
+	style := changes[0]
+	if style.Heading != "## Style" || style.Kind != "changed" ||
+		len(style.Added) != 1 || style.Added[0] != "Use gofmt." || len(style.Removed) != 1 || style.Removed[0] != "Use tabs." {
+		t.Errorf("changes[0] = %+v, want Style changed from tabs to gofmt", style)
+	}
+	if changes[1].Heading != "## Security" || changes[1].Kind != "added" {
+		t.Errorf("changes[1] = %+v, want Security added", changes[1])
+	}
+	if changes[2].Heading != "## Old" || changes[2].Kind != "removed" {
+		t.Errorf("changes[2] = %+v, want Old removed", changes[2])
+	}
+
+	// The version stamp alone is no rule change
+	if changes := CompareRules([]byte(old), SetRulesVersion([]byte(old), "2.0")); len(changes) != 0 {
+		t.Errorf("CompareRules(version bump) = %+v, want none", changes)
+	}
+}
+
+func TestGitFileHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "--quiet")
+	for _, content := range []string{"v1\n", "v2\n"} {
+		writeTestFile(t, filepath.Join(repo, "sub", "rules.md"), content)
+		run("add", ".")
+		run("commit", "--quiet", "-m", content)
+	}
+
+	history, err := GitFileHistory(filepath.Join(repo, "sub"), "rules.md")
+	if err != nil {
+		t.Fatalf("GitFileHistory() failed: %v", err)
+	}
+	if len(history) != 2 || string(history[0].Content) != "v1\n" || string(history[1].Content) != "v2\n" || len(history[1].Commit) != 40 {
+		t.Errorf("GitFileHistory() = %+v, want v1 then v2", history)
+	}
+}
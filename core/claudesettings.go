@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClaudeSettingsSource holds the Claude Code settings shared by the team
+var ClaudeSettingsSource = filepath.Join(".viberules", "claude-settings.yaml")
+
+// ClaudeSettingsPath is Claude Code's project settings file. Developers keep
+// personal overrides in .claude/settings.local.json, which Claude Code merges
+// on top of it and viberules never touches.
+var ClaudeSettingsPath = filepath.Join(".claude", "settings.json")
+
+// claudeSettingsKey is the key recording the checksum of a generated setting
+func claudeSettingsKey(key string) string {
+	return ClaudeSettingsPath + "#" + key
+}
+
+// LoadClaudeSettings reads ClaudeSettingsSource and adds the hooks declared in
+// HooksSource. It returns nil if the project shares no settings.
+func (e *Engine) LoadClaudeSettings() (map[string]interface{}, error) {
+	var settings map[string]interface{}
+	content, err := e.fs.ReadFile(ClaudeSettingsSource)
+	switch {
+	case os.IsNotExist(err):
+		// Hooks alone are fine
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", ClaudeSettingsSource, err)
+	default:
+		if err := yaml.Unmarshal(content, &settings); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", ClaudeSettingsSource, err)
+		}
+	}
+
+	hooks, err := e.LoadHooks()
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) > 0 {
+		if _, ok := settings["hooks"]; ok {
+			return nil, fmt.Errorf("hooks are declared in both %s and %s", ClaudeSettingsSource, HooksSource)
+		}
+		if settings == nil {
+			settings = make(map[string]interface{})
+		}
+		settings["hooks"] = RenderHooks(hooks)
+	}
+	return settings, nil
+}
+
+// ManagedClaudeSettings returns the recorded checksum keys of generated settings, sorted
+func ManagedClaudeSettings(recorded map[string]string) []string {
+	var keys []string
+	for key := range recorded {
+		if strings.HasPrefix(key, ClaudeSettingsPath+"#") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedSettings returns the top-level keys of settings, sorted
+func sortedSettings(settings map[string]interface{}) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteClaudeSettings writes each top-level setting of the source into
+// ClaudeSettingsPath, keeping keys it doesn't manage, and removes settings it
+// wrote earlier that are gone from the source. It returns the checksum of each
+// written setting keyed as recorded.
+func (e *Engine) WriteClaudeSettings(recorded map[string]string, overwrite bool) (map[string]string, error) {
+	settings, err := e.LoadClaudeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	written := make(map[string]string)
+	for _, key := range sortedSettings(settings) {
+		sum, err := e.WriteJSONKey(ClaudeSettingsPath, key, settings[key], recorded[claudeSettingsKey(key)], overwrite)
+		if err != nil {
+			return nil, err
+		}
+		written[claudeSettingsKey(key)] = sum
+	}
+
+	for _, managed := range ManagedClaudeSettings(recorded) {
+		if _, ok := written[managed]; ok {
+			continue
+		}
+		key := strings.TrimPrefix(managed, ClaudeSettingsPath+"#")
+		if err := e.RemoveJSONKey(ClaudeSettingsPath, key, recorded[managed], overwrite); err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// RemoveClaudeSettings removes every setting written by WriteClaudeSettings
+func (e *Engine) RemoveClaudeSettings(recorded map[string]string) error {
+	for _, managed := range ManagedClaudeSettings(recorded) {
+		key := strings.TrimPrefix(managed, ClaudeSettingsPath+"#")
+		if err := e.RemoveJSONKey(ClaudeSettingsPath, key, recorded[managed], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckClaudeSettings reports the state of every setting the project shares
+func (e *Engine) CheckClaudeSettings(recorded map[string]string) ([]OutputStatus, error) {
+	settings, err := e.LoadClaudeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []OutputStatus
+	for _, key := range sortedSettings(settings) {
+		all = append(all, OutputStatus{
+			Target: "claude",
+			Path:   claudeSettingsKey(key),
+			State:  e.CheckJSONKey(ClaudeSettingsPath, key, settings[key], recorded[claudeSettingsKey(key)]),
+		})
+	}
+	return e.reportDrift(all...), nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestWriteClaudeSettings(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, ClaudeSettingsSource, `permissions:
+  allow: ["Bash(npm run test:*)"]
+  deny: ["Read(./.env)"]
+env:
+  NODE_ENV: development
+`)
+	writeTestFile(t, ClaudeSettingsPath, `{"model": "sonnet"}`)
+	writeTestFile(t, ".claude/settings.local.json", `{"permissions": {"allow": ["Bash(make:*)"]}}`)
+
+	recorded, err := e.WriteClaudeSettings(nil, false)
+	if err != nil {
+		t.Fatalf("WriteClaudeSettings failed: %v", err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("WriteClaudeSettings recorded %v, want env and permissions", recorded)
+	}
+
+	var settings map[string]interface{}
+	content, _ := os.ReadFile(ClaudeSettingsPath)
+	if err := json.Unmarshal(content, &settings); err != nil {
+		t.Fatalf("settings.json is not valid JSON: %v", err)
+	}
+	if settings["model"] != "sonnet" {
+		t.Error("Settings not in the source should be kept")
+	}
+	if _, ok := settings["permissions"]; !ok {
+		t.Error("permissions should be written")
+	}
+	local, _ := os.ReadFile(".claude/settings.local.json")
+	if string(local) != `{"permissions": {"allow": ["Bash(make:*)"]}}` {
+		t.Error("settings.local.json should never be modified")
+	}
+
+	statuses, err := e.CheckClaudeSettings(recorded)
+	if err != nil {
+		t.Fatalf("CheckClaudeSettings failed: %v", err)
+	}
+	for _, status := range statuses {
+		if status.State != OutputOK {
+			t.Errorf("%s: state = %v, want ok", status.Path, status.State)
+		}
+	}
+
+	// Settings dropped from the source are removed
+	writeTestFile(t, ClaudeSettingsSource, "env:\n  NODE_ENV: development\n")
+	if _, err := e.WriteClaudeSettings(recorded, false); err != nil {
+		t.Fatalf("WriteClaudeSettings failed: %v", err)
+	}
+	content, _ = os.ReadFile(ClaudeSettingsPath)
+	settings = nil
+	json.Unmarshal(content, &settings)
+	if _, ok := settings["permissions"]; ok {
+		t.Error("permissions removed from the source should be removed from settings.json")
+	}
+}
+
+func TestWriteClaudeSettingsKeepsHandWrittenKeys(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, ClaudeSettingsSource, "permissions:\n  allow: [\"Bash(ls)\"]\n")
+	writeTestFile(t, ClaudeSettingsPath, `{"permissions": {"allow": ["Bash(rm:*)"]}}`)
+
+	if _, err := e.WriteClaudeSettings(nil, false); err == nil {
+		t.Error("WriteClaudeSettings should refuse to replace permissions it didn't write")
+	}
+}
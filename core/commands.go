@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandsDir holds shared slash commands, one markdown file per command
+var CommandsDir = filepath.Join(".viberules", "commands")
+
+// GetCommandTargets returns where targets read project slash commands
+func GetCommandTargets() []PromptDir {
+	return []PromptDir{
+		{Name: "claude", Source: CommandsDir, Dir: filepath.Join(".claude", "commands"), Ext: ".md", render: renderMarkdownCommand},
+		{Name: "cursor", Source: CommandsDir, Dir: filepath.Join(".cursor", "commands"), Ext: ".md", render: renderPlainCommand},
+		{Name: "gemini", Source: CommandsDir, Dir: filepath.Join(".gemini", "commands"), Ext: ".toml", render: renderTOMLCommand},
+	}
+}
+
+// FindCommandTarget returns the command location of a target
+func FindCommandTarget(name string) (PromptDir, bool) {
+	return findPromptDir(GetCommandTargets(), name)
+}
+
+// commandDescription returns the description field of a command's frontmatter
+func commandDescription(content []byte) (string, error) {
+	if !bytes.HasPrefix(content, []byte("---\n")) {
+		return "", nil
+	}
+	header := content[len("---\n"):]
+	end := bytes.Index(header, []byte("\n---\n"))
+	if end < 0 {
+		return "", nil
+	}
+
+	var fields struct {
+		Description string `yaml:"description"`
+	}
+	if err := yaml.Unmarshal(header[:end], &fields); err != nil {
+		return "", fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return fields.Description, nil
+}
+
+// Placeholders in command prompts. Sources may use either tool's syntax;
+// each target gets its own.
+var (
+	claudeArgsPattern  = regexp.MustCompile(`\$ARGUMENTS\b`)
+	geminiArgsPattern  = regexp.MustCompile(`\{\{\s*args\s*\}\}`)
+	claudeShellPattern = regexp.MustCompile("!`([^`\n]+)`")
+	geminiShellPattern = regexp.MustCompile(`!\{([^{}\n]+)\}`)
+)
+
+// toClaudePlaceholders rewrites Gemini's {{args}} and !{command} to $ARGUMENTS and !`command`
+func toClaudePlaceholders(prompt []byte) []byte {
+	prompt = geminiArgsPattern.ReplaceAll(prompt, []byte("$$ARGUMENTS"))
+	return geminiShellPattern.ReplaceAll(prompt, []byte("!`$1`"))
+}
+
+// toGeminiPlaceholders rewrites Claude's $ARGUMENTS and !`command` to {{args}} and !{command}
+func toGeminiPlaceholders(prompt []byte) []byte {
+	prompt = claudeArgsPattern.ReplaceAll(prompt, []byte("{{args}}"))
+	return claudeShellPattern.ReplaceAll(prompt, []byte("!{$1}"))
+}
+
+// renderMarkdownCommand keeps Claude's markdown and frontmatter, with Claude placeholders
+func renderMarkdownCommand(content []byte) ([]byte, error) {
+	return toClaudePlaceholders(content), nil
+}
+
+// renderPlainCommand drops the frontmatter, which Cursor would show as part of
+// the prompt, and argument placeholders: Cursor appends the arguments itself
+func renderPlainCommand(content []byte) ([]byte, error) {
+	prompt := claudeArgsPattern.ReplaceAll(stripFrontmatter(content), nil)
+	return geminiArgsPattern.ReplaceAll(prompt, nil), nil
+}
+
+// renderTOMLCommand converts a command to Gemini CLI's TOML format
+func renderTOMLCommand(content []byte) ([]byte, error) {
+	description, err := commandDescription(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if description != "" {
+		fmt.Fprintf(&out, "description = %s\n", tomlString(description))
+	}
+	fmt.Fprintf(&out, "prompt = \"\"\"\n%s\"\"\"\n", tomlMultilineEscaper.Replace(string(toGeminiPlaceholders(stripFrontmatter(content)))))
+	return out.Bytes(), nil
+}
+
+var tomlMultilineEscaper = strings.NewReplacer(`\`, `\\`, `"""`, `""\"`)
+
+// tomlString quotes s as a single-line TOML basic string
+func tomlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(s) + `"`
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testCommand = "---\ndescription: Review the \"current\" diff\n---\nReview the staged changes.\n"
+
+func TestRenderCommand(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, filepath.Join(CommandsDir, "review.md"), testCommand)
+
+	tests := []struct {
+		target string
+		path   string
+		want   string
+	}{
+		{"claude", filepath.Join(".claude", "commands", "review.md"), testCommand},
+		{"cursor", filepath.Join(".cursor", "commands", "review.md"), "Review the staged changes.\n"},
+		{"gemini", filepath.Join(".gemini", "commands", "review.toml"),
+			"description = \"Review the \\\"current\\\" diff\"\nprompt = \"\"\"\nReview the staged changes.\n\"\"\"\n"},
+	}
+	for _, tt := range tests {
+		target, _ := FindCommandTarget(tt.target)
+		if got := target.PromptPath("review"); got != tt.path {
+			t.Errorf("%s: CommandPath = %s, want %s", tt.target, got, tt.path)
+		}
+		content, err := e.RenderPrompt(target, "review")
+		if err != nil {
+			t.Fatalf("%s: RenderCommand failed: %v", tt.target, err)
+		}
+		if string(content) != tt.want {
+			t.Errorf("%s: RenderCommand = %q, want %q", tt.target, content, tt.want)
+		}
+	}
+}
+
+func TestWriteCommandsRemovesDeletedSources(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, filepath.Join(CommandsDir, "review.md"), testCommand)
+	writeTestFile(t, filepath.Join(CommandsDir, "fix.md"), "Fix the failing test.\n")
+	target, _ := FindCommandTarget("claude")
+
+	recorded, err := e.WritePromptDir(target, nil, false)
+	if err != nil {
+		t.Fatalf("WriteCommands failed: %v", err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("WriteCommands wrote %d commands, want 2", len(recorded))
+	}
+
+	if err := os.Remove(filepath.Join(CommandsDir, "fix.md")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.WritePromptDir(target, recorded, false); err != nil {
+		t.Fatalf("WriteCommands failed: %v", err)
+	}
+	if fileExistsForTest(target.PromptPath("fix")) {
+		t.Error("Command whose source was deleted should be removed")
+	}
+	if !fileExistsForTest(target.PromptPath("review")) {
+		t.Error("Command with a source should be kept")
+	}
+}
+
+func TestWriteCommandsKeepsUnmanagedFiles(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, filepath.Join(CommandsDir, "review.md"), testCommand)
+	writeTestFile(t, filepath.Join(".claude", "commands", "review.md"), "My own command\n")
+	target, _ := FindCommandTarget("claude")
+
+	if _, err := e.WritePromptDir(target, nil, false); err == nil {
+		t.Error("WriteCommands should refuse to replace a command it didn't write")
+	}
+}
+
+func TestCommandPlaceholders(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		claude string
+		cursor string
+		gemini string
+	}{
+		{
+			name:   "claude syntax",
+			source: "Fix issue $ARGUMENTS\nDiff: !`git diff`\n",
+			claude: "Fix issue $ARGUMENTS\nDiff: !`git diff`\n",
+			cursor: "Fix issue \nDiff: !`git diff`\n",
+			gemini: "prompt = \"\"\"\nFix issue {{args}}\nDiff: !{git diff}\n\"\"\"\n",
+		},
+		{
+			name:   "gemini syntax",
+			source: "Fix issue {{args}}\nDiff: !{git diff}\n",
+			claude: "Fix issue $ARGUMENTS\nDiff: !`git diff`\n",
+			cursor: "Fix issue \nDiff: !{git diff}\n",
+			gemini: "prompt = \"\"\"\nFix issue {{args}}\nDiff: !{git diff}\n\"\"\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := setupProject(t, "# Rules\n")
+			writeTestFile(t, filepath.Join(CommandsDir, "fix.md"), tt.source)
+
+			for target, want := range map[string]string{"claude": tt.claude, "cursor": tt.cursor, "gemini": tt.gemini} {
+				ct, _ := FindCommandTarget(target)
+				content, err := e.RenderPrompt(ct, "fix")
+				if err != nil {
+					t.Fatalf("%s: RenderCommand failed: %v", target, err)
+				}
+				if string(content) != want {
+					t.Errorf("%s: RenderCommand = %q, want %q", target, content, want)
+				}
+			}
+		})
+	}
+}
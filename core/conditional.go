@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// onlyPattern opens a section shown only to the listed targets:
+// <!-- viberules:only claude,codex -->
+var onlyPattern = regexp.MustCompile(`^[ \t]*<!--[ \t]*viberules:only[ \t]+([A-Za-z0-9_, -]+?)[ \t]*-->[ \t]*$`)
+
+// endOnlyPattern closes the innermost section opened by onlyPattern
+var endOnlyPattern = regexp.MustCompile(`^[ \t]*<!--[ \t]*/viberules:only[ \t]*-->[ \t]*$`)
+
+// HasConditionals reports whether content contains per-target sections
+func HasConditionals(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		if onlyPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterSections returns a transform that keeps sections enclosed in
+// viberules:only markers only for the targets they list. Sections may nest;
+// a line is kept when every enclosing section lists the target. Marker lines
+// are always removed.
+func (e *Engine) FilterSections() Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		if !bytes.Contains(content, []byte("viberules:only")) {
+			return content, nil
+		}
+
+		var out bytes.Buffer
+		var stack []bool // visibility of each open section
+		hidden := 0      // number of open sections that exclude the target
+
+		lines := strings.SplitAfter(string(content), "\n")
+		for i, line := range lines {
+			text := strings.TrimRight(line, "\r\n")
+
+			if m := onlyPattern.FindStringSubmatch(text); m != nil {
+				visible, err := e.listsTarget(m[1], ctx.Target)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+				stack = append(stack, visible)
+				if !visible {
+					hidden++
+				}
+				continue
+			}
+			if endOnlyPattern.MatchString(text) {
+				if len(stack) == 0 {
+					return nil, fmt.Errorf("line %d: /viberules:only without matching viberules:only", i+1)
+				}
+				if !stack[len(stack)-1] {
+					hidden--
+				}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			if hidden == 0 {
+				out.WriteString(line)
+			}
+		}
+
+		if len(stack) > 0 {
+			return nil, fmt.Errorf("viberules:only section is not closed with <!-- /viberules:only -->")
+		}
+		return out.Bytes(), nil
+	}
+}
+
+// listsTarget reports whether the comma-separated list names target.
+// Every name must be a known target so typos don't silently hide sections.
+func (e *Engine) listsTarget(list, target string) (bool, error) {
+	found := false
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !e.isKnownTarget(name) {
+			return false, fmt.Errorf("unknown target %s in viberules:only", name)
+		}
+		if name == target {
+			found = true
+		}
+	}
+	return found, nil
+}
+
+// isKnownTarget reports whether name is a project or global target
+func (e *Engine) isKnownTarget(name string) bool {
+	for _, target := range append(e.GetAllTargets(), e.GetGlobalTargets()...) {
+		if target.Name == name {
+			return true
+		}
+	}
+	return false
+}
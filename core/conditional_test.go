@@ -0,0 +1,55 @@
+package core
+
+import "testing"
+
+func TestFilterSections(t *testing.T) {
+	e := New(OSFS{})
+	rules := `# Rules
+- Shared rule
+<!-- viberules:only claude,codex -->
+- Agent rule
+<!-- viberules:only claude -->
+- Claude rule
+<!-- /viberules:only -->
+<!-- /viberules:only -->
+<!-- viberules:only gemini -->
+- Gemini rule
+<!-- /viberules:only -->
+- Closing rule
+`
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"claude", "# Rules\n- Shared rule\n- Agent rule\n- Claude rule\n- Closing rule\n"},
+		{"codex", "# Rules\n- Shared rule\n- Agent rule\n- Closing rule\n"},
+		{"gemini", "# Rules\n- Shared rule\n- Gemini rule\n- Closing rule\n"},
+		{"amazonq", "# Rules\n- Shared rule\n- Closing rule\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			content, err := e.FilterSections()(&RenderContext{Target: tt.target}, []byte(rules))
+			if err != nil {
+				t.Fatalf("FilterSections failed: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("Content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSectionsErrors(t *testing.T) {
+	e := New(OSFS{})
+	invalid := map[string]string{
+		"unclosed":       "<!-- viberules:only claude -->\n- rule\n",
+		"unopened":       "- rule\n<!-- /viberules:only -->\n",
+		"unknown target": "<!-- viberules:only claud -->\n- rule\n<!-- /viberules:only -->\n",
+	}
+	for name, rules := range invalid {
+		if _, err := e.FilterSections()(&RenderContext{Target: "claude"}, []byte(rules)); err == nil {
+			t.Errorf("FilterSections should fail for %s section", name)
+		}
+	}
+}
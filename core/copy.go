@@ -0,0 +1,336 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Link strategies supported for target outputs
+const (
+	StrategySymlink  = "symlink"  // outputs are symlinks to the rules file
+	StrategyCopy     = "copy"     // outputs are real files copied from the rules file
+	StrategyGenerate = "generate" // outputs are real files rendered through a pipeline
+	StrategyPointer  = "pointer"  // outputs are small files importing the rules file (@path)
+)
+
+// IsValidStrategy reports whether strategy is a supported link strategy
+func IsValidStrategy(strategy string) bool {
+	switch strategy {
+	case StrategySymlink, StrategyCopy, StrategyGenerate, StrategyPointer:
+		return true
+	}
+	return false
+}
+
+// WritesFiles reports whether a strategy produces real files instead of symlinks
+func WritesFiles(strategy string) bool {
+	return strategy == StrategyCopy || strategy == StrategyGenerate || strategy == StrategyPointer
+}
+
+// OutputState describes the health of a single target output
+type OutputState int
+
+const (
+	OutputOK        OutputState = iota // output is up to date
+	OutputMissing                      // output does not exist
+	OutputBroken                       // symlink is broken or points elsewhere
+	OutputModified                     // copied file was edited after it was written
+	OutputStale                        // copied file no longer matches the rules file
+	OutputUnmanaged                    // a file exists that viberules did not create
+	OutputOutside                      // symlink resolves outside the project
+)
+
+func (s OutputState) String() string {
+	switch s {
+	case OutputOK:
+		return "ok"
+	case OutputMissing:
+		return "missing"
+	case OutputBroken:
+		return "broken"
+	case OutputModified:
+		return "modified"
+	case OutputStale:
+		return "stale"
+	case OutputUnmanaged:
+		return "unmanaged"
+	case OutputOutside:
+		return "outside"
+	}
+	return "unknown"
+}
+
+// OutputStatus reports the state of one output of a target
+type OutputStatus struct {
+	Target string // target name
+	Path   string // output path
+	State  OutputState
+}
+
+// Checksum returns the hex-encoded SHA-256 of content
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// FileChecksum returns the checksum of the file at path
+func (e *Engine) FileChecksum(path string) (string, error) {
+	content, err := e.fs.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return Checksum(content), nil
+}
+
+// CopyTargetFiles writes byte-identical copies of the rules file to every output of a target.
+// recorded holds the checksums of previous copies keyed by output path. A regular
+// file whose content no longer matches its recorded checksum was edited by hand
+// and is only replaced when overwrite is true.
+// It returns the checksum of each written output keyed by output path.
+func (e *Engine) CopyTargetFiles(targetName string, recorded map[string]string, overwrite bool) (map[string]string, error) {
+	return e.GenerateTargetFiles(targetName, nil, recorded, overwrite)
+}
+
+// RemoveTargetCopies removes copied or generated outputs of a target.
+// Symlinks and missing files are left alone, and files without a recorded checksum
+// are never touched. Copies edited after they were written are only removed when
+// force is true.
+func (e *Engine) RemoveTargetCopies(targetName string, recorded map[string]string, force bool) error {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return unknownTarget(targetName)
+	}
+
+	for _, link := range target.Links {
+		if err := e.removeCopiedFile(link.Target, recorded[link.Target], force); err != nil {
+			return err
+		}
+	}
+
+	// Parts are copies too; symlinked parts are left for RemoveTargetSymlinks
+	parts, err := e.existingParts(target)
+	if err != nil {
+		return err
+	}
+	for _, path := range parts {
+		if err := e.removeCopiedFile(path, recorded[path], force); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckTargetOutputs reports the state of every output of a target
+// for the given link strategy. pipeline is only used by strategies that write files.
+func (e *Engine) CheckTargetOutputs(targetName, strategy string, pipeline Pipeline, recorded map[string]string) ([]OutputStatus, error) {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return nil, unknownTarget(targetName)
+	}
+
+	links, err := e.OutputLinks(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []OutputStatus
+	for _, link := range links {
+		if !WritesFiles(strategy) {
+			statuses = append(statuses, OutputStatus{Target: target.Name, Path: link.Target, State: e.checkSymlinkOutput(link)})
+			continue
+		}
+
+		outputs, err := e.renderLinkOutputs(pipeline, target, link)
+		if err != nil {
+			// Outputs that can't be rendered are out of date with their source
+			outputs = []renderedOutput{{Path: link.Target}}
+		}
+		for _, output := range outputs {
+			state := e.CheckManagedFile(output.Path, output.Content, recorded[output.Path])
+			if err != nil && state == OutputOK {
+				state = OutputStale
+			}
+			statuses = append(statuses, OutputStatus{Target: target.Name, Path: output.Path, State: state})
+		}
+	}
+
+	// Parts that are no longer outputs are removed on the next sync
+	var paths []string
+	for _, status := range statuses {
+		paths = append(paths, status.Path)
+	}
+	stale, err := e.staleParts(target, paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stale {
+		statuses = append(statuses, OutputStatus{Target: target.Name, Path: path, State: OutputStale})
+	}
+	return e.reportDrift(statuses...), nil
+}
+
+func (e *Engine) checkSymlinkOutput(link SymlinkDef) OutputState {
+	info, err := e.fs.Lstat(link.Target)
+	if os.IsNotExist(err) {
+		return OutputMissing
+	}
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return OutputUnmanaged
+	}
+	if current, err := e.fs.Readlink(link.Target); err == nil && e.CheckLinkInside(current, link.Target) != nil {
+		return OutputOutside
+	}
+	if !e.IsSymlinkValid(link.Target, link.Source) {
+		return OutputBroken
+	}
+	return OutputOK
+}
+
+// WriteManagedFile writes a generated file that is not a target output, such as
+// an ignore file, with the same protection as copies. It returns the checksum to record.
+func (e *Engine) WriteManagedFile(path string, content []byte, recorded string, overwrite bool) (string, error) {
+	if err := e.writeManagedFile(path, content, recorded, overwrite); err != nil {
+		return "", err
+	}
+	return Checksum(content), nil
+}
+
+// RemoveManagedFile removes a file written by WriteManagedFile unless it was edited by hand
+func (e *Engine) RemoveManagedFile(path, recorded string, force bool) error {
+	return e.removeCopiedFile(path, recorded, force)
+}
+
+// CheckManagedFile reports the state of a file written by WriteManagedFile
+// against the content it should currently have
+func (e *Engine) CheckManagedFile(path string, expected []byte, recorded string) OutputState {
+	info, err := e.fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return OutputMissing
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return OutputUnmanaged
+	}
+
+	current, written, err := e.fileChecksums(path, recorded, expected)
+	if err != nil {
+		return OutputBroken
+	}
+	if written == "" {
+		return OutputUnmanaged
+	}
+	if current != written {
+		return OutputModified
+	}
+	if Checksum(expected) != current {
+		return OutputStale
+	}
+	return OutputOK
+}
+
+// writeManagedFile writes content to path, replacing a symlink or a previously
+// written copy. Unmanaged or hand-edited regular files are refused unless overwrite is set.
+// A file still holding the content recorded for it is left alone when its
+// inputs render the same content again, so unchanged outputs keep their
+// modification time and aren't rewritten on every sync.
+func (e *Engine) writeManagedFile(path string, content []byte, recorded string, overwrite bool) error {
+	path = filepath.Clean(path)
+
+	info, err := e.fs.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		// Nothing to replace
+	case err != nil:
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	case info.Mode()&os.ModeSymlink != 0:
+		if err := e.removeSymlink(path); err != nil {
+			return err
+		}
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("refusing to overwrite %s: not a regular file", path)
+	case recorded == Checksum(content) && e.holdsChecksum(path, recorded):
+		e.logger.Debug("skipping unchanged output", "path", path)
+		return nil
+	case !overwrite:
+		current, written, err := e.fileChecksums(path, recorded, content)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if current != written && current != Checksum(content) {
+			if written == "" {
+				return fmt.Errorf("refusing to overwrite %s: file is not managed by viberules", path)
+			}
+			return fmt.Errorf("refusing to overwrite %s: file was modified since it was copied", path)
+		}
+	case e.trash:
+		// Content viberules didn't write can be recovered from the trash
+		current, written, err := e.fileChecksums(path, recorded, content)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if current != written && current != Checksum(content) {
+			e.logger.Debug("moving edited file to the trash", "path", path)
+			if err := e.moveToTrash(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := e.fs.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+	}
+
+	if err := e.fs.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	e.logger.Debug("wrote output", "path", path, "bytes", len(content))
+
+	return nil
+}
+
+// holdsChecksum reports whether the file at path has checksum sum
+func (e *Engine) holdsChecksum(path, sum string) bool {
+	current, err := e.FileChecksum(path)
+	return err == nil && current == sum
+}
+
+// removeCopiedFile removes a regular file previously written by viberules
+func (e *Engine) removeCopiedFile(path, recorded string, force bool) error {
+	path = filepath.Clean(path)
+
+	info, err := e.fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	// Only regular files that viberules wrote are candidates for removal
+	if !info.Mode().IsRegular() || recorded == "" {
+		return nil
+	}
+
+	current, err := e.FileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if current != recorded {
+		if !force {
+			return fmt.Errorf("refusing to remove %s: file was modified since it was copied", path)
+		}
+		if e.trash {
+			return e.moveToTrash(path)
+		}
+	}
+
+	if err := e.fs.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// setupProject creates a temporary project with a rules file, changes into
+// it and returns an engine working on it
+func setupProject(t *testing.T, rules string) *Engine {
+	t.Helper()
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(oldDir) })
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+	if err := os.MkdirAll(".viberules", 0755); err != nil {
+		t.Fatalf("Failed to create .viberules directory: %v", err)
+	}
+	if err := os.WriteFile(".viberules/rules.md", []byte(rules), 0644); err != nil {
+		t.Fatalf("Failed to create rules.md: %v", err)
+	}
+	return New(OSFS{})
+}
+
+func TestCopyTargetFiles(t *testing.T) {
+	e := setupProject(t, "rules v1")
+
+	written, err := e.CopyTargetFiles("amazonq", nil, false)
+	if err != nil {
+		t.Fatalf("CopyTargetFiles(amazonq) failed: %v", err)
+	}
+
+	path := ".amazonq/rules/AMAZONQ.md"
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("Copied file %s does not exist: %v", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		t.Errorf("%s should be a regular file", path)
+	}
+	if written[path] != Checksum([]byte("rules v1")) {
+		t.Errorf("Recorded checksum = %s, want checksum of rules content", written[path])
+	}
+
+	statuses, err := e.CheckTargetOutputs("amazonq", StrategyCopy, nil, written)
+	if err != nil {
+		t.Fatalf("CheckTargetOutputs failed: %v", err)
+	}
+	if statuses[0].State != OutputOK {
+		t.Errorf("State after copy = %s, want ok", statuses[0].State)
+	}
+}
+
+func TestCopyDriftDetection(t *testing.T) {
+	e := setupProject(t, "rules v1")
+
+	recorded, err := e.CopyTargetFiles("claude", nil, false)
+	if err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+
+	// Source changed: copy becomes stale and can be refreshed safely
+	if err := os.WriteFile(".viberules/rules.md", []byte("rules v2"), 0644); err != nil {
+		t.Fatalf("Failed to update rules.md: %v", err)
+	}
+	statuses, _ := e.CheckTargetOutputs("claude", StrategyCopy, nil, recorded)
+	if statuses[0].State != OutputStale {
+		t.Errorf("State after source change = %s, want stale", statuses[0].State)
+	}
+	if recorded, err = e.CopyTargetFiles("claude", recorded, false); err != nil {
+		t.Fatalf("Re-copying a stale file should succeed: %v", err)
+	}
+
+	// Manual edit: copy is modified and must not be overwritten without force
+	if err := os.WriteFile("CLAUDE.md", []byte("edited by hand"), 0644); err != nil {
+		t.Fatalf("Failed to edit CLAUDE.md: %v", err)
+	}
+	statuses, _ = e.CheckTargetOutputs("claude", StrategyCopy, nil, recorded)
+	if statuses[0].State != OutputModified {
+		t.Errorf("State after manual edit = %s, want modified", statuses[0].State)
+	}
+	if _, err := e.CopyTargetFiles("claude", recorded, false); err == nil {
+		t.Error("CopyTargetFiles should refuse to overwrite a modified file")
+	}
+	if err := e.RemoveTargetCopies("claude", recorded, false); err == nil {
+		t.Error("RemoveTargetCopies should refuse to remove a modified file")
+	}
+	if _, err := e.CopyTargetFiles("claude", recorded, true); err != nil {
+		t.Errorf("CopyTargetFiles with overwrite should succeed: %v", err)
+	}
+}
+
+func TestCopyReplacesSymlinkAndBack(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	if err := e.CreateTargetSymlinks("gemini"); err != nil {
+		t.Fatalf("CreateTargetSymlinks(gemini) failed: %v", err)
+	}
+
+	recorded, err := e.CopyTargetFiles("gemini", nil, false)
+	if err != nil {
+		t.Fatalf("CopyTargetFiles should replace an existing symlink: %v", err)
+	}
+
+	// Unmodified copy can be removed so a symlink can take its place
+	if err := e.RemoveTargetCopies("gemini", recorded, false); err != nil {
+		t.Fatalf("RemoveTargetCopies failed: %v", err)
+	}
+	if err := e.CreateTargetSymlinks("gemini"); err != nil {
+		t.Fatalf("CreateTargetSymlinks after removing copy failed: %v", err)
+	}
+	if !e.IsSymlinkValid("GEMINI.md", ".viberules/rules.md") {
+		t.Error("GEMINI.md should be a valid symlink again")
+	}
+}
+
+func TestCopyRefusesUnmanagedFile(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	if err := os.WriteFile("AGENTS.md", []byte("hand written"), 0644); err != nil {
+		t.Fatalf("Failed to create AGENTS.md: %v", err)
+	}
+
+	if _, err := e.CopyTargetFiles("codex", nil, false); err == nil {
+		t.Error("CopyTargetFiles should refuse to overwrite an unmanaged file")
+	}
+
+	// Files without a recorded checksum are never removed
+	if err := e.RemoveTargetCopies("codex", nil, true); err != nil {
+		t.Fatalf("RemoveTargetCopies failed: %v", err)
+	}
+	if !fileExistsForTest("AGENTS.md") {
+		t.Error("Unmanaged AGENTS.md should not be removed")
+	}
+}
+
+func fileExistsForTest(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func TestCopySkipsUnchangedOutputs(t *testing.T) {
+	e := setupProject(t, "rules v1")
+	path := "CLAUDE.md"
+
+	recorded, err := e.CopyTargetFiles("claude", nil, false)
+	if err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	modTime := func() time.Time {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		return info.ModTime()
+	}
+
+	// Same inputs: the output isn't written again
+	if recorded, err = e.CopyTargetFiles("claude", recorded, false); err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	if !modTime().Equal(old) {
+		t.Error("An output whose inputs didn't change was rewritten")
+	}
+	if recorded[path] != Checksum([]byte("rules v1")) {
+		t.Errorf("Recorded checksum = %s, want the checksum of the unchanged output", recorded[path])
+	}
+
+	// Changed inputs rewrite it
+	if err := os.WriteFile(".viberules/rules.md", []byte("rules v2"), 0644); err != nil {
+		t.Fatalf("Failed to update rules.md: %v", err)
+	}
+	if _, err := e.CopyTargetFiles("claude", recorded, false); err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "rules v2" || modTime().Equal(old) {
+		t.Errorf("Output after the source changed = %q, want it rewritten", content)
+	}
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// cursorTarget is the target whose outputs are Cursor .mdc rules
+const cursorTarget = "cursor"
+
+// FrontmatterFor returns the frontmatter transform of a target: Cursor's .mdc
+// metadata for cursor, plain YAML frontmatter built from fields otherwise
+func FrontmatterFor(targetName string, fields map[string]interface{}) Transform {
+	if targetName == cursorTarget {
+		return CursorFrontmatter(fields)
+	}
+	return InjectFrontmatter(fields)
+}
+
+// CursorFrontmatter returns a transform that writes the description, globs and
+// alwaysApply fields Cursor uses to decide when a rule is active. globs may be
+// a string or a list and is written comma-separated, unquoted, as Cursor expects.
+// Without description or globs the rule is always applied.
+func CursorFrontmatter(fields map[string]interface{}) Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		description, globs, alwaysApply, err := cursorFields(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		var out bytes.Buffer
+		out.WriteString("---\n")
+		fmt.Fprintf(&out, "description: %s\n", description)
+		fmt.Fprintf(&out, "globs: %s\n", globs)
+		fmt.Fprintf(&out, "alwaysApply: %t\n", alwaysApply)
+		out.WriteString("---\n")
+		out.Write(stripFrontmatter(content))
+		return out.Bytes(), nil
+	}
+}
+
+// cursorFields validates the configured frontmatter of the cursor target
+func cursorFields(fields map[string]interface{}) (description, globs string, alwaysApply bool, err error) {
+	explicit := false
+	for key, value := range fields {
+		switch key {
+		case "description":
+			s, ok := value.(string)
+			if !ok {
+				return "", "", false, fmt.Errorf("cursor frontmatter: description must be a string")
+			}
+			description = strings.TrimSpace(s)
+		case "globs":
+			switch v := value.(type) {
+			case string:
+				globs = v
+			case []interface{}:
+				var patterns []string
+				for _, item := range v {
+					pattern, ok := item.(string)
+					if !ok {
+						return "", "", false, fmt.Errorf("cursor frontmatter: globs must be strings")
+					}
+					patterns = append(patterns, pattern)
+				}
+				globs = strings.Join(patterns, ",")
+			default:
+				return "", "", false, fmt.Errorf("cursor frontmatter: globs must be a string or a list")
+			}
+		case "alwaysApply":
+			b, ok := value.(bool)
+			if !ok {
+				return "", "", false, fmt.Errorf("cursor frontmatter: alwaysApply must be true or false")
+			}
+			alwaysApply, explicit = b, true
+		default:
+			return "", "", false, fmt.Errorf("unsupported cursor frontmatter field %s (use description, globs, alwaysApply)", key)
+		}
+	}
+
+	if strings.ContainsAny(description, "\n") {
+		return "", "", false, fmt.Errorf("cursor frontmatter: description must be a single line")
+	}
+	if !explicit {
+		alwaysApply = description == "" && globs == ""
+	}
+	return description, globs, alwaysApply, nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import "testing"
+
+func TestCursorFrontmatter(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "always applied by default",
+			fields: nil,
+			want:   "---\ndescription: \nglobs: \nalwaysApply: true\n---\n# Rules\n",
+		},
+		{
+			name:   "auto attached by globs",
+			fields: map[string]interface{}{"globs": []interface{}{"*.ts", "src/**/*.tsx"}},
+			want:   "---\ndescription: \nglobs: *.ts,src/**/*.tsx\nalwaysApply: false\n---\n# Rules\n",
+		},
+		{
+			name:   "agent requested with explicit alwaysApply",
+			fields: map[string]interface{}{"description": "Project rules", "alwaysApply": true},
+			want:   "---\ndescription: Project rules\nglobs: \nalwaysApply: true\n---\n# Rules\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := CursorFrontmatter(tt.fields)(&RenderContext{Target: "cursor"}, []byte("---\nold: true\n---\n# Rules\n"))
+			if err != nil {
+				t.Fatalf("CursorFrontmatter failed: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("Content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+
+	invalid := []map[string]interface{}{
+		{"alwaysApply": "yes"},
+		{"globs": 42},
+		{"priority": 1},
+	}
+	for _, fields := range invalid {
+		if _, err := CursorFrontmatter(fields)(&RenderContext{}, []byte("# Rules\n")); err == nil {
+			t.Errorf("CursorFrontmatter(%v) should fail", fields)
+		}
+	}
+}
+
+func TestGenerateCursorRules(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	pipeline := Pipeline{FrontmatterFor("cursor", map[string]interface{}{"globs": "*.go"})}
+	if _, err := e.GenerateTargetFiles("cursor", pipeline, nil, false); err != nil {
+		t.Fatalf("GenerateTargetFiles(cursor) failed: %v", err)
+	}
+	if !fileExistsForTest(".cursor/rules/viberules.mdc") {
+		t.Error(".cursor/rules/viberules.mdc should be generated")
+	}
+}
@@ -0,0 +1,15 @@
+// Package core is the viberules engine: it renders the rules file into the
+// files each assistant reads, links or copies them into place, and checks
+// them for drift. The viberules command is a wrapper around it.
+//
+// The package depends on nothing but the standard library, yaml.v3 and the
+// BLAKE2b package of golang.org/x/crypto, so other tools, such as project
+// scaffolders, can import it without pulling in the CLI. Operations are
+// methods of an Engine, which New creates for the filesystem of one project.
+// Its setters configure it before use: SetScope selects the target registry
+// scope, SetObserver receives events, SetLogger receives debug records, and
+// SetRulesFile and SetOutputPaths apply project configuration. Separate
+// projects can be worked on concurrently with one engine each; they share
+// only what is outside any project, the credentials of DefaultCredentials
+// and the caches of remote content.
+package core
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestEngineDependencies(t *testing.T) {
+	// Tools embedding the engine should not pull in the CLI's dependencies
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	out, err := exec.Command("go", "list", "-deps", "-f", "{{if not .Standard}}{{.ImportPath}}{{end}}", ".").Output()
+	if err != nil {
+		t.Fatalf("go list failed: %v", err)
+	}
+	allowed := map[string]bool{
+		"github.com/sky1core/viberules/core": true,
+		"gopkg.in/yaml.v3":                   true,
+		"golang.org/x/crypto/blake2b":        true, // prehashed minisign signatures
+		"golang.org/x/sys/cpu":               true, // used by blake2b
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		if !allowed[dep] {
+			t.Errorf("core depends on %s", dep)
+		}
+	}
+}
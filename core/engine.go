@@ -0,0 +1,61 @@
+package core
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Engine works on the rules and outputs below the root of one filesystem: a
+// project, or the home directory in the global scope. Its settings, logger
+// included, are its own, so tools can work on several projects at once with
+// one engine each. What lives outside any project is shared by every engine
+// in the process: the credentials DefaultCredentials reads from the
+// environment and netrc, and the caches of remote content below
+// DefaultRefCacheDir and DefaultContentCache. An engine itself is not safe
+// for concurrent use.
+type Engine struct {
+	fs          FS
+	scope       Scope
+	rulesFile   string
+	outputPaths map[string]string // rules file path of overridden targets, keyed by target name
+	observer    Observer
+	observerMu  sync.Mutex // serializes observer calls made by concurrent workers
+	trash       bool
+	registry    Registry
+	logger      *slog.Logger
+}
+
+// New returns an engine working on f, an OSFS rooted at a project or a MemFS
+// in tests, with the built-in targets of the project scope. Files outside any
+// project, the platform trash and the cache of remote includes, stay on the
+// operating system's filesystem.
+func New(f FS) *Engine {
+	return &Engine{
+		fs:        f,
+		scope:     ScopeProject,
+		rulesFile: DefaultRulesFile,
+		observer:  NopObserver{},
+		registry:  BuiltinRegistry(),
+		logger:    discardLogger,
+	}
+}
+
+// FS returns the filesystem the engine works on
+func (e *Engine) FS() FS {
+	return e.fs
+}
+
+// WithFS returns an engine with the settings of e working on f, such as a
+// PlanFS over e's filesystem
+func (e *Engine) WithFS(f FS) *Engine {
+	return &Engine{
+		fs:          f,
+		scope:       e.scope,
+		rulesFile:   e.rulesFile,
+		outputPaths: e.outputPaths,
+		observer:    e.observer,
+		trash:       e.trash,
+		registry:    e.registry,
+		logger:      e.logger,
+	}
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestEnginesWorkConcurrently(t *testing.T) {
+	// Each project keeps its rules somewhere else
+	sources := []string{DefaultRulesFile, filepath.Join("docs", "rules.md"), filepath.Join("ai", "rules.md")}
+	mems := make([]*MemFS, len(sources))
+	engines := make([]*Engine, len(sources))
+	for i, source := range sources {
+		mems[i] = NewMemFS()
+		if err := mems[i].MkdirAll(filepath.Dir(source), 0755); err != nil {
+			t.Fatalf("MkdirAll() failed: %v", err)
+		}
+		if err := mems[i].WriteFile(source, []byte(fmt.Sprintf("rules %d", i)), 0644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+		engines[i] = New(mems[i])
+		engines[i].SetRulesFile(source)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(engines))
+	for i, e := range engines {
+		wg.Add(1)
+		go func(i int, e *Engine) {
+			defer wg.Done()
+			errs[i] = e.CreateTargetSymlinks("claude")
+		}(i, e)
+	}
+	wg.Wait()
+
+	for i, mem := range mems {
+		if errs[i] != nil {
+			t.Fatalf("CreateTargetSymlinks() in project %d failed: %v", i, errs[i])
+		}
+		if content, err := mem.ReadFile("CLAUDE.md"); err != nil || string(content) != fmt.Sprintf("rules %d", i) {
+			t.Errorf("CLAUDE.md of project %d = %q, %v, want its own rules", i, content, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors callers can branch on with errors.Is. Errors returned by the core
+// package and the CLI match them whatever their message says.
+var (
+	// ErrNotInitialized reports a directory that is not a viberules project
+	ErrNotInitialized = errors.New("not initialized")
+	// ErrUnknownTarget reports a target name missing from the active scope
+	ErrUnknownTarget = errors.New("unknown target")
+	// ErrDrift reports outputs that no longer match the rules
+	ErrDrift = errors.New("outputs need attention")
+	// ErrWindowsUnsupportedStrategy reports a link strategy Windows cannot
+	// provide, such as symlinks without Developer Mode
+	ErrWindowsUnsupportedStrategy = errors.New("strategy not supported on Windows")
+	// ErrConflict reports files viberules did not create in the way of its
+	// outputs; the error is a *ConflictError listing them
+	ErrConflict = errors.New("files in the way of outputs")
+	// ErrAuth reports a remote source that refused access, for lack of
+	// credentials or with the ones given
+	ErrAuth = errors.New("authentication failed")
+	// ErrNotFound reports a remote source without the requested content
+	ErrNotFound = errors.New("remote content not found")
+	// ErrOffline reports remote content needed while offline mode forbids
+	// network access
+	ErrOffline = errors.New("offline")
+)
+
+// ConflictError lists the files viberules did not create that occupy outputs
+type ConflictError struct {
+	Paths   []string // occupied output paths
+	Targets []string // the target of each path
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	b.WriteString("files not created by viberules are in the way of outputs:")
+	for i, path := range e.Paths {
+		fmt.Fprintf(&b, "\n  - %s (%s)", path, e.Targets[i])
+	}
+	return b.String()
+}
+
+// Is makes errors.Is(err, ErrConflict) match
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// kindError is an error that also matches kind with errors.Is
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// WithKind makes errors.Is(err, kind) report true, keeping err's message
+func WithKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+// unknownTarget returns the error for a target missing from the active scope
+func unknownTarget(name string) error {
+	return fmt.Errorf("%w: %s", ErrUnknownTarget, name)
+}
+
+// CheckOccupiedOutputs returns a *ConflictError listing the outputs of
+// targets held by files viberules did not create (see UnmanagedOutputs),
+// except the paths in skip
+func (e *Engine) CheckOccupiedOutputs(targets []string, recorded map[string]string, skip []string) error {
+	conflict := &ConflictError{}
+	for _, target := range targets {
+		paths, err := e.UnmanagedOutputs(target, recorded)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if !containsString(skip, path) {
+				conflict.Paths = append(conflict.Paths, path)
+				conflict.Targets = append(conflict.Targets, target)
+			}
+		}
+	}
+	if len(conflict.Paths) == 0 {
+		return nil
+	}
+	return conflict
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckOccupiedOutputs(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	if err := e.CheckOccupiedOutputs([]string{"claude", "gemini"}, nil, nil); err != nil {
+		t.Fatalf("CheckOccupiedOutputs() on a clean project = %v", err)
+	}
+	for _, path := range []string{"CLAUDE.md", "GEMINI.md"} {
+		if err := os.WriteFile(path, []byte("# Hand-written\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	err := e.CheckOccupiedOutputs([]string{"claude", "gemini"}, nil, []string{"GEMINI.md"})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("CheckOccupiedOutputs() = %v, want ErrConflict", err)
+	}
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !reflect.DeepEqual(conflict.Paths, []string{"CLAUDE.md"}) || !reflect.DeepEqual(conflict.Targets, []string{"claude"}) {
+		t.Errorf("conflict = %+v, want CLAUDE.md of claude", conflict)
+	}
+	if !strings.Contains(err.Error(), "  - CLAUDE.md (claude)") {
+		t.Errorf("error = %q, want the occupied path listed", err)
+	}
+
+	if err := e.CheckOccupiedOutputs([]string{"nope"}, nil, nil); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("CheckOccupiedOutputs(nope) = %v, want ErrUnknownTarget", err)
+	}
+}
+
+func TestWithKind(t *testing.T) {
+	err := WithKind(ErrNotInitialized, os.ErrNotExist)
+	if !errors.Is(err, ErrNotInitialized) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("WithKind() = %v, want both the kind and the wrapped error to match", err)
+	}
+	if err.Error() != os.ErrNotExist.Error() {
+		t.Errorf("WithKind() message = %q, want the wrapped message", err)
+	}
+}
@@ -0,0 +1,473 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FS is the filesystem project files are read and written through. Relative
+// paths are resolved against the filesystem's root, so the same code can
+// work on a project without changing the working directory.
+type FS interface {
+	Lstat(name string) (fs.FileInfo, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Readlink(name string) (string, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldname, newname string) error
+	Symlink(oldname, newname string) error
+	// Abs returns the absolute form of name below the root
+	Abs(name string) (string, error)
+	// EvalSymlinks returns name with every symlink resolved
+	EvalSymlinks(name string) (string, error)
+}
+
+// replacer is implemented by filesystems that replace a file without readers
+// ever seeing a partial write
+type replacer interface {
+	ReplaceFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// walkDir walks the tree at root through the engine's filesystem like filepath.WalkDir
+func (e *Engine) walkDir(root string, fn fs.WalkDirFunc) error {
+	info, err := e.fs.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = e.walkDirEntry(root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (e *Engine) walkDirEntry(name string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(name, d, nil); err != nil || !d.IsDir() {
+		if err == fs.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := e.fs.ReadDir(name)
+	if err != nil {
+		if err = fn(name, d, err); err != nil {
+			if err == fs.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, entry := range entries {
+		if err := e.walkDirEntry(filepath.Join(name, entry.Name()), entry, fn); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// OSFS is the operating system's filesystem. Relative paths are resolved
+// against Root, or the working directory when Root is empty.
+type OSFS struct {
+	Root string
+}
+
+func (o OSFS) path(name string) string {
+	if o.Root == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(o.Root, name)
+}
+
+func (o OSFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(o.path(name)) }
+func (o OSFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(o.path(name)) }
+func (o OSFS) ReadFile(name string) ([]byte, error)   { return os.ReadFile(o.path(name)) }
+func (o OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(o.path(name))
+}
+func (o OSFS) Readlink(name string) (string, error) { return os.Readlink(o.path(name)) }
+func (o OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(o.path(name), data, perm)
+}
+func (o OSFS) MkdirAll(name string, perm fs.FileMode) error { return os.MkdirAll(o.path(name), perm) }
+func (o OSFS) Remove(name string) error                     { return os.Remove(o.path(name)) }
+func (o OSFS) RemoveAll(name string) error                  { return os.RemoveAll(o.path(name)) }
+func (o OSFS) Rename(oldname, newname string) error {
+	return os.Rename(o.path(oldname), o.path(newname))
+}
+func (o OSFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, o.path(newname))
+}
+func (o OSFS) Abs(name string) (string, error) { return filepath.Abs(o.path(name)) }
+func (o OSFS) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(o.path(name))
+}
+
+// ReplaceFile writes data to a temporary file in the same directory and
+// renames it over name
+func (o OSFS) ReplaceFile(name string, data []byte, perm fs.FileMode) error {
+	name = o.path(name)
+	temp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", name, err)
+	}
+	defer os.Remove(temp.Name()) // no-op once renamed
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write %s: %w", temp.Name(), err)
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to sync %s: %w", temp.Name(), err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", temp.Name(), err)
+	}
+	if err := os.Chmod(temp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", temp.Name(), err)
+	}
+	if err := os.Rename(temp.Name(), name); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", name, err)
+	}
+	return nil
+}
+
+// maxLinks bounds the symlinks followed resolving one path
+const maxLinks = 40
+
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+	errLinkLoop = errors.New("too many levels of symbolic links")
+)
+
+// MemFS is a filesystem kept in memory, with directories, files and
+// symlinks. Relative paths start at its root, "/". It is safe for
+// concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	mode    fs.FileMode
+	data    []byte
+	link    string
+	modTime time.Time
+}
+
+// NewMemFS returns an empty MemFS
+func NewMemFS() *MemFS {
+	return &MemFS{nodes: map[string]*memNode{
+		"/": {mode: fs.ModeDir | 0755, modTime: time.Now()},
+	}}
+}
+
+// clean returns name as an absolute slash-separated path
+func (m *MemFS) clean(name string) string {
+	return path.Join("/", filepath.ToSlash(name))
+}
+
+// resolve returns the path name refers to once symlinks are followed, the
+// last element only when follow is set. Missing elements are kept as written.
+func (m *MemFS) resolve(name string, follow bool) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(m.clean(name), "/"), "/")
+	current := "/"
+	links := 0
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "" {
+			continue
+		}
+		next := path.Join(current, parts[i])
+		node, ok := m.nodes[next]
+		if ok && node.mode&fs.ModeSymlink != 0 && (follow || i < len(parts)-1) {
+			if links++; links > maxLinks {
+				return "", errLinkLoop
+			}
+			target := node.link
+			if !path.IsAbs(target) {
+				target = path.Join(current, target)
+			}
+			rest := strings.Join(parts[i+1:], "/")
+			parts = strings.Split(strings.TrimPrefix(path.Join(target, rest), "/"), "/")
+			current = "/"
+			i = -1
+			continue
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// lookup resolves name and returns its node
+func (m *MemFS) lookup(op, name string, follow bool) (string, *memNode, error) {
+	p, err := m.resolve(name, follow)
+	if err != nil {
+		return "", nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	node, ok := m.nodes[p]
+	if !ok {
+		return p, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return p, node, nil
+}
+
+// create resolves name for a new entry, whose parent must be a directory
+func (m *MemFS) create(op, name string, follow bool) (string, error) {
+	p, err := m.resolve(name, follow)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	parent, ok := m.nodes[path.Dir(p)]
+	if !ok {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if !parent.mode.IsDir() {
+		return "", &fs.PathError{Op: op, Path: name, Err: errNotDir}
+	}
+	return p, nil
+}
+
+// children returns the paths directly below dir, sorted
+func (m *MemFS) children(dir string) []string {
+	var names []string
+	for p := range m.nodes {
+		if p != "/" && path.Dir(p) == dir {
+			names = append(names, p)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{name: path.Base(p), node: node}, nil
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{name: path.Base(p), node: node}, nil
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: errNotDir}
+	}
+	var entries []fs.DirEntry
+	for _, child := range m.children(p) {
+		entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: path.Base(child), node: m.nodes[child]}))
+	}
+	return entries, nil
+}
+
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if node.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.FromSlash(node.link), nil
+}
+
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.create("open", name, true)
+	if err != nil {
+		return err
+	}
+	if node, ok := m.nodes[p]; ok {
+		if node.mode.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+		}
+		perm = node.mode.Perm()
+	}
+	m.nodes[p] = &memNode{mode: perm.Perm(), data: append([]byte(nil), data...), modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.resolve(name, true)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	current := "/"
+	for _, part := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		current = path.Join(current, part)
+		node, ok := m.nodes[current]
+		if !ok {
+			m.nodes[current] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+			continue
+		}
+		if !node.mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+		}
+	}
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup("remove", name, false)
+	if err != nil {
+		return err
+	}
+	if node.mode.IsDir() && len(m.children(p)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.resolve(name, false)
+	if err != nil {
+		return &fs.PathError{Op: "removeall", Path: name, Err: err}
+	}
+	if p == "/" {
+		return &fs.PathError{Op: "removeall", Path: name, Err: fs.ErrInvalid}
+	}
+	for key := range m.nodes {
+		if key == p || strings.HasPrefix(key, p+"/") {
+			delete(m.nodes, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from, node, err := m.lookup("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	to, err := m.create("rename", newname, false)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	if existing, ok := m.nodes[to]; ok && existing.mode.IsDir() {
+		if !node.mode.IsDir() {
+			return &fs.PathError{Op: "rename", Path: newname, Err: errIsDir}
+		}
+		if len(m.children(to)) > 0 {
+			return &fs.PathError{Op: "rename", Path: newname, Err: errNotEmpty}
+		}
+	}
+	if strings.HasPrefix(to, from+"/") {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrInvalid}
+	}
+	for key, moved := range m.nodes {
+		if strings.HasPrefix(key, from+"/") {
+			delete(m.nodes, key)
+			m.nodes[to+strings.TrimPrefix(key, from)] = moved
+		}
+	}
+	delete(m.nodes, from)
+	m.nodes[to] = node
+	return nil
+}
+
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.create("symlink", newname, false)
+	if err != nil {
+		return err
+	}
+	if _, ok := m.nodes[p]; ok {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+	m.nodes[p] = &memNode{mode: fs.ModeSymlink | 0777, link: filepath.ToSlash(oldname), modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) Abs(name string) (string, error) {
+	return filepath.FromSlash(m.clean(name)), nil
+}
+
+func (m *MemFS) EvalSymlinks(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, _, err := m.lookup("lstat", name, true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(p), nil
+}
+
+// memInfo describes a MemFS node
+type memInfo struct {
+	name string
+	node *memNode
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i memInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i memInfo) ModTime() time.Time { return i.node.modTime }
+func (i memInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
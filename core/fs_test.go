@@ -0,0 +1,121 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemFSProject(t *testing.T) {
+	mem := NewMemFS()
+	e := New(mem)
+
+	if err := mem.MkdirAll(".viberules", 0755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := mem.WriteFile(".viberules/rules.md", []byte("rules"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks() failed: %v", err)
+	}
+	content, err := mem.ReadFile("CLAUDE.md")
+	if err != nil || string(content) != "rules" {
+		t.Errorf("CLAUDE.md = %q, %v, want the rules through the symlink", content, err)
+	}
+	if _, err := e.CopyTargetFiles("amazonq", nil, false); err != nil {
+		t.Fatalf("CopyTargetFiles() failed: %v", err)
+	}
+	if content, err := mem.ReadFile(".amazonq/rules/AMAZONQ.md"); err != nil || string(content) != "rules" {
+		t.Errorf("AMAZONQ.md = %q, %v, want a copy of the rules", content, err)
+	}
+
+	// Nothing reached the disk
+	if _, err := os.Lstat("CLAUDE.md"); !os.IsNotExist(err) {
+		t.Errorf("CLAUDE.md was created on disk: %v", err)
+	}
+
+}
+
+func TestMemFS(t *testing.T) {
+	mem := NewMemFS()
+
+	if err := mem.WriteFile("missing/file", nil, 0644); !os.IsNotExist(err) {
+		t.Errorf("WriteFile() without a parent = %v, want not exist", err)
+	}
+	if err := mem.MkdirAll("a/b", 0755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := mem.WriteFile("a/b/file", []byte("x"), 0600); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := mem.Symlink("a/b", "link"); err != nil {
+		t.Fatalf("Symlink() failed: %v", err)
+	}
+	if err := mem.Symlink("a", "link"); !os.IsExist(err) {
+		t.Errorf("Symlink() over an existing link = %v, want exists", err)
+	}
+
+	if info, err := mem.Lstat("link"); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Lstat(link) = %v, %v, want a symlink", info, err)
+	}
+	if info, err := mem.Stat("link/file"); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Stat(link/file) = %v, %v, want the file", info, err)
+	}
+	if resolved, err := mem.EvalSymlinks("link/file"); err != nil || resolved != filepath.FromSlash("/a/b/file") {
+		t.Errorf("EvalSymlinks() = %q, %v", resolved, err)
+	}
+	if err := mem.Remove("a"); err == nil {
+		t.Error("Remove() removed a directory that is not empty")
+	}
+
+	if err := mem.Rename("a", "c"); err != nil {
+		t.Fatalf("Rename() failed: %v", err)
+	}
+	if content, err := mem.ReadFile("c/b/file"); err != nil || string(content) != "x" {
+		t.Errorf("ReadFile(c/b/file) = %q, %v", content, err)
+	}
+	if _, err := mem.Stat("link"); !os.IsNotExist(err) {
+		t.Errorf("Stat() through a dangling link = %v, want not exist", err)
+	}
+
+	if err := mem.Symlink("loop", "loop"); err != nil {
+		t.Fatalf("Symlink() failed: %v", err)
+	}
+	if _, err := mem.Stat("loop"); err == nil {
+		t.Error("Stat() followed a symlink loop")
+	}
+
+	if err := mem.RemoveAll("c"); err != nil {
+		t.Fatalf("RemoveAll() failed: %v", err)
+	}
+	entries, err := mem.ReadDir(".")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("ReadDir() = %v, %v, want only the links", entries, err)
+	}
+}
+
+func TestOSFSRoot(t *testing.T) {
+	setupProject(t, "rules")
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	// Work on the project from somewhere else
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	e := New(OSFS{Root: root})
+
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks() failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(root, "CLAUDE.md"))
+	if err != nil || string(content) != "rules" {
+		t.Errorf("CLAUDE.md = %q, %v, want the rules in the root", content, err)
+	}
+	if _, err := os.Lstat("CLAUDE.md"); !os.IsNotExist(err) {
+		t.Errorf("CLAUDE.md was created in the working directory: %v", err)
+	}
+}
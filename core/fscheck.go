@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FSProblem is a property of the filesystem a project lives on that breaks
+// its outputs or makes them churn
+type FSProblem struct {
+	Message  string
+	Symlinks bool // only symlink outputs are affected; the copy strategy avoids it
+}
+
+// syncedFolders are path components of folders that sync services mirror.
+// They don't keep symlinks, or replace them with copies on other machines.
+var syncedFolders = []struct{ name, service string }{
+	{"Dropbox", "Dropbox"},
+	{"OneDrive", "OneDrive"},
+	{"Mobile Documents", "iCloud Drive"},
+	{"iCloud Drive", "iCloud Drive"},
+	{"Google Drive", "Google Drive"},
+}
+
+// filesystemProblems describes the filesystems filesystemType names
+var filesystemProblems = map[string]FSProblem{
+	"exfat":  {Message: "the project is on an exFAT volume, which has no symlinks", Symlinks: true},
+	"fat":    {Message: "the project is on a FAT volume, which has no symlinks", Symlinks: true},
+	"nfs":    {Message: "the project is on an NFS mount; symlinks may not resolve on other clients", Symlinks: true},
+	"smb":    {Message: "the project is on an SMB share, which often stores symlinks as plain files", Symlinks: true},
+	"afp":    {Message: "the project is on an AFP share, which often stores symlinks as plain files", Symlinks: true},
+	"webdav": {Message: "the project is on a WebDAV mount, which has no symlinks", Symlinks: true},
+	"9p":     {Message: "the project is on a 9p mount such as a Windows drive under WSL, where Windows tools can't follow symlinks", Symlinks: true},
+}
+
+// CheckFilesystem looks for properties of the filesystem of the current
+// project that break the outputs of targets: missing symlink support,
+// network and synced folders, and file names that collide on
+// case-insensitive filesystems
+func (e *Engine) CheckFilesystem(targets []string) ([]FSProblem, error) {
+	var problems []FSProblem
+
+	// Writing a link is the only sure test of symlink support
+	probe := filepath.Join(".viberules", fmt.Sprintf(".symlink-probe-%d", os.Getpid()))
+	if err := e.fs.Symlink(filepath.Base(e.rulesFile), probe); err != nil {
+		problems = append(problems, FSProblem{Message: fmt.Sprintf("symlinks can't be created here: %v", err), Symlinks: true})
+	} else {
+		e.fs.Remove(probe)
+	}
+
+	abs, err := e.fs.Abs(".")
+	if err != nil {
+		return nil, err
+	}
+	if problem, ok := filesystemProblems[filesystemType(abs)]; ok {
+		problems = append(problems, problem)
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(abs), "/") {
+		for _, folder := range syncedFolders {
+			if dir == folder.name || strings.HasPrefix(dir, folder.name+" - ") {
+				problems = append(problems, FSProblem{
+					Message:  fmt.Sprintf("the project is in a folder synced by %s, which doesn't keep symlinks across machines", folder.service),
+					Symlinks: true,
+				})
+			}
+		}
+	}
+
+	collisions, err := e.caseCollisions(targets)
+	if err != nil {
+		return nil, err
+	}
+	for _, collision := range collisions {
+		message := fmt.Sprintf("%s and the output %s differ only in case; they are one file on case-insensitive filesystems", collision[0], collision[1])
+		if e.caseInsensitive() {
+			message = fmt.Sprintf("%s takes the place of the output %s on this case-insensitive filesystem", collision[0], collision[1])
+		}
+		problems = append(problems, FSProblem{Message: message})
+	}
+	return problems, nil
+}
+
+// caseInsensitive reports whether the project's filesystem ignores the case
+// of file names, judging by whether the rules file is found in upper case
+func (e *Engine) caseInsensitive() bool {
+	upper := strings.ToUpper(e.rulesFile)
+	if upper == e.rulesFile {
+		return false
+	}
+	_, err := e.fs.Lstat(upper)
+	return err == nil
+}
+
+// caseCollisions returns pairs of a file and an output of targets whose
+// names differ only in case, as claude.md and CLAUDE.md
+func (e *Engine) caseCollisions(targets []string) ([][2]string, error) {
+	var collisions [][2]string
+	seen := make(map[string]bool)
+	for _, name := range targets {
+		target, ok := e.FindTarget(name)
+		if !ok {
+			continue
+		}
+		links, err := e.OutputLinks(target)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			output := filepath.Clean(link.Target)
+			if seen[output] {
+				continue
+			}
+			seen[output] = true
+			entries, err := e.fs.ReadDir(filepath.Dir(output))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if entry.Name() != filepath.Base(output) && strings.EqualFold(entry.Name(), filepath.Base(output)) {
+					collisions = append(collisions, [2]string{filepath.Join(filepath.Dir(output), entry.Name()), output})
+				}
+			}
+		}
+	}
+	return collisions, nil
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFilesystem(t *testing.T) {
+	e := setupProject(t, "rules")
+	if problems, err := e.CheckFilesystem([]string{"claude"}); err != nil || len(problems) != 0 {
+		t.Fatalf("CheckFilesystem() = %+v, %v; want no problems", problems, err)
+	}
+	if entries, _ := os.ReadDir(".viberules"); len(entries) != 1 {
+		t.Errorf(".viberules has %d entries, want the symlink probe removed", len(entries))
+	}
+
+	// claude.md is CLAUDE.md on case-insensitive filesystems
+	writeTestFile(t, "claude.md", "lower case\n")
+	problems, err := e.CheckFilesystem([]string{"claude", "codex"})
+	if err != nil || len(problems) != 1 || problems[0].Symlinks || !strings.Contains(problems[0].Message, "claude.md") {
+		t.Errorf("CheckFilesystem() = %+v, %v; want the claude.md collision", problems, err)
+	}
+	if problems, _ := e.CheckFilesystem([]string{"codex"}); len(problems) != 0 {
+		t.Errorf("CheckFilesystem() without claude = %+v, want no problems", problems)
+	}
+}
+
+func TestCheckFilesystemSyncedFolder(t *testing.T) {
+	e := New(OSFS{})
+	dir := filepath.Join(t.TempDir(), "OneDrive - Acme", "project")
+	writeTestFile(t, filepath.Join(dir, ".viberules", "rules.md"), "rules")
+	oldDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldDir) })
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	problems, err := e.CheckFilesystem(nil)
+	if err != nil || len(problems) != 1 || !problems[0].Symlinks || !strings.Contains(problems[0].Message, "OneDrive") {
+		t.Errorf("CheckFilesystem() = %+v, %v; want the OneDrive folder", problems, err)
+	}
+}
@@ -0,0 +1,30 @@
+package core
+
+import "syscall"
+
+// filesystemNames maps the macOS filesystem names CheckFilesystem knows to its own
+var filesystemNames = map[string]string{
+	"nfs":    "nfs",
+	"smbfs":  "smb",
+	"afpfs":  "afp",
+	"webdav": "webdav",
+	"exfat":  "exfat",
+	"msdos":  "fat",
+}
+
+// filesystemType returns the type of the filesystem holding path, or "" when
+// it is none CheckFilesystem knows
+func filesystemType(path string) string {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return ""
+	}
+	var name []byte
+	for _, c := range stat.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return filesystemNames[string(name)]
+}
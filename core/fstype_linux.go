@@ -0,0 +1,24 @@
+package core
+
+import "syscall"
+
+// filesystemMagic names the filesystems CheckFilesystem knows by their statfs magic
+var filesystemMagic = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "smb", // cifs
+	0xfe534d42: "smb", // smb2
+	0x2011bab0: "exfat",
+	0x4d44:     "fat",
+	0x01021997: "9p", // WSL's Windows drives among others
+}
+
+// filesystemType returns the type of the filesystem holding path, or "" when
+// it is none CheckFilesystem knows
+func filesystemType(path string) string {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return ""
+	}
+	return filesystemMagic[uint32(stat.Type)]
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package core
+
+// filesystemType can't tell filesystems apart on this platform
+func filesystemType(path string) string {
+	return ""
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenderContext describes the output being generated
+type RenderContext struct {
+	Target string // target name
+	Output string // output path relative to the project root
+	Source string // rules file path relative to the project root
+	Part   bool   // Source is a RulesDir file rendered on its own; content shared by every output is added to the main output only
+
+	// Overflow holds content split off the output by FitBudget, written as additional parts
+	Overflow [][]byte
+}
+
+// Transform is a single generation stage. It receives the content produced by
+// the previous stage and returns the content for the next one.
+type Transform func(ctx *RenderContext, content []byte) ([]byte, error)
+
+// Pipeline is an ordered list of transforms applied to the rules file.
+// An empty pipeline produces a byte-identical copy.
+type Pipeline []Transform
+
+// Render reads the source file of ctx and runs it through every stage of p
+func (e *Engine) Render(p Pipeline, ctx *RenderContext) ([]byte, error) {
+	content, err := e.fs.ReadFile(ctx.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	for _, transform := range p {
+		content, err = transform(ctx, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", ctx.Output, err)
+		}
+	}
+
+	return content, nil
+}
+
+// RenderLink renders the output of a single link of a target through p
+func (e *Engine) RenderLink(p Pipeline, targetName string, link SymlinkDef) ([]byte, error) {
+	return e.Render(p, linkContext(targetName, link))
+}
+
+func linkContext(targetName string, link SymlinkDef) *RenderContext {
+	return &RenderContext{
+		Target: targetName,
+		Output: link.Target,
+		Source: link.ResolvedSource(),
+		Part:   link.Part,
+	}
+}
+
+// renderedOutput is the content of one file written for a target
+type renderedOutput struct {
+	Path    string
+	Content []byte
+}
+
+// renderLinkOutputs renders a link and the files split off it
+func (e *Engine) renderLinkOutputs(p Pipeline, target Target, link SymlinkDef) ([]renderedOutput, error) {
+	ctx := linkContext(target.Name, link)
+	content, err := e.Render(p, ctx)
+	if err != nil {
+		return nil, err
+	}
+	outputs := []renderedOutput{{Path: link.Target, Content: content}}
+	for i, chunk := range ctx.Overflow {
+		outputs = append(outputs, renderedOutput{Path: target.overflowPath(i), Content: chunk})
+	}
+	return outputs, nil
+}
+
+// InjectFrontmatter returns a transform that prepends a YAML frontmatter block
+// built from fields. An existing frontmatter block in the content is replaced.
+func InjectFrontmatter(fields map[string]interface{}) Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		if len(fields) == 0 {
+			return content, nil
+		}
+
+		header, err := yaml.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal frontmatter: %w", err)
+		}
+
+		var out bytes.Buffer
+		out.WriteString("---\n")
+		out.Write(header)
+		out.WriteString("---\n")
+		out.Write(stripFrontmatter(content))
+		return out.Bytes(), nil
+	}
+}
+
+// stripFrontmatter removes a leading YAML frontmatter block if present
+func stripFrontmatter(content []byte) []byte {
+	if !bytes.HasPrefix(content, []byte("---\n")) {
+		return content
+	}
+	rest := content[len("---\n"):]
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		return content
+	}
+	return rest[end+len("\n---\n"):]
+}
+
+// outputPaths returns the paths of outputs
+func outputPaths(outputs []renderedOutput) []string {
+	paths := make([]string, len(outputs))
+	for i, output := range outputs {
+		paths[i] = output.Path
+	}
+	return paths
+}
+
+// GenerateTargetFiles renders every output of a target through pipeline and
+// writes the results as real files. See CopyTargetFiles for how recorded and
+// overwrite protect files edited by hand.
+func (e *Engine) GenerateTargetFiles(targetName string, pipeline Pipeline, recorded map[string]string, overwrite bool) (map[string]string, error) {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return nil, unknownTarget(targetName)
+	}
+
+	links, err := e.OutputLinks(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []renderedOutput
+	for _, link := range links {
+		rendered, err := e.renderLinkOutputs(pipeline, target, link)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, rendered...)
+	}
+	if err := e.removeStaleParts(target, outputPaths(outputs), recorded, overwrite); err != nil {
+		return nil, err
+	}
+
+	written := make(map[string]string)
+	for _, output := range outputs {
+		if err := e.writeManagedFile(output.Path, output.Content, recorded[output.Path], overwrite); err != nil {
+			return nil, err
+		}
+		written[output.Path] = Checksum(output.Content)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPipelineRender(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	upper := func(ctx *RenderContext, content []byte) ([]byte, error) {
+		return []byte(strings.ToUpper(string(content)) + ctx.Target), nil
+	}
+
+	link := SymlinkDef{Source: ".viberules/rules.md", Target: "CLAUDE.md"}
+	content, err := e.RenderLink(Pipeline{upper}, "claude", link)
+	if err != nil {
+		t.Fatalf("RenderLink failed: %v", err)
+	}
+	if string(content) != "# RULES\nclaude" {
+		t.Errorf("Rendered content = %q, want %q", content, "# RULES\nclaude")
+	}
+
+	// Empty pipeline is a plain copy
+	content, err = e.RenderLink(nil, "claude", link)
+	if err != nil {
+		t.Fatalf("RenderLink with empty pipeline failed: %v", err)
+	}
+	if string(content) != "# Rules\n" {
+		t.Errorf("Empty pipeline content = %q, want source content", content)
+	}
+}
+
+func TestInjectFrontmatter(t *testing.T) {
+	transform := InjectFrontmatter(map[string]interface{}{"alwaysApply": true})
+
+	content, err := transform(&RenderContext{}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("InjectFrontmatter failed: %v", err)
+	}
+	want := "---\nalwaysApply: true\n---\n# Rules\n"
+	if string(content) != want {
+		t.Errorf("Content = %q, want %q", content, want)
+	}
+
+	// Existing frontmatter is replaced, not duplicated
+	content, err = transform(&RenderContext{}, content)
+	if err != nil {
+		t.Fatalf("InjectFrontmatter on existing frontmatter failed: %v", err)
+	}
+	if string(content) != want {
+		t.Errorf("Content after second pass = %q, want %q", content, want)
+	}
+}
+
+func TestGenerateTargetFilesDrift(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	pipeline := Pipeline{InjectFrontmatter(map[string]interface{}{"description": "project rules"})}
+	recorded, err := e.GenerateTargetFiles("claude", pipeline, nil, false)
+	if err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+
+	content, err := os.ReadFile("CLAUDE.md")
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "---\ndescription: project rules\n---\n") {
+		t.Errorf("Generated file is missing frontmatter: %q", content)
+	}
+
+	statuses, _ := e.CheckTargetOutputs("claude", StrategyGenerate, pipeline, recorded)
+	if statuses[0].State != OutputOK {
+		t.Errorf("State after generate = %s, want ok", statuses[0].State)
+	}
+
+	// Changing the pipeline makes the output stale
+	changed := Pipeline{InjectFrontmatter(map[string]interface{}{"description": "other"})}
+	statuses, _ = e.CheckTargetOutputs("claude", StrategyGenerate, changed, recorded)
+	if statuses[0].State != OutputStale {
+		t.Errorf("State after pipeline change = %s, want stale", statuses[0].State)
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GitExcludePath returns the repository's personal exclude file
+// (.git/info/exclude) for the git repository containing dir, relative to dir
+// when git reports it so. Worktrees and submodules resolve to their own
+// git directory.
+func GitExcludePath(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// GitHooksDir returns the directory git runs hooks from for the repository
+// containing dir, relative to dir when git reports it so
+func GitHooksDir(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// GitAttributesPath returns the repository's personal attributes file
+// (.git/info/attributes) for the git repository containing dir, relative to
+// dir when git reports it so
+func GitAttributesPath(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--git-path", "info/attributes")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// GitConfigSet sets key to value in the config of the repository containing dir
+func GitConfigSet(dir, key, value string) error {
+	_, err := runGit(dir, "config", key, value)
+	return err
+}
+
+// GitConfigRemoveSection removes a section, such as merge.viberules, from the
+// config of the repository containing dir. A missing section is not an error.
+func GitConfigRemoveSection(dir, section string) error {
+	if _, err := runGit(dir, "config", "--get-regexp", "^"+regexp.QuoteMeta(section)+"\\."); err != nil {
+		return nil
+	}
+	_, err := runGit(dir, "config", "--remove-section", section)
+	return err
+}
+
+// GitPrefix returns dir relative to the root of its git repository, with a
+// trailing slash, or an empty string at the root
+func GitPrefix(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitRoot returns the root directory of the git repository containing dir
+func GitRoot(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// GitInit creates an empty git repository in dir
+func GitInit(dir string) error {
+	_, err := runGit(dir, "init", "-q")
+	return err
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitExcludePath(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if _, err := GitExcludePath(dir); err == nil {
+		t.Error("GitExcludePath() outside a repository should fail")
+	}
+
+	if _, err := runGit(dir, "init", "-q"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	sub := filepath.Join(dir, "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	path, err := GitExcludePath(sub)
+	if err != nil {
+		t.Fatalf("GitExcludePath() failed: %v", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(sub, path)
+	}
+	if want := filepath.Join(dir, ".git", "info", "exclude"); filepath.Clean(path) != want {
+		t.Errorf("GitExcludePath() = %q, want %q", path, want)
+	}
+
+	if prefix, err := GitPrefix(sub); err != nil || prefix != "pkg/" {
+		t.Errorf("GitPrefix() = %q, %v; want pkg/", prefix, err)
+	}
+}
+
+func TestGitHooksDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if _, err := runGit(dir, "init", "-q"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	if path, err := GitHooksDir(dir); err != nil || filepath.Clean(path) != filepath.Join(".git", "hooks") {
+		t.Errorf("GitHooksDir() = %q, %v; want .git/hooks", path, err)
+	}
+
+	// core.hooksPath moves the hooks out of the git directory
+	if _, err := runGit(dir, "config", "core.hooksPath", ".githooks"); err != nil {
+		t.Fatalf("git config failed: %v", err)
+	}
+	if path, err := GitHooksDir(dir); err != nil || path != ".githooks" {
+		t.Errorf("GitHooksDir() with core.hooksPath = %q, %v; want .githooks", path, err)
+	}
+}
+
+func TestGitConfigSection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if _, err := runGit(dir, "init", "-q"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	if err := GitConfigRemoveSection(dir, "merge.viberules"); err != nil {
+		t.Errorf("GitConfigRemoveSection() of a missing section = %v, want nil", err)
+	}
+	if err := GitConfigSet(dir, "merge.viberules.driver", "viberules merge-driver %O %A %B"); err != nil {
+		t.Fatalf("GitConfigSet() failed: %v", err)
+	}
+	if out, err := runGit(dir, "config", "merge.viberules.driver"); err != nil || string(out) != "viberules merge-driver %O %A %B\n" {
+		t.Errorf("merge.viberules.driver = %q, %v", out, err)
+	}
+	if err := GitConfigRemoveSection(dir, "merge.viberules"); err != nil {
+		t.Fatalf("GitConfigRemoveSection() failed: %v", err)
+	}
+	if _, err := runGit(dir, "config", "merge.viberules.driver"); err == nil {
+		t.Error("merge.viberules.driver should be removed")
+	}
+
+	if path, err := GitAttributesPath(dir); err != nil || filepath.Clean(path) != filepath.Join(".git", "info", "attributes") {
+		t.Errorf("GitAttributesPath() = %q, %v; want .git/info/attributes", path, err)
+	}
+}
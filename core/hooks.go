@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HooksSource declares the Claude Code hooks shared by the team
+var HooksSource = filepath.Join(".viberules", "hooks.yaml")
+
+// hookEvents are the Claude Code events hooks can run on
+var hookEvents = []string{
+	"PreToolUse", "PostToolUse", "UserPromptSubmit", "Notification",
+	"Stop", "SubagentStop", "PreCompact", "SessionStart", "SessionEnd",
+}
+
+// Hook is a command run by Claude Code on an event. Matcher selects tools
+// for PreToolUse and PostToolUse, e.g. "Bash" or "Edit|Write".
+type Hook struct {
+	Matcher string `yaml:"matcher,omitempty"`
+	Command string `yaml:"command"`
+	Timeout int    `yaml:"timeout,omitempty"` // seconds
+}
+
+// LoadHooks reads HooksSource, keyed by event. It returns nil if the project shares no hooks.
+func (e *Engine) LoadHooks() (map[string][]Hook, error) {
+	content, err := e.fs.ReadFile(HooksSource)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", HooksSource, err)
+	}
+
+	var hooks map[string][]Hook
+	if err := yaml.Unmarshal(content, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", HooksSource, err)
+	}
+	for event, list := range hooks {
+		if !containsString(hookEvents, event) {
+			return nil, fmt.Errorf("%s: unknown hook event %s", HooksSource, event)
+		}
+		for _, hook := range list {
+			if hook.Command == "" {
+				return nil, fmt.Errorf("%s: %s hook without a command", HooksSource, event)
+			}
+		}
+	}
+	return hooks, nil
+}
+
+// RenderHooks converts hooks to the hooks setting of .claude/settings.json,
+// grouping commands that share a matcher in the order they are declared
+func RenderHooks(hooks map[string][]Hook) map[string]interface{} {
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	rendered := make(map[string]interface{})
+	for _, event := range events {
+		var groups []map[string]interface{}
+		index := make(map[string]int)
+		for _, hook := range hooks[event] {
+			command := map[string]interface{}{"type": "command", "command": hook.Command}
+			if hook.Timeout > 0 {
+				command["timeout"] = hook.Timeout
+			}
+
+			i, ok := index[hook.Matcher]
+			if !ok {
+				i = len(groups)
+				index[hook.Matcher] = i
+				group := map[string]interface{}{"hooks": []interface{}{}}
+				if hook.Matcher != "" {
+					group["matcher"] = hook.Matcher
+				}
+				groups = append(groups, group)
+			}
+			groups[i]["hooks"] = append(groups[i]["hooks"].([]interface{}), command)
+		}
+		rendered[event] = groups
+	}
+	return rendered
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRenderHooks(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, HooksSource, `PreToolUse:
+  - matcher: Bash
+    command: ./scripts/check-command.sh
+  - matcher: Bash
+    command: ./scripts/audit.sh
+    timeout: 10
+Stop:
+  - command: make lint
+`)
+
+	settings, err := e.LoadClaudeSettings()
+	if err != nil {
+		t.Fatalf("LoadClaudeSettings failed: %v", err)
+	}
+	got, err := json.Marshal(settings["hooks"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"PreToolUse":[{"hooks":[{"command":"./scripts/check-command.sh","type":"command"},` +
+		`{"command":"./scripts/audit.sh","timeout":10,"type":"command"}],"matcher":"Bash"}],` +
+		`"Stop":[{"hooks":[{"command":"make lint","type":"command"}]}]}`
+	if string(got) != want {
+		t.Errorf("hooks = %s\nwant %s", got, want)
+	}
+}
+
+func TestLoadHooksValidates(t *testing.T) {
+	e := New(OSFS{})
+	tests := []struct {
+		name  string
+		hooks string
+	}{
+		{"unknown event", "BeforeEdit:\n  - command: true\n"},
+		{"missing command", "Stop:\n  - matcher: Bash\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupProject(t, "# Rules\n")
+			writeTestFile(t, HooksSource, tt.hooks)
+			if _, err := e.LoadHooks(); err == nil {
+				t.Error("LoadHooks should fail")
+			}
+		})
+	}
+}
+
+func TestHooksDeclaredTwice(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, HooksSource, "Stop:\n  - command: make lint\n")
+	writeTestFile(t, ClaudeSettingsSource, "hooks: {}\n")
+
+	if _, err := e.LoadClaudeSettings(); err == nil {
+		t.Error("LoadClaudeSettings should reject hooks declared in both sources")
+	}
+}
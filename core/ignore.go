@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// IgnoreSource lists paths assistants must not read, in .gitignore syntax
+var IgnoreSource = filepath.Join(".viberules", "ignore")
+
+const ignoreHeader = "# Generated by viberules from .viberules/ignore. Do not edit.\n"
+
+// IgnoreTool is an assistant that reads an ignore file from the project root
+type IgnoreTool struct {
+	Name string // matches the target name for tools that are also rule targets
+	Path string
+}
+
+// GetIgnoreTools returns the assistants viberules can write ignore files for
+func GetIgnoreTools() []IgnoreTool {
+	return []IgnoreTool{
+		{Name: "cursor", Path: ".cursorignore"},
+		{Name: "gemini", Path: ".aiexclude"},
+		{Name: "codeium", Path: ".codeiumignore"},
+		{Name: "aider", Path: ".aiderignore"},
+	}
+}
+
+// FindIgnoreTool returns the ignore tool with the given name
+func FindIgnoreTool(name string) (IgnoreTool, bool) {
+	for _, tool := range GetIgnoreTools() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return IgnoreTool{}, false
+}
+
+// RenderIgnoreFile returns the content of every generated ignore file,
+// or nil if the project has no ignore source
+func (e *Engine) RenderIgnoreFile() ([]byte, error) {
+	source, err := e.fs.ReadFile(IgnoreSource)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreSource, err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(ignoreHeader)
+	out.Write(source)
+	if len(source) > 0 && !bytes.HasSuffix(source, []byte("\n")) {
+		out.WriteString("\n")
+	}
+	return out.Bytes(), nil
+}
+
+// WriteIgnoreFile writes the ignore file of a tool from the ignore source and
+// returns the checksum to record. Without a source, a previously written file
+// is removed and an empty checksum is returned.
+func (e *Engine) WriteIgnoreFile(tool IgnoreTool, recorded string, overwrite bool) (string, error) {
+	content, err := e.RenderIgnoreFile()
+	if err != nil {
+		return "", err
+	}
+	if content == nil {
+		return "", e.RemoveManagedFile(tool.Path, recorded, overwrite)
+	}
+	return e.WriteManagedFile(tool.Path, content, recorded, overwrite)
+}
+
+// CheckIgnoreFile reports the state of a tool's ignore file. ok is false when
+// the project has no ignore source.
+func (e *Engine) CheckIgnoreFile(tool IgnoreTool, recorded string) (status OutputStatus, ok bool, err error) {
+	content, err := e.RenderIgnoreFile()
+	if err != nil || content == nil {
+		return OutputStatus{}, false, err
+	}
+	status = OutputStatus{Target: tool.Name, Path: tool.Path, State: e.CheckManagedFile(tool.Path, content, recorded)}
+	e.reportDrift(status)
+	return status, true, nil
+}
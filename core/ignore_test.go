@@ -0,0 +1,64 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteIgnoreFile(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	tool, _ := FindIgnoreTool("cursor")
+
+	// Without a source nothing is written
+	sum, err := e.WriteIgnoreFile(tool, "", false)
+	if err != nil || sum != "" || fileExistsForTest(".cursorignore") {
+		t.Fatalf("WriteIgnoreFile without source = %q, %v; want nothing written", sum, err)
+	}
+
+	writeTestFile(t, IgnoreSource, ".env\nsecrets/")
+	sum, err = e.WriteIgnoreFile(tool, "", false)
+	if err != nil {
+		t.Fatalf("WriteIgnoreFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(".cursorignore")
+	if !strings.HasSuffix(string(content), ".env\nsecrets/\n") || !strings.HasPrefix(string(content), "# Generated by viberules") {
+		t.Errorf(".cursorignore = %q, want header and source patterns", content)
+	}
+
+	status, ok, err := e.CheckIgnoreFile(tool, sum)
+	if err != nil || !ok || status.State != OutputOK {
+		t.Errorf("CheckIgnoreFile = %v, %v, %v; want ok", status, ok, err)
+	}
+
+	// Changing the source makes the file stale; a rewrite is allowed
+	writeTestFile(t, IgnoreSource, ".env\n")
+	if status, _, _ := e.CheckIgnoreFile(tool, sum); status.State != OutputStale {
+		t.Errorf("State after source change = %s, want stale", status.State)
+	}
+	if sum, err = e.WriteIgnoreFile(tool, sum, false); err != nil {
+		t.Fatalf("Rewriting a stale ignore file failed: %v", err)
+	}
+
+	// Removing the source removes the generated file
+	if err := os.Remove(IgnoreSource); err != nil {
+		t.Fatalf("Failed to remove ignore source: %v", err)
+	}
+	if _, err := e.WriteIgnoreFile(tool, sum, false); err != nil {
+		t.Fatalf("WriteIgnoreFile after removing source failed: %v", err)
+	}
+	if fileExistsForTest(".cursorignore") {
+		t.Error(".cursorignore should be removed with its source")
+	}
+}
+
+func TestWriteIgnoreFileRefusesUnmanaged(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, IgnoreSource, ".env\n")
+	writeTestFile(t, ".aiexclude", "hand written\n")
+
+	tool, _ := FindIgnoreTool("gemini")
+	if _, err := e.WriteIgnoreFile(tool, "", false); err == nil {
+		t.Error("WriteIgnoreFile should refuse to overwrite an unmanaged file")
+	}
+}
@@ -0,0 +1,460 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Import is the project of another rules tool, converted for viberules
+type Import struct {
+	Tool        string
+	Rules       []byte   // content of the rules file
+	Targets     []string // viberules targets the tool was set up for; none when it didn't say
+	Skipped     []string // assistants of the tool viberules has no target for
+	Ignore      []string // patterns for IgnoreSource
+	Files       []string // the tool's files that were read
+	Unconverted []string // the tool's files viberules can't convert, such as MCP servers
+}
+
+// ImportTools are the rules tools ImportProject reads
+var ImportTools = []string{"rulesync", "ruler", "ai-rules"}
+
+// importTargets maps the assistant names of each tool to viberules targets
+var importTargets = map[string]map[string]string{
+	"rulesync": {"claudecode": "claude", "amazonqcli": "amazonq", "geminicli": "gemini", "codexcli": "codex", "agentsmd": "codex", "cursor": "cursor"},
+	"ruler":    {"claude": "claude", "amazonqcli": "amazonq", "gemini-cli": "gemini", "codex": "codex", "agentsmd": "codex", "cursor": "cursor"},
+	"ai-rules": {"claude": "claude", "gemini": "gemini", "codex": "codex", "cursor": "cursor"},
+}
+
+// ImportProject reads the project of tool at the root of the engine: its
+// rules, the assistants it writes them for and its ignore entries
+func (e *Engine) ImportProject(tool string) (Import, error) {
+	imported := Import{Tool: tool}
+	var err error
+	switch tool {
+	case "rulesync":
+		err = e.readRulesync(&imported)
+	case "ruler":
+		err = e.readRuler(&imported)
+	case "ai-rules":
+		err = e.readAIRules(&imported)
+	default:
+		return Import{}, fmt.Errorf("unknown tool: %s (available: %s)", tool, strings.Join(ImportTools, ", "))
+	}
+	if err != nil {
+		return Import{}, err
+	}
+	if len(imported.Files) == 0 {
+		return Import{}, fmt.Errorf("no %s project in the current directory", tool)
+	}
+	return imported, nil
+}
+
+// ruleFile is a rules file of another tool with the frontmatter fields that
+// matter to viberules
+type ruleFile struct {
+	path    string
+	body    []byte
+	root    bool     // the main rules, which come first
+	targets []string // viberules targets it is limited to; all when empty
+	globs   []string // files it applies to; all when empty
+}
+
+// rulesyncFrontmatter is the frontmatter of a rulesync rule
+type rulesyncFrontmatter struct {
+	Root    bool        `yaml:"root"`
+	Targets interface{} `yaml:"targets"` // a list, or "*" for every assistant
+	Globs   interface{} `yaml:"globs"`
+}
+
+// readRulesync reads rulesync.jsonc and the rules in .rulesync/rules (or
+// .rulesync itself, as older versions keep them) and .rulesyncignore
+func (e *Engine) readRulesync(im *Import) error {
+	for _, path := range []string{"rulesync.jsonc", "rulesync.json"} {
+		content, err := e.fs.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var config struct {
+			Targets []string `json:"targets"`
+		}
+		if err := json.Unmarshal(stripJSONComments(content), &config); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		im.Files = append(im.Files, path)
+		e.addTargets(im, config.Targets)
+		break
+	}
+
+	dir := filepath.Join(".rulesync", "rules")
+	if !e.isDir(dir) {
+		dir = ".rulesync"
+	}
+	paths, err := e.markdownFiles(dir, false)
+	if err != nil {
+		return err
+	}
+	var rules []ruleFile
+	for _, path := range paths {
+		content, err := e.fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		frontmatter, body := splitFrontmatter(content)
+		var fields rulesyncFrontmatter
+		if err := yaml.Unmarshal(bytes.Trim(frontmatter, "-\n"), &fields); err != nil {
+			return fmt.Errorf("failed to parse the frontmatter of %s: %w", path, err)
+		}
+		rule := ruleFile{path: path, body: body, root: fields.Root, globs: stringList(fields.Globs)}
+		if names := stringList(fields.Targets); len(names) > 0 && !containsString(names, "*") {
+			if rule.targets = e.mapTargets(im, names); len(rule.targets) == 0 {
+				im.Unconverted = append(im.Unconverted, path)
+				continue
+			}
+		}
+		rules = append(rules, rule)
+	}
+	im.addRules(rules)
+
+	if err := e.readIgnore(im, ".rulesyncignore"); err != nil {
+		return err
+	}
+	e.addUnconverted(im, filepath.Join(".rulesync", "commands"), filepath.Join(".rulesync", "subagents"),
+		filepath.Join(".rulesync", ".mcp.json"), filepath.Join(".rulesync", "mcp.json"))
+	return nil
+}
+
+// readRuler reads .ruler/ruler.toml and every markdown file below .ruler,
+// which ruler concatenates with AGENTS.md first
+func (e *Engine) readRuler(im *Import) error {
+	if !e.isDir(".ruler") {
+		return nil
+	}
+	configPath := filepath.Join(".ruler", "ruler.toml")
+	content, err := e.fs.ReadFile(configPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	default:
+		im.Files = append(im.Files, configPath)
+		defaults, enabled := readRulerConfig(string(content))
+		if len(defaults) == 0 {
+			defaults = enabled
+		}
+		e.addTargets(im, defaults)
+	}
+
+	paths, err := e.markdownFiles(".ruler", true)
+	if err != nil {
+		return err
+	}
+	var rules []ruleFile
+	for _, path := range paths {
+		content, err := e.fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		rules = append(rules, ruleFile{path: path, body: content, root: path == filepath.Join(".ruler", "AGENTS.md")})
+	}
+	im.addRules(rules)
+	e.addUnconverted(im, filepath.Join(".ruler", "mcp.json"))
+	return nil
+}
+
+// readRulerConfig returns default_agents and the agents enabled in their
+// [agents.<name>] table of a ruler.toml. Only those keys are read, so a
+// small subset of TOML is enough.
+func readRulerConfig(content string) (defaults, enabled []string) {
+	table := ""
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+		if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+			table = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case table == "" && key == "default_agents":
+			// Arrays may span lines
+			for strings.HasPrefix(value, "[") && !strings.Contains(value, "]") && i+1 < len(lines) {
+				i++
+				value += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+			}
+			for _, item := range strings.Split(strings.Trim(value, "[] "), ",") {
+				if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+					defaults = append(defaults, item)
+				}
+			}
+		case strings.HasPrefix(table, "agents.") && key == "enabled" && value == "true":
+			enabled = append(enabled, strings.Trim(strings.TrimPrefix(table, "agents."), `"'`))
+		}
+	}
+	return defaults, enabled
+}
+
+// stripTOMLComment removes a # comment outside strings from a TOML line
+func stripTOMLComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// aiRulesFrontmatter is the frontmatter of an ai-rules rule
+type aiRulesFrontmatter struct {
+	AlwaysApply  *bool       `yaml:"alwaysApply"`
+	FileMatching interface{} `yaml:"fileMatching"`
+}
+
+// readAIRules reads ai-rules/ai-rules-config.yaml and the rules in ai-rules
+func (e *Engine) readAIRules(im *Import) error {
+	if !e.isDir("ai-rules") {
+		return nil
+	}
+	configPath := filepath.Join("ai-rules", "ai-rules-config.yaml")
+	content, err := e.fs.ReadFile(configPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	default:
+		var config struct {
+			Agents []string `yaml:"agents"`
+		}
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", configPath, err)
+		}
+		im.Files = append(im.Files, configPath)
+		e.addTargets(im, config.Agents)
+	}
+
+	paths, err := e.markdownFiles("ai-rules", false)
+	if err != nil {
+		return err
+	}
+	var rules []ruleFile
+	for _, path := range paths {
+		content, err := e.fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		frontmatter, body := splitFrontmatter(content)
+		var fields aiRulesFrontmatter
+		if err := yaml.Unmarshal(bytes.Trim(frontmatter, "-\n"), &fields); err != nil {
+			return fmt.Errorf("failed to parse the frontmatter of %s: %w", path, err)
+		}
+		rule := ruleFile{path: path, body: body}
+		if fields.AlwaysApply == nil || !*fields.AlwaysApply {
+			rule.globs = stringList(fields.FileMatching)
+		}
+		rules = append(rules, rule)
+	}
+	im.addRules(rules)
+	e.addUnconverted(im, filepath.Join("ai-rules", "commands"), filepath.Join("ai-rules", "skills"), filepath.Join("ai-rules", "mcp.json"))
+	return nil
+}
+
+// addTargets records the viberules targets for the assistants of the tool
+func (e *Engine) addTargets(im *Import, names []string) {
+	for _, target := range e.mapTargets(im, names) {
+		if !containsString(im.Targets, target) {
+			im.Targets = append(im.Targets, target)
+		}
+	}
+}
+
+// mapTargets returns the viberules targets of assistant names, recording
+// the names without one as skipped
+func (e *Engine) mapTargets(im *Import, names []string) []string {
+	var targets []string
+	for _, name := range names {
+		if name == "*" {
+			return nil
+		}
+		target, ok := importTargets[im.Tool][name]
+		if _, exists := e.FindTarget(target); !ok || !exists {
+			if !containsString(im.Skipped, name) {
+				im.Skipped = append(im.Skipped, name)
+			}
+			continue
+		}
+		if !containsString(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// addRules joins rules into the rules file: root rules first, then the
+// others in path order. Rules for some assistants only go in viberules:only
+// sections, and rules for some files say which.
+func (im *Import) addRules(rules []ruleFile) {
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].root && !rules[j].root })
+
+	var out bytes.Buffer
+	for _, rule := range rules {
+		im.Files = append(im.Files, rule.path)
+		body := bytes.TrimSpace(rule.body)
+		if len(body) == 0 {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		if len(rule.targets) > 0 {
+			fmt.Fprintf(&out, "<!-- viberules:only %s -->\n", strings.Join(rule.targets, ","))
+		}
+		if len(rule.globs) > 0 {
+			fmt.Fprintf(&out, "_Applies to files matching %s._\n\n", "`"+strings.Join(rule.globs, "`, `")+"`")
+		}
+		out.Write(body)
+		out.WriteString("\n")
+		if len(rule.targets) > 0 {
+			out.WriteString("<!-- /viberules:only -->\n")
+		}
+	}
+	im.Rules = out.Bytes()
+}
+
+// readIgnore adds the patterns of an ignore file in .gitignore syntax
+func (e *Engine) readIgnore(im *Import, path string) error {
+	content, err := e.fs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	im.Files = append(im.Files, path)
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			im.Ignore = append(im.Ignore, line)
+		}
+	}
+	return nil
+}
+
+// addUnconverted records the paths that exist as files viberules can't convert
+func (e *Engine) addUnconverted(im *Import, paths ...string) {
+	for _, path := range paths {
+		if _, err := e.fs.Lstat(path); err == nil {
+			im.Unconverted = append(im.Unconverted, path)
+		}
+	}
+}
+
+// markdownFiles returns the markdown files in dir in path order, and in its
+// subdirectories too with recursive set
+func (e *Engine) markdownFiles(dir string, recursive bool) ([]string, error) {
+	if !e.isDir(dir) {
+		return nil, nil
+	}
+	var paths []string
+	err := e.walkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ".md") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// isDir reports whether path is a directory
+func (e *Engine) isDir(path string) bool {
+	info, err := e.fs.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// stringList returns a YAML value that is a string or a list of strings as a list
+func stringList(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	case []interface{}:
+		var list []string
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// stripJSONComments removes // and /* */ comments outside strings, turning
+// JSONC into JSON
+func stripJSONComments(content []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case inString:
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(content) {
+				i++
+				out.WriteByte(content[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				return out.Bytes()
+			}
+			i += end + 3
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
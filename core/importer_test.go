@@ -0,0 +1,136 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chdirTemp runs the rest of the test in an empty directory
+func chdirTemp(t *testing.T) *Engine {
+	t.Helper()
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(oldDir) })
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+	return New(OSFS{})
+}
+
+func TestImportRulesync(t *testing.T) {
+	e := chdirTemp(t)
+	writeTestFile(t, "rulesync.jsonc", `{
+  // assistants to generate for
+  "targets": ["claudecode", "cursor", "copilot"], /* copilot has no target */
+  "baseDirs": ["."]
+}`)
+	writeTestFile(t, ".rulesync/rules/overview.md", "---\nroot: true\ntargets: [\"*\"]\n---\n# Overview\n\nUse tabs.\n")
+	writeTestFile(t, ".rulesync/rules/api.md", "---\nroot: false\ntargets: [claudecode]\nglobs: [\"api/**/*.go\"]\n---\n# API\n\nReturn errors.\n")
+	writeTestFile(t, ".rulesync/rules/copilot.md", "---\ntargets: [copilot]\n---\nCopilot only.\n")
+	writeTestFile(t, ".rulesync/commands/review.md", "Review.\n")
+	writeTestFile(t, ".rulesyncignore", "# secrets\n.env\n\nbuild/\n")
+
+	imported, err := e.ImportProject("rulesync")
+	if err != nil {
+		t.Fatalf("ImportProject() failed: %v", err)
+	}
+	want := "# Overview\n\nUse tabs.\n\n" +
+		"<!-- viberules:only claude -->\n_Applies to files matching `api/**/*.go`._\n\n# API\n\nReturn errors.\n<!-- /viberules:only -->\n"
+	if string(imported.Rules) != want {
+		t.Errorf("Rules =\n%s\nwant\n%s", imported.Rules, want)
+	}
+	if want := []string{"claude", "cursor"}; !reflect.DeepEqual(imported.Targets, want) {
+		t.Errorf("Targets = %v, want %v", imported.Targets, want)
+	}
+	if want := []string{"copilot"}; !reflect.DeepEqual(imported.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", imported.Skipped, want)
+	}
+	if want := []string{".env", "build/"}; !reflect.DeepEqual(imported.Ignore, want) {
+		t.Errorf("Ignore = %v, want %v", imported.Ignore, want)
+	}
+	want2 := []string{filepath.Join(".rulesync", "rules", "copilot.md"), filepath.Join(".rulesync", "commands")}
+	if !reflect.DeepEqual(imported.Unconverted, want2) {
+		t.Errorf("Unconverted = %v, want %v", imported.Unconverted, want2)
+	}
+}
+
+func TestImportRuler(t *testing.T) {
+	e := chdirTemp(t)
+	writeTestFile(t, ".ruler/ruler.toml", `# Agents to apply rules to
+default_agents = [
+  "claude", # Claude Code
+  "codex",
+]
+
+[agents.cursor]
+enabled = true
+`)
+	writeTestFile(t, ".ruler/style.md", "Use tabs.\n")
+	writeTestFile(t, ".ruler/AGENTS.md", "# Project\n")
+	writeTestFile(t, ".ruler/api/errors.md", "Wrap errors.\n")
+	writeTestFile(t, ".ruler/mcp.json", "{}\n")
+
+	imported, err := e.ImportProject("ruler")
+	if err != nil {
+		t.Fatalf("ImportProject() failed: %v", err)
+	}
+	if want := "# Project\n\nWrap errors.\n\nUse tabs.\n"; string(imported.Rules) != want {
+		t.Errorf("Rules = %q, want %q", imported.Rules, want)
+	}
+	if want := []string{"claude", "codex"}; !reflect.DeepEqual(imported.Targets, want) {
+		t.Errorf("Targets = %v, want default_agents %v", imported.Targets, want)
+	}
+	if want := []string{filepath.Join(".ruler", "mcp.json")}; !reflect.DeepEqual(imported.Unconverted, want) {
+		t.Errorf("Unconverted = %v, want %v", imported.Unconverted, want)
+	}
+
+	// Without default_agents, the enabled agents apply
+	defaults, enabled := readRulerConfig("[agents.claude]\nenabled = true\n[agents.gemini-cli]\nenabled = false\n")
+	if defaults != nil || !reflect.DeepEqual(enabled, []string{"claude"}) {
+		t.Errorf("readRulerConfig() = %v, %v; want only the enabled claude", defaults, enabled)
+	}
+}
+
+func TestImportAIRules(t *testing.T) {
+	e := chdirTemp(t)
+	writeTestFile(t, "ai-rules/ai-rules-config.yaml", "agents: [claude, gemini, goose]\n")
+	writeTestFile(t, "ai-rules/general.md", "---\ndescription: General\nalwaysApply: true\n---\nBe brief.\n")
+	writeTestFile(t, "ai-rules/tests.md", "---\ndescription: Tests\nalwaysApply: false\nfileMatching: \"**/*_test.go\"\n---\nUse table tests.\n")
+
+	imported, err := e.ImportProject("ai-rules")
+	if err != nil {
+		t.Fatalf("ImportProject() failed: %v", err)
+	}
+	if want := "Be brief.\n\n_Applies to files matching `**/*_test.go`._\n\nUse table tests.\n"; string(imported.Rules) != want {
+		t.Errorf("Rules = %q, want %q", imported.Rules, want)
+	}
+	if want := []string{"claude", "gemini"}; !reflect.DeepEqual(imported.Targets, want) || !reflect.DeepEqual(imported.Skipped, []string{"goose"}) {
+		t.Errorf("Targets = %v, skipped %v; want %v and goose", imported.Targets, imported.Skipped, want)
+	}
+}
+
+func TestImportProjectErrors(t *testing.T) {
+	e := chdirTemp(t)
+	if _, err := e.ImportProject("ruler"); err == nil || !strings.Contains(err.Error(), "no ruler project") {
+		t.Errorf("ImportProject() without a project = %v", err)
+	}
+	if _, err := e.ImportProject("other"); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+		t.Errorf("ImportProject(other) = %v", err)
+	}
+	writeTestFile(t, "rulesync.jsonc", "{\"targets\": [\"claudecode\"]")
+	if _, err := e.ImportProject("rulesync"); err == nil {
+		t.Error("ImportProject() with a broken config should fail")
+	}
+}
+
+func TestStripJSONComments(t *testing.T) {
+	in := `{"url": "https://example.com/a", /* c */ "b": "x\"//y" // end` + "\n}"
+	if got, want := string(stripJSONComments([]byte(in))), "{\"url\": \"https://example.com/a\",  \"b\": \"x\\\"//y\" \n}"; got != want {
+		t.Errorf("stripJSONComments() = %q, want %q", got, want)
+	}
+}
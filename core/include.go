@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// includePattern matches a line consisting of an include directive:
+// <!-- viberules:include ./style/go.md -->
+var includePattern = regexp.MustCompile(`(?m)^[ \t]*<!--[ \t]*viberules:include[ \t]+(\S+)[ \t]*-->[ \t]*$`)
+
+// maxIncludeDepth bounds nested includes as a safety net on top of cycle detection
+const maxIncludeDepth = 32
+
+// HasIncludes reports whether content contains include directives
+func HasIncludes(content []byte) bool {
+	return includePattern.Match(content)
+}
+
+// ExpandIncludes replaces include directives in content, read from path, with the
+// content of the referenced files. Local paths are relative to the including file
+// and must stay inside the project that owns it. https:// and git:: references are
+// retrieved with fetcher; relative includes inside remote files resolve against
+// their remote location. Included files may include others; cycles are reported
+// as errors. Fetching stops when ctx is done.
+func (e *Engine) ExpandIncludes(ctx context.Context, content []byte, path string, fetcher Fetcher) ([]byte, error) {
+	abs, err := e.fs.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	src := includeSource{path: abs}
+	return e.expandIncludes(ctx, content, src, []string{src.String()}, fetcher)
+}
+
+// IncludeRules returns a transform that expands include directives in the rules file
+func (e *Engine) IncludeRules(ctx context.Context, fetcher Fetcher) Transform {
+	return func(rc *RenderContext, content []byte) ([]byte, error) {
+		return e.ExpandIncludes(ctx, content, rc.Source, fetcher)
+	}
+}
+
+// includeSource is the location of a file that may contain include directives
+type includeSource struct {
+	path   string     // absolute local path
+	remote *RemoteRef // set for remote content
+}
+
+func (s includeSource) String() string {
+	if s.remote != nil {
+		return s.remote.String()
+	}
+	return s.path
+}
+
+// resolve returns the location of ref, an include directive found in s
+func (s includeSource) resolve(ref string) (includeSource, error) {
+	if s.remote != nil {
+		resolved, err := s.remote.Resolve(ref)
+		if err != nil {
+			return includeSource{}, err
+		}
+		return includeSource{remote: &resolved}, nil
+	}
+
+	if IsRemoteRef(ref) {
+		remote, err := ParseRemoteRef(ref)
+		if err != nil {
+			return includeSource{}, err
+		}
+		return includeSource{remote: &remote}, nil
+	}
+
+	included := filepath.Clean(filepath.Join(filepath.Dir(s.path), ref))
+	if rel, err := filepath.Rel(projectRootOf(s.path), included); err != nil || strings.HasPrefix(rel, "..") {
+		return includeSource{}, fmt.Errorf("include %s in %s points outside the project", ref, s.path)
+	}
+	return includeSource{path: included}, nil
+}
+
+// read returns the content at s, reading local files from f
+func (s includeSource) read(ctx context.Context, f FS, fetcher Fetcher) ([]byte, error) {
+	if s.remote == nil {
+		return f.ReadFile(s.path)
+	}
+	if fetcher == nil {
+		return nil, fmt.Errorf("remote includes are not available")
+	}
+	return fetcher.Fetch(ctx, *s.remote)
+}
+
+func (e *Engine) expandIncludes(ctx context.Context, content []byte, src includeSource, stack []string, fetcher Fetcher) ([]byte, error) {
+	if len(stack) > maxIncludeDepth {
+		return nil, fmt.Errorf("includes nested deeper than %d levels", maxIncludeDepth)
+	}
+
+	var expandErr error
+	out := includePattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		if expandErr != nil {
+			return match
+		}
+
+		ref := string(includePattern.FindSubmatch(match)[1])
+		included, err := src.resolve(ref)
+		if err != nil {
+			expandErr = err
+			return match
+		}
+		key := included.String()
+		for _, seen := range stack {
+			if seen == key {
+				expandErr = fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), key)
+				return match
+			}
+		}
+
+		data, err := included.read(ctx, e.fs, fetcher)
+		if err != nil {
+			expandErr = fmt.Errorf("failed to include %s: %w", ref, err)
+			return match
+		}
+		expanded, err := e.expandIncludes(ctx, data, included, append(stack, key), fetcher)
+		if err != nil {
+			expandErr = err
+			return match
+		}
+		return bytes.TrimRight(expanded, "\n")
+	})
+	if expandErr != nil {
+		return nil, expandErr
+	}
+
+	return out, nil
+}
+
+// projectRootOf returns the directory containing the .viberules directory that
+// holds path, or the directory of path if it is not inside one
+func projectRootOf(path string) string {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".viberules" {
+			return filepath.Dir(dir)
+		}
+		if filepath.Dir(dir) == dir {
+			return filepath.Dir(path)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestExpandIncludes(t *testing.T) {
+	e := setupProject(t, "# Rules\n<!-- viberules:include ./style/go.md -->\n## End\n")
+	writeTestFile(t, ".viberules/style/go.md", "## Go\n<!-- viberules:include errors.md -->\n")
+	writeTestFile(t, ".viberules/style/errors.md", "- Wrap errors\n")
+
+	content, err := os.ReadFile(e.RulesFile())
+	if err != nil {
+		t.Fatalf("Failed to read rules: %v", err)
+	}
+	if !HasIncludes(content) {
+		t.Error("HasIncludes should detect the include directive")
+	}
+
+	expanded, err := e.ExpandIncludes(context.Background(), content, e.RulesFile(), nil)
+	if err != nil {
+		t.Fatalf("ExpandIncludes failed: %v", err)
+	}
+	want := "# Rules\n## Go\n- Wrap errors\n## End\n"
+	if string(expanded) != want {
+		t.Errorf("Expanded = %q, want %q", expanded, want)
+	}
+}
+
+func TestExpandIncludesErrors(t *testing.T) {
+	e := setupProject(t, "")
+
+	tests := []struct {
+		name    string
+		content string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "cycle",
+			content: "<!-- viberules:include a.md -->",
+			files: map[string]string{
+				".viberules/a.md": "<!-- viberules:include b.md -->",
+				".viberules/b.md": "<!-- viberules:include a.md -->",
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name:    "outside project",
+			content: "<!-- viberules:include ../../etc/passwd -->",
+			wantErr: "outside the project",
+		},
+		{
+			name:    "missing file",
+			content: "<!-- viberules:include missing.md -->",
+			wantErr: "failed to include",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for path, content := range tt.files {
+				writeTestFile(t, path, content)
+			}
+			_, err := e.ExpandIncludes(context.Background(), []byte(tt.content), e.RulesFile(), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExpandIncludes error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
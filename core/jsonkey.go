@@ -0,0 +1,184 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Some tools keep settings viberules manages in a JSON file they share with
+// other configuration (e.g. mcpServers in .gemini/settings.json). Only the
+// managed key is written; the checksum of its value detects edits by hand.
+
+// readJSONObject reads a JSON object from path, returning an empty object if it doesn't exist
+func (e *Engine) readJSONObject(path string) (map[string]json.RawMessage, error) {
+	content, err := e.fs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	object := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return object, nil
+	}
+	if err := json.Unmarshal(content, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return object, nil
+}
+
+// writeJSONObject writes object to path with stable formatting
+func (e *Engine) writeJSONObject(path string, object map[string]json.RawMessage) error {
+	content, err := MarshalJSON(object)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := e.fs.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+	}
+	if err := e.fs.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// MarshalJSON encodes value with two-space indentation, sorted object keys
+// and a trailing newline, as the tools themselves write their config files
+func MarshalJSON(value interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// jsonChecksum returns the checksum of the canonical encoding of a JSON value
+func jsonChecksum(raw json.RawMessage) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return Checksum(canonical), nil
+}
+
+// WriteJSONKey sets key in the JSON object stored at path, keeping every other
+// key. A value edited by hand since it was recorded is only replaced with overwrite.
+// It returns the checksum to record.
+func (e *Engine) WriteJSONKey(path, key string, value interface{}, recorded string, overwrite bool) (string, error) {
+	object, err := e.readJSONObject(path)
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	expected, err := jsonChecksum(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	if current, ok := object[key]; ok && !overwrite {
+		sum, err := jsonChecksum(current)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s in %s: %w", key, path, err)
+		}
+		if sum != recorded && sum != expected {
+			if recorded == "" {
+				return "", fmt.Errorf("refusing to overwrite %s in %s: not managed by viberules", key, path)
+			}
+			return "", fmt.Errorf("refusing to overwrite %s in %s: it was modified since it was generated", key, path)
+		}
+	}
+
+	object[key] = encoded
+	if err := e.writeJSONObject(path, object); err != nil {
+		return "", err
+	}
+	return expected, nil
+}
+
+// RemoveJSONKey removes a key written by WriteJSONKey. The file is removed
+// when nothing else is left in it.
+func (e *Engine) RemoveJSONKey(path, key, recorded string, force bool) error {
+	if recorded == "" {
+		return nil
+	}
+	if _, err := e.fs.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	object, err := e.readJSONObject(path)
+	if err != nil {
+		return err
+	}
+	current, ok := object[key]
+	if !ok {
+		return nil
+	}
+
+	if !force {
+		sum, err := jsonChecksum(current)
+		if err != nil || sum != recorded {
+			return fmt.Errorf("refusing to remove %s from %s: it was modified since it was generated", key, path)
+		}
+	}
+
+	delete(object, key)
+	if len(object) == 0 {
+		if err := e.fs.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	return e.writeJSONObject(path, object)
+}
+
+// CheckJSONKey reports the state of a key written by WriteJSONKey
+func (e *Engine) CheckJSONKey(path, key string, value interface{}, recorded string) OutputState {
+	if _, err := e.fs.Stat(path); os.IsNotExist(err) {
+		return OutputMissing
+	}
+	object, err := e.readJSONObject(path)
+	if err != nil {
+		return OutputBroken
+	}
+	current, ok := object[key]
+	if !ok {
+		return OutputMissing
+	}
+	if recorded == "" {
+		return OutputUnmanaged
+	}
+
+	sum, err := jsonChecksum(current)
+	if err != nil {
+		return OutputBroken
+	}
+	if sum != recorded {
+		return OutputModified
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return OutputBroken
+	}
+	if expected, err := jsonChecksum(encoded); err != nil || expected != sum {
+		return OutputStale
+	}
+	return OutputOK
+}
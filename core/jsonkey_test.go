@@ -0,0 +1,62 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONKeyKeepsOtherSettings(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, ".gemini/settings.json", `{"theme": "dark"}`)
+
+	value := map[string]interface{}{"github": map[string]interface{}{"command": "gh-mcp"}}
+	sum, err := e.WriteJSONKey(".gemini/settings.json", "mcpServers", value, "", false)
+	if err != nil {
+		t.Fatalf("WriteJSONKey failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(".gemini/settings.json")
+	if !strings.Contains(string(content), `"theme": "dark"`) || !strings.Contains(string(content), `"command": "gh-mcp"`) {
+		t.Errorf("settings.json = %s, want existing theme and new servers", content)
+	}
+	if state := e.CheckJSONKey(".gemini/settings.json", "mcpServers", value, sum); state != OutputOK {
+		t.Errorf("State after write = %s, want ok", state)
+	}
+
+	// Editing the managed key by hand is detected and protected
+	writeTestFile(t, ".gemini/settings.json", `{"theme": "dark", "mcpServers": {"mine": {}}}`)
+	if state := e.CheckJSONKey(".gemini/settings.json", "mcpServers", value, sum); state != OutputModified {
+		t.Errorf("State after manual edit = %s, want modified", state)
+	}
+	if _, err := e.WriteJSONKey(".gemini/settings.json", "mcpServers", value, sum, false); err == nil {
+		t.Error("WriteJSONKey should refuse to overwrite a modified key")
+	}
+	if sum, err = e.WriteJSONKey(".gemini/settings.json", "mcpServers", value, sum, true); err != nil {
+		t.Fatalf("WriteJSONKey with overwrite failed: %v", err)
+	}
+
+	// Removing the key keeps the rest of the file
+	if err := e.RemoveJSONKey(".gemini/settings.json", "mcpServers", sum, false); err != nil {
+		t.Fatalf("RemoveJSONKey failed: %v", err)
+	}
+	content, _ = os.ReadFile(".gemini/settings.json")
+	if strings.Contains(string(content), "mcpServers") || !strings.Contains(string(content), "theme") {
+		t.Errorf("settings.json after removal = %s, want only theme", content)
+	}
+}
+
+func TestRemoveJSONKeyRemovesEmptyFile(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	sum, err := e.WriteJSONKey(".mcp.json", "mcpServers", map[string]interface{}{}, "", false)
+	if err != nil {
+		t.Fatalf("WriteJSONKey failed: %v", err)
+	}
+	if err := e.RemoveJSONKey(".mcp.json", "mcpServers", sum, false); err != nil {
+		t.Fatalf("RemoveJSONKey failed: %v", err)
+	}
+	if fileExistsForTest(".mcp.json") {
+		t.Error("A file left empty should be removed")
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LegacyFile is a rules file of an older convention that an assistant reads
+// besides, or instead of, the outputs viberules manages
+type LegacyFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// legacyFiles are the files FindLegacyFiles looks for. A file is only
+// reported where dir exists, as a tool's config directory shows it is used.
+var legacyFiles = []struct {
+	path, dir, reason string
+}{
+	{".cursorrules", "", "Cursor still reads the deprecated .cursorrules on top of .cursor/rules"},
+	{".windsurfrules", "", "Windsurf reads the deprecated .windsurfrules on top of .windsurf/rules"},
+	{"GEMINI.md", ".gemini", "Gemini CLI reads a GEMINI.md at the root on top of the managed rules"},
+}
+
+// FindLegacyFiles returns the legacy files in the project that aren't
+// outputs of targets, the enabled targets, or files recorded as written by
+// viberules. Symlinks are left out: they point at rules kept elsewhere.
+func (e *Engine) FindLegacyFiles(targets []string, recorded map[string]string) ([]LegacyFile, error) {
+	outputs := make(map[string]bool)
+	for _, name := range targets {
+		target, ok := e.FindTarget(name)
+		if !ok {
+			continue
+		}
+		links, err := e.OutputLinks(target)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			outputs[filepath.Clean(link.Target)] = true
+		}
+	}
+
+	var found []LegacyFile
+	for _, legacy := range legacyFiles {
+		if outputs[legacy.path] || recorded[legacy.path] != "" {
+			continue
+		}
+		if legacy.dir != "" {
+			if info, err := e.fs.Stat(legacy.dir); err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		info, err := e.fs.Lstat(legacy.path)
+		if os.IsNotExist(err) || (err == nil && !info.Mode().IsRegular()) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, LegacyFile{Path: legacy.path, Reason: legacy.reason})
+	}
+	return found, nil
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindLegacyFiles(t *testing.T) {
+	e := setupProject(t, "rules")
+	writeTestFile(t, ".cursorrules", "Old cursor rules.\n")
+	writeTestFile(t, ".windsurfrules", "Old windsurf rules.\n")
+	writeTestFile(t, "GEMINI.md", "Old gemini rules.\n")
+
+	paths := func(files []LegacyFile) []string {
+		var paths []string
+		for _, file := range files {
+			paths = append(paths, file.Path)
+		}
+		return paths
+	}
+
+	// GEMINI.md only counts next to a Gemini CLI config
+	found, err := e.FindLegacyFiles([]string{"claude"}, nil)
+	if want := []string{".cursorrules", ".windsurfrules"}; err != nil || !reflect.DeepEqual(paths(found), want) {
+		t.Errorf("FindLegacyFiles() = %v, %v; want %v", paths(found), err, want)
+	}
+	if err := os.Mkdir(".gemini", 0755); err != nil {
+		t.Fatalf("Failed to create .gemini: %v", err)
+	}
+	found, _ = e.FindLegacyFiles([]string{"claude"}, nil)
+	if want := []string{".cursorrules", ".windsurfrules", "GEMINI.md"}; !reflect.DeepEqual(paths(found), want) {
+		t.Errorf("FindLegacyFiles() with .gemini = %v, want %v", paths(found), want)
+	}
+
+	// Outputs of enabled targets, recorded files and links are managed
+	os.Remove(".cursorrules")
+	if err := os.Symlink(filepath.Join(".viberules", "rules.md"), ".cursorrules"); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	found, _ = e.FindLegacyFiles([]string{"claude", "gemini"}, map[string]string{".windsurfrules": "sum"})
+	if len(found) != 0 {
+		t.Errorf("FindLegacyFiles() = %v, want none", paths(found))
+	}
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// LocalRulesFile holds personal rules that are never committed
+var LocalRulesFile = filepath.Join(".viberules", "rules.local.md")
+
+// LocalRulesContent seeds rules.local.md on init
+const LocalRulesContent = `<!--
+Personal rules for this project. This file is never committed.
+Generated outputs include it after rules.md; tools with a personal rules
+file (e.g. CLAUDE.local.md) link to it with the symlink and copy strategies.
+-->
+`
+
+// NotesFile is a personal scratchpad that persists across sessions. Like
+// rules.local.md it is never committed.
+var NotesFile = filepath.Join(".viberules", "notes.local.md")
+
+// NotesContent seeds notes.local.md on init
+const NotesContent = `<!--
+Personal notes for this project: decisions, open questions, reminders.
+This file is never committed. Generated outputs include it after your
+personal rules, and tools with a personal rules file (e.g. CLAUDE.local.md)
+import it with the symlink and copy strategies.
+-->
+`
+
+var htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// readPersonalFile returns the content of a personal file, or nil if it is
+// missing or holds nothing but comments
+func (e *Engine) readPersonalFile(path string) ([]byte, error) {
+	content, err := e.fs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(bytes.TrimSpace(htmlCommentPattern.ReplaceAll(content, nil))) == 0 {
+		return nil, nil
+	}
+	return content, nil
+}
+
+// LocalRules returns the personal rules of the project, or nil if the file is
+// missing or holds nothing but comments
+func (e *Engine) LocalRules() ([]byte, error) {
+	return e.readPersonalFile(LocalRulesFile)
+}
+
+// Notes returns the personal notes of the project, or nil if the file is
+// missing or holds nothing but comments
+func (e *Engine) Notes() ([]byte, error) {
+	return e.readPersonalFile(NotesFile)
+}
+
+// MergeLocalRules returns a transform that appends personal rules and then
+// personal notes, expanding their includes with fetcher
+func (e *Engine) MergeLocalRules(ctx context.Context, fetcher Fetcher) Transform {
+	return func(rc *RenderContext, content []byte) ([]byte, error) {
+		if rc.Part {
+			return content, nil
+		}
+		for _, path := range []string{LocalRulesFile, NotesFile} {
+			personal, err := e.readPersonalFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if personal == nil {
+				continue
+			}
+			personal, err = e.ExpandIncludes(ctx, personal, path, fetcher)
+			if err != nil {
+				return nil, err
+			}
+
+			var out bytes.Buffer
+			out.Write(bytes.TrimRight(content, "\n"))
+			out.WriteString("\n\n")
+			out.Write(personal)
+			content = out.Bytes()
+		}
+		return content, nil
+	}
+}
+
+// localPointerHeader opens a personal rules file that imports personal
+// rules and notes
+func localPointerHeader() string {
+	return fmt.Sprintf("<!-- Managed by viberules: edit %s or %s instead. -->\n", filepath.ToSlash(LocalRulesFile), filepath.ToSlash(NotesFile))
+}
+
+// localPointer returns the content of the personal rules file at link when it
+// imports rules.local.md and notes.local.md instead of linking the first: when
+// the tool expands @imports and there are notes. It returns nil otherwise.
+func (e *Engine) localPointer(target Target, link SymlinkDef) ([]byte, error) {
+	if !target.Imports {
+		return nil, nil
+	}
+	notes, err := e.Notes()
+	if err != nil || notes == nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteString(localPointerHeader())
+	for _, source := range []string{link.Source, NotesFile} {
+		if _, err := e.fs.Stat(source); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Dir(link.Target), source)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, "@%s\n", filepath.ToSlash(rel))
+	}
+	return out.Bytes(), nil
+}
+
+// isLocalPointer reports whether path is a personal rules file written by
+// CreateLocalSymlinks
+func (e *Engine) isLocalPointer(path string) bool {
+	info, err := e.fs.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	content, err := e.fs.ReadFile(path)
+	return err == nil && bytes.HasPrefix(content, []byte(localPointerHeader()))
+}
+
+// CreateLocalSymlinks links a target's personal rules file to rules.local.md.
+// When the tool expands @imports and notes.local.md holds notes, the file
+// imports both instead, so the notes reach the tool with every strategy.
+// Nothing is linked when rules.local.md doesn't exist, and files the user
+// created themselves are left alone.
+func (e *Engine) CreateLocalSymlinks(targetName string) error {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return unknownTarget(targetName)
+	}
+
+	for _, link := range target.LocalLinks {
+		info, err := e.fs.Lstat(link.Target)
+		if err == nil && info.Mode()&os.ModeSymlink == 0 && !e.isLocalPointer(link.Target) {
+			continue
+		}
+		pointer, err := e.localPointer(target, link)
+		if err != nil {
+			return err
+		}
+
+		if pointer == nil {
+			if _, err := e.fs.Stat(link.Source); err != nil {
+				continue
+			}
+			if err := e.removeLocalPointer(link.Target); err != nil {
+				return err
+			}
+			if err := e.createScopedSymlink(link.Source, link.Target); err != nil {
+				return fmt.Errorf("failed to create symlink: %w", err)
+			}
+			continue
+		}
+		// Replace a link first, so the write doesn't go through it
+		if err := e.removeSymlink(link.Target); err != nil {
+			return err
+		}
+		if err := WriteFileAtomic(e.fs, link.Target, pointer, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeLocalPointer removes path if it is a personal rules file written by
+// CreateLocalSymlinks
+func (e *Engine) removeLocalPointer(path string) error {
+	if !e.isLocalPointer(path) {
+		return nil
+	}
+	if err := e.fs.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveLocalSymlinks removes the links and files created by CreateLocalSymlinks
+func (e *Engine) RemoveLocalSymlinks(targetName string) error {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return unknownTarget(targetName)
+	}
+
+	for _, link := range target.LocalLinks {
+		if err := e.removeLocalPointer(link.Target); err != nil {
+			return err
+		}
+		// Only links pointing at rules.local.md are ours, even if it was deleted
+		if dest, err := e.fs.Readlink(link.Target); err != nil || filepath.Clean(dest) != filepath.Clean(link.Source) {
+			continue
+		}
+		if err := e.removeSymlink(link.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckLocalSymlinks reports the state of a target's personal rules links
+// and files
+func (e *Engine) CheckLocalSymlinks(targetName string) ([]OutputStatus, error) {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return nil, unknownTarget(targetName)
+	}
+
+	var statuses []OutputStatus
+	for _, link := range target.LocalLinks {
+		pointer, err := e.localPointer(target, link)
+		if err != nil {
+			return nil, err
+		}
+		var state OutputState
+		switch {
+		case pointer != nil:
+			state = e.checkLocalPointer(link.Target, pointer)
+		case e.isLocalPointer(link.Target):
+			state = OutputStale // notes were emptied; sync links rules.local.md again
+		default:
+			if _, err := e.fs.Stat(LocalRulesFile); err != nil {
+				continue
+			}
+			state = e.checkSymlinkOutput(link)
+		}
+		statuses = append(statuses, OutputStatus{Target: target.Name, Path: link.Target, State: state})
+	}
+	return e.reportDrift(statuses...), nil
+}
+
+// checkLocalPointer reports the state of a personal rules file that should
+// hold pointer
+func (e *Engine) checkLocalPointer(path string, pointer []byte) OutputState {
+	info, err := e.fs.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		return OutputMissing
+	case err != nil:
+		return OutputUnmanaged
+	case info.Mode()&os.ModeSymlink != 0:
+		return OutputStale // a link made before there were notes
+	case !e.isLocalPointer(path):
+		return OutputUnmanaged
+	}
+	if content, err := e.fs.ReadFile(path); err != nil || !bytes.Equal(content, pointer) {
+		return OutputStale
+	}
+	return OutputOK
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestMergeLocalRules(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	// The seeded file only holds a comment and adds nothing
+	writeTestFile(t, LocalRulesFile, LocalRulesContent)
+	content, err := e.MergeLocalRules(context.Background(), nil)(&RenderContext{Target: "claude"}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("MergeLocalRules failed: %v", err)
+	}
+	if string(content) != "# Rules\n" {
+		t.Errorf("Content with comment-only local rules = %q, want rules unchanged", content)
+	}
+
+	writeTestFile(t, LocalRulesFile, "## Personal\n- Answer in Korean\n")
+	content, err = e.MergeLocalRules(context.Background(), nil)(&RenderContext{Target: "claude"}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("MergeLocalRules failed: %v", err)
+	}
+	want := "# Rules\n\n## Personal\n- Answer in Korean\n"
+	if string(content) != want {
+		t.Errorf("Content = %q, want %q", content, want)
+	}
+}
+
+func TestLocalSymlinks(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	// Without rules.local.md nothing is linked
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks failed: %v", err)
+	}
+	if fileExistsForTest("CLAUDE.local.md") {
+		t.Error("CLAUDE.local.md should not be created without rules.local.md")
+	}
+
+	writeTestFile(t, LocalRulesFile, "- Personal rule\n")
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks failed: %v", err)
+	}
+	if !e.IsSymlinkValid("CLAUDE.local.md", ".viberules/rules.local.md") {
+		t.Error("CLAUDE.local.md should link to .viberules/rules.local.md")
+	}
+	statuses, err := e.CheckLocalSymlinks("claude")
+	if err != nil || len(statuses) != 1 || statuses[0].State != OutputOK {
+		t.Errorf("CheckLocalSymlinks = %v, %v; want one ok link", statuses, err)
+	}
+
+	if err := e.RemoveLocalSymlinks("claude"); err != nil {
+		t.Fatalf("RemoveLocalSymlinks failed: %v", err)
+	}
+	if fileExistsForTest("CLAUDE.local.md") {
+		t.Error("CLAUDE.local.md should be removed")
+	}
+
+	// A personal file the user wrote is never replaced or removed
+	if err := os.WriteFile("CLAUDE.local.md", []byte("mine"), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.local.md: %v", err)
+	}
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks with existing file failed: %v", err)
+	}
+	if err := e.RemoveLocalSymlinks("claude"); err != nil {
+		t.Fatalf("RemoveLocalSymlinks with existing file failed: %v", err)
+	}
+	if content, _ := os.ReadFile("CLAUDE.local.md"); string(content) != "mine" {
+		t.Errorf("User's CLAUDE.local.md = %q, want it untouched", content)
+	}
+}
+
+func TestMergeNotes(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, LocalRulesFile, "## Personal\n- Answer in Korean\n")
+	writeTestFile(t, NotesFile, NotesContent+"## Notes\n- Migration to v2 API is half done\n")
+
+	content, err := e.MergeLocalRules(context.Background(), nil)(&RenderContext{Target: "claude"}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("MergeLocalRules failed: %v", err)
+	}
+	want := "# Rules\n\n## Personal\n- Answer in Korean\n\n" + NotesContent + "## Notes\n- Migration to v2 API is half done\n"
+	if string(content) != want {
+		t.Errorf("Content = %q, want %q", content, want)
+	}
+}
+
+func TestLocalPointer(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, LocalRulesFile, "## Personal\n")
+	writeTestFile(t, NotesFile, NotesContent)
+
+	// Without notes the personal rules file stays a link
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks failed: %v", err)
+	}
+	if !e.IsSymlinkValid("CLAUDE.local.md", LocalRulesFile) {
+		t.Fatal("CLAUDE.local.md should link to rules.local.md without notes")
+	}
+
+	// With notes it imports both, and the link no longer matches
+	writeTestFile(t, NotesFile, "## Notes\n- Half done\n")
+	if statuses, err := e.CheckLocalSymlinks("claude"); err != nil || len(statuses) != 1 || statuses[0].State != OutputStale {
+		t.Errorf("CheckLocalSymlinks with new notes = %v, %v; want the link stale", statuses, err)
+	}
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks failed: %v", err)
+	}
+	want := localPointerHeader() + "@.viberules/rules.local.md\n@.viberules/notes.local.md\n"
+	if content, _ := os.ReadFile("CLAUDE.local.md"); string(content) != want {
+		t.Errorf("CLAUDE.local.md = %q, want %q", content, want)
+	}
+	if content, _ := os.ReadFile(LocalRulesFile); string(content) != "## Personal\n" {
+		t.Errorf("rules.local.md = %q, want it untouched", content)
+	}
+	if statuses, err := e.CheckLocalSymlinks("claude"); err != nil || len(statuses) != 1 || statuses[0].State != OutputOK {
+		t.Errorf("CheckLocalSymlinks = %v, %v; want the imports ok", statuses, err)
+	}
+
+	// Emptied notes bring the link back
+	writeTestFile(t, NotesFile, NotesContent)
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks failed: %v", err)
+	}
+	if !e.IsSymlinkValid("CLAUDE.local.md", LocalRulesFile) {
+		t.Error("CLAUDE.local.md should link to rules.local.md again")
+	}
+
+	writeTestFile(t, NotesFile, "## Notes\n")
+	if err := e.CreateLocalSymlinks("claude"); err != nil {
+		t.Fatalf("CreateLocalSymlinks failed: %v", err)
+	}
+	if err := e.RemoveLocalSymlinks("claude"); err != nil {
+		t.Fatalf("RemoveLocalSymlinks failed: %v", err)
+	}
+	if fileExistsForTest("CLAUDE.local.md") {
+		t.Error("The imports file should be removed with the target")
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockfilePath records the resolved remote sources of a project
+var LockfilePath = filepath.Join(".viberules", "viberules.lock")
+
+const lockfileHeader = "# Generated by viberules. Do not edit; run 'viberules update' to move pins.\n"
+
+// Lockfile pins remote rule sources to exact revisions and content hashes
+type Lockfile struct {
+	Sources []LockedSource `yaml:"sources"`
+	Shared  *LockedSource  `yaml:"shared,omitempty"` // shared rules merged by the last pull (see PullShared)
+	Packs   []LockedPack   `yaml:"packs,omitempty"`  // installed rule packs (see PacksDir)
+}
+
+// LockedSource is the resolved state of one remote reference
+type LockedSource struct {
+	Ref    string `yaml:"ref"`              // reference as written in the include directive
+	Commit string `yaml:"commit,omitempty"` // resolved commit for git references, manifest digest for OCI references
+	SHA256 string `yaml:"sha256"`           // checksum of the fetched content
+}
+
+// Find returns the locked state of ref
+func (l *Lockfile) Find(ref string) (LockedSource, bool) {
+	for _, source := range l.Sources {
+		if source.Ref == ref {
+			return source, true
+		}
+	}
+	return LockedSource{}, false
+}
+
+// set records source, replacing an existing entry for the same ref
+func (l *Lockfile) set(source LockedSource) {
+	for i := range l.Sources {
+		if l.Sources[i].Ref == source.Ref {
+			l.Sources[i] = source
+			return
+		}
+	}
+	l.Sources = append(l.Sources, source)
+	sort.Slice(l.Sources, func(i, j int) bool { return l.Sources[i].Ref < l.Sources[j].Ref })
+}
+
+// LoadLockfile reads a lockfile from f. A missing file yields an empty lockfile.
+func LoadLockfile(f FS, path string) (*Lockfile, error) {
+	content, err := f.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Lockfile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock Lockfile
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// Save writes the lockfile to f
+func (l *Lockfile) Save(f FS, path string) error {
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	if err := f.WriteFile(path, append([]byte(lockfileHeader), content...), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// LockedFetcher fetches remote sources through Inner, reproducing the revisions
+// and content recorded in Lock. Sources missing from the lock are fetched and
+// recorded. With Update set, every source is re-resolved and re-recorded.
+// Locked content found in Cache isn't fetched again.
+type LockedFetcher struct {
+	Inner  RevisionFetcher
+	Lock   *Lockfile
+	Update bool
+	Cache  *ContentCache
+	Logger *slog.Logger // debug records of what is fetched; none when nil
+
+	mu      sync.Mutex
+	changed bool
+}
+
+// Changed reports whether the lock was modified by fetches
+func (f *LockedFetcher) Changed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.changed
+}
+
+// Fetch implements Fetcher
+func (f *LockedFetcher) Fetch(ctx context.Context, ref RemoteRef) ([]byte, error) {
+	key := ref.String()
+
+	f.mu.Lock()
+	locked, ok := f.Lock.Find(key)
+	f.mu.Unlock()
+
+	if ok && !f.Update {
+		if content, cached := f.Cache.Get(locked.SHA256); cached {
+			logTo(f.Logger).Debug("using locked content from the cache", "ref", key, "sha256", locked.SHA256)
+			return content, nil
+		}
+		pinned := ref
+		if (ref.Git || ref.OCI) && locked.Commit != "" {
+			pinned.Ref = locked.Commit
+		}
+		logTo(f.Logger).Debug("fetching locked revision", "ref", key, "revision", locked.Commit)
+		content, _, err := f.Inner.FetchRevision(ctx, pinned)
+		if err != nil {
+			return nil, err
+		}
+		if Checksum(content) != locked.SHA256 {
+			return nil, fmt.Errorf("content of %s does not match %s; run 'viberules update' to accept the change", key, LockfilePath)
+		}
+		f.Cache.Put(content)
+		return content, nil
+	}
+
+	content, revision, err := f.Inner.FetchRevision(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	f.Cache.Put(content)
+
+	source := LockedSource{Ref: key, Commit: revision, SHA256: Checksum(content)}
+	f.mu.Lock()
+	if !ok || locked != source {
+		f.Lock.set(source)
+		f.changed = true
+	}
+	f.mu.Unlock()
+
+	return content, nil
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// fakeFetcher serves content per ref string and reports a revision per ref
+type fakeFetcher struct {
+	content  map[string]string
+	revision map[string]string
+	fetched  []string
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, ref RemoteRef) ([]byte, error) {
+	content, _, err := f.FetchRevision(ctx, ref)
+	return content, err
+}
+
+func (f *fakeFetcher) FetchRevision(ctx context.Context, ref RemoteRef) ([]byte, string, error) {
+	key := ref.String()
+	f.fetched = append(f.fetched, key)
+	content, ok := f.content[key]
+	if !ok {
+		return nil, "", fmt.Errorf("not found: %s", key)
+	}
+	return []byte(content), f.revision[key], nil
+}
+
+func TestLockedFetcherPinsGitCommit(t *testing.T) {
+	ref := RemoteRef{URL: "https://github.com/org/rules.git", Git: true, Path: "base.md", Ref: "main"}
+	pinned := ref
+	pinned.Ref = "1111111111111111111111111111111111111111"
+
+	inner := &fakeFetcher{
+		content:  map[string]string{ref.String(): "v1\n", pinned.String(): "v1\n"},
+		revision: map[string]string{ref.String(): pinned.Ref},
+	}
+	lock := &Lockfile{}
+	fetcher := &LockedFetcher{Inner: inner, Lock: lock}
+
+	if _, err := fetcher.Fetch(context.Background(), ref); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if !fetcher.Changed() {
+		t.Error("Fetching an unlocked source should record it")
+	}
+	locked, ok := lock.Find(ref.String())
+	if !ok || locked.Commit != pinned.Ref || locked.SHA256 != Checksum([]byte("v1\n")) {
+		t.Fatalf("Locked source = %+v, want commit and checksum of v1", locked)
+	}
+
+	// The branch moves upstream; a fresh run still reads the pinned commit
+	inner.content[ref.String()] = "v2\n"
+	fetcher = &LockedFetcher{Inner: inner, Lock: lock}
+	content, err := fetcher.Fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Fetch with lock failed: %v", err)
+	}
+	if string(content) != "v1\n" || inner.fetched[len(inner.fetched)-1] != pinned.String() {
+		t.Errorf("Fetch with lock = %q from %s, want v1 from pinned commit", content, inner.fetched[len(inner.fetched)-1])
+	}
+	if fetcher.Changed() {
+		t.Error("Reproducing a locked source should not change the lock")
+	}
+
+	// Update moves the pin
+	inner.revision[ref.String()] = "2222222222222222222222222222222222222222"
+	fetcher = &LockedFetcher{Inner: inner, Lock: lock, Update: true}
+	content, err = fetcher.Fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Fetch with update failed: %v", err)
+	}
+	if string(content) != "v2\n" || !fetcher.Changed() {
+		t.Errorf("Fetch with update = %q (changed %v), want v2 and a changed lock", content, fetcher.Changed())
+	}
+	if locked, _ := lock.Find(ref.String()); locked.Commit != "2222222222222222222222222222222222222222" {
+		t.Errorf("Locked commit after update = %s, want the new revision", locked.Commit)
+	}
+}
+
+func TestLockedFetcherRejectsChangedContent(t *testing.T) {
+	ref := RemoteRef{URL: "https://example.com/rules/base.md"}
+	inner := &fakeFetcher{content: map[string]string{ref.String(): "original\n"}}
+	lock := &Lockfile{}
+
+	if _, err := (&LockedFetcher{Inner: inner, Lock: lock}).Fetch(context.Background(), ref); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	inner.content[ref.String()] = "tampered\n"
+	if _, err := (&LockedFetcher{Inner: inner, Lock: lock}).Fetch(context.Background(), ref); err == nil {
+		t.Error("Fetch should fail when content no longer matches the lockfile")
+	}
+}
+
+func TestLockfileSaveLoad(t *testing.T) {
+	e := New(OSFS{})
+	path := filepath.Join(t.TempDir(), "viberules.lock")
+
+	lock, err := LoadLockfile(e.FS(), path)
+	if err != nil {
+		t.Fatalf("LoadLockfile on missing file failed: %v", err)
+	}
+	if len(lock.Sources) != 0 {
+		t.Errorf("Missing lockfile should be empty, got %+v", lock.Sources)
+	}
+
+	lock.set(LockedSource{Ref: "https://example.com/b.md", SHA256: "bb"})
+	lock.set(LockedSource{Ref: "git::https://example.com/r.git//a.md", Commit: "abc", SHA256: "aa"})
+	if err := lock.Save(e.FS(), path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadLockfile(e.FS(), path)
+	if err != nil {
+		t.Fatalf("LoadLockfile failed: %v", err)
+	}
+	if len(loaded.Sources) != 2 || loaded.Sources[0].Ref != "git::https://example.com/r.git//a.md" {
+		t.Errorf("Loaded sources = %+v, want both sources sorted by ref", loaded.Sources)
+	}
+}
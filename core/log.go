@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+)
+
+// An engine's logger receives debug records of what its operations attempt
+// and decide, such as paths resolved and files left alone. Unlike Observer
+// events they are meant for diagnosing a user's environment, not for the user.
+
+// SetLogger makes the engine's operations log to l; nil stops logging
+func (e *Engine) SetLogger(l *slog.Logger) {
+	e.logger = logTo(l)
+}
+
+// Logger returns the engine's logger, for callers that log the decisions
+// they make around its operations
+func (e *Engine) Logger() *slog.Logger {
+	return e.logger
+}
+
+// discardLogger drops every record
+var discardLogger = slog.New(discardHandler{})
+
+// logTo returns l, or a logger dropping every record when l is nil
+func logTo(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	return l
+}
+
+// discardHandler drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
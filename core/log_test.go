@@ -0,0 +1,45 @@
+package core
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	e := setupProject(t, "rules v1")
+	var buf bytes.Buffer
+	e.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	recorded, err := e.CopyTargetFiles("claude", nil, false)
+	if err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	if _, err := e.CopyTargetFiles("claude", recorded, false); err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	for _, want := range []string{`msg="wrote output" path=CLAUDE.md`, `msg="skipping unchanged output" path=CLAUDE.md`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log lacks %s:\n%s", want, buf.String())
+		}
+	}
+
+	// Each engine logs to its own logger
+	other := setupProject(t, "rules v1")
+	if _, err := other.CopyTargetFiles("claude", nil, true); err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	if strings.Count(buf.String(), `msg="wrote output"`) != 1 {
+		t.Errorf("another engine logged to this engine's logger:\n%s", buf.String())
+	}
+
+	e.SetLogger(nil)
+	buf.Reset()
+	if _, err := e.CopyTargetFiles("claude", nil, true); err != nil {
+		t.Fatalf("CopyTargetFiles(claude) failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("SetLogger(nil) should stop logging, got:\n%s", buf.String())
+	}
+}
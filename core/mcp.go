@@ -0,0 +1,238 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MCPSource declares the MCP servers shared by every assistant
+var MCPSource = filepath.Join(".viberules", "mcp.yaml")
+
+// mcpKey is the key holding servers in every supported tool's config
+const mcpKey = "mcpServers"
+
+// MCPServer is a server declared in MCPSource. Local servers set Command,
+// remote servers set URL.
+type MCPServer struct {
+	Command string            `yaml:"command,omitempty"`
+	Args    []string          `yaml:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	Targets  []string `yaml:"targets,omitempty"`  // only these targets get the server; all when empty
+	Disabled bool     `yaml:"disabled,omitempty"` // kept in the source but not generated
+}
+
+// MCPConfig is the content of MCPSource
+type MCPConfig struct {
+	Servers map[string]MCPServer `yaml:"servers"`
+}
+
+// LoadMCPConfig reads MCPSource. It returns nil if the project declares no servers.
+func (e *Engine) LoadMCPConfig() (*MCPConfig, error) {
+	content, err := e.fs.ReadFile(MCPSource)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", MCPSource, err)
+	}
+
+	var config MCPConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MCPSource, err)
+	}
+	for name, server := range config.Servers {
+		if (server.Command == "") == (server.URL == "") {
+			return nil, fmt.Errorf("%s: server %s must set either command or url", MCPSource, name)
+		}
+	}
+	return &config, nil
+}
+
+// ServersFor returns the names of the servers generated for a target, sorted
+func (c *MCPConfig) ServersFor(targetName string) []string {
+	var names []string
+	for name, server := range c.Servers {
+		if server.Disabled {
+			continue
+		}
+		if len(server.Targets) > 0 && !containsString(server.Targets, targetName) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MCPTarget is where a target reads its project MCP servers
+type MCPTarget struct {
+	Name string
+	Path string // JSON file holding an mcpServers object
+	// remoteURLKey is the field remote servers use for their URL
+	remoteURLKey string
+	// remoteType is written as "type" for remote servers when the tool needs it
+	remoteType string
+	// envSyntax formats a ${VAR} placeholder for tools that interpolate the
+	// environment when they start a server. Others get values resolved at generation time.
+	envSyntax string
+}
+
+// envPlaceholder matches a ${VAR} placeholder in MCPSource
+var envPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// GetMCPTargets returns the targets that read project MCP servers
+func GetMCPTargets() []MCPTarget {
+	return []MCPTarget{
+		{Name: "claude", Path: ".mcp.json", remoteURLKey: "url", remoteType: "http", envSyntax: "${%s}"},
+		{Name: "cursor", Path: filepath.Join(".cursor", "mcp.json"), remoteURLKey: "url", envSyntax: "${env:%s}"},
+		{Name: "amazonq", Path: filepath.Join(".amazonq", "mcp.json"), remoteURLKey: "url"},
+		{Name: "gemini", Path: filepath.Join(".gemini", "settings.json"), remoteURLKey: "httpUrl", envSyntax: "${%s}"},
+	}
+}
+
+// FindMCPTarget returns the MCP location of a target
+func FindMCPTarget(name string) (MCPTarget, bool) {
+	for _, target := range GetMCPTargets() {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return MCPTarget{}, false
+}
+
+// ChecksumKey is the key recording the checksum of the generated servers
+func (t MCPTarget) ChecksumKey() string {
+	return t.Path + "#" + mcpKey
+}
+
+// Render converts servers to the tool's mcpServers object
+func (t MCPTarget) Render(config *MCPConfig) map[string]interface{} {
+	servers := make(map[string]interface{})
+	for _, name := range config.ServersFor(t.Name) {
+		server := config.Servers[name]
+		entry := make(map[string]interface{})
+		if server.Command != "" {
+			entry["command"] = t.expand(server.Command)
+			if len(server.Args) > 0 {
+				args := make([]string, len(server.Args))
+				for i, arg := range server.Args {
+					args[i] = t.expand(arg)
+				}
+				entry["args"] = args
+			}
+			if len(server.Env) > 0 {
+				entry["env"] = t.expandMap(server.Env)
+			}
+		} else {
+			entry[t.remoteURLKey] = t.expand(server.URL)
+			if t.remoteType != "" {
+				entry["type"] = t.remoteType
+			}
+			if len(server.Headers) > 0 {
+				entry["headers"] = t.expandMap(server.Headers)
+			}
+		}
+		servers[name] = entry
+	}
+	return servers
+}
+
+// expand rewrites the ${VAR} placeholders of s in the tool's syntax, or
+// replaces them with the value of the environment variable. Unset variables
+// are left as placeholders.
+func (t MCPTarget) expand(s string) string {
+	return envPlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := envPlaceholder.FindStringSubmatch(match)[1]
+		if t.envSyntax != "" {
+			return fmt.Sprintf(t.envSyntax, name)
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return match
+	})
+}
+
+func (t MCPTarget) expandMap(values map[string]string) map[string]string {
+	expanded := make(map[string]string, len(values))
+	for key, value := range values {
+		expanded[key] = t.expand(value)
+	}
+	return expanded
+}
+
+// ResolvedVariables returns the environment variables whose values the target's
+// MCP file receives at generation time, because the tool can't interpolate
+// them itself, sorted. Such files must stay out of version control.
+func (t MCPTarget) ResolvedVariables(config *MCPConfig) []string {
+	if config == nil || t.envSyntax != "" {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range config.ServersFor(t.Name) {
+		server := config.Servers[name]
+		values := append([]string{server.Command, server.URL}, server.Args...)
+		for _, value := range server.Env {
+			values = append(values, value)
+		}
+		for _, value := range server.Headers {
+			values = append(values, value)
+		}
+		for _, value := range values {
+			for _, match := range envPlaceholder.FindAllStringSubmatch(value, -1) {
+				if !seen[match[1]] {
+					seen[match[1]] = true
+					names = append(names, match[1])
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteMCPServers writes the servers of a target into its config file and
+// returns the checksum to record. Without servers for the target, servers
+// written earlier are removed and an empty checksum is returned.
+func (e *Engine) WriteMCPServers(target MCPTarget, config *MCPConfig, recorded string, overwrite bool) (string, error) {
+	if config == nil || len(config.ServersFor(target.Name)) == 0 {
+		return "", e.RemoveJSONKey(target.Path, mcpKey, recorded, overwrite)
+	}
+	return e.WriteJSONKey(target.Path, mcpKey, target.Render(config), recorded, overwrite)
+}
+
+// RemoveMCPServers removes servers written by WriteMCPServers
+func (e *Engine) RemoveMCPServers(target MCPTarget, recorded string) error {
+	return e.RemoveJSONKey(target.Path, mcpKey, recorded, false)
+}
+
+// CheckMCPServers reports the state of a target's MCP servers. ok is false
+// when the target gets no servers.
+func (e *Engine) CheckMCPServers(target MCPTarget, config *MCPConfig, recorded string) (status OutputStatus, ok bool) {
+	if config == nil || len(config.ServersFor(target.Name)) == 0 {
+		return OutputStatus{}, false
+	}
+	state := e.CheckJSONKey(target.Path, mcpKey, target.Render(config), recorded)
+	status = OutputStatus{Target: target.Name, Path: target.Path, State: state}
+	e.reportDrift(status)
+	return status, true
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const testMCPSource = `servers:
+  github:
+    command: npx
+    args: ["-y", "@modelcontextprotocol/server-github"]
+  browser:
+    command: npx
+    args: ["@playwright/mcp"]
+    targets: [claude]
+  docs:
+    url: https://mcp.example.com/mcp
+  legacy:
+    command: old-server
+    disabled: true
+`
+
+func TestMCPServersFor(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, MCPSource, testMCPSource)
+
+	config, err := e.LoadMCPConfig()
+	if err != nil {
+		t.Fatalf("LoadMCPConfig failed: %v", err)
+	}
+	if got := config.ServersFor("claude"); !reflect.DeepEqual(got, []string{"browser", "docs", "github"}) {
+		t.Errorf("ServersFor(claude) = %v, want browser, docs, github", got)
+	}
+	if got := config.ServersFor("cursor"); !reflect.DeepEqual(got, []string{"docs", "github"}) {
+		t.Errorf("ServersFor(cursor) = %v, want docs, github", got)
+	}
+}
+
+func TestWriteMCPServers(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, MCPSource, testMCPSource)
+	config, err := e.LoadMCPConfig()
+	if err != nil {
+		t.Fatalf("LoadMCPConfig failed: %v", err)
+	}
+
+	gemini, _ := FindMCPTarget("gemini")
+	sum, err := e.WriteMCPServers(gemini, config, "", false)
+	if err != nil {
+		t.Fatalf("WriteMCPServers(gemini) failed: %v", err)
+	}
+
+	var settings struct {
+		MCPServers map[string]map[string]interface{} `json:"mcpServers"`
+	}
+	content, _ := os.ReadFile(gemini.Path)
+	if err := json.Unmarshal(content, &settings); err != nil {
+		t.Fatalf("Generated settings are not valid JSON: %v", err)
+	}
+	if _, ok := settings.MCPServers["browser"]; ok {
+		t.Error("browser is limited to claude and should not be written for gemini")
+	}
+	if settings.MCPServers["docs"]["httpUrl"] != "https://mcp.example.com/mcp" {
+		t.Errorf("Remote server for gemini = %v, want httpUrl", settings.MCPServers["docs"])
+	}
+
+	if status, ok := e.CheckMCPServers(gemini, config, sum); !ok || status.State != OutputOK {
+		t.Errorf("CheckMCPServers = %v, %v; want ok", status, ok)
+	}
+
+	// A config without servers removes what was written
+	if _, err := e.WriteMCPServers(gemini, nil, sum, false); err != nil {
+		t.Fatalf("WriteMCPServers without servers failed: %v", err)
+	}
+	if fileExistsForTest(gemini.Path) {
+		t.Error("settings.json holding only generated servers should be removed")
+	}
+}
+
+func TestLoadMCPConfigValidates(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, MCPSource, "servers:\n  broken:\n    args: [x]\n")
+
+	if _, err := e.LoadMCPConfig(); err == nil {
+		t.Error("LoadMCPConfig should reject servers without command or url")
+	}
+}
+
+func TestMCPEnvPlaceholders(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, MCPSource, `servers:
+  github:
+    command: npx
+    env:
+      GITHUB_TOKEN: ${VIBERULES_TEST_TOKEN}
+`)
+	t.Setenv("VIBERULES_TEST_TOKEN", "secret")
+	config, err := e.LoadMCPConfig()
+	if err != nil {
+		t.Fatalf("LoadMCPConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"claude", "${VIBERULES_TEST_TOKEN}"},
+		{"cursor", "${env:VIBERULES_TEST_TOKEN}"},
+		{"amazonq", "secret"},
+	}
+	for _, tt := range tests {
+		target, _ := FindMCPTarget(tt.target)
+		entry := target.Render(config)["github"].(map[string]interface{})
+		if got := entry["env"].(map[string]string)["GITHUB_TOKEN"]; got != tt.want {
+			t.Errorf("%s: GITHUB_TOKEN = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	amazonq, _ := FindMCPTarget("amazonq")
+	if got := amazonq.ResolvedVariables(config); !reflect.DeepEqual(got, []string{"VIBERULES_TEST_TOKEN"}) {
+		t.Errorf("ResolvedVariables(amazonq) = %v", got)
+	}
+	claude, _ := FindMCPTarget("claude")
+	if got := claude.ResolvedVariables(config); got != nil {
+		t.Errorf("ResolvedVariables(claude) = %v, want none", got)
+	}
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Conflict markers written around sections both sides changed differently
+const (
+	conflictOurs   = "<<<<<<< ours"
+	conflictSep    = "======="
+	conflictTheirs = ">>>>>>> theirs"
+)
+
+// rulesSection is a heading with the lines below it up to the next heading,
+// or the lines before the first heading
+type rulesSection struct {
+	key  string // heading line, numbered when repeated; empty for the preamble
+	body string // lines of the section, ending with a newline
+	gap  string // blank lines after the section, compared apart from its body
+}
+
+// MergeRules merges two versions of a rules file that were changed from a
+// common ancestor, section by section. A section changed on one side only
+// takes that side's version, sections added on either side are kept, and
+// when both sides only added lines to the end of a section, both additions
+// are kept. Sections both sides changed differently are written with
+// conflict markers, and MergeRules reports that conflicts remain.
+func MergeRules(base, ours, theirs []byte) ([]byte, bool) {
+	baseSections := indexSections(splitSections(string(base)))
+	oursList := splitSections(string(ours))
+	oursSections := indexSections(oursList)
+	theirsList := splitSections(string(theirs))
+	theirsSections := indexSections(theirsList)
+
+	// Our order, with sections only they have placed after the section they follow
+	var keys []string
+	seen := make(map[string]bool)
+	for _, section := range oursList {
+		keys = append(keys, section.key)
+		seen[section.key] = true
+	}
+	previous := ""
+	for _, section := range theirsList {
+		if !seen[section.key] {
+			keys = insertAfter(keys, previous, section.key)
+			seen[section.key] = true
+		}
+		previous = section.key
+	}
+
+	var merged []rulesSection
+	conflicts := false
+	for _, key := range keys {
+		b, inBase := baseSections[key]
+		o, inOurs := oursSections[key]
+		t, inTheirs := theirsSections[key]
+		switch {
+		case !inOurs && !inTheirs:
+			continue // removed on both sides
+		case inOurs == inTheirs && o.body == t.body:
+			merged = append(merged, o)
+		case inOurs == inBase && o.body == b.body:
+			if inTheirs {
+				merged = append(merged, t) // only they changed it
+			}
+		case inTheirs == inBase && t.body == b.body:
+			if inOurs {
+				merged = append(merged, o) // only we changed it
+			}
+		default:
+			if body, ok := mergeAppends(b.body, o.body, t.body); ok && inBase && inOurs && inTheirs {
+				merged = append(merged, rulesSection{body: body, gap: o.gap})
+				continue
+			}
+			conflicts = true
+			gap := o.gap
+			if !inOurs {
+				gap = t.gap
+			}
+			body := conflictOurs + "\n" + o.body + conflictSep + "\n" + t.body + conflictTheirs + "\n"
+			merged = append(merged, rulesSection{body: body, gap: gap})
+		}
+	}
+
+	// The file ends as ours did, even when the section that ended it is gone
+	if len(merged) > 0 && len(oursList) > 0 {
+		merged[len(merged)-1].gap = oursList[len(oursList)-1].gap
+	}
+
+	// Sections that ended the file on one side may be followed by others now
+	var out strings.Builder
+	for i, section := range merged {
+		out.WriteString(section.body)
+		if section.gap == "" && i < len(merged)-1 {
+			out.WriteString("\n")
+		}
+		out.WriteString(section.gap)
+	}
+	return []byte(out.String()), conflicts
+}
+
+// splitSections splits markdown at its headings, leaving fenced code blocks whole
+func splitSections(content string) []rulesSection {
+	var sections []rulesSection
+	var current *rulesSection
+	counts := make(map[string]int)
+	inFence := false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if current == nil || (!inFence && isHeadingLine(line)) {
+			key := ""
+			if isHeadingLine(line) {
+				key = trimmed
+				counts[key]++
+				if counts[key] > 1 {
+					key += " #" + strconv.Itoa(counts[key])
+				}
+			}
+			sections = append(sections, rulesSection{key: key})
+			current = &sections[len(sections)-1]
+		}
+		current.body += line
+	}
+
+	for i := range sections {
+		body := strings.TrimRight(sections[i].body, "\r\n\t ")
+		sections[i].gap = strings.TrimPrefix(sections[i].body[len(body):], "\n")
+		sections[i].body = body + "\n"
+	}
+	return sections
+}
+
+// isHeadingLine reports whether line is an ATX markdown heading
+func isHeadingLine(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return false
+	}
+	rest := line[level:]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r'
+}
+
+func indexSections(sections []rulesSection) map[string]rulesSection {
+	index := make(map[string]rulesSection, len(sections))
+	for _, section := range sections {
+		index[section.key] = section
+	}
+	return index
+}
+
+// insertAfter inserts key into keys after previous, or first when previous is empty
+func insertAfter(keys []string, previous, key string) []string {
+	at := 0
+	if previous != "" {
+		at = len(keys)
+		for i, k := range keys {
+			if k == previous {
+				at = i + 1
+				break
+			}
+		}
+	}
+	keys = append(keys, "")
+	copy(keys[at+1:], keys[at:])
+	keys[at] = key
+	return keys
+}
+
+// mergeAppends merges a section both sides only added lines to the end of,
+// keeping our additions first
+func mergeAppends(base, ours, theirs string) (string, bool) {
+	if !strings.HasPrefix(ours, base) || !strings.HasPrefix(theirs, base) {
+		return "", false
+	}
+	return ours + theirs[len(base):], true
+}
+
+// HasConflictMarkers reports whether content holds the conflict markers
+// MergeRules writes around sections it couldn't merge
+func HasConflictMarkers(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == conflictOurs || line == conflictTheirs {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeRules(t *testing.T) {
+	base := "# Rules\n\nIntro.\n\n## Style\n\nUse tabs.\n\n## Testing\n\nRun go test.\n"
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts bool
+	}{
+		{
+			name:   "different sections",
+			ours:   strings.Replace(base, "Use tabs.", "Use gofmt.", 1),
+			theirs: strings.Replace(base, "Run go test.", "Run go test -race.", 1),
+			want:   "# Rules\n\nIntro.\n\n## Style\n\nUse gofmt.\n\n## Testing\n\nRun go test -race.\n",
+		},
+		{
+			name:   "both append to a section",
+			ours:   strings.Replace(base, "Use tabs.\n", "Use tabs.\nName errors errFoo.\n", 1),
+			theirs: strings.Replace(base, "Use tabs.\n", "Use tabs.\nKeep lines short.\n", 1),
+			want:   "# Rules\n\nIntro.\n\n## Style\n\nUse tabs.\nName errors errFoo.\nKeep lines short.\n\n## Testing\n\nRun go test.\n",
+		},
+		{
+			name:   "added and removed sections",
+			ours:   base + "\n## Docs\n\nDocument exports.\n",
+			theirs: strings.Replace(base, "## Testing\n\nRun go test.\n", "## Security\n\nNo secrets.\n", 1),
+			want:   "# Rules\n\nIntro.\n\n## Style\n\nUse tabs.\n\n## Security\n\nNo secrets.\n\n## Docs\n\nDocument exports.\n",
+		},
+		{
+			name:      "same line changed",
+			ours:      strings.Replace(base, "Use tabs.", "Use spaces.", 1),
+			theirs:    strings.Replace(base, "Use tabs.", "Use two spaces.", 1),
+			want:      "# Rules\n\nIntro.\n\n<<<<<<< ours\n## Style\n\nUse spaces.\n=======\n## Style\n\nUse two spaces.\n>>>>>>> theirs\n\n## Testing\n\nRun go test.\n",
+			conflicts: true,
+		},
+		{
+			name:   "last section removed on one side",
+			ours:   base,
+			theirs: strings.Replace(base, "\n## Testing\n\nRun go test.\n", "", 1),
+			want:   "# Rules\n\nIntro.\n\n## Style\n\nUse tabs.\n",
+		},
+		{
+			name:   "section removed on both sides",
+			ours:   strings.Replace(base, "\n## Testing\n\nRun go test.\n", "", 1),
+			theirs: "# Rules\n\nRead this.\n\n## Style\n\nUse tabs.\n",
+			want:   "# Rules\n\nRead this.\n\n## Style\n\nUse tabs.\n",
+		},
+		{
+			name:   "headings in code blocks",
+			ours:   base + "\n## Shell\n\n```sh\n# not a heading\necho a\n```\n",
+			theirs: base,
+			want:   base + "\n## Shell\n\n```sh\n# not a heading\necho a\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := MergeRules([]byte(base), []byte(tt.ours), []byte(tt.theirs))
+			if string(got) != tt.want || conflicts != tt.conflicts {
+				t.Errorf("MergeRules() = %q, %v\nwant %q, %v", got, conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestHasConflictMarkers(t *testing.T) {
+	merged, conflicts := MergeRules([]byte("## A\n\nx\n"), []byte("## A\n\ny\n"), []byte("## A\n\nz\n"))
+	if !conflicts || !HasConflictMarkers(merged) {
+		t.Errorf("HasConflictMarkers(%q) = false, want true", merged)
+	}
+	if HasConflictMarkers([]byte("## A\n\n```\n<<<<<<< HEAD\n```\n")) {
+		t.Error("HasConflictMarkers should only match the markers MergeRules writes")
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignatureSuffix is appended to the path of a signed file to get its minisign signature
+const SignatureSuffix = ".minisig"
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// String returns the key ID as minisign prints it
+func (k PublicKey) String() string {
+	id := k.ID
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id[:]))
+}
+
+// ParsePublicKey parses a minisign public key: the base64 line, or the whole
+// .pub file with its comment line
+func ParsePublicKey(text string) (PublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return PublicKey{}, fmt.Errorf("invalid minisign public key %q", line)
+	}
+	var key PublicKey
+	copy(key.ID[:], raw[2:10])
+	key.Key = ed25519.PublicKey(raw[10:])
+	return key, nil
+}
+
+// VerifySignature checks a minisign signature of content against keys and
+// returns its trusted comment. Both legacy signatures and the prehashed ones
+// minisign makes by default are accepted.
+func VerifySignature(content, signature []byte, keys []PublicKey) (string, error) {
+	lines := strings.Split(strings.TrimRight(string(signature), "\r\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment: ") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("invalid minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("invalid minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", fmt.Errorf("invalid minisign signature")
+	}
+	comment := strings.TrimRight(strings.TrimPrefix(lines[2], "trusted comment: "), "\r")
+
+	message := content
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	default:
+		return "", fmt.Errorf("unsupported minisign signature algorithm %q", raw[:2])
+	}
+	for _, key := range keys {
+		if !bytes.Equal(key.ID[:], raw[2:10]) {
+			continue
+		}
+		sig := raw[10:]
+		if !ed25519.Verify(key.Key, message, sig) {
+			return "", fmt.Errorf("signature by key %s does not match the content", key)
+		}
+		if !ed25519.Verify(key.Key, append(append([]byte(nil), sig...), comment...), global) {
+			return "", fmt.Errorf("trusted comment of the signature by key %s was altered", key)
+		}
+		return comment, nil
+	}
+	var id PublicKey
+	copy(id.ID[:], raw[2:10])
+	return "", fmt.Errorf("signed by untrusted key %s", id)
+}
+
+// VerifyShared fetches the shared rules at ref and their signature from the
+// same commit, and checks the signature against keys. It returns the verified
+// commit; fetcher must be the one the rules are then read with, so they are
+// the ones checked.
+func VerifyShared(ctx context.Context, fetcher RevisionFetcher, ref RemoteRef, keys []PublicKey) (string, error) {
+	if !ref.Git {
+		return "", fmt.Errorf("%s: signatures can only be verified for rules in git repositories", ref)
+	}
+	content, commit, err := fetcher.FetchRevision(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	sigRef := ref
+	sigRef.Path += SignatureSuffix
+	sigRef.Ref = commit
+	signature, _, err := fetcher.FetchRevision(ctx, sigRef)
+	if err != nil {
+		return "", fmt.Errorf("%s is not signed: %w", ref, err)
+	}
+	if _, err := VerifySignature(content, signature, keys); err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return commit, nil
+}
+
+// SignMinisign signs content with the minisign CLI and returns the signature.
+// key is the secret key file, or minisign's default key when empty; minisign
+// asks for its password on the terminal. The trusted comment is fixed, so
+// signing the same content again gives the same signature.
+func SignMinisign(ctx context.Context, key string, content []byte, comment string) ([]byte, error) {
+	if _, err := exec.LookPath("minisign"); err != nil {
+		return nil, fmt.Errorf("signing needs minisign (https://jedisct1.github.io/minisign/): %w", err)
+	}
+	dir, err := os.MkdirTemp("", "viberules-sign-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "rules.md")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	args := []string{"-S", "-m", file, "-x", file + SignatureSuffix, "-c", comment, "-t", comment}
+	if key != "" {
+		args = append(args, "-s", key)
+	}
+	cmd := exec.CommandContext(ctx, "minisign", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("minisign failed: %w", err)
+	}
+	return os.ReadFile(file + SignatureSuffix)
+}
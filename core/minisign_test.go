@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testMinisign returns a minisign public key line and a signer making
+// signatures in the given algorithm, Ed (legacy) or ED (prehashed)
+func testMinisign(t *testing.T, id string) (string, func(content []byte, algorithm, comment string) []byte) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
+	}
+	keyLine := base64.StdEncoding.EncodeToString(append([]byte("Ed"+id), public...))
+	sign := func(content []byte, algorithm, comment string) []byte {
+		message := content
+		if algorithm == "ED" {
+			digest := blake2b.Sum512(content)
+			message = digest[:]
+		}
+		sig := ed25519.Sign(private, message)
+		global := ed25519.Sign(private, append(append([]byte(nil), sig...), comment...))
+		return []byte("untrusted comment: test\n" +
+			base64.StdEncoding.EncodeToString(append([]byte(algorithm+id), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	return keyLine, sign
+}
+
+func TestVerifySignature(t *testing.T) {
+	keyLine, sign := testMinisign(t, "12345678")
+	key, err := ParsePublicKey("untrusted comment: minisign public key\n" + keyLine + "\n")
+	if err != nil {
+		t.Fatalf("ParsePublicKey() failed: %v", err)
+	}
+	if key.String() != "3837363534333231" {
+		t.Errorf("key ID = %s, want 3837363534333231", key)
+	}
+	otherLine, otherSign := testMinisign(t, "abcdefgh")
+	other, err := ParsePublicKey(otherLine)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() failed: %v", err)
+	}
+
+	content := []byte("# Rules\n")
+	for _, algorithm := range []string{"Ed", "ED"} {
+		comment, err := VerifySignature(content, sign(content, algorithm, "team rules"), []PublicKey{other, key})
+		if err != nil || comment != "team rules" {
+			t.Errorf("VerifySignature(%s) = %q, %v", algorithm, comment, err)
+		}
+	}
+
+	signature := sign(content, "ED", "team rules")
+	tampered := strings.Replace(string(signature), "team rules", "team rulez", 1)
+	for name, tt := range map[string]struct {
+		content, signature []byte
+		keys               []PublicKey
+	}{
+		"altered content":   {[]byte("# Rulez\n"), signature, []PublicKey{key}},
+		"altered comment":   {content, []byte(tampered), []PublicKey{key}},
+		"untrusted key":     {content, otherSign(content, "ED", "x"), []PublicKey{key}},
+		"no trusted keys":   {content, signature, nil},
+		"not a signature":   {content, []byte("hello\n"), []PublicKey{key}},
+		"unknown algorithm": {content, []byte(strings.Replace(string(signature), "RUQ", "RXX", 1)), []PublicKey{key}},
+	} {
+		if _, err := VerifySignature(tt.content, tt.signature, tt.keys); err == nil {
+			t.Errorf("VerifySignature(%s) should fail", name)
+		}
+	}
+
+	if _, err := ParsePublicKey("not a key"); err == nil {
+		t.Error("ParsePublicKey should fail for an invalid key")
+	}
+}
+
+func TestSignMinisign(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake minisign is a shell script")
+	}
+	// A fake minisign writes its arguments where the signature goes
+	bin := t.TempDir()
+	script := "#!/bin/sh\nout=\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = -x ]; then out=$2; fi\n  echo \"$1\" >> \"$0.args\"\n  shift\ndone\necho signed > \"$out\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "minisign"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake minisign: %v", err)
+	}
+	t.Setenv("PATH", bin)
+
+	signature, err := SignMinisign(context.Background(), "/keys/team.key", []byte("# Rules\n"), "team rules")
+	if err != nil || string(signature) != "signed\n" {
+		t.Fatalf("SignMinisign() = %q, %v", signature, err)
+	}
+	args, err := os.ReadFile(filepath.Join(bin, "minisign.args"))
+	if err != nil {
+		t.Fatalf("Failed to read arguments: %v", err)
+	}
+	for _, want := range []string{"-S\n", "-t\nteam rules\n", "-s\n/keys/team.key\n"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("minisign arguments %q lack %q", args, want)
+		}
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := SignMinisign(context.Background(), "", []byte("# Rules\n"), "x"); err == nil {
+		t.Error("SignMinisign should fail without minisign")
+	}
+}
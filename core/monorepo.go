@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rulesPath returns the rules file of the project rooted at dir
+func rulesPath(dir string) string {
+	return filepath.Join(dir, ".viberules", "rules.md")
+}
+
+// IsProject reports whether dir is the root of a viberules project: it has
+// .viberules/rules.md, or a config that may keep the rules file elsewhere
+func (e *Engine) IsProject(dir string) bool {
+	for _, path := range []string{rulesPath(dir), filepath.Join(dir, ".viberules", ".config.yaml")} {
+		if _, err := e.fs.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isHome reports whether dir is the user's home directory, which holds global rules
+func isHome(dir string) bool {
+	home, err := os.UserHomeDir()
+	return err == nil && filepath.Clean(home) == dir
+}
+
+// isBoundary reports whether dir ends an upward search: the root of a git
+// repository or the home directory
+func (e *Engine) isBoundary(dir string) bool {
+	if _, err := e.fs.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	return isHome(dir)
+}
+
+// FindProjectRoot returns the nearest directory at or above start that is a
+// viberules project (see IsProject). The search never leaves the enclosing git repository
+// and never selects the home directory.
+func (e *Engine) FindProjectRoot(start string) (string, bool) {
+	dir, err := e.fs.Abs(start)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if !isHome(dir) && e.IsProject(dir) {
+			return dir, true
+		}
+		if e.isBoundary(dir) {
+			return "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// FindAncestorRules returns the rules files of projects enclosing projectRoot,
+// ordered from the outermost (repository root) to the nearest parent.
+// The search stops at the git repository root.
+func (e *Engine) FindAncestorRules(projectRoot string) []string {
+	dir, err := e.fs.Abs(projectRoot)
+	if err != nil || e.isBoundary(dir) {
+		return nil
+	}
+
+	var found []string
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+
+		if isHome(dir) {
+			break
+		}
+		if _, err := e.fs.Stat(rulesPath(dir)); err == nil {
+			found = append([]string{rulesPath(dir)}, found...)
+		}
+		if e.isBoundary(dir) {
+			break
+		}
+	}
+
+	return found
+}
+
+// InheritRules returns a transform that prepends the content of ancestor rules
+// files, expanding their includes with fetcher. Ancestors come first so the package's own rules, which appear last,
+// take precedence for assistants that favor later instructions.
+func (e *Engine) InheritRules(ctx context.Context, ancestors []string, fetcher Fetcher) Transform {
+	return func(rc *RenderContext, content []byte) ([]byte, error) {
+		if rc.Part {
+			return content, nil
+		}
+		if len(ancestors) == 0 {
+			return content, nil
+		}
+
+		var out bytes.Buffer
+		for _, path := range ancestors {
+			inherited, err := e.fs.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read inherited rules %s: %w", path, err)
+			}
+			inherited, err = e.ExpandIncludes(ctx, inherited, path, fetcher)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(bytes.TrimRight(inherited, "\n"))
+			out.WriteString("\n\n")
+		}
+		out.Write(content)
+		return out.Bytes(), nil
+	}
+}
+
+// skippedDirs are never searched for nested projects
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".viberules":   true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// FindNestedProjects returns root and every directory below it that is a
+// viberules project (see IsProject), in lexical order. Symlinked directories are not followed.
+// The search stops with ctx's error when ctx is done.
+func (e *Engine) FindNestedProjects(ctx context.Context, root string) ([]string, error) {
+	var projects []string
+	err := e.walkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && skippedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if e.IsProject(path) {
+			projects = append(projects, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for nested projects: %w", err)
+	}
+	return projects, nil
+}
+
+// FindSubmodules returns the work trees of the checked-out git submodules of
+// the repository at root, including submodules of submodules, in the order
+// git lists them. Submodules that were never initialized have no work tree
+// and are left out.
+func FindSubmodules(ctx context.Context, root string) ([]string, error) {
+	out, err := runGitContext(ctx, root, "submodule", "--quiet", "foreach", "--recursive", `echo "$displaypath"`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submodules: %w", err)
+	}
+	var submodules []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			submodules = append(submodules, filepath.Join(root, filepath.FromSlash(line)))
+		}
+	}
+	return submodules, nil
+}
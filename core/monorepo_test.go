@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupMonorepo creates a git repository with a root project and a nested package project
+func setupMonorepo(t *testing.T) (root, pkg string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	root = t.TempDir()
+	pkg = filepath.Join(root, "packages", "api")
+	for _, dir := range []string{
+		filepath.Join(root, ".git"),
+		filepath.Join(root, ".viberules"),
+		filepath.Join(pkg, ".viberules"),
+		filepath.Join(pkg, "src"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(rulesPath(root), []byte("root rules\n"), 0644); err != nil {
+		t.Fatalf("Failed to create root rules: %v", err)
+	}
+	if err := os.WriteFile(rulesPath(pkg), []byte("package rules\n"), 0644); err != nil {
+		t.Fatalf("Failed to create package rules: %v", err)
+	}
+	return root, pkg
+}
+
+func TestFindProjectRoot(t *testing.T) {
+	e := New(OSFS{})
+	root, pkg := setupMonorepo(t)
+
+	found, ok := e.FindProjectRoot(filepath.Join(pkg, "src"))
+	if !ok || found != pkg {
+		t.Errorf("FindProjectRoot(src) = %s, %v; want %s", found, ok, pkg)
+	}
+
+	found, ok = e.FindProjectRoot(filepath.Join(root, "packages"))
+	if !ok || found != root {
+		t.Errorf("FindProjectRoot(packages) = %s, %v; want %s", found, ok, root)
+	}
+
+	// The search never leaves the git repository
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outside, "repo", ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+	if _, ok := e.FindProjectRoot(filepath.Join(outside, "repo")); ok {
+		t.Error("FindProjectRoot should not find a project outside the repository")
+	}
+}
+
+func TestFindAncestorRulesAndInherit(t *testing.T) {
+	e := New(OSFS{})
+	root, pkg := setupMonorepo(t)
+
+	ancestors := e.FindAncestorRules(pkg)
+	if len(ancestors) != 1 || ancestors[0] != rulesPath(root) {
+		t.Fatalf("FindAncestorRules(pkg) = %v, want [%s]", ancestors, rulesPath(root))
+	}
+	if got := e.FindAncestorRules(root); len(got) != 0 {
+		t.Errorf("FindAncestorRules(root) = %v, want none", got)
+	}
+
+	content, err := e.InheritRules(context.Background(), ancestors, nil)(&RenderContext{}, []byte("package rules\n"))
+	if err != nil {
+		t.Fatalf("InheritRules failed: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "root rules\n\npackage rules") {
+		t.Errorf("Inherited content = %q, root rules should come first", content)
+	}
+}
+
+func TestFindNestedProjects(t *testing.T) {
+	e := New(OSFS{})
+	root, pkg := setupMonorepo(t)
+
+	// Projects inside skipped directories are ignored
+	ignored := filepath.Join(root, "node_modules", "dep", ".viberules")
+	if err := os.MkdirAll(ignored, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", ignored, err)
+	}
+	if err := os.WriteFile(filepath.Join(ignored, "rules.md"), []byte("dep"), 0644); err != nil {
+		t.Fatalf("Failed to create dependency rules: %v", err)
+	}
+
+	projects, err := e.FindNestedProjects(context.Background(), root)
+	if err != nil {
+		t.Fatalf("FindNestedProjects failed: %v", err)
+	}
+	if len(projects) != 2 || projects[0] != root || projects[1] != pkg {
+		t.Errorf("FindNestedProjects = %v, want [%s %s]", projects, root, pkg)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.FindNestedProjects(ctx, root); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindNestedProjects() after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestFindSubmodules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	git := func(dir string, args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "protocol.file.allow=always", "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if _, err := runGit(dir, args...); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	lib := t.TempDir()
+	git(lib, "init", "-q")
+	git(lib, "commit", "-q", "--allow-empty", "-m", "init")
+
+	root := t.TempDir()
+	git(root, "init", "-q")
+	git(root, "submodule", "--quiet", "add", lib, "vendor/lib")
+	git(root, "submodule", "--quiet", "add", lib, "unused")
+	git(root, "submodule", "--quiet", "deinit", "-f", "unused")
+
+	submodules, err := FindSubmodules(context.Background(), root)
+	if err != nil {
+		t.Fatalf("FindSubmodules() failed: %v", err)
+	}
+	if want := []string{filepath.Join(root, "vendor", "lib")}; !reflect.DeepEqual(submodules, want) {
+		t.Errorf("FindSubmodules() = %v, want %v", submodules, want)
+	}
+
+	if _, err := FindSubmodules(context.Background(), t.TempDir()); err == nil {
+		t.Error("FindSubmodules() outside a repository should fail")
+	}
+}
@@ -0,0 +1,51 @@
+package core
+
+// Observer is told about the changes core operations make and the problems
+// they find, so callers can render progress and logs; the core package
+// prints nothing itself. Methods are called synchronously and one at a time,
+// though operations working on several targets at once call them from their
+// worker goroutines.
+type Observer interface {
+	// OnSymlinkCreated is called after a symlink at path pointing to source is created
+	OnSymlinkCreated(path, source string)
+	// OnFileBackedUp is called after a file at path is moved aside to backup
+	OnFileBackedUp(path, backup string)
+	// OnGitignoreUpdated is called after the viberules section of an ignore
+	// file, .gitignore or the git exclude file, or of .gitattributes is rewritten
+	OnGitignoreUpdated(path string)
+	// OnDriftDetected is called for every output a check finds not up to date
+	OnDriftDetected(status OutputStatus)
+}
+
+// NopObserver ignores every event. Embed it to handle only some of them.
+type NopObserver struct{}
+
+func (NopObserver) OnSymlinkCreated(path, source string) {}
+func (NopObserver) OnFileBackedUp(path, backup string)   {}
+func (NopObserver) OnGitignoreUpdated(path string)       {}
+func (NopObserver) OnDriftDetected(status OutputStatus)  {}
+
+// SetObserver makes core operations report their events to o; nil stops reporting
+func (e *Engine) SetObserver(o Observer) {
+	if o == nil {
+		o = NopObserver{}
+	}
+	e.observer = o
+}
+
+// Observer returns the observer set by SetObserver, for callers that make
+// changes on behalf of the core package, such as updating .gitignore
+func (e *Engine) Observer() Observer {
+	return e.observer
+}
+
+// reportDrift passes the statuses that are not OK to the observer and
+// returns statuses unchanged
+func (e *Engine) reportDrift(statuses ...OutputStatus) []OutputStatus {
+	for _, status := range statuses {
+		if status.State != OutputOK {
+			e.observer.OnDriftDetected(status)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// recordingObserver records the events it is told about
+type recordingObserver struct {
+	NopObserver
+	events []string
+}
+
+func (r *recordingObserver) OnSymlinkCreated(path, source string) {
+	r.events = append(r.events, "link "+path+" -> "+source)
+}
+
+func (r *recordingObserver) OnFileBackedUp(path, backup string) {
+	r.events = append(r.events, "backup "+path+" -> "+backup)
+}
+
+func (r *recordingObserver) OnDriftDetected(status OutputStatus) {
+	r.events = append(r.events, "drift "+status.Path+" "+status.State.String())
+}
+
+func TestObserver(t *testing.T) {
+	e := setupProject(t, "rules")
+	recorder := &recordingObserver{}
+	e.SetObserver(recorder)
+
+	if err := os.WriteFile("CLAUDE.md", []byte("# Hand-written\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+	dir := filepath.Join(BackupDir, "test")
+	if _, err := e.BackupUnmanagedOutputs("claude", nil, dir); err != nil {
+		t.Fatalf("BackupUnmanagedOutputs() failed: %v", err)
+	}
+	if _, err := e.CheckTargetOutputs("claude", StrategySymlink, nil, nil); err != nil {
+		t.Fatalf("CheckTargetOutputs() failed: %v", err)
+	}
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks() failed: %v", err)
+	}
+	// Outputs that are up to date are not reported
+	if _, err := e.CheckTargetOutputs("claude", StrategySymlink, nil, nil); err != nil {
+		t.Fatalf("CheckTargetOutputs() failed: %v", err)
+	}
+
+	want := []string{
+		"backup CLAUDE.md -> " + filepath.Join(dir, "CLAUDE.md"),
+		"drift CLAUDE.md missing",
+		"link CLAUDE.md -> .viberules/rules.md",
+	}
+	if !reflect.DeepEqual(recorder.events, want) {
+		t.Errorf("events = %q, want %q", recorder.events, want)
+	}
+}
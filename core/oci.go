@@ -0,0 +1,367 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Rules are stored in OCI registries the way ORAS stores files: an image
+// manifest with an empty config and the file as its only layer.
+const (
+	// OCIArtifactType identifies viberules rules among a registry's artifacts
+	OCIArtifactType = "application/vnd.viberules.rules.v1"
+
+	ociManifestType = "application/vnd.oci.image.manifest.v1+json"
+	ociEmptyType    = "application/vnd.oci.empty.v1+json"
+	ociLayerType    = "text/markdown"
+	ociTitle        = "org.opencontainers.image.title"
+	ociDefaultTag   = "latest"
+)
+
+// ociEmptyConfig is the config blob of artifacts without a config
+var ociEmptyConfig = []byte("{}")
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	ArtifactType  string          `json:"artifactType,omitempty"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+// parseOCIRef parses the part of an oci:// reference after the scheme:
+// <registry>/<repository>[:<tag>|@<digest>]
+func parseOCIRef(s string) (RemoteRef, error) {
+	rest := strings.TrimPrefix(s, "oci://")
+	name, ref := rest, ""
+	if i := strings.Index(rest, "@"); i >= 0 {
+		name, ref = rest[:i], rest[i+1:]
+		if !strings.HasPrefix(ref, "sha256:") || len(ref) != len("sha256:")+64 {
+			return RemoteRef{}, fmt.Errorf("invalid OCI reference %s: digests are sha256:<64 hex digits>", s)
+		}
+	} else if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		name, ref = rest[:i], rest[i+1:]
+	}
+	host, repo, ok := strings.Cut(name, "/")
+	if !ok || host == "" || repo == "" || path.Clean("/"+repo) != "/"+repo {
+		return RemoteRef{}, fmt.Errorf("invalid OCI reference %s: use oci://<registry>/<repository>:<tag>", s)
+	}
+	return RemoteRef{URL: name, OCI: true, Ref: ref}, nil
+}
+
+// ociRegistry talks to the registry of one repository over the OCI
+// distribution API, getting bearer tokens when the registry asks: anonymous
+// ones, or for the credential of the registry's host
+type ociRegistry struct {
+	client     *http.Client
+	base       string // scheme and host
+	repo       string
+	credential Credential
+	token      string // bearer token from the registry's token service
+	basic      bool   // the registry takes the credential directly
+}
+
+func newOCIRegistry(client *http.Client, ref RemoteRef, credentials Credentials) *ociRegistry {
+	host, repo, _ := strings.Cut(ref.URL, "/")
+	var credential Credential
+	if credentials != nil {
+		hostname := host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			hostname = h
+		}
+		credential, _ = credentials(hostname)
+	}
+	// Local registries, as used for development, rarely have certificates
+	scheme := "https"
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if hostname == "localhost" || net.ParseIP(hostname).IsLoopback() {
+		scheme = "http"
+	}
+	return &ociRegistry{client: client, base: scheme + "://" + host, repo: repo, credential: credential}
+}
+
+// do sends a request to the registry, authenticating and retrying once if it
+// answers 401. Relative URLs are resolved against the registry.
+func (r *ociRegistry) do(ctx context.Context, method, target string, header http.Header, body []byte) (*http.Response, error) {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = r.base + target
+	}
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		switch {
+		case r.token != "":
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		case r.basic:
+			req.SetBasicAuth(r.username(), r.credential.Token)
+		}
+		return r.client.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || r.token != "" || r.basic {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := r.authenticate(ctx, challenge, method != http.MethodGet && method != http.MethodHead); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+// authenticate gets a bearer token for the repository from the realm of a
+// WWW-Authenticate challenge
+func (r *ociRegistry) authenticate(ctx context.Context, challenge string, push bool) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") && r.credential.Token != "" {
+		r.basic = true
+		return nil
+	}
+	if !strings.EqualFold(scheme, "Bearer") {
+		return WithKind(ErrAuth, fmt.Errorf("registry %s needs credentials (%s)", r.base, AuthHint(r.hostname())))
+	}
+	fields := make(map[string]string)
+	for _, param := range strings.Split(params, ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok {
+			fields[key] = strings.Trim(value, `"`)
+		}
+	}
+	if fields["realm"] == "" {
+		return fmt.Errorf("registry %s sent an authentication challenge without a realm", r.base)
+	}
+	scope := "repository:" + r.repo + ":pull"
+	if push {
+		scope += ",push"
+	}
+	query := url.Values{"scope": {scope}}
+	if fields["service"] != "" {
+		query.Set("service", fields["service"])
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fields["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if r.credential.Token != "" {
+		req.SetBasicAuth(r.username(), r.credential.Token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get a token for %s: %w", r.base, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return httpStatusError("registry "+r.base+" token for "+r.repo, resp, r.credential)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteSize)).Decode(&token); err != nil {
+		return fmt.Errorf("failed to read the token for %s: %w", r.base, err)
+	}
+	r.token = token.Token
+	if r.token == "" {
+		r.token = token.AccessToken
+	}
+	if r.token == "" {
+		return fmt.Errorf("registry %s sent an empty token", r.base)
+	}
+	return nil
+}
+
+// username is the user the credential is sent as; registries that accept
+// tokens as passwords ignore it
+func (r *ociRegistry) username() string {
+	if r.credential.Username != "" {
+		return r.credential.Username
+	}
+	return "viberules"
+}
+
+// hostname is the registry's host without the port
+func (r *ociRegistry) hostname() string {
+	u, err := url.Parse(r.base)
+	if err != nil {
+		return r.base
+	}
+	return u.Hostname()
+}
+
+// get reads a manifest or blob, verifying it against digest when given
+func (r *ociRegistry) get(ctx context.Context, target, accept, digest string) ([]byte, error) {
+	resp, err := r.do(ctx, http.MethodGet, target, http.Header{"Accept": {accept}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError(r.base+target, resp, r.credential)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxRemoteSize {
+		return nil, fmt.Errorf("remote content too large: %s%s (max %d bytes)", r.base, target, maxRemoteSize)
+	}
+	if digest != "" && ociDigest(content) != digest {
+		return nil, fmt.Errorf("content of %s%s does not match its digest", r.base, target)
+	}
+	return content, nil
+}
+
+// fetchOCI reads the rules file stored in an OCI artifact and returns it with
+// the digest of the artifact's manifest
+func fetchOCI(ctx context.Context, client *http.Client, ref RemoteRef, credentials Credentials) ([]byte, string, error) {
+	registry := newOCIRegistry(client, ref, credentials)
+	tag := ref.Ref
+	if tag == "" {
+		tag = ociDefaultTag
+	}
+	expected := ""
+	if strings.HasPrefix(tag, "sha256:") {
+		expected = tag
+	}
+	body, err := registry.get(ctx, "/v2/"+registry.repo+"/manifests/"+tag, ociManifestType, expected)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to parse the manifest of %s: %w", ref, err)
+	}
+
+	// ORAS artifacts of several files name each layer after its file
+	var layer *ociDescriptor
+	for i, candidate := range manifest.Layers {
+		if len(manifest.Layers) == 1 || strings.HasSuffix(candidate.Annotations[ociTitle], ".md") {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return nil, "", fmt.Errorf("%s has no markdown layer", ref)
+	}
+	content, err := registry.get(ctx, "/v2/"+registry.repo+"/blobs/"+layer.Digest, "*/*", layer.Digest)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	return content, ociDigest(body), nil
+}
+
+// PushOCI stores content as an artifact at ref, a tagged oci:// reference,
+// with name as its file name, and returns the digest of its manifest
+func PushOCI(ctx context.Context, ref RemoteRef, name string, content []byte, credentials Credentials) (string, error) {
+	if !ref.OCI {
+		return "", fmt.Errorf("%s is not an oci:// reference", ref)
+	}
+	if strings.HasPrefix(ref.Ref, "sha256:") {
+		return "", fmt.Errorf("push to a tag, not a digest: %s", ref)
+	}
+	tag := ref.Ref
+	if tag == "" {
+		tag = ociDefaultTag
+	}
+	registry := newOCIRegistry(&http.Client{Timeout: 30 * time.Second}, ref, credentials)
+	for _, blob := range [][]byte{ociEmptyConfig, content} {
+		if err := registry.pushBlob(ctx, blob); err != nil {
+			return "", fmt.Errorf("failed to push %s: %w", ref, err)
+		}
+	}
+
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestType,
+		ArtifactType:  OCIArtifactType,
+		Config:        ociDescriptor{MediaType: ociEmptyType, Digest: ociDigest(ociEmptyConfig), Size: int64(len(ociEmptyConfig))},
+		Layers: []ociDescriptor{{
+			MediaType:   ociLayerType,
+			Digest:      ociDigest(content),
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitle: name},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := registry.do(ctx, http.MethodPut, "/v2/"+registry.repo+"/manifests/"+tag, http.Header{"Content-Type": {ociManifestType}}, manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to push the manifest of %w", httpStatusError(ref.String(), resp, registry.credential))
+	}
+	return ociDigest(manifest), nil
+}
+
+// pushBlob uploads blob unless the registry already has it
+func (r *ociRegistry) pushBlob(ctx context.Context, blob []byte) error {
+	digest := ociDigest(blob)
+	resp, err := r.do(ctx, http.MethodHead, "/v2/"+r.repo+"/blobs/"+digest, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = r.do(ctx, http.MethodPost, "/v2/"+r.repo+"/blobs/uploads/", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusAccepted || location == "" {
+		return fmt.Errorf("registry refused the upload: %w", httpStatusError(r.base+"/v2/"+r.repo, resp, r.credential))
+	}
+	separator := "?"
+	if strings.Contains(location, "?") {
+		separator = "&"
+	}
+	resp, err = r.do(ctx, http.MethodPut, location+separator+"digest="+url.QueryEscape(digest),
+		http.Header{"Content-Type": {"application/octet-stream"}}, blob)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("registry refused blob %s: %s", digest, resp.Status)
+	}
+	return nil
+}
+
+// ociDigest returns the OCI digest of content
+func ociDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRegistry is an in-memory OCI registry that hands out bearer tokens from
+// its own /token endpoint, like registries that allow anonymous pulls
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte // by tag and by digest
+	uploads   int
+	password  string // token requests must carry it when set, as for private repositories
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, string) {
+	t.Helper()
+	registry := &fakeRegistry{blobs: make(map[string][]byte), manifests: make(map[string][]byte)}
+	server := httptest.NewServer(registry.handler())
+	t.Cleanup(server.Close)
+	return registry, strings.TrimPrefix(server.URL, "http://")
+}
+
+func (f *fakeRegistry) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.URL.Path == "/token" {
+			if _, password, _ := r.BasicAuth(); password != f.password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token": "t-%s"}`, r.URL.Query().Get("scope"))
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer t-repository:acme/rules:") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="fake"`, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		rest := strings.TrimPrefix(r.URL.Path, "/v2/acme/rules/")
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.HasPrefix(rest, "manifests/"):
+			reference := strings.TrimPrefix(rest, "manifests/")
+			if r.Method == http.MethodPut {
+				f.manifests[reference] = body
+				f.manifests[ociDigest(body)] = body
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			manifest, ok := f.manifests[reference]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", ociManifestType)
+			w.Write(manifest)
+		case rest == "blobs/uploads/":
+			f.uploads++
+			w.Header().Set("Location", fmt.Sprintf("/v2/acme/rules/blobs/uploads/%d?state=x", f.uploads))
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasPrefix(rest, "blobs/uploads/"):
+			digest := r.URL.Query().Get("digest")
+			if r.URL.Query().Get("state") != "x" || ociDigest(body) != digest {
+				http.Error(w, "bad upload", http.StatusBadRequest)
+				return
+			}
+			f.blobs[digest] = body
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(rest, "blobs/"):
+			blob, ok := f.blobs[strings.TrimPrefix(rest, "blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestOCIPushFetch(t *testing.T) {
+	registry, host := newFakeRegistry(t)
+	ref, err := ParseRemoteRef("oci://" + host + "/acme/rules:v1")
+	if err != nil {
+		t.Fatalf("ParseRemoteRef failed: %v", err)
+	}
+
+	digest, err := PushOCI(context.Background(), ref, "security.md", []byte("## Security\n"), nil)
+	if err != nil {
+		t.Fatalf("PushOCI failed: %v", err)
+	}
+	if len(registry.blobs) != 2 {
+		t.Errorf("Registry has %d blobs, want the config and the rules", len(registry.blobs))
+	}
+	// Pushing again reuses the blobs
+	if _, err := PushOCI(context.Background(), ref, "security.md", []byte("## Security\n"), nil); err != nil || registry.uploads != 2 {
+		t.Errorf("Second PushOCI uploaded %d blobs in total, %v; want no new uploads", registry.uploads, err)
+	}
+
+	content, revision, err := NewNetFetcher().FetchRevision(context.Background(), ref)
+	if err != nil || string(content) != "## Security\n" || revision != digest {
+		t.Fatalf("FetchRevision = %q, %q, %v; want the rules at %s", content, revision, err, digest)
+	}
+
+	byDigest := ref
+	byDigest.Ref = digest
+	if content, _, err := NewNetFetcher().FetchRevision(context.Background(), byDigest); err != nil || string(content) != "## Security\n" {
+		t.Errorf("FetchRevision(%s) = %q, %v", byDigest, content, err)
+	}
+
+	missing := ref
+	missing.Ref = "v2"
+	if _, _, err := NewNetFetcher().FetchRevision(context.Background(), missing); err == nil {
+		t.Error("Fetching a missing tag should fail")
+	}
+	if _, err := PushOCI(context.Background(), byDigest, "security.md", nil, nil); err == nil {
+		t.Error("Pushing to a digest should fail")
+	}
+}
+
+func TestOCIDigestPinning(t *testing.T) {
+	registry, host := newFakeRegistry(t)
+	ref, _ := ParseRemoteRef("oci://" + host + "/acme/rules:v1")
+	first, err := PushOCI(context.Background(), ref, "rules.md", []byte("v1\n"), nil)
+	if err != nil {
+		t.Fatalf("PushOCI failed: %v", err)
+	}
+
+	lock := &Lockfile{}
+	if _, err := (&LockedFetcher{Inner: NewNetFetcher(), Lock: lock}).Fetch(context.Background(), ref); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if locked, ok := lock.Find(ref.String()); !ok || locked.Commit != first {
+		t.Fatalf("Locked source = %+v, want the manifest digest %s", locked, first)
+	}
+
+	// The tag moves; the lock keeps reading the pinned manifest
+	if _, err := PushOCI(context.Background(), ref, "rules.md", []byte("v2\n"), nil); err != nil {
+		t.Fatalf("PushOCI failed: %v", err)
+	}
+	content, err := (&LockedFetcher{Inner: NewNetFetcher(), Lock: lock}).Fetch(context.Background(), ref)
+	if err != nil || string(content) != "v1\n" {
+		t.Errorf("Fetch with lock = %q, %v; want the pinned v1", content, err)
+	}
+
+	// A registry serving other content for a digest is caught
+	registry.manifests[first] = registry.manifests["v1"]
+	if _, err := (&LockedFetcher{Inner: NewNetFetcher(), Lock: lock}).Fetch(context.Background(), ref); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("Fetch of a tampered manifest = %v, want a digest mismatch", err)
+	}
+}
+
+func TestOCICredentials(t *testing.T) {
+	registry, host := newFakeRegistry(t)
+	ref, _ := ParseRemoteRef("oci://" + host + "/acme/rules:v1")
+	if _, err := PushOCI(context.Background(), ref, "rules.md", []byte("# Private\n"), nil); err != nil {
+		t.Fatalf("PushOCI failed: %v", err)
+	}
+	registry.password = "s3cret"
+
+	fetcher := &NetFetcher{Client: http.DefaultClient}
+	if _, err := fetcher.Fetch(context.Background(), ref); !errors.Is(err, ErrAuth) {
+		t.Errorf("Fetch without credentials = %v, want ErrAuth", err)
+	}
+	fetcher = &NetFetcher{Client: http.DefaultClient, Credentials: func(host string) (Credential, error) {
+		if host != "127.0.0.1" {
+			return Credential{}, nil
+		}
+		return Credential{Username: "ci", Token: "s3cret", Source: "test"}, nil
+	}}
+	if content, err := fetcher.Fetch(context.Background(), ref); err != nil || string(content) != "# Private\n" {
+		t.Errorf("Fetch with credentials = %q, %v", content, err)
+	}
+
+	missing := ref
+	missing.Ref = "v2"
+	if _, err := fetcher.Fetch(context.Background(), missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Fetch of a missing tag = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Projects can move a target's rules file (e.g. CLAUDE.md into docs/ai/).
+// The override replaces the path of the target's link wherever targets are
+// looked up, so symlinks, copies and status all follow it.
+
+// SetOutputPaths overrides the rules file path of targets in the active scope,
+// keyed by target name. Paths are relative to the scope root.
+func (e *Engine) SetOutputPaths(paths map[string]string) {
+	e.outputPaths = paths
+}
+
+// DefaultOutputPath returns the rules file path of a target without overrides
+func (e *Engine) DefaultOutputPath(name string) (string, bool) {
+	for _, target := range e.defaultTargets() {
+		if target.Name == name && len(target.Links) > 0 {
+			return target.Links[0].Target, true
+		}
+	}
+	return "", false
+}
+
+// ValidateOutputPath checks that path can replace the rules file of a target:
+// it must stay inside the project, outside .viberules, and not take another
+// target's default output
+func (e *Engine) ValidateOutputPath(name, path string) error {
+	if _, ok := e.DefaultOutputPath(name); !ok {
+		return fmt.Errorf("unknown target: %s", name)
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return fmt.Errorf("output path must be relative to the project root: %q", path)
+	}
+	clean := filepath.Clean(path)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("output path must stay inside the project: %q", path)
+	}
+	if clean == ".viberules" || strings.HasPrefix(clean, ".viberules"+string(filepath.Separator)) {
+		return fmt.Errorf("output path must not be inside .viberules: %q", path)
+	}
+	for _, target := range e.defaultTargets() {
+		if target.Name != name && len(target.Links) > 0 && target.Links[0].Target == clean {
+			return fmt.Errorf("output path %q is the output of %s", path, target.Name)
+		}
+	}
+	return nil
+}
+
+// withOutputPath returns the target with its rules file moved to path.
+// The link source is recomputed relative to the new location.
+func (t Target) withOutputPath(path string) Target {
+	if len(t.Links) == 0 {
+		return t
+	}
+	links := append([]SymlinkDef(nil), t.Links...)
+	path = filepath.Clean(path)
+	source, err := filepath.Rel(filepath.Dir(path), links[0].ResolvedSource())
+	if err != nil {
+		return t
+	}
+	links[0] = SymlinkDef{Source: source, Target: path}
+	t.Links = links
+	return t
+}
+
+// RemoveRelocatedOutput removes what a target left at its default path before
+// its output was moved: a symlink to the rules file, or a copy with a recorded
+// checksum. Copies edited by hand are only removed with force.
+func (e *Engine) RemoveRelocatedOutput(name string, recorded map[string]string, force bool) error {
+	path, ok := e.DefaultOutputPath(name)
+	if !ok {
+		return nil
+	}
+	target, ok := e.FindTarget(name)
+	if !ok || len(target.Links) == 0 || target.Links[0].Target == path {
+		return nil
+	}
+
+	info, err := e.fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		// Only links into .viberules are ours
+		source, err := e.fs.Readlink(path)
+		if err != nil || filepath.Join(filepath.Dir(path), source) != target.Links[0].ResolvedSource() {
+			return nil
+		}
+		return e.removeSymlink(path)
+	}
+	return e.removeCopiedFile(path, recorded[path], force)
+}
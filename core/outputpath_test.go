@@ -0,0 +1,96 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetOutputPaths(t *testing.T) {
+	e := setupProject(t, "rules")
+	e.SetOutputPaths(map[string]string{"claude": filepath.Join("docs", "ai", "CLAUDE.md")})
+
+	target, ok := e.FindTarget("claude")
+	if !ok {
+		t.Fatal("FindTarget(claude) not found")
+	}
+	link := target.Links[0]
+	if link.Target != filepath.Join("docs", "ai", "CLAUDE.md") {
+		t.Errorf("link target = %q, want docs/ai/CLAUDE.md", link.Target)
+	}
+	if link.ResolvedSource() != filepath.Join(".viberules", "rules.md") {
+		t.Errorf("ResolvedSource() = %q, want .viberules/rules.md", link.ResolvedSource())
+	}
+
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks() failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join("docs", "ai", "CLAUDE.md"))
+	if err != nil || string(content) != "rules" {
+		t.Errorf("moved output = %q, %v; want the rules file", content, err)
+	}
+
+	if path, _ := e.DefaultOutputPath("claude"); path != "CLAUDE.md" {
+		t.Errorf("DefaultOutputPath(claude) = %q, want CLAUDE.md", path)
+	}
+}
+
+func TestValidateOutputPath(t *testing.T) {
+	e := New(OSFS{})
+	tests := []struct {
+		target string
+		path   string
+		valid  bool
+	}{
+		{"claude", filepath.Join("docs", "CLAUDE.md"), true},
+		{"amazonq", "AMAZONQ.md", true},
+		{"claude", "", false},
+		{"claude", "/etc/CLAUDE.md", false},
+		{"claude", filepath.Join("..", "CLAUDE.md"), false},
+		{"claude", filepath.Join(".viberules", "CLAUDE.md"), false},
+		{"claude", "AGENTS.md", false},
+		{"unknown", "RULES.md", false},
+	}
+
+	for _, tt := range tests {
+		err := e.ValidateOutputPath(tt.target, tt.path)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateOutputPath(%q, %q) = %v, want valid %v", tt.target, tt.path, err, tt.valid)
+		}
+	}
+}
+
+func TestRemoveRelocatedOutput(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	// Outputs written at the default path before the override
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks(claude) failed: %v", err)
+	}
+	written, err := e.CopyTargetFiles("gemini", nil, false)
+	if err != nil {
+		t.Fatalf("CopyTargetFiles(gemini) failed: %v", err)
+	}
+	writeTestFile(t, "AGENTS.md", "hand-written")
+
+	e.SetOutputPaths(map[string]string{
+		"claude": filepath.Join("docs", "CLAUDE.md"),
+		"gemini": filepath.Join("docs", "GEMINI.md"),
+		"codex":  filepath.Join("docs", "AGENTS.md"),
+	})
+	for _, name := range []string{"claude", "gemini", "codex"} {
+		if err := e.RemoveRelocatedOutput(name, written, false); err != nil {
+			t.Fatalf("RemoveRelocatedOutput(%s) failed: %v", name, err)
+		}
+	}
+
+	if fileExistsForTest("CLAUDE.md") {
+		t.Error("symlink at the default path should be removed")
+	}
+	if fileExistsForTest("GEMINI.md") {
+		t.Error("copy at the default path should be removed")
+	}
+	if !fileExistsForTest("AGENTS.md") {
+		t.Error("unmanaged file at the default path should be kept")
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Overflow modes for rules over a target's token budget
+const (
+	OverflowWarn     = "warn"     // only report it
+	OverflowTruncate = "truncate" // drop trailing sections
+	OverflowSplit    = "split"    // move trailing sections to additional files
+)
+
+// IsValidOverflow reports whether mode is a supported overflow mode
+func IsValidOverflow(mode string) bool {
+	return mode == OverflowWarn || mode == OverflowTruncate || mode == OverflowSplit
+}
+
+// TopLevelSections splits content before every # and ## heading outside
+// fenced code blocks. Text before the first heading stays with the first section.
+func TopLevelSections(content []byte) [][]byte {
+	var sections [][]byte
+	var current bytes.Buffer
+	inFence := false
+
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		topLevel := strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## ")
+		if !inFence && topLevel && len(bytes.TrimSpace(current.Bytes())) > 0 {
+			sections = append(sections, append([]byte(nil), current.Bytes()...))
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if len(bytes.TrimSpace(current.Bytes())) > 0 {
+		sections = append(sections, current.Bytes())
+	}
+	return sections
+}
+
+// ChunkSections packs consecutive top-level sections of content into chunks of
+// at most budget tokens. A section larger than the budget gets a chunk of its own.
+func ChunkSections(content []byte, budget int) [][]byte {
+	var chunks [][]byte
+	var current []byte
+	for _, section := range TopLevelSections(content) {
+		if len(current) > 0 && EstimateTokens(current)+EstimateTokens(section) > budget {
+			chunks = append(chunks, current)
+			current = nil
+		}
+		current = append(current, section...)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// sectionHeading returns the first line of a section
+func sectionHeading(section []byte) string {
+	line, _, _ := bytes.Cut(bytes.TrimLeft(section, "\n"), []byte("\n"))
+	return strings.TrimSpace(string(line))
+}
+
+// OmittedSections returns the headings of the sections truncation drops from content
+func OmittedSections(content []byte, budget int) []string {
+	chunks := ChunkSections(content, budget)
+	if len(chunks) < 2 {
+		return nil
+	}
+	var headings []string
+	for _, chunk := range chunks[1:] {
+		for _, section := range TopLevelSections(chunk) {
+			headings = append(headings, sectionHeading(section))
+		}
+	}
+	return headings
+}
+
+// FitBudget returns a transform that keeps a target's rules within budget
+// tokens by whole top-level sections. Truncation drops the sections that don't
+// fit; splitting moves them to additional outputs of targets that load every
+// file in a directory. Parts are left alone.
+func (e *Engine) FitBudget(mode string, budget int) Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		if mode == "" || mode == OverflowWarn || budget <= 0 || ctx.Part {
+			return content, nil
+		}
+		if !IsValidOverflow(mode) {
+			return nil, fmt.Errorf("invalid overflow mode %q for %s (use warn, truncate or split)", mode, ctx.Target)
+		}
+		if EstimateTokens(content) <= budget {
+			return content, nil
+		}
+
+		chunks := ChunkSections(content, budget)
+		switch mode {
+		case OverflowTruncate:
+			omitted := len(TopLevelSections(content)) - len(TopLevelSections(chunks[0]))
+			var out bytes.Buffer
+			out.Write(bytes.TrimRight(chunks[0], "\n"))
+			fmt.Fprintf(&out, "\n\n<!-- viberules: %d section(s) omitted to fit the token budget of %d -->\n", omitted, budget)
+			return out.Bytes(), nil
+		default:
+			target, ok := e.FindTarget(ctx.Target)
+			if !ok || target.PartsDir == "" {
+				return nil, fmt.Errorf("%s can't split its rules: it reads a single file (use truncate)", ctx.Target)
+			}
+			ctx.Overflow = chunks[1:]
+			return chunks[0], nil
+		}
+	}
+}
+
+// overflowPath returns the path of the i-th file split off a target's main output.
+// Split files sort right after the main output and before RulesDir parts.
+func (t Target) overflowPath(i int) string {
+	return filepath.Join(t.PartsDir, fmt.Sprintf("%s00-continued-%02d.md", partPrefix, i+1))
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// overflowRules has three sections of ~25 tokens each
+var overflowRules = "# Rules\n" + strings.Repeat("a", 90) + "\n## Style\n" + strings.Repeat("b", 90) + "\n```\n# not a heading\n```\n## Testing\n" + strings.Repeat("c", 90) + "\n"
+
+func TestTopLevelSections(t *testing.T) {
+	sections := TopLevelSections([]byte(overflowRules))
+	var headings []string
+	for _, section := range sections {
+		headings = append(headings, sectionHeading(section))
+	}
+	if want := []string{"# Rules", "## Style", "## Testing"}; !reflect.DeepEqual(headings, want) {
+		t.Errorf("Sections = %v, want %v", headings, want)
+	}
+	if strings.Join(func() []string {
+		var parts []string
+		for _, s := range sections {
+			parts = append(parts, string(s))
+		}
+		return parts
+	}(), "") != overflowRules {
+		t.Error("Sections should add up to the content")
+	}
+}
+
+func TestFitBudgetTruncate(t *testing.T) {
+	e := New(OSFS{})
+	ctx := &RenderContext{Target: "claude"}
+	content, err := e.FitBudget(OverflowTruncate, 60)(ctx, []byte(overflowRules))
+	if err != nil {
+		t.Fatalf("FitBudget failed: %v", err)
+	}
+	if strings.Contains(string(content), "## Testing") || !strings.Contains(string(content), "## Style") {
+		t.Errorf("Truncated content should keep whole sections within budget:\n%s", content)
+	}
+	if !strings.Contains(string(content), "<!-- viberules: 1 section(s) omitted") {
+		t.Errorf("Truncated content should say what was omitted:\n%s", content)
+	}
+	if got := OmittedSections([]byte(overflowRules), 60); !reflect.DeepEqual(got, []string{"## Testing"}) {
+		t.Errorf("OmittedSections = %v, want ## Testing", got)
+	}
+
+	if _, err := e.FitBudget(OverflowSplit, 60)(ctx, []byte(overflowRules)); err == nil {
+		t.Error("Splitting should fail for a target that reads a single file")
+	}
+}
+
+func TestFitBudgetSplit(t *testing.T) {
+	e := setupProject(t, overflowRules)
+
+	recorded, err := e.GenerateTargetFiles("amazonq", Pipeline{e.FitBudget(OverflowSplit, 30)}, nil, false)
+	if err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+	dir := filepath.Join(".amazonq", "rules")
+	want := map[string]string{
+		filepath.Join(dir, "AMAZONQ.md"):                   "# Rules\n",
+		filepath.Join(dir, "viberules-00-continued-01.md"): "## Style\n",
+		filepath.Join(dir, "viberules-00-continued-02.md"): "## Testing\n",
+	}
+	if len(recorded) != len(want) {
+		t.Fatalf("GenerateTargetFiles wrote %v, want %d files", recorded, len(want))
+	}
+	for path, prefix := range want {
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.HasPrefix(string(content), prefix) {
+			t.Errorf("%s = %q, want it to start with %q", path, content, prefix)
+		}
+	}
+
+	statuses, err := e.CheckTargetOutputs("amazonq", StrategyGenerate, Pipeline{e.FitBudget(OverflowSplit, 30)}, recorded)
+	if err != nil {
+		t.Fatalf("CheckTargetOutputs failed: %v", err)
+	}
+	for _, status := range statuses {
+		if status.State != OutputOK {
+			t.Errorf("%s: state = %v, want ok", status.Path, status.State)
+		}
+	}
+
+	// Raising the budget drops the split files
+	if _, err := e.GenerateTargetFiles("amazonq", Pipeline{e.FitBudget(OverflowSplit, 1000)}, recorded, false); err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+	if fileExistsForTest(filepath.Join(dir, "viberules-00-continued-01.md")) {
+		t.Error("Split files should be removed once the rules fit")
+	}
+}
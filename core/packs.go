@@ -0,0 +1,247 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PacksDir holds installed rule packs, concatenated after RulesDir files in
+// name order
+var PacksDir = filepath.Join(".viberules", "packs")
+
+// packNamePattern matches valid pack names, which are also file names
+var packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// PackIndex lists the rule packs available to install. A pack may be listed
+// once per version, so projects can stay on a major version.
+type PackIndex struct {
+	Packs []Pack `yaml:"packs"`
+}
+
+// Pack is a reusable set of rules, such as a style guide or a security baseline
+type Pack struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Version     string `yaml:"version,omitempty"` // semantic version, for constraints
+	Source      string `yaml:"source"`            // remote reference in include syntax, or a path relative to the index
+}
+
+// LoadPackIndex reads the pack index at location, a remote reference in
+// include syntax or a local file, and resolves relative pack sources against it
+func LoadPackIndex(ctx context.Context, location string, fetcher Fetcher) (PackIndex, error) {
+	var content []byte
+	var remote *RemoteRef
+	if IsRemoteRef(location) {
+		ref, err := ParseRemoteRef(location)
+		if err != nil {
+			return PackIndex{}, err
+		}
+		if content, err = fetcher.Fetch(ctx, ref); err != nil {
+			return PackIndex{}, fmt.Errorf("failed to fetch pack index %s: %w", location, err)
+		}
+		remote = &ref
+	} else {
+		var err error
+		if content, err = os.ReadFile(location); err != nil {
+			return PackIndex{}, fmt.Errorf("failed to read pack index: %w", err)
+		}
+	}
+
+	var index PackIndex
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return PackIndex{}, fmt.Errorf("failed to parse pack index %s: %w", location, err)
+	}
+	seen := make(map[string]map[string]bool) // versions listed per pack
+	for i, pack := range index.Packs {
+		if !packNamePattern.MatchString(pack.Name) {
+			return PackIndex{}, fmt.Errorf("invalid pack name in %s: %q", location, pack.Name)
+		}
+		versions := seen[pack.Name]
+		if versions == nil {
+			versions = make(map[string]bool)
+			seen[pack.Name] = versions
+		}
+		if versions[pack.Version] || (len(versions) > 0 && (pack.Version == "" || versions[""])) {
+			return PackIndex{}, fmt.Errorf("pack %s is listed twice in %s; list it once per version", pack.Name, location)
+		}
+		versions[pack.Version] = true
+		if pack.Source == "" {
+			return PackIndex{}, fmt.Errorf("pack %s in %s has no source", pack.Name, location)
+		}
+
+		switch {
+		case remote != nil:
+			resolved, err := remote.Resolve(pack.Source)
+			if err != nil {
+				return PackIndex{}, fmt.Errorf("pack %s: %w", pack.Name, err)
+			}
+			index.Packs[i].Source = resolved.String()
+		case IsRemoteRef(pack.Source):
+			// Refuse the whole index before any of its packs is fetched
+			if _, err := ParseRemoteRef(pack.Source); err != nil {
+				return PackIndex{}, fmt.Errorf("pack %s in %s: %w", pack.Name, location, err)
+			}
+		case !IsRemoteRef(pack.Source) && !filepath.IsAbs(pack.Source):
+			dir, err := filepath.Abs(filepath.Dir(location))
+			if err != nil {
+				return PackIndex{}, err
+			}
+			index.Packs[i].Source = filepath.Join(dir, filepath.FromSlash(pack.Source))
+		}
+	}
+	// By name, then newest first
+	sort.SliceStable(index.Packs, func(i, j int) bool {
+		a, b := index.Packs[i], index.Packs[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		av, aErr := ParseVersion(a.Version)
+		bv, bErr := ParseVersion(b.Version)
+		if aErr != nil || bErr != nil {
+			return aErr == nil
+		}
+		return av.Compare(bv) > 0
+	})
+	return index, nil
+}
+
+// Find returns the latest version of the pack called name
+func (i PackIndex) Find(name string) (Pack, bool) {
+	for _, pack := range i.Packs {
+		if pack.Name == name {
+			return pack, true
+		}
+	}
+	return Pack{}, false
+}
+
+// Resolve returns the latest version of the pack called name that constraint
+// allows
+func (i PackIndex) Resolve(name string, constraint Constraint) (Pack, error) {
+	listed := false
+	for _, pack := range i.Packs {
+		if pack.Name != name {
+			continue
+		}
+		listed = true
+		if v, err := ParseVersion(pack.Version); err == nil && constraint.Allows(v) {
+			return pack, nil
+		}
+	}
+	if !listed {
+		return Pack{}, fmt.Errorf("no pack named '%s' in the index", name)
+	}
+	return Pack{}, fmt.Errorf("no version of pack %s matches %s", name, constraint)
+}
+
+// Search returns the latest version of the packs whose name or description
+// contains query, ignoring case, by name; an empty query matches every pack
+func (i PackIndex) Search(query string) []Pack {
+	query = strings.ToLower(query)
+	var found []Pack
+	for j, pack := range i.Packs {
+		if j > 0 && i.Packs[j-1].Name == pack.Name {
+			continue
+		}
+		if strings.Contains(strings.ToLower(pack.Name), query) || strings.Contains(strings.ToLower(pack.Description), query) {
+			found = append(found, pack)
+		}
+	}
+	return found
+}
+
+// FetchPack returns the content of pack and the revision it was read at: the
+// commit for git sources, the manifest digest for OCI sources
+func FetchPack(ctx context.Context, pack Pack, fetcher RevisionFetcher) ([]byte, string, error) {
+	if !IsRemoteRef(pack.Source) {
+		content, err := os.ReadFile(pack.Source)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read pack %s: %w", pack.Name, err)
+		}
+		return content, "", nil
+	}
+	ref, err := ParseRemoteRef(pack.Source)
+	if err != nil {
+		return nil, "", err
+	}
+	content, commit, err := fetcher.FetchRevision(ctx, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch pack %s: %w", pack.Name, err)
+	}
+	return content, commit, nil
+}
+
+// PackPath returns where the pack called name is installed
+func PackPath(name string) string {
+	return filepath.Join(PacksDir, name+".md")
+}
+
+// PackFiles returns the installed packs in concatenation order
+func (e *Engine) PackFiles() ([]string, error) {
+	entries, err := e.fs.ReadDir(PacksDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", PacksDir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		files = append(files, filepath.Join(PacksDir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LockedPack records an installed pack
+type LockedPack struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version,omitempty"`
+	Constraint string `yaml:"constraint,omitempty"` // versions 'viberules update' may move to
+	Source     string `yaml:"source"`
+	Commit     string `yaml:"commit,omitempty"` // commit or manifest digest the pack was read at, for git and OCI sources
+	SHA256     string `yaml:"sha256"`           // checksum of the installed content
+}
+
+// FindPack returns the record of the installed pack called name
+func (l *Lockfile) FindPack(name string) (LockedPack, bool) {
+	for _, pack := range l.Packs {
+		if pack.Name == name {
+			return pack, true
+		}
+	}
+	return LockedPack{}, false
+}
+
+// SetPack records an installed pack, replacing the record of the same name
+func (l *Lockfile) SetPack(pack LockedPack) {
+	l.RemovePack(pack.Name)
+	l.Packs = append(l.Packs, pack)
+	sort.Slice(l.Packs, func(i, j int) bool { return l.Packs[i].Name < l.Packs[j].Name })
+}
+
+// RemovePack forgets the pack called name
+func (l *Lockfile) RemovePack(name string) {
+	for i, pack := range l.Packs {
+		if pack.Name == name {
+			l.Packs = append(l.Packs[:i], l.Packs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Empty reports whether the lockfile records nothing, so it needn't exist
+func (l *Lockfile) Empty() bool {
+	return len(l.Sources) == 0 && l.Shared == nil && len(l.Packs) == 0
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPackIndex(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, "index.yaml")
+	writeTestFile(t, index, `packs:
+  - name: security-baseline
+    description: Secrets and dependency hygiene
+    source: packs/security.md
+  - name: go-style
+    description: Go style guide
+    version: 1.2.0
+    source: git::https://github.com/acme/packs//go-style.md?ref=v1.2.0
+  - name: go-style
+    version: 2.0.0
+    source: git::https://github.com/acme/packs//go-style.md?ref=v2.0.0
+  - name: go-style
+    version: 1.10.1
+    source: git::https://github.com/acme/packs//go-style.md?ref=v1.10.1
+`)
+
+	loaded, err := LoadPackIndex(context.Background(), index, nil)
+	if err != nil {
+		t.Fatalf("LoadPackIndex() failed: %v", err)
+	}
+	if len(loaded.Packs) != 4 || loaded.Packs[0].Name != "go-style" {
+		t.Fatalf("LoadPackIndex() = %+v, want four entries by name", loaded.Packs)
+	}
+	if latest, _ := loaded.Find("go-style"); latest.Version != "2.0.0" {
+		t.Errorf("Find(go-style) = %+v, want the latest version", latest)
+	}
+	for constraint, want := range map[string]string{"^1.2": "1.10.1", "~1.2": "1.2.0", "*": "2.0.0"} {
+		parsed, _ := ParseConstraint(constraint)
+		if got, err := loaded.Resolve("go-style", parsed); err != nil || got.Version != want {
+			t.Errorf("Resolve(go-style, %s) = %+v, %v; want %s", constraint, got, err, want)
+		}
+	}
+	major3, _ := ParseConstraint("^3")
+	if _, err := loaded.Resolve("go-style", major3); err == nil {
+		t.Error("Resolve(go-style, ^3) should fail")
+	}
+	security, ok := loaded.Find("security-baseline")
+	if !ok || security.Source != filepath.Join(dir, "packs", "security.md") {
+		t.Errorf("Find(security-baseline) = %+v, %v; want its source next to the index", security, ok)
+	}
+	if got := loaded.Search("SECRETS"); len(got) != 1 || got[0].Name != "security-baseline" {
+		t.Errorf("Search(SECRETS) = %+v", got)
+	}
+	if got := loaded.Search(""); len(got) != 2 {
+		t.Errorf("Search(\"\") = %+v, want every pack once", got)
+	}
+
+	// A source git would take as an option is never fetched
+	bad := Pack{Name: "bad", Source: "git::--upload-pack=touch${IFS}" + filepath.Join(dir, "pwned") + ";false//x.md"}
+	if _, _, err := FetchPack(context.Background(), bad, nil); err == nil {
+		t.Error("FetchPack() with an option as the repository should fail")
+	}
+
+	writeTestFile(t, filepath.Join(dir, "packs", "security.md"), "## Security\n")
+	if content, commit, err := FetchPack(context.Background(), security, nil); err != nil || string(content) != "## Security\n" || commit != "" {
+		t.Errorf("FetchPack(local) = %q, %q, %v", content, commit, err)
+	}
+
+	for name, content := range map[string]string{
+		"bad name":          "packs:\n  - name: ../x\n    source: x.md\n",
+		"no source":         "packs:\n  - name: x\n",
+		"duplicate":         "packs:\n  - name: x\n    source: x.md\n  - name: x\n    source: y.md\n",
+		"duplicate version": "packs:\n  - name: x\n    version: 1.0.0\n    source: x.md\n  - name: x\n    version: 1.0.0\n    source: y.md\n",
+		"unversioned twice": "packs:\n  - name: x\n    version: 1.0.0\n    source: x.md\n  - name: x\n    source: y.md\n",
+		"option source":     "packs:\n  - name: x\n    source: git::--upload-pack=touch${IFS}pwned;false//x.md\n",
+	} {
+		writeTestFile(t, index, content)
+		if _, err := LoadPackIndex(context.Background(), index, nil); err == nil {
+			t.Errorf("LoadPackIndex(%s) should fail", name)
+		}
+	}
+}
+
+func TestPackFiles(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	for _, path := range []string{
+		filepath.Join(RulesDir, "10-style.md"),
+		filepath.Join(PacksDir, "security.md"),
+		filepath.Join(PacksDir, "go-style.md"),
+		filepath.Join(PacksDir, "notes.txt"),
+	} {
+		writeTestFile(t, path, "x\n")
+	}
+
+	files, err := e.RulesDirFiles()
+	if err != nil {
+		t.Fatalf("RulesDirFiles() failed: %v", err)
+	}
+	want := []string{filepath.Join(RulesDir, "10-style.md"), PackPath("go-style"), PackPath("security")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("RulesDirFiles() = %v, want %v", files, want)
+	}
+
+	// Packs count without a rules directory
+	if err := os.RemoveAll(RulesDir); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := e.RulesDirFiles(); err != nil || len(files) != 2 {
+		t.Errorf("RulesDirFiles() = %v, %v; want the two packs", files, err)
+	}
+
+	// Parts of packs don't collide with RulesDir parts of the same name
+	links, err := e.OutputLinks(Target{Name: "t", PartsDir: "rules"})
+	if err != nil || len(links) != 2 || links[0].Target != filepath.Join("rules", "viberules-pack-go-style.md") {
+		t.Errorf("OutputLinks() = %+v, %v", links, err)
+	}
+}
+
+func TestLockfilePacks(t *testing.T) {
+	lock := &Lockfile{}
+	if !lock.Empty() {
+		t.Error("A new lockfile should be empty")
+	}
+	lock.SetPack(LockedPack{Name: "security", SHA256: "a"})
+	lock.SetPack(LockedPack{Name: "go-style", SHA256: "b"})
+	lock.SetPack(LockedPack{Name: "security", SHA256: "c"})
+	if len(lock.Packs) != 2 || lock.Packs[0].Name != "go-style" || lock.Packs[1].SHA256 != "c" {
+		t.Errorf("Packs = %+v, want go-style then the updated security", lock.Packs)
+	}
+	if lock.Empty() {
+		t.Error("A lockfile with packs is not empty")
+	}
+	lock.RemovePack("security")
+	lock.RemovePack("go-style")
+	if _, ok := lock.FindPack("go-style"); ok || !lock.Empty() {
+		t.Errorf("Packs after removal = %+v", lock.Packs)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Some tools load every file in a rules directory (Amazon Q reads all of
+// .amazonq/rules). For those targets each file in RulesDir becomes a file of
+// its own next to the main output instead of being concatenated into it.
+
+// partPrefix marks rules directory files written into a target's PartsDir.
+// The main output sorts before them, so tools see the files in source order.
+const partPrefix = "viberules-"
+
+// OutputLinks returns every output of a target: its links followed by one
+// part per file in RulesDir for targets with a PartsDir
+func (e *Engine) OutputLinks(t Target) ([]SymlinkDef, error) {
+	if t.PartsDir == "" {
+		return t.Links, nil
+	}
+	files, err := e.RulesDirFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	links := append([]SymlinkDef(nil), t.Links...)
+	for _, file := range files {
+		source, err := filepath.Rel(t.PartsDir, file)
+		if err != nil {
+			return nil, err
+		}
+		// Packs may share a name with a RulesDir file
+		name := partPrefix + filepath.Base(file)
+		if filepath.Dir(file) == PacksDir {
+			name = partPrefix + "pack-" + filepath.Base(file)
+		}
+		links = append(links, SymlinkDef{
+			Source: source,
+			Target: filepath.Join(t.PartsDir, name),
+			Part:   true,
+		})
+	}
+	return links, nil
+}
+
+// IsPartPath reports whether path is a part written into the target's PartsDir
+func (t Target) IsPartPath(path string) bool {
+	return t.PartsDir != "" && filepath.Dir(path) == t.PartsDir && strings.HasPrefix(filepath.Base(path), partPrefix)
+}
+
+// existingParts returns the parts present in the target's PartsDir, including
+// those whose source was deleted, sorted
+func (e *Engine) existingParts(t Target) ([]string, error) {
+	if t.PartsDir == "" {
+		return nil, nil
+	}
+	entries, err := e.fs.ReadDir(t.PartsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", t.PartsDir, err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if path := filepath.Join(t.PartsDir, entry.Name()); t.IsPartPath(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// linkTargets returns the output paths of links
+func linkTargets(links []SymlinkDef) []string {
+	paths := make([]string, len(links))
+	for i, link := range links {
+		paths[i] = link.Target
+	}
+	return paths
+}
+
+// staleParts returns the parts present on disk that are not among outputs
+func (e *Engine) staleParts(t Target, outputs []string) ([]string, error) {
+	existing, err := e.existingParts(t)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool)
+	for _, path := range outputs {
+		current[path] = true
+	}
+	var stale []string
+	for _, path := range existing {
+		if !current[path] {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
+
+// removeStaleParts removes parts that are no longer outputs: symlinks into RulesDir,
+// and copies with a recorded checksum
+func (e *Engine) removeStaleParts(t Target, outputs []string, recorded map[string]string, force bool) error {
+	stale, err := e.staleParts(t, outputs)
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err := e.removePart(path, recorded[path], force); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removePart removes a part written by any strategy. Regular files are only
+// removed when viberules wrote them.
+func (e *Engine) removePart(path, recorded string, force bool) error {
+	info, err := e.fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return e.removeSymlink(path)
+	}
+	return e.removeCopiedFile(path, recorded, force)
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAmazonQParts(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, filepath.Join(RulesDir, "10-style.md"), "# Style\n")
+	writeTestFile(t, filepath.Join(RulesDir, "20-testing.md"), "# Testing\n")
+	part := filepath.Join(".amazonq", "rules", "viberules-10-style.md")
+
+	// The main output keeps rules.md alone; each rules directory file is its own output
+	recorded, err := e.GenerateTargetFiles("amazonq", Pipeline{e.ConcatRulesDir(context.Background(), nil)}, nil, false)
+	if err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+	if len(recorded) != 3 {
+		t.Fatalf("GenerateTargetFiles wrote %v, want AMAZONQ.md and two parts", recorded)
+	}
+	main, _ := os.ReadFile(filepath.Join(".amazonq", "rules", "AMAZONQ.md"))
+	if string(main) != "# Rules\n" {
+		t.Errorf("AMAZONQ.md = %q, want rules.md only", main)
+	}
+	content, _ := os.ReadFile(part)
+	if string(content) != "# Style\n" {
+		t.Errorf("%s = %q, want the source file", part, content)
+	}
+
+	// Parts whose source was deleted are reported and then removed
+	if err := os.Remove(filepath.Join(RulesDir, "20-testing.md")); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := e.CheckTargetOutputs("amazonq", StrategyGenerate, Pipeline{e.ConcatRulesDir(context.Background(), nil)}, recorded)
+	if err != nil {
+		t.Fatalf("CheckTargetOutputs failed: %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.State != OutputStale {
+		t.Errorf("Deleted part state = %v, want stale", last.State)
+	}
+	if _, err := e.GenerateTargetFiles("amazonq", nil, recorded, false); err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+	if fileExistsForTest(filepath.Join(".amazonq", "rules", "viberules-20-testing.md")) {
+		t.Error("Part whose source was deleted should be removed")
+	}
+
+	// Switching to symlinks replaces the copies, and removing the target cleans up
+	if err := e.RemoveTargetCopies("amazonq", recorded, false); err != nil {
+		t.Fatalf("RemoveTargetCopies failed: %v", err)
+	}
+	if err := e.CreateTargetSymlinks("amazonq"); err != nil {
+		t.Fatalf("CreateTargetSymlinks failed: %v", err)
+	}
+	if !e.IsSymlinkValid(part, filepath.Join("..", "..", RulesDir, "10-style.md")) {
+		t.Errorf("%s should link to its source", part)
+	}
+	if err := e.RemoveTargetSymlinks("amazonq"); err != nil {
+		t.Fatalf("RemoveTargetSymlinks failed: %v", err)
+	}
+	if fileExistsForTest(part) {
+		t.Error("Symlinked part should be removed with the target")
+	}
+}
@@ -0,0 +1,666 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commands plan their changes before making them: they run against a PlanFS,
+// which keeps what they write in memory, and Plan turns that into the files
+// to create, replace or remove. The plan is then printed, for a dry run, or
+// made with ApplyPlan.
+
+// PlanAction is what a planned operation does to its path
+type PlanAction string
+
+const (
+	PlanCreate  PlanAction = "create"
+	PlanReplace PlanAction = "replace"
+	PlanRemove  PlanAction = "remove"
+)
+
+// FileOp is a planned change to a file, symlink or directory
+type FileOp struct {
+	Action   PlanAction
+	Path     string      // absolute
+	Mode     fs.FileMode // type and permissions of what is created or replaced, or of what is removed
+	Content  []byte      // content of a file
+	Link     string      // destination of a symlink
+	Trash    bool        // what is replaced or removed goes to the platform trash
+	Target   string      // target whose output is at Path, if any
+	Strategy string      // link strategy of that output
+}
+
+// PlanFS is a filesystem that keeps every change in memory on top of a base
+// filesystem, which is only read. Reads see the changes made so far. Views
+// made with Within resolve relative names against other directories and
+// share the changes. It is safe for concurrent use.
+type PlanFS struct {
+	base  FS
+	dir   string // absolute directory relative names are resolved against
+	state *planState
+}
+
+// planState holds the changes shared by the views of a PlanFS
+type planState struct {
+	mu      sync.Mutex
+	nodes   map[string]*memNode // changed paths, nil once removed
+	trashed map[string]bool     // paths whose previous content goes to the trash
+}
+
+// NewPlanFS returns a PlanFS over base, resolving relative names against the
+// root of base
+func NewPlanFS(base FS) (*PlanFS, error) {
+	dir, err := base.Abs(".")
+	if err != nil {
+		return nil, err
+	}
+	return &PlanFS{base: base, dir: dir, state: &planState{
+		nodes:   make(map[string]*memNode),
+		trashed: make(map[string]bool),
+	}}, nil
+}
+
+// Within returns a view of p resolving relative names against dir
+func (p *PlanFS) Within(dir string) *PlanFS {
+	return &PlanFS{base: p.base, dir: p.abs(dir), state: p.state}
+}
+
+// abs returns name as an absolute, clean path
+func (p *PlanFS) abs(name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(p.dir, name)
+}
+
+// resolve returns the path name refers to once symlinks are followed, the
+// last element only when follow is set. Missing elements are kept as written.
+func (p *PlanFS) resolve(name string, follow bool) (string, error) {
+	name = p.abs(name)
+	links := 0
+restart:
+	volume := filepath.VolumeName(name)
+	root := volume + string(filepath.Separator)
+	parts := strings.Split(strings.TrimPrefix(name[len(volume):], string(filepath.Separator)), string(filepath.Separator))
+	current := root
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		next := filepath.Join(current, part)
+		if follow || i < len(parts)-1 {
+			if info, err := p.lstat(next); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+				if links++; links > maxLinks {
+					return "", errLinkLoop
+				}
+				target, err := p.readlink(next)
+				if err != nil {
+					return "", err
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(current, target)
+				}
+				name = filepath.Join(append([]string{target}, parts[i+1:]...)...)
+				goto restart
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// shadowed reports whether a change to a directory above name hides what
+// the base has at name
+func (p *PlanFS) shadowed(name string) bool {
+	for dir := filepath.Dir(name); ; dir = filepath.Dir(dir) {
+		if _, ok := p.state.nodes[dir]; ok {
+			return true
+		}
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+// lstat describes the resolved path name without following a final symlink
+func (p *PlanFS) lstat(name string) (fs.FileInfo, error) {
+	if node, ok := p.state.nodes[name]; ok {
+		if node == nil {
+			return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+		}
+		return memInfo{name: filepath.Base(name), node: node}, nil
+	}
+	if p.shadowed(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return p.base.Lstat(name)
+}
+
+func (p *PlanFS) readlink(name string) (string, error) {
+	if node, ok := p.state.nodes[name]; ok && node != nil {
+		if node.mode&fs.ModeSymlink == 0 {
+			return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+		}
+		return filepath.FromSlash(node.link), nil
+	}
+	if _, err := p.lstat(name); err != nil {
+		return "", err
+	}
+	return p.base.Readlink(name)
+}
+
+func (p *PlanFS) readFile(name string) ([]byte, error) {
+	if node, ok := p.state.nodes[name]; ok && node != nil {
+		if node.mode.IsDir() {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+		}
+		return append([]byte(nil), node.data...), nil
+	}
+	if _, err := p.lstat(name); err != nil {
+		return nil, err
+	}
+	return p.base.ReadFile(name)
+}
+
+func (p *PlanFS) readDir(name string) ([]fs.DirEntry, error) {
+	info, err := p.lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: errNotDir}
+	}
+	entries := make(map[string]fs.DirEntry)
+	if _, changed := p.state.nodes[name]; !changed {
+		base, err := p.base.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range base {
+			entries[entry.Name()] = entry
+		}
+	}
+	for path, node := range p.state.nodes {
+		if filepath.Dir(path) != name || path == name {
+			continue
+		}
+		if node == nil {
+			delete(entries, filepath.Base(path))
+		} else {
+			entries[filepath.Base(path)] = fs.FileInfoToDirEntry(memInfo{name: filepath.Base(path), node: node})
+		}
+	}
+	var sorted []fs.DirEntry
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return sorted, nil
+}
+
+// parentDir fails unless the directory holding the resolved path name exists
+func (p *PlanFS) parentDir(op, name string) error {
+	info, err := p.lstat(filepath.Dir(name))
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: errNotDir}
+	}
+	return nil
+}
+
+// forget drops the changes below the resolved path name
+func (p *PlanFS) forget(name string) {
+	for path := range p.state.nodes {
+		if strings.HasPrefix(path, name+string(filepath.Separator)) {
+			delete(p.state.nodes, path)
+		}
+	}
+}
+
+// copyTree returns what is at the resolved path name and below it, keyed by
+// path relative to name
+func (p *PlanFS) copyTree(name string) (map[string]*memNode, error) {
+	nodes := make(map[string]*memNode)
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		path := filepath.Join(name, rel)
+		info, err := p.lstat(path)
+		if err != nil {
+			return err
+		}
+		node := &memNode{mode: info.Mode(), modTime: info.ModTime()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := p.readlink(path)
+			if err != nil {
+				return err
+			}
+			node.link = filepath.ToSlash(link)
+		case info.IsDir():
+			entries, err := p.readDir(path)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if err := walk(filepath.Join(rel, entry.Name())); err != nil {
+					return err
+				}
+			}
+		default:
+			if node.data, err = p.readFile(path); err != nil {
+				return err
+			}
+		}
+		nodes[rel] = node
+		return nil
+	}
+	return nodes, walk(".")
+}
+
+func (p *PlanFS) Lstat(name string) (fs.FileInfo, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, false)
+	if err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return p.lstat(path)
+}
+
+func (p *PlanFS) Stat(name string) (fs.FileInfo, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return p.lstat(path)
+}
+
+func (p *PlanFS) ReadFile(name string) ([]byte, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return p.readFile(path)
+}
+
+func (p *PlanFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return p.readDir(path)
+}
+
+func (p *PlanFS) Readlink(name string) (string, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, false)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return p.readlink(path)
+}
+
+func (p *PlanFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, true)
+	if err != nil {
+		return &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if err := p.parentDir("open", path); err != nil {
+		return err
+	}
+	if info, err := p.lstat(path); err == nil {
+		if info.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+		}
+		perm = info.Mode().Perm()
+	}
+	p.state.nodes[path] = &memNode{mode: perm.Perm(), data: append([]byte(nil), data...), modTime: time.Now()}
+	return nil
+}
+
+func (p *PlanFS) MkdirAll(name string, perm fs.FileMode) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, true)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		info, err := p.lstat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+			}
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		p.state.nodes[missing[i]] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+func (p *PlanFS) Remove(name string) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, false)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	info, err := p.lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := p.readDir(path)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+		}
+	}
+	p.forget(path)
+	p.state.nodes[path] = nil
+	return nil
+}
+
+func (p *PlanFS) RemoveAll(name string) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, false)
+	if err != nil {
+		return &fs.PathError{Op: "removeall", Path: name, Err: err}
+	}
+	if filepath.Dir(path) == path {
+		return &fs.PathError{Op: "removeall", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := p.lstat(path); err != nil {
+		return nil
+	}
+	p.forget(path)
+	p.state.nodes[path] = nil
+	return nil
+}
+
+func (p *PlanFS) Rename(oldname, newname string) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	from, err := p.resolve(oldname, false)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	to, err := p.resolve(newname, false)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: newname, Err: err}
+	}
+	info, err := p.lstat(from)
+	if err != nil {
+		return err
+	}
+	if err := p.parentDir("rename", to); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	if existing, err := p.lstat(to); err == nil && existing.IsDir() {
+		if !info.IsDir() {
+			return &fs.PathError{Op: "rename", Path: newname, Err: errIsDir}
+		}
+		if entries, err := p.readDir(to); err != nil || len(entries) > 0 {
+			return &fs.PathError{Op: "rename", Path: newname, Err: errNotEmpty}
+		}
+	}
+	if strings.HasPrefix(to, from+string(filepath.Separator)) {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrInvalid}
+	}
+
+	moved, err := p.copyTree(from)
+	if err != nil {
+		return err
+	}
+	p.forget(from)
+	p.state.nodes[from] = nil
+	p.forget(to)
+	for rel, node := range moved {
+		p.state.nodes[filepath.Join(to, rel)] = node
+	}
+	return nil
+}
+
+func (p *PlanFS) Symlink(oldname, newname string) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(newname, false)
+	if err != nil {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: err}
+	}
+	if err := p.parentDir("symlink", path); err != nil {
+		return err
+	}
+	if _, err := p.lstat(path); err == nil {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+	p.state.nodes[path] = &memNode{mode: fs.ModeSymlink | 0777, link: filepath.ToSlash(oldname), modTime: time.Now()}
+	return nil
+}
+
+func (p *PlanFS) Abs(name string) (string, error) {
+	return p.abs(name), nil
+}
+
+func (p *PlanFS) EvalSymlinks(name string) (string, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, true)
+	if err != nil {
+		return "", &fs.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	if _, err := p.lstat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Trash removes the file at name, planning to move it to the platform trash
+func (p *PlanFS) Trash(name string) error {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	path, err := p.resolve(name, false)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	if _, err := p.lstat(path); err != nil {
+		return err
+	}
+	p.state.nodes[path] = nil
+	p.state.trashed[path] = true
+	return nil
+}
+
+// Plan returns the operations that make the base look like p, in path
+// order, so directories come before what they hold
+func (p *PlanFS) Plan() ([]FileOp, error) {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+
+	var ops []FileOp
+	for path, node := range p.state.nodes {
+		before, err := p.base.Lstat(path)
+		existed := err == nil
+		trash := p.state.trashed[path]
+		switch {
+		case node == nil:
+			if existed {
+				ops = append(ops, FileOp{Action: PlanRemove, Path: path, Mode: before.Mode(), Trash: trash})
+			}
+		case node.mode.IsDir():
+			if existed && before.IsDir() {
+				// Recreated: what the base held and the command didn't put back goes
+				entries, err := p.base.ReadDir(path)
+				if err != nil {
+					return nil, err
+				}
+				for _, entry := range entries {
+					child := filepath.Join(path, entry.Name())
+					if _, changed := p.state.nodes[child]; !changed {
+						ops = append(ops, FileOp{Action: PlanRemove, Path: child, Mode: entry.Type()})
+					}
+				}
+				continue
+			}
+			ops = append(ops, FileOp{Action: action(existed), Path: path, Mode: node.mode})
+		case node.mode&fs.ModeSymlink != 0:
+			link := filepath.FromSlash(node.link)
+			if existed && before.Mode()&fs.ModeSymlink != 0 {
+				if current, err := p.base.Readlink(path); err == nil && current == link {
+					continue
+				}
+			}
+			ops = append(ops, FileOp{Action: action(existed), Path: path, Mode: node.mode, Link: link, Trash: trash})
+		default:
+			if existed && before.Mode().IsRegular() && before.Mode().Perm() == node.mode.Perm() {
+				if current, err := p.base.ReadFile(path); err == nil && bytes.Equal(current, node.data) {
+					continue
+				}
+			}
+			ops = append(ops, FileOp{Action: action(existed), Path: path, Mode: node.mode, Content: node.data, Trash: trash})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Path < ops[j].Path })
+	return ops, nil
+}
+
+// action returns how a path is written depending on whether it existed
+func action(existed bool) PlanAction {
+	if existed {
+		return PlanReplace
+	}
+	return PlanCreate
+}
+
+// ApplyPlan makes the changes of ops to the engine's filesystem, in order
+func (e *Engine) ApplyPlan(ops []FileOp) error {
+	for _, op := range ops {
+		if err := applyOp(e.fs, op); err != nil {
+			return err
+		}
+		e.logger.Debug("applied planned change", "action", op.Action, "path", op.Path)
+	}
+	return nil
+}
+
+func applyOp(f FS, op FileOp) error {
+	if op.Trash {
+		if _, err := f.Lstat(op.Path); err == nil {
+			if err := trashFile(op.Path); err != nil {
+				return fmt.Errorf("failed to move %s to the trash: %w", op.Path, err)
+			}
+		}
+	}
+	if op.Action == PlanRemove {
+		if err := f.RemoveAll(op.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", op.Path, err)
+		}
+		return nil
+	}
+
+	// What is in the way is replaced, unless a file replaces a file
+	if info, err := f.Lstat(op.Path); err == nil && !(info.Mode().IsRegular() && op.Mode.IsRegular()) && !(info.IsDir() && op.Mode.IsDir()) {
+		if err := f.RemoveAll(op.Path); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", op.Path, err)
+		}
+	}
+	switch {
+	case op.Mode.IsDir():
+		if err := f.MkdirAll(op.Path, op.Mode.Perm()); err != nil {
+			return fmt.Errorf("failed to create %s: %w", op.Path, err)
+		}
+		return nil
+	case op.Mode&fs.ModeSymlink != 0:
+		if err := f.MkdirAll(filepath.Dir(op.Path), 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+		if err := f.Symlink(op.Link, op.Path); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", op.Path, err)
+		}
+		return nil
+	}
+	if err := f.MkdirAll(filepath.Dir(op.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if r, ok := f.(replacer); ok {
+		return r.ReplaceFile(op.Path, op.Content, op.Mode.Perm())
+	}
+	// WriteFile keeps the permissions of an existing file
+	if err := f.Remove(op.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace %s: %w", op.Path, err)
+	}
+	if err := f.WriteFile(op.Path, op.Content, op.Mode.Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", op.Path, err)
+	}
+	return nil
+}
+
+// LabelOutputs fills in the target and strategy of ops that write outputs of
+// the engine's targets; strategyOf returns the link strategy of a target
+func (e *Engine) LabelOutputs(ops []FileOp, strategyOf func(target string) string) {
+	root, err := e.fs.Abs(".")
+	if err != nil {
+		return
+	}
+	owners := make(map[string]string) // output path to target
+	var partsDirs []string
+	partsOwners := make(map[string]string)
+	for _, target := range e.Targets() {
+		for _, link := range append(target.Links, target.LocalLinks...) {
+			owners[filepath.Clean(link.Target)] = target.Name
+		}
+		if target.PartsDir != "" {
+			dir := filepath.Clean(target.PartsDir)
+			partsDirs = append(partsDirs, dir)
+			partsOwners[dir] = target.Name
+		}
+	}
+	for i := range ops {
+		rel, err := filepath.Rel(root, ops[i].Path)
+		if err != nil {
+			continue
+		}
+		name, ok := owners[rel]
+		for _, dir := range partsDirs {
+			if !ok && isWithin(rel, dir) {
+				name, ok = partsOwners[dir], true
+			}
+		}
+		if ok {
+			ops[i].Target = name
+			ops[i].Strategy = strategyOf(name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// planOps returns the action and path of each planned change
+func planOps(t *testing.T, plan *PlanFS) []string {
+	t.Helper()
+	ops, err := plan.Plan()
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	var got []string
+	for _, op := range ops {
+		got = append(got, string(op.Action)+" "+op.Path)
+	}
+	return got
+}
+
+func TestPlanFS(t *testing.T) {
+	mem := NewMemFS()
+	mem.MkdirAll("/project/.viberules", 0755)
+	mem.WriteFile("/project/.viberules/rules.md", []byte("rules"), 0644)
+	mem.WriteFile("/project/AGENTS.md", []byte("old"), 0644)
+	mem.WriteFile("/project/same.md", []byte("same"), 0644)
+	mem.MkdirAll("/outside", 0755)
+	mem.Symlink("/outside", "/project/linked")
+
+	base, err := NewPlanFS(mem)
+	if err != nil {
+		t.Fatalf("NewPlanFS() failed: %v", err)
+	}
+	plan := base.Within("/project")
+	if err := plan.Symlink(".viberules/rules.md", "CLAUDE.md"); err != nil {
+		t.Fatalf("Symlink() failed: %v", err)
+	}
+	if err := plan.Remove("AGENTS.md"); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if err := plan.WriteFile("same.md", []byte("same"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := plan.MkdirAll(".cursor/rules", 0755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := plan.WriteFile(".cursor/rules/rules.mdc", []byte("rules"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	// Writes through a symlink land where it points
+	if err := plan.WriteFile("linked/notes.md", []byte("notes"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	// Reads see the plan
+	if content, err := plan.ReadFile("CLAUDE.md"); err != nil || string(content) != "rules" {
+		t.Errorf("CLAUDE.md = %q, %v, want the rules through the planned symlink", content, err)
+	}
+	if _, err := plan.Lstat("AGENTS.md"); !os.IsNotExist(err) {
+		t.Errorf("AGENTS.md should be gone from the plan, got %v", err)
+	}
+	entries, err := plan.ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{".cursor", ".viberules", "CLAUDE.md", "linked", "same.md"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir() = %v, want %v", names, want)
+	}
+
+	// Rewriting a file as it was changes nothing
+	want := []string{
+		"create /outside/notes.md",
+		"create /project/.cursor",
+		"create /project/.cursor/rules",
+		"create /project/.cursor/rules/rules.mdc",
+		"remove /project/AGENTS.md",
+		"create /project/CLAUDE.md",
+	}
+	if got := planOps(t, plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %v, want %v", got, want)
+	}
+
+	// The base is untouched until the plan is applied
+	if _, err := mem.Lstat("/project/CLAUDE.md"); !os.IsNotExist(err) {
+		t.Errorf("CLAUDE.md was created before applying the plan: %v", err)
+	}
+	ops, _ := plan.Plan()
+	if err := New(mem).ApplyPlan(ops); err != nil {
+		t.Fatalf("ApplyPlan() failed: %v", err)
+	}
+	if link, err := mem.Readlink("/project/CLAUDE.md"); err != nil || link != ".viberules/rules.md" {
+		t.Errorf("CLAUDE.md -> %q, %v after applying", link, err)
+	}
+	if _, err := mem.Lstat("/project/AGENTS.md"); !os.IsNotExist(err) {
+		t.Errorf("AGENTS.md should be removed, got %v", err)
+	}
+	if content, err := mem.ReadFile("/outside/notes.md"); err != nil || string(content) != "notes" {
+		t.Errorf("notes.md = %q, %v after applying", content, err)
+	}
+}
+
+func TestPlanFSReplaceDirectory(t *testing.T) {
+	mem := NewMemFS()
+	mem.MkdirAll("/project/.cursor/rules", 0755)
+	mem.WriteFile("/project/.cursor/rules/old.mdc", []byte("old"), 0644)
+	mem.WriteFile("/project/.cursor/rules/kept.mdc", []byte("kept"), 0644)
+	mem.WriteFile("/project/rules.md", []byte("rules"), 0600)
+
+	base, err := NewPlanFS(mem)
+	if err != nil {
+		t.Fatalf("NewPlanFS() failed: %v", err)
+	}
+	plan := base.Within("/project")
+	if err := plan.RemoveAll(".cursor/rules"); err != nil {
+		t.Fatalf("RemoveAll() failed: %v", err)
+	}
+	if err := plan.MkdirAll(".cursor/rules", 0755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := plan.WriteFile(".cursor/rules/kept.mdc", []byte("kept"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := plan.Rename("rules.md", "moved.md"); err != nil {
+		t.Fatalf("Rename() failed: %v", err)
+	}
+
+	want := []string{
+		"remove /project/.cursor/rules/old.mdc",
+		"create /project/moved.md",
+		"remove /project/rules.md",
+	}
+	if got := planOps(t, plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %v, want %v", got, want)
+	}
+	if info, err := plan.Lstat("moved.md"); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("moved.md should keep its permissions, got %v, %v", info, err)
+	}
+}
+
+func TestPlanFSWithin(t *testing.T) {
+	mem := NewMemFS()
+	mem.MkdirAll("/repo/pkg", 0755)
+
+	plan, err := NewPlanFS(mem)
+	if err != nil {
+		t.Fatalf("NewPlanFS() failed: %v", err)
+	}
+	sub := plan.Within("/repo/pkg")
+	if err := sub.WriteFile("CLAUDE.md", []byte("rules"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if content, err := plan.ReadFile(filepath.Join("repo", "pkg", "CLAUDE.md")); err != nil || string(content) != "rules" {
+		t.Errorf("views should share the plan, got %q, %v", content, err)
+	}
+}
+
+func TestPlanLabelOutputs(t *testing.T) {
+	mem := NewMemFS()
+	e := New(mem)
+	ops := []FileOp{
+		{Action: PlanCreate, Path: "/CLAUDE.md"},
+		{Action: PlanCreate, Path: "/.cursor/rules/viberules.mdc"},
+		{Action: PlanRemove, Path: "/.amazonq/rules/viberules-old.md"},
+		{Action: PlanReplace, Path: "/.gitignore"},
+	}
+	e.LabelOutputs(ops, func(target string) string { return "copy" })
+	if ops[0].Target != "claude" || ops[0].Strategy != "copy" {
+		t.Errorf("CLAUDE.md is labeled (%s, %s), want (claude, copy)", ops[0].Target, ops[0].Strategy)
+	}
+	if ops[1].Target != "cursor" {
+		t.Errorf("viberules.mdc is labeled %q, want cursor", ops[1].Target)
+	}
+	// Parts are labeled by the directory they are written into
+	if ops[2].Target != "amazonq" {
+		t.Errorf("viberules-old.md is labeled %q, want amazonq", ops[2].Target)
+	}
+	if ops[3].Target != "" {
+		t.Errorf(".gitignore is labeled %q, want no target", ops[3].Target)
+	}
+}
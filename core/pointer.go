@@ -0,0 +1,45 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+)
+
+// pointerHeader opens every pointer file
+func (e *Engine) pointerHeader() string {
+	return fmt.Sprintf("<!-- Managed by viberules: edit %s instead. -->\n", filepath.ToSlash(e.rulesFile))
+}
+
+// PointerFile returns a transform that replaces the content with imports of
+// the rules file and RulesDir files (@path lines), for tools that expand
+// imports in their rules file. The output stays tiny and never changes when
+// the rules do, so it can be committed instead of a symlink.
+func (e *Engine) PointerFile() Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		target, ok := e.FindTarget(ctx.Target)
+		if !ok || !target.Imports {
+			return nil, fmt.Errorf("%s does not support @imports; the pointer strategy is not available", ctx.Target)
+		}
+
+		sources := []string{ctx.Source}
+		if !ctx.Part && target.PartsDir == "" {
+			files, err := e.RulesDirFiles()
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, files...)
+		}
+
+		var out bytes.Buffer
+		out.WriteString(e.pointerHeader())
+		for _, source := range sources {
+			rel, err := filepath.Rel(filepath.Dir(ctx.Output), source)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&out, "@%s\n", filepath.ToSlash(rel))
+		}
+		return out.Bytes(), nil
+	}
+}
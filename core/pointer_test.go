@@ -0,0 +1,28 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPointerFile(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, filepath.Join(RulesDir, "10-style.md"), "# Style\n")
+
+	if _, err := e.GenerateTargetFiles("claude", Pipeline{e.PointerFile()}, nil, false); err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+	content, err := os.ReadFile("CLAUDE.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := e.pointerHeader() + "@.viberules/rules.md\n@.viberules/rules/10-style.md\n"
+	if string(content) != want {
+		t.Errorf("CLAUDE.md = %q, want %q", content, want)
+	}
+
+	if _, err := e.GenerateTargetFiles("codex", Pipeline{e.PointerFile()}, nil, false); err == nil {
+		t.Error("Pointer files should be refused for targets without @imports")
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ReadPolicySection returns the content of a policy section: a file relative
+// to the project root, or a remote reference fetched through fetcher
+func (e *Engine) ReadPolicySection(ctx context.Context, ref string, fetcher Fetcher) ([]byte, error) {
+	if !IsRemoteRef(ref) {
+		return e.fs.ReadFile(ref)
+	}
+	remote, err := ParseRemoteRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if fetcher == nil {
+		return nil, fmt.Errorf("remote policy sections are not available")
+	}
+	return fetcher.Fetch(ctx, remote)
+}
+
+// HasSection reports whether rules contain section verbatim, as whole lines
+func HasSection(rules, section []byte) bool {
+	section = trimSection(section)
+	if len(section) == 0 {
+		return true
+	}
+	return bytes.Contains(append([]byte("\n"), withNewline(string(rules))...), append([]byte("\n"), section...))
+}
+
+// RequireSection returns rules with section in them verbatim. A section
+// starting with a heading replaces the part of rules under the same heading,
+// up to the next heading of the same or a higher level, so an edited copy is
+// put back in place; otherwise section is appended. It reports whether rules
+// changed.
+func RequireSection(rules, section []byte) ([]byte, bool) {
+	if HasSection(rules, section) {
+		return rules, false
+	}
+	section = trimSection(section)
+	lines := strings.SplitAfter(withNewline(string(rules)), "\n")
+
+	heading := strings.TrimSpace(strings.SplitN(string(section), "\n", 2)[0])
+	if isHeadingLine(heading) {
+		start, end := headingRange(lines, heading)
+		if start >= 0 {
+			var out strings.Builder
+			out.WriteString(strings.Join(lines[:start], ""))
+			out.Write(section)
+			if rest := strings.TrimLeft(strings.Join(lines[end:], ""), "\r\n"); rest != "" {
+				out.WriteString("\n" + rest)
+			}
+			return []byte(out.String()), true
+		}
+	}
+
+	out := withNewline(string(rules))
+	if strings.TrimSpace(out) != "" {
+		out += "\n"
+	}
+	return append([]byte(out), section...), true
+}
+
+// headingRange returns the lines of the part of a file under heading: from the
+// heading to the next heading of the same or a higher level. start is -1 when
+// the heading isn't there.
+func headingRange(lines []string, heading string) (start, end int) {
+	level := headingLevel(heading)
+	start, end = -1, len(lines)
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if inFence || !isHeadingLine(line) {
+			continue
+		}
+		if start < 0 {
+			if trimmed == heading {
+				start = i
+			}
+			continue
+		}
+		if headingLevel(trimmed) <= level {
+			end = i
+			break
+		}
+	}
+	return start, end
+}
+
+// headingLevel returns the number of # of a heading line
+func headingLevel(line string) int {
+	return len(line) - len(strings.TrimLeft(line, "#"))
+}
+
+// trimSection returns section without surrounding blank lines, ending with a newline
+func trimSection(section []byte) []byte {
+	trimmed := strings.Trim(string(section), "\r\n")
+	if strings.TrimSpace(trimmed) == "" {
+		return nil
+	}
+	return []byte(strings.TrimRight(trimmed, "\r\n") + "\n")
+}
+
+// withNewline returns s ending with a newline, unless it is empty
+func withNewline(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestRequireSection(t *testing.T) {
+	section := "## Security\n\nNever commit secrets.\n"
+	tests := []struct {
+		name    string
+		rules   string
+		want    string
+		changed bool
+	}{
+		{"present", "# Rules\n\n" + section + "\n## Style\n", "# Rules\n\n" + section + "\n## Style\n", false},
+		{"missing", "# Rules\n\nUse tabs.", "# Rules\n\nUse tabs.\n\n" + section, true},
+		{"empty", "", section, true},
+		{
+			"edited",
+			"# Rules\n\n## Security\n\nSecrets are fine.\n\n### Keys\n\nRotate.\n\n## Style\n\nUse tabs.\n",
+			"# Rules\n\n" + section + "\n## Style\n\nUse tabs.\n",
+			true,
+		},
+		{
+			"edited at the end",
+			"# Rules\n\n## Security\n\nSecrets are fine.\n\n",
+			"# Rules\n\n" + section,
+			true,
+		},
+		{
+			"heading inside a code block",
+			"# Rules\n\n```\n## Security\n```\n",
+			"# Rules\n\n```\n## Security\n```\n\n" + section,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := RequireSection([]byte(tt.rules), []byte(section))
+			if string(got) != tt.want || changed != tt.changed {
+				t.Errorf("RequireSection() = %q, %v; want %q, %v", got, changed, tt.want, tt.changed)
+			}
+			if !HasSection(got, []byte(section)) {
+				t.Errorf("HasSection(%q) = false after RequireSection", got)
+			}
+		})
+	}
+
+	// Only whole lines count
+	if HasSection([]byte("# Rules\nSee ## Security\n\nNever commit secrets.\n"), []byte(section)) {
+		t.Error("HasSection should not match a section starting mid-line")
+	}
+}
+
+func TestReadPolicySection(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	if err := os.WriteFile("policy.md", []byte("## Security\n"), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if got, err := e.ReadPolicySection(context.Background(), "policy.md", nil); err != nil || string(got) != "## Security\n" {
+		t.Errorf("ReadPolicySection(local) = %q, %v", got, err)
+	}
+	if _, err := e.ReadPolicySection(context.Background(), "https://example.com/policy.md", nil); err == nil {
+		t.Error("ReadPolicySection should fail for a remote section without a fetcher")
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProfilesDir holds named rule sets, one markdown file per profile
+var ProfilesDir = filepath.Join(".viberules", "profiles")
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateProfileName checks that name is usable as a profile file name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name: %s (use letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// ProfilePath returns the rules file of a profile
+func ProfilePath(name string) string {
+	return filepath.Join(ProfilesDir, name+".md")
+}
+
+// ListProfiles returns the names of all profiles in lexical order
+func (e *Engine) ListProfiles() ([]string, error) {
+	entries, err := e.fs.ReadDir(ProfilesDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".md"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateProfile creates a new profile with the given content
+func (e *Engine) CreateProfile(name string, content []byte) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	path := ProfilePath(name)
+	if _, err := e.fs.Lstat(path); err == nil {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+	if err := e.fs.MkdirAll(ProfilesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	if err := e.fs.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to create profile %s: %w", name, err)
+	}
+	return nil
+}
+
+// ActiveProfile returns the profile rules.md currently links to
+func (e *Engine) ActiveProfile() (string, bool) {
+	dest, err := e.fs.Readlink(e.rulesFile)
+	if err != nil {
+		return "", false
+	}
+	if filepath.Dir(filepath.Join(filepath.Dir(e.rulesFile), dest)) != ProfilesDir {
+		return "", false
+	}
+	return strings.TrimSuffix(filepath.Base(dest), ".md"), true
+}
+
+// UseProfile makes rules.md a symlink to the given profile so every target follows it.
+// A regular rules.md is preserved as the "default" profile the first time a profile is used.
+func (e *Engine) UseProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if _, err := e.fs.Stat(ProfilePath(name)); err != nil {
+		return fmt.Errorf("profile %s not found", name)
+	}
+
+	info, err := e.fs.Lstat(e.rulesFile)
+	if err == nil && info.Mode().IsRegular() {
+		defaultPath := ProfilePath("default")
+		if _, err := e.fs.Lstat(defaultPath); err == nil {
+			return fmt.Errorf("refusing to replace %s: profile 'default' already exists", e.rulesFile)
+		}
+		if err := e.fs.Rename(e.rulesFile, defaultPath); err != nil {
+			return fmt.Errorf("failed to save %s as profile 'default': %w", e.rulesFile, err)
+		}
+	}
+
+	source, err := filepath.Rel(filepath.Dir(e.rulesFile), ProfilePath(name))
+	if err != nil {
+		return err
+	}
+	return e.createScopedSymlink(source, e.rulesFile)
+}
+
+// nestedLink places a link of a target under dir, pointing at source.
+// source is relative to the project root.
+func nestedLink(link SymlinkDef, dir, source string) (SymlinkDef, error) {
+	target := filepath.Join(dir, filepath.Base(link.Target))
+	rel, err := filepath.Rel(filepath.Dir(target), source)
+	if err != nil {
+		return SymlinkDef{}, fmt.Errorf("failed to compute link for %s: %w", target, err)
+	}
+	return SymlinkDef{Source: rel, Target: target}, nil
+}
+
+// CreateNestedSymlinks creates symlinks for a target inside dir pointing at source.
+// Targets that don't read rules from subdirectories are skipped.
+func (e *Engine) CreateNestedSymlinks(targetName, dir, source string) error {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return unknownTarget(targetName)
+	}
+	if !target.Nested {
+		return nil
+	}
+
+	for _, link := range target.Links {
+		nested, err := nestedLink(link, dir, source)
+		if err != nil {
+			return err
+		}
+		if err := e.createScopedSymlink(nested.Source, nested.Target); err != nil {
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveNestedSymlinks removes symlinks created by CreateNestedSymlinks
+func (e *Engine) RemoveNestedSymlinks(targetName, dir string) error {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return unknownTarget(targetName)
+	}
+	if !target.Nested {
+		return nil
+	}
+
+	for _, link := range target.Links {
+		if err := e.removeSymlink(filepath.Join(dir, filepath.Base(link.Target))); err != nil {
+			return fmt.Errorf("failed to remove symlink: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckNestedSymlinks reports the state of a target's symlinks inside dir
+func (e *Engine) CheckNestedSymlinks(targetName, dir, source string) ([]OutputStatus, error) {
+	target, ok := e.FindTarget(targetName)
+	if !ok {
+		return nil, unknownTarget(targetName)
+	}
+	if !target.Nested {
+		return nil, nil
+	}
+
+	var statuses []OutputStatus
+	for _, link := range target.Links {
+		nested, err := nestedLink(link, dir, source)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, OutputStatus{Target: target.Name, Path: nested.Target, State: e.checkSymlinkOutput(nested)})
+	}
+	return e.reportDrift(statuses...), nil
+}
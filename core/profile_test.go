@@ -0,0 +1,100 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUseProfile(t *testing.T) {
+	e := setupProject(t, "original rules")
+
+	if err := e.CreateProfile("backend", []byte("backend rules")); err != nil {
+		t.Fatalf("CreateProfile(backend) failed: %v", err)
+	}
+	if err := e.CreateProfile("backend", nil); err == nil {
+		t.Error("CreateProfile should fail for an existing profile")
+	}
+	if err := e.CreateProfile("../escape", nil); err == nil {
+		t.Error("CreateProfile should reject names with path separators")
+	}
+
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Fatalf("CreateTargetSymlinks(claude) failed: %v", err)
+	}
+	if err := e.UseProfile("backend"); err != nil {
+		t.Fatalf("UseProfile(backend) failed: %v", err)
+	}
+
+	// The original rules are kept as the default profile
+	content, err := os.ReadFile(ProfilePath("default"))
+	if err != nil || string(content) != "original rules" {
+		t.Errorf("default profile = %q, %v; want original rules", content, err)
+	}
+
+	// Existing symlinks follow the active profile
+	content, err = os.ReadFile("CLAUDE.md")
+	if err != nil || string(content) != "backend rules" {
+		t.Errorf("CLAUDE.md = %q, %v; want backend rules", content, err)
+	}
+
+	if active, ok := e.ActiveProfile(); !ok || active != "backend" {
+		t.Errorf("ActiveProfile() = %s, %v; want backend", active, ok)
+	}
+
+	profiles, err := e.ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"backend", "default"}) {
+		t.Errorf("ListProfiles() = %v, want [backend default]", profiles)
+	}
+
+	if err := e.UseProfile("default"); err != nil {
+		t.Fatalf("Switching back to default failed: %v", err)
+	}
+	if err := e.UseProfile("missing"); err == nil {
+		t.Error("UseProfile should fail for a missing profile")
+	}
+}
+
+func TestNestedSymlinks(t *testing.T) {
+	e := setupProject(t, "rules")
+
+	if err := e.CreateProfile("frontend", []byte("frontend rules")); err != nil {
+		t.Fatalf("CreateProfile(frontend) failed: %v", err)
+	}
+	dir := filepath.Join("apps", "web")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+
+	if err := e.CreateNestedSymlinks("claude", dir, ProfilePath("frontend")); err != nil {
+		t.Fatalf("CreateNestedSymlinks(claude) failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "CLAUDE.md"))
+	if err != nil || string(content) != "frontend rules" {
+		t.Errorf("Nested CLAUDE.md = %q, %v; want frontend rules", content, err)
+	}
+
+	statuses, err := e.CheckNestedSymlinks("claude", dir, ProfilePath("frontend"))
+	if err != nil || len(statuses) != 1 || statuses[0].State != OutputOK {
+		t.Errorf("CheckNestedSymlinks = %v, %v; want one ok output", statuses, err)
+	}
+
+	// Targets that only read the project root are skipped
+	if err := e.CreateNestedSymlinks("amazonq", dir, ProfilePath("frontend")); err != nil {
+		t.Fatalf("CreateNestedSymlinks(amazonq) failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "AMAZONQ.md")); !os.IsNotExist(err) {
+		t.Error("amazonq should not get nested outputs")
+	}
+
+	if err := e.RemoveNestedSymlinks("claude", dir); err != nil {
+		t.Fatalf("RemoveNestedSymlinks failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("Nested CLAUDE.md was not removed")
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PromptDir is a directory of prompt files a target reads, such as slash
+// commands or subagents, installed from one markdown file per prompt in a
+// source directory under .viberules
+type PromptDir struct {
+	Name   string // target name
+	Source string
+	Dir    string
+	Ext    string
+	// render converts a source file to the tool's format
+	render func(content []byte) ([]byte, error)
+}
+
+// findPromptDir returns the prompt directory of a target
+func findPromptDir(dirs []PromptDir, name string) (PromptDir, bool) {
+	for _, dir := range dirs {
+		if dir.Name == name {
+			return dir, true
+		}
+	}
+	return PromptDir{}, false
+}
+
+// PromptPath returns where the target reads the prompt with the given name
+func (d PromptDir) PromptPath(name string) string {
+	return filepath.Join(d.Dir, name+d.Ext)
+}
+
+// Managed returns the recorded paths of prompts written into the directory, sorted
+func (d PromptDir) Managed(recorded map[string]string) []string {
+	var paths []string
+	for path := range recorded {
+		if filepath.Dir(path) == d.Dir && filepath.Ext(path) == d.Ext {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// PromptSources returns the names of the prompts in the source directory of d, sorted
+func (e *Engine) PromptSources(d PromptDir) ([]string, error) {
+	entries, err := e.fs.ReadDir(d.Source)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", d.Source, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && filepath.Ext(entry.Name()) == ".md" {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".md"))
+		}
+	}
+	return names, nil
+}
+
+// RenderPrompt returns the content of a source prompt of d in the target's format
+func (e *Engine) RenderPrompt(d PromptDir, name string) ([]byte, error) {
+	source := filepath.Join(d.Source, name+".md")
+	content, err := e.fs.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	rendered, err := d.render(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return rendered, nil
+}
+
+// WritePromptDir installs every source prompt for a target and removes
+// prompts it installed earlier whose source is gone. Files edited by hand are
+// protected as with copies. It returns the checksum of each written prompt
+// keyed by path.
+func (e *Engine) WritePromptDir(d PromptDir, recorded map[string]string, overwrite bool) (map[string]string, error) {
+	names, err := e.PromptSources(d)
+	if err != nil {
+		return nil, err
+	}
+
+	written := make(map[string]string)
+	for _, name := range names {
+		content, err := e.RenderPrompt(d, name)
+		if err != nil {
+			return nil, err
+		}
+		path := d.PromptPath(name)
+		sum, err := e.WriteManagedFile(path, content, recorded[path], overwrite)
+		if err != nil {
+			return nil, err
+		}
+		written[path] = sum
+	}
+
+	for _, path := range d.Managed(recorded) {
+		if _, ok := written[path]; ok {
+			continue
+		}
+		if err := e.RemoveManagedFile(path, recorded[path], overwrite); err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// RemovePromptDir removes every prompt installed for a target
+func (e *Engine) RemovePromptDir(d PromptDir, recorded map[string]string) error {
+	for _, path := range d.Managed(recorded) {
+		if err := e.RemoveManagedFile(path, recorded[path], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckPromptDir reports the state of every prompt a target should have,
+// and of prompts installed earlier whose source is gone
+func (e *Engine) CheckPromptDir(d PromptDir, recorded map[string]string) ([]OutputStatus, error) {
+	names, err := e.PromptSources(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []OutputStatus
+	for _, name := range names {
+		content, err := e.RenderPrompt(d, name)
+		if err != nil {
+			return nil, err
+		}
+		path := d.PromptPath(name)
+		all = append(all, OutputStatus{
+			Target: d.Name,
+			Path:   path,
+			State:  e.CheckManagedFile(path, content, recorded[path]),
+		})
+	}
+
+	// Prompts whose source was deleted are removed on the next sync
+	for _, path := range d.Managed(recorded) {
+		name := strings.TrimSuffix(filepath.Base(path), d.Ext)
+		if containsString(names, name) {
+			continue
+		}
+		if _, err := e.fs.Lstat(path); err == nil {
+			all = append(all, OutputStatus{Target: d.Name, Path: path, State: OutputStale})
+		}
+	}
+	return e.reportDrift(all...), nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitChanges returns the uncommitted changes under dir, one porcelain status
+// line each; ignored files don't count
+func GitChanges(dir string) ([]string, error) {
+	out, err := runGit(dir, "status", "--porcelain", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if line != "" {
+			changes = append(changes, line)
+		}
+	}
+	return changes, nil
+}
+
+// GitCreateBranch creates branch at HEAD of the repository containing dir and switches to it
+func GitCreateBranch(dir, branch string) error {
+	_, err := runGit(dir, "checkout", "--quiet", "-b", branch)
+	return err
+}
+
+// GitCommitAll commits every change under dir, except ignored files, and
+// returns the commit. Commits use the git identity of the current user.
+func GitCommitAll(dir, message string) (string, error) {
+	if _, err := runGit(dir, "add", "--all", "--", "."); err != nil {
+		return "", err
+	}
+	if _, err := runGit(dir, "commit", "--quiet", "-m", message, "--", "."); err != nil {
+		return "", err
+	}
+	out, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitPush pushes branch of the repository containing dir to its origin remote
+func GitPush(ctx context.Context, dir, branch string) error {
+	_, err := runGitContext(ctx, dir, "push", "--quiet", "--set-upstream", "origin", branch)
+	return err
+}
+
+// OpenPullRequest opens a pull request from a pushed branch with the gh CLI,
+// or a merge request with the glab CLI when origin is a GitLab remote, and
+// returns what the CLI prints, normally the URL. Both use their own login.
+func OpenPullRequest(ctx context.Context, dir, branch, title, body string) (string, error) {
+	origin, err := runGitContext(ctx, dir, "remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	name, args := "gh", []string{"pr", "create", "--head", branch, "--title", title, "--body", body}
+	if strings.Contains(strings.ToLower(string(origin)), "gitlab") {
+		name, args = "glab", []string{"mr", "create", "--source-branch", branch, "--title", title, "--description", body, "--yes"}
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("opening a pull request needs the %s CLI: %w", name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s %s: %s", name, args[0], msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGitCommitAll(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+
+	remote := t.TempDir()
+	repo := t.TempDir()
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	run(remote, "init", "--quiet", "--bare")
+	run(repo, "init", "--quiet")
+	writeTestFile(t, filepath.Join(repo, ".gitignore"), "ignored.txt\n")
+	run(repo, "add", ".")
+	run(repo, "commit", "--quiet", "-m", "init")
+	run(repo, "remote", "add", "origin", remote)
+
+	writeTestFile(t, filepath.Join(repo, "ignored.txt"), "x\n")
+	if changes, err := GitChanges(repo); err != nil || len(changes) != 0 {
+		t.Errorf("GitChanges() = %v, %v; ignored files don't count", changes, err)
+	}
+	writeTestFile(t, filepath.Join(repo, "rules.md"), "# Rules\n")
+	if changes, err := GitChanges(repo); err != nil || len(changes) != 1 {
+		t.Errorf("GitChanges() = %v, %v; want rules.md", changes, err)
+	}
+
+	if err := GitCreateBranch(repo, "proposal"); err != nil {
+		t.Fatalf("GitCreateBranch() failed: %v", err)
+	}
+	commit, err := GitCommitAll(repo, "Add rules")
+	if err != nil {
+		t.Fatalf("GitCommitAll() failed: %v", err)
+	}
+	if got := run(repo, "rev-parse", "proposal"); got != commit+"\n" {
+		t.Errorf("GitCommitAll() = %q, proposal at %q", commit, got)
+	}
+	if got := run(repo, "show", "--name-only", "--format=", "HEAD"); got != "rules.md\n" {
+		t.Errorf("committed files = %q, want rules.md only", got)
+	}
+
+	if err := GitPush(context.Background(), repo, "proposal"); err != nil {
+		t.Fatalf("GitPush() failed: %v", err)
+	}
+	if got := run(remote, "rev-parse", "proposal"); got != commit+"\n" {
+		t.Errorf("pushed proposal at %q, want %s", got, commit)
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake CLIs are shell scripts")
+	}
+	repo := t.TempDir()
+	cmd := exec.Command("git", "init", "--quiet")
+	cmd.Dir = repo
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	// The fake CLIs print their name and arguments as the URL
+	bin := t.TempDir()
+	for _, name := range []string{"gh", "glab"} {
+		script := "#!/bin/sh\necho " + name + " \"$@\"\n"
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to write fake %s: %v", name, err)
+		}
+	}
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+filepath.Dir(git))
+
+	for remote, want := range map[string]string{
+		"https://github.com/acme/api.git":     "gh pr create --head proposal",
+		"git@gitlab.example.com:acme/api.git": "glab mr create --source-branch proposal",
+	} {
+		setRemote := exec.Command("git", "remote", "add", "origin", remote)
+		setRemote.Dir = repo
+		if out, err := setRemote.CombinedOutput(); err != nil {
+			t.Fatalf("git remote add failed: %v\n%s", err, out)
+		}
+		got, err := OpenPullRequest(context.Background(), repo, "proposal", "Update rules", "body")
+		if err != nil || !strings.HasPrefix(got, want) {
+			t.Errorf("OpenPullRequest(%s) = %q, %v; want %q", remote, got, err, want)
+		}
+		removeRemote := exec.Command("git", "remote", "remove", "origin")
+		removeRemote.Dir = repo
+		if out, err := removeRemote.CombinedOutput(); err != nil {
+			t.Fatalf("git remote remove failed: %v\n%s", err, out)
+		}
+	}
+}
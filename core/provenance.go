@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// provenancePattern matches the provenance line AddProvenance puts at the end of an output
+var provenancePattern = regexp.MustCompile(`(?m)^<!-- viberules:provenance source=sha256:([0-9a-f]{64}) content=sha256:([0-9a-f]{64}) -->\n\z`)
+
+// provenanceTampered is reported as the recorded checksum of an output whose
+// provenance line doesn't match its content, so it never equals a real checksum
+const provenanceTampered = "provenance mismatch"
+
+// Provenance is the origin recorded in a committed output
+type Provenance struct {
+	Source  string // checksum of the rules file the output was rendered from
+	Content string // checksum of the output above the provenance line
+}
+
+// AddProvenance returns a transform that ends the output with a comment holding
+// the checksums of its source and of the content above it. Outputs committed to
+// git carry it, so any clone can tell a file viberules wrote from one edited by
+// hand, without the checksums recorded in the personal config.
+func (e *Engine) AddProvenance() Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		source, err := e.fs.ReadFile(ctx.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules file: %w", err)
+		}
+		_, body, _ := ReadProvenance(content)
+		if len(body) > 0 && body[len(body)-1] != '\n' {
+			body = append(body, '\n')
+		}
+		line := fmt.Sprintf("<!-- viberules:provenance source=sha256:%s content=sha256:%s -->\n", Checksum(source), Checksum(body))
+		return append(body, line...), nil
+	}
+}
+
+// ReadProvenance returns the provenance at the end of content and the content
+// above it. ok is false, and body all of content, when there is none.
+func ReadProvenance(content []byte) (provenance Provenance, body []byte, ok bool) {
+	match := provenancePattern.FindSubmatchIndex(content)
+	if match == nil {
+		return Provenance{}, content, false
+	}
+	provenance = Provenance{
+		Source:  string(content[match[2]:match[3]]),
+		Content: string(content[match[4]:match[5]]),
+	}
+	return provenance, append([]byte(nil), content[:match[0]]...), true
+}
+
+// StripProvenance removes the provenance line from the end of content
+func StripProvenance(content []byte) []byte {
+	_, body, _ := ReadProvenance(content)
+	return body
+}
+
+// fileChecksums returns the checksum of the file at path and the checksum
+// viberules last wrote to it. When content, what the file should hold, carries
+// provenance, so does a file viberules wrote: a file ending with a matching
+// provenance line vouches for itself, even without a recorded checksum, as it
+// may come from a commit synced elsewhere, and one whose line doesn't match was
+// edited. Otherwise the recorded checksum is used.
+func (e *Engine) fileChecksums(path, recorded string, content []byte) (current, written string, err error) {
+	existing, err := e.fs.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	current = Checksum(existing)
+	if _, _, tracked := ReadProvenance(content); !tracked {
+		return current, recorded, nil
+	}
+	provenance, body, ok := ReadProvenance(existing)
+	switch {
+	case !ok:
+		return current, recorded, nil
+	case Checksum(body) != provenance.Content:
+		return current, provenanceTampered, nil
+	}
+	return current, current, nil
+}
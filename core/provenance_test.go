@@ -0,0 +1,60 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	ctx := &RenderContext{Target: "claude", Output: "CLAUDE.md", Source: e.RulesFile()}
+	content, err := e.Render(Pipeline{e.AddProvenance()}, ctx)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	provenance, body, ok := ReadProvenance(content)
+	if !ok || string(body) != "# Rules\n" {
+		t.Fatalf("ReadProvenance(%q) = %q, %v; want the rules above the provenance line", content, body, ok)
+	}
+	if provenance.Source != Checksum([]byte("# Rules\n")) || provenance.Content != Checksum(body) {
+		t.Errorf("provenance = %+v, want the checksums of the rules", provenance)
+	}
+	if again, _ := e.AddProvenance()(ctx, content); string(again) != string(content) {
+		t.Errorf("AddProvenance should replace an existing line, got %q", again)
+	}
+
+	// A committed output vouches for itself without a recorded checksum
+	if err := os.WriteFile("CLAUDE.md", content, 0644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+	if state := e.CheckManagedFile("CLAUDE.md", content, ""); state != OutputOK {
+		t.Errorf("CheckManagedFile(committed) = %v, want ok", state)
+	}
+	if state := e.CheckManagedFile("CLAUDE.md", StripProvenance(content), ""); state != OutputUnmanaged {
+		t.Errorf("CheckManagedFile(untracked) = %v, want unmanaged", state)
+	}
+
+	if err := os.WriteFile(e.RulesFile(), []byte("# Rules v2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	expected, err := e.Render(Pipeline{e.AddProvenance()}, ctx)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if state := e.CheckManagedFile("CLAUDE.md", expected, ""); state != OutputStale {
+		t.Errorf("CheckManagedFile(old rules) = %v, want stale", state)
+	}
+
+	edited := strings.Replace(string(content), "# Rules", "# Edited", 1)
+	if err := os.WriteFile("CLAUDE.md", []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+	if state := e.CheckManagedFile("CLAUDE.md", expected, ""); state != OutputModified {
+		t.Errorf("CheckManagedFile(edited) = %v, want modified", state)
+	}
+	if err := e.writeManagedFile("CLAUDE.md", expected, "", false); err == nil {
+		t.Error("writeManagedFile should refuse to overwrite an edited output")
+	}
+}
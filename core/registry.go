@@ -0,0 +1,172 @@
+package core
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed targets.yaml
+var builtinRegistryData []byte
+
+// Registry lists the targets viberules manages in each scope
+type Registry struct {
+	Project RegistryScope `yaml:"project" json:"project"`
+	Global  RegistryScope `yaml:"global" json:"global"`
+}
+
+// RegistryScope holds the targets of one scope and the directories created for them
+type RegistryScope struct {
+	RequiredDirs []string `yaml:"required_dirs,omitempty" json:"required_dirs,omitempty"`
+	Targets      []Target `yaml:"targets" json:"targets"`
+}
+
+// BuiltinRegistry returns the registry that ships with viberules
+func BuiltinRegistry() Registry {
+	return mustParseRegistry(builtinRegistryData)
+}
+
+// SetRegistry replaces the registry targets are looked up in
+func (e *Engine) SetRegistry(r Registry) {
+	e.registry = r
+}
+
+// Registry returns the registry targets are looked up in
+func (e *Engine) Registry() Registry {
+	return Registry{Project: e.registry.Project.clone(), Global: e.registry.Global.clone()}
+}
+
+// ParseRegistry reads a registry in the format of the built-in targets.yaml.
+// Paths are written with forward slashes and returned in the OS format.
+func ParseRegistry(data []byte) (Registry, error) {
+	var r Registry
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return Registry{}, err
+	}
+	for _, s := range []*RegistryScope{&r.Project, &r.Global} {
+		if err := s.validate(); err != nil {
+			return Registry{}, err
+		}
+		*s = s.convert(filepath.FromSlash)
+	}
+	return r, nil
+}
+
+func mustParseRegistry(data []byte) Registry {
+	r, err := ParseRegistry(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in target registry: %v", err))
+	}
+	return r
+}
+
+// Extend returns the registry with the targets and directories of ext added.
+// A target of ext with the name of an existing target replaces it in place.
+func (r Registry) Extend(ext Registry) Registry {
+	return Registry{Project: r.Project.extend(ext.Project), Global: r.Global.extend(ext.Global)}
+}
+
+// ToSlash returns the registry with paths written with forward slashes,
+// as in targets.yaml
+func (r Registry) ToSlash() Registry {
+	return Registry{Project: r.Project.convert(filepath.ToSlash), Global: r.Global.convert(filepath.ToSlash)}
+}
+
+func (s RegistryScope) extend(ext RegistryScope) RegistryScope {
+	out := s.clone()
+	for _, dir := range ext.RequiredDirs {
+		if !containsString(out.RequiredDirs, dir) {
+			out.RequiredDirs = append(out.RequiredDirs, dir)
+		}
+	}
+	for _, target := range ext.clone().Targets {
+		replaced := false
+		for i := range out.Targets {
+			if out.Targets[i].Name == target.Name {
+				out.Targets[i] = target
+				replaced = true
+			}
+		}
+		if !replaced {
+			out.Targets = append(out.Targets, target)
+		}
+	}
+	return out
+}
+
+// clone returns a copy of the scope sharing no slices with it, so callers may
+// change targets they get from the registry
+func (s RegistryScope) clone() RegistryScope {
+	return s.convert(func(path string) string { return path })
+}
+
+// convert returns a copy of the scope with every path passed through fn
+func (s RegistryScope) convert(fn func(string) string) RegistryScope {
+	links := func(defs []SymlinkDef) []SymlinkDef {
+		if defs == nil {
+			return nil
+		}
+		out := make([]SymlinkDef, len(defs))
+		for i, def := range defs {
+			out[i] = SymlinkDef{Source: fn(def.Source), Target: fn(def.Target), Part: def.Part}
+		}
+		return out
+	}
+
+	out := RegistryScope{}
+	for _, dir := range s.RequiredDirs {
+		out.RequiredDirs = append(out.RequiredDirs, fn(dir))
+	}
+	for _, target := range s.Targets {
+		target.Links = links(target.Links)
+		target.LocalLinks = links(target.LocalLinks)
+		if target.PartsDir != "" {
+			target.PartsDir = fn(target.PartsDir)
+		}
+		out.Targets = append(out.Targets, target)
+	}
+	return out
+}
+
+// validate checks that every target has a name and outputs inside the root,
+// and that directories stay inside the root
+func (s RegistryScope) validate() error {
+	for _, dir := range s.RequiredDirs {
+		if !localPath(dir) {
+			return fmt.Errorf("required directory outside the root: %s", dir)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, target := range s.Targets {
+		if target.Name == "" {
+			return fmt.Errorf("target without a name")
+		}
+		if seen[target.Name] {
+			return fmt.Errorf("duplicate target: %s", target.Name)
+		}
+		seen[target.Name] = true
+		if len(target.Links) == 0 {
+			return fmt.Errorf("target %s has no links", target.Name)
+		}
+		if target.Strategy != "" && !IsValidStrategy(target.Strategy) {
+			return fmt.Errorf("invalid strategy of target %s: %s", target.Name, target.Strategy)
+		}
+		if target.PartsDir != "" && !localPath(target.PartsDir) {
+			return fmt.Errorf("parts_dir of target %s outside the root: %s", target.Name, target.PartsDir)
+		}
+		for _, link := range append(append([]SymlinkDef(nil), target.Links...), target.LocalLinks...) {
+			if link.Source == "" || !localPath(link.Target) {
+				return fmt.Errorf("invalid link of target %s: %s -> %s", target.Name, link.Target, link.Source)
+			}
+		}
+	}
+	return nil
+}
+
+// localPath reports whether a slash-separated path is relative and stays
+// inside the directory it is relative to
+func localPath(path string) bool {
+	return path != "" && filepath.IsLocal(filepath.FromSlash(path))
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRegistry(t *testing.T) {
+	r, err := ParseRegistry([]byte(`
+project:
+  required_dirs: [.windsurf/rules]
+  targets:
+    - name: windsurf
+      links:
+        - source: ../../.viberules/rules.md
+          target: .windsurf/rules/viberules.md
+      strategy: copy
+`))
+	if err != nil {
+		t.Fatalf("ParseRegistry() failed: %v", err)
+	}
+	want := RegistryScope{
+		RequiredDirs: []string{filepath.Join(".windsurf", "rules")},
+		Targets: []Target{{
+			Name:     "windsurf",
+			Links:    []SymlinkDef{{Source: filepath.Join("..", "..", ".viberules", "rules.md"), Target: filepath.Join(".windsurf", "rules", "viberules.md")}},
+			Strategy: StrategyCopy,
+		}},
+	}
+	if !reflect.DeepEqual(r.Project, want) {
+		t.Errorf("ParseRegistry() project = %+v, want %+v", r.Project, want)
+	}
+
+	invalid := map[string]string{
+		"no name":          "project:\n  targets:\n    - links: [{source: a, target: b}]\n",
+		"no links":         "project:\n  targets:\n    - name: x\n",
+		"duplicate":        "global:\n  targets:\n    - {name: x, links: [{source: a, target: b}]}\n    - {name: x, links: [{source: a, target: c}]}\n",
+		"escaping output":  "project:\n  targets:\n    - {name: x, links: [{source: a, target: ../b}]}\n",
+		"absolute output":  "project:\n  targets:\n    - {name: x, links: [{source: a, target: /b}]}\n",
+		"invalid strategy": "project:\n  targets:\n    - {name: x, links: [{source: a, target: b}], strategy: hardlink}\n",
+		"escaping dir":     "project:\n  required_dirs: [../x]\n",
+	}
+	for name, data := range invalid {
+		if _, err := ParseRegistry([]byte(data)); err == nil {
+			t.Errorf("ParseRegistry() with %s succeeded, want an error", name)
+		}
+	}
+}
+
+func TestRegistryExtend(t *testing.T) {
+	e := New(OSFS{})
+
+	ext, err := ParseRegistry([]byte(`
+project:
+  targets:
+    - name: codex
+      links: [{source: ../.viberules/rules.md, target: .codex/AGENTS.md}]
+    - name: windsurf
+      links: [{source: .viberules/rules.md, target: .windsurfrules}]
+`))
+	if err != nil {
+		t.Fatalf("ParseRegistry() failed: %v", err)
+	}
+	e.SetRegistry(BuiltinRegistry().Extend(ext))
+
+	if want := []string{"claude", "amazonq", "gemini", "codex", "cursor", "windsurf"}; !reflect.DeepEqual(e.TargetNames(), want) {
+		t.Errorf("TargetNames() = %v, want %v", e.TargetNames(), want)
+	}
+	codex, _ := e.FindTarget("codex")
+	if codex.Links[0].Target != filepath.Join(".codex", "AGENTS.md") || codex.Nested {
+		t.Errorf("codex = %+v, want the user definition", codex)
+	}
+	if got := len(e.GetGlobalTargets()); got != 4 {
+		t.Errorf("GetGlobalTargets() = %d targets, want the 4 built-in ones", got)
+	}
+
+	// Targets handed out are copies
+	e.GetAllTargets()[0].Links[0].Target = "changed"
+	if claude, _ := e.FindTarget("claude"); claude.Links[0].Target != "CLAUDE.md" {
+		t.Errorf("changing a returned target changed the registry: %+v", claude)
+	}
+}
+
+func TestBuiltinRegistryRoundTrip(t *testing.T) {
+	e := New(OSFS{})
+	// The dump of 'viberules targets --json' uses the format of targets.yaml
+	r, err := ParseRegistry(builtinRegistryData)
+	if err != nil {
+		t.Fatalf("ParseRegistry() failed: %v", err)
+	}
+	if slashed := r.ToSlash(); slashed.Project.Targets[1].Links[0].Target != ".amazonq/rules/AMAZONQ.md" {
+		t.Errorf("ToSlash() amazonq output = %q", slashed.Project.Targets[1].Links[0].Target)
+	}
+	if !reflect.DeepEqual(r, e.Registry()) {
+		t.Errorf("built-in registry differs from the current one")
+	}
+}
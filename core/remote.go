@@ -0,0 +1,368 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRemoteSize limits the size of fetched remote content
+const maxRemoteSize = 1 * 1024 * 1024 // 1MB
+
+// RemoteRef identifies rule content outside the project: an https URL, a file
+// inside a git repository, written as git::<repo>//<path>?ref=<ref>, or an
+// artifact in an OCI registry, written as oci://<registry>/<repository>:<tag>
+// or @<digest>
+type RemoteRef struct {
+	URL  string // https URL, the git repository URL, or <registry>/<repository>
+	Git  bool   // URL is a git repository
+	OCI  bool   // URL is an OCI repository
+	Path string // file inside the git repository
+	Ref  string // git branch, tag or commit, empty for the default branch; or OCI tag or digest, empty for latest
+}
+
+// IsRemoteRef reports whether an include reference points outside the project
+func IsRemoteRef(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "git::") || strings.HasPrefix(s, "oci://")
+}
+
+// ParseRemoteRef parses an https URL, git:: or oci:// reference
+func ParseRemoteRef(s string) (RemoteRef, error) {
+	if strings.HasPrefix(s, "https://") {
+		if _, err := url.Parse(s); err != nil {
+			return RemoteRef{}, fmt.Errorf("invalid URL %s: %w", s, err)
+		}
+		return RemoteRef{URL: s}, nil
+	}
+	if strings.HasPrefix(s, "oci://") {
+		return parseOCIRef(s)
+	}
+
+	if !strings.HasPrefix(s, "git::") {
+		return RemoteRef{}, fmt.Errorf("unsupported remote reference: %s (use https://, git:: or oci://)", s)
+	}
+	rest := strings.TrimPrefix(s, "git::")
+
+	ref := ""
+	if i := strings.LastIndex(rest, "?ref="); i >= 0 {
+		ref = rest[i+len("?ref="):]
+		rest = rest[:i]
+	}
+
+	// The file path follows "//" after the scheme separator
+	schemeEnd := strings.Index(rest, "://")
+	searchFrom := 0
+	if schemeEnd >= 0 {
+		searchFrom = schemeEnd + len("://")
+	}
+	sep := strings.Index(rest[searchFrom:], "//")
+	if sep < 0 {
+		return RemoteRef{}, fmt.Errorf("invalid git reference %s: missing //<path>", s)
+	}
+	repo := rest[:searchFrom+sep]
+	file := strings.TrimPrefix(path.Clean("/"+rest[searchFrom+sep+2:]), "/")
+	if file == "" {
+		return RemoteRef{}, fmt.Errorf("invalid git reference %s: empty path", s)
+	}
+
+	remote := RemoteRef{URL: repo, Git: true, Path: file, Ref: ref}
+	if err := remote.checkGit(); err != nil {
+		return RemoteRef{}, fmt.Errorf("invalid git reference %s: %w", s, err)
+	}
+	return remote, nil
+}
+
+// gitSchemes are the URL schemes of git repositories viberules fetches. Git
+// runs commands for others, such as ext::.
+var gitSchemes = []string{"https", "ssh", "git", "file"}
+
+// scpURL matches git's scp-like syntax for ssh, user@host:path
+var scpURL = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._~-]*@[A-Za-z0-9][A-Za-z0-9.-]*:[^:]`)
+
+// CheckGitURL returns an error unless repo is a git repository URL with one of
+// the gitSchemes or in the form user@host:path. Nothing starting with "-" is
+// accepted, so a URL never reaches git as an option.
+func CheckGitURL(repo string) error {
+	if strings.HasPrefix(repo, "-") {
+		return fmt.Errorf("repository %s starts with '-'", repo)
+	}
+	if scpURL.MatchString(repo) {
+		return nil
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return fmt.Errorf("invalid repository %s: %w", repo, err)
+	}
+	if strings.HasPrefix(u.Host, "-") || strings.HasPrefix(u.User.Username(), "-") {
+		return fmt.Errorf("repository %s has a host or user starting with '-'", repo)
+	}
+	for _, scheme := range gitSchemes {
+		if u.Scheme == scheme && (u.Host != "" || (scheme == "file" && u.Path != "")) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported repository %s (use https://, ssh://, git://, file:// or user@host:path)", repo)
+}
+
+// checkGit returns an error unless the repository and ref of a git reference
+// are safe to pass to git
+func (r RemoteRef) checkGit() error {
+	if err := CheckGitURL(r.URL); err != nil {
+		return err
+	}
+	if strings.HasPrefix(r.Ref, "-") {
+		return fmt.Errorf("ref %s starts with '-'", r.Ref)
+	}
+	return nil
+}
+
+// String returns the reference in include syntax
+func (r RemoteRef) String() string {
+	if r.OCI {
+		switch {
+		case r.Ref == "":
+			return "oci://" + r.URL
+		case strings.HasPrefix(r.Ref, "sha256:"):
+			return "oci://" + r.URL + "@" + r.Ref
+		default:
+			return "oci://" + r.URL + ":" + r.Ref
+		}
+	}
+	if !r.Git {
+		return r.URL
+	}
+	s := "git::" + r.URL + "//" + r.Path
+	if r.Ref != "" {
+		s += "?ref=" + r.Ref
+	}
+	return s
+}
+
+// Resolve returns the reference for rel, an include found inside this remote file
+func (r RemoteRef) Resolve(rel string) (RemoteRef, error) {
+	if IsRemoteRef(rel) {
+		return ParseRemoteRef(rel)
+	}
+	if r.OCI {
+		return RemoteRef{}, fmt.Errorf("include %s in %s: an OCI artifact holds a single file, so it can only include remote references", rel, r)
+	}
+
+	if r.Git {
+		resolved := r
+		resolved.Path = strings.TrimPrefix(path.Clean("/"+path.Join(path.Dir(r.Path), rel)), "/")
+		return resolved, nil
+	}
+
+	base, err := url.Parse(r.URL)
+	if err != nil {
+		return RemoteRef{}, err
+	}
+	relURL, err := url.Parse(rel)
+	if err != nil {
+		return RemoteRef{}, fmt.Errorf("invalid include %s: %w", rel, err)
+	}
+	return RemoteRef{URL: base.ResolveReference(relURL).String()}, nil
+}
+
+// Fetcher retrieves remote rule content
+type Fetcher interface {
+	Fetch(ctx context.Context, ref RemoteRef) ([]byte, error)
+}
+
+// RevisionFetcher is a Fetcher that also reports the exact revision it fetched:
+// the commit for git references, the manifest digest for OCI references,
+// empty for URLs
+type RevisionFetcher interface {
+	Fetcher
+	FetchRevision(ctx context.Context, ref RemoteRef) ([]byte, string, error)
+}
+
+// NetFetcher fetches https URLs over HTTP, git references with the git CLI,
+// and OCI references from their registry. Both go through the proxies named
+// by HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+// Results are memoized for the lifetime of the fetcher.
+type NetFetcher struct {
+	Client      *http.Client
+	Credentials Credentials  // credentials for private hosts; none when nil
+	Offline     bool         // fail every fetch instead of using the network
+	Logger      *slog.Logger // debug records of fetches; none when nil
+
+	mu    sync.Mutex
+	cache map[string]fetched
+}
+
+type fetched struct {
+	content  []byte
+	revision string
+}
+
+// NewNetFetcher returns a fetcher with a default HTTP timeout and the default
+// credentials
+func NewNetFetcher() *NetFetcher {
+	return &NetFetcher{Client: &http.Client{Timeout: 30 * time.Second}, Credentials: DefaultCredentials}
+}
+
+// credential returns the credential for host. Lookup failures are left for the
+// remote to report, so a broken token command doesn't hide public content.
+func (f *NetFetcher) credential(host string) Credential {
+	if f.Credentials == nil || host == "" {
+		return Credential{}
+	}
+	credential, _ := f.Credentials(host)
+	return credential
+}
+
+// Fetch implements Fetcher
+func (f *NetFetcher) Fetch(ctx context.Context, ref RemoteRef) ([]byte, error) {
+	content, _, err := f.FetchRevision(ctx, ref)
+	return content, err
+}
+
+// FetchRevision implements RevisionFetcher
+func (f *NetFetcher) FetchRevision(ctx context.Context, ref RemoteRef) ([]byte, string, error) {
+	key := ref.String()
+
+	f.mu.Lock()
+	if result, ok := f.cache[key]; ok {
+		f.mu.Unlock()
+		return result.content, result.revision, nil
+	}
+	f.mu.Unlock()
+
+	if f.Offline {
+		logTo(f.Logger).Debug("not fetching while offline", "ref", key)
+		return nil, "", WithKind(ErrOffline, fmt.Errorf("%s is not cached and viberules is offline; run once with network access", ref))
+	}
+
+	logTo(f.Logger).Debug("fetching remote content", "ref", key)
+	var result fetched
+	var err error
+	switch {
+	case ref.Git:
+		result.content, result.revision, err = fetchGit(ctx, ref, f.credential(gitHost(ref.URL)))
+	case ref.OCI:
+		result.content, result.revision, err = fetchOCI(ctx, f.Client, ref, f.Credentials)
+	default:
+		result.content, err = f.fetchHTTP(ctx, ref.URL)
+	}
+	if err != nil {
+		logTo(f.Logger).Debug("fetch failed", "ref", key, "error", err)
+		return nil, "", err
+	}
+	logTo(f.Logger).Debug("fetched remote content", "ref", key, "revision", result.revision, "bytes", len(result.content))
+
+	f.mu.Lock()
+	if f.cache == nil {
+		f.cache = make(map[string]fetched)
+	}
+	f.cache[key] = result
+	f.mu.Unlock()
+
+	return result.content, result.revision, nil
+}
+
+func (f *NetFetcher) fetchHTTP(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	credential := f.credential(req.URL.Hostname())
+	credential.apply(req)
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %w", httpStatusError(rawURL, resp, credential))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	if len(content) > maxRemoteSize {
+		return nil, fmt.Errorf("remote content too large: %s (max %d bytes)", rawURL, maxRemoteSize)
+	}
+	return content, nil
+}
+
+// fetchGit reads a single file at a ref from a git repository using a shallow fetch.
+// It returns the content and the commit the ref resolved to.
+func fetchGit(ctx context.Context, ref RemoteRef, credential Credential) ([]byte, string, error) {
+	if err := ref.checkGit(); err != nil {
+		return nil, "", err
+	}
+	dir, err := os.MkdirTemp("", "viberules-git-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rev := ref.Ref
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	if _, err := runGitContext(ctx, dir, "init", "--quiet"); err != nil {
+		return nil, "", err
+	}
+	if _, err := runGitEnv(ctx, dir, credential.gitEnv(), "fetch", "--quiet", "--depth", "1", "--", ref.URL, rev); err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	commit, err := runGitContext(ctx, dir, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	content, err := runGitContext(ctx, dir, "show", "FETCH_HEAD:"+ref.Path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	if len(content) > maxRemoteSize {
+		return nil, "", fmt.Errorf("remote content too large: %s (max %d bytes)", ref, maxRemoteSize)
+	}
+	return content, strings.TrimSpace(string(commit)), nil
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	return runGitContext(context.Background(), dir, args...)
+}
+
+// runGitContext runs git in dir, killing it when ctx is done
+func runGitContext(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	return runGitEnv(ctx, dir, nil, args...)
+}
+
+// runGitEnv runs git in dir with env, or the current environment when nil.
+// Errors are marked as ErrAuth or ErrNotFound when git says so.
+func runGitEnv(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("git %s: %w", args[0], ctx.Err())
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, classifyGitError(fmt.Errorf("git %s: %s", args[0], msg), msg)
+	}
+	return out, nil
+}
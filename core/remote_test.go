@@ -0,0 +1,213 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRemoteRef(t *testing.T) {
+	tests := []struct {
+		input string
+		want  RemoteRef
+	}{
+		{
+			input: "https://example.com/rules/base.md",
+			want:  RemoteRef{URL: "https://example.com/rules/base.md"},
+		},
+		{
+			input: "git::https://github.com/org/rules.git//go/style.md?ref=v1.2.0",
+			want:  RemoteRef{URL: "https://github.com/org/rules.git", Git: true, Path: "go/style.md", Ref: "v1.2.0"},
+		},
+		{
+			input: "git::git@github.com:org/rules.git//base.md",
+			want:  RemoteRef{URL: "git@github.com:org/rules.git", Git: true, Path: "base.md"},
+		},
+		{
+			input: "oci://registry.example.com:5000/acme/rules:v1",
+			want:  RemoteRef{URL: "registry.example.com:5000/acme/rules", OCI: true, Ref: "v1"},
+		},
+		{
+			input: "oci://ghcr.io/acme/rules@sha256:" + strings.Repeat("a", 64),
+			want:  RemoteRef{URL: "ghcr.io/acme/rules", OCI: true, Ref: "sha256:" + strings.Repeat("a", 64)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRemoteRef(tt.input)
+			if err != nil {
+				t.Fatalf("ParseRemoteRef failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRemoteRef = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.input {
+				t.Errorf("String() = %s, want %s", got.String(), tt.input)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"http://example.com/rules.md", "git::https://github.com/org/rules.git", "oci://ghcr.io", "oci://ghcr.io/acme/rules@sha256:abc"} {
+		if _, err := ParseRemoteRef(invalid); err == nil {
+			t.Errorf("ParseRemoteRef(%s) should fail", invalid)
+		}
+	}
+
+	// Repositories and refs that git would take as options or commands
+	for _, invalid := range []string{
+		"git::--upload-pack=touch${IFS}/tmp/pwned;false//x.md",
+		"git::https://github.com/org/rules.git//x.md?ref=--upload-pack=touch",
+		"git::ext::sh -c touch% /tmp/pwned//x.md",
+		"git::ssh://-oProxyCommand=touch/x//x.md",
+		"git::-oProxyCommand=x@host:org/rules.git//x.md",
+		"git::/tmp/rules//x.md",
+	} {
+		if _, err := ParseRemoteRef(invalid); err == nil {
+			t.Errorf("ParseRemoteRef(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestFetchGitRejectsOptions(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "pwned")
+	ref := RemoteRef{URL: "--upload-pack=touch " + marker, Git: true, Path: "x.md"}
+	if _, _, err := fetchGit(context.Background(), ref, Credential{}); err == nil {
+		t.Error("fetchGit() with an option as the repository should fail")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("fetchGit() ran the command in the repository URL")
+	}
+}
+
+func TestRemoteRefResolve(t *testing.T) {
+	git := RemoteRef{URL: "https://github.com/org/rules.git", Git: true, Path: "go/style.md", Ref: "v1"}
+	resolved, err := git.Resolve("../common/errors.md")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.Path != "common/errors.md" || resolved.Ref != "v1" || resolved.URL != git.URL {
+		t.Errorf("Resolve in git repo = %+v, want same repo and ref with path common/errors.md", resolved)
+	}
+
+	web := RemoteRef{URL: "https://example.com/rules/base.md"}
+	resolved, err = web.Resolve("extra.md")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.URL != "https://example.com/rules/extra.md" {
+		t.Errorf("Resolve against URL = %s, want https://example.com/rules/extra.md", resolved.URL)
+	}
+}
+
+func TestNetFetcherHTTP(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/base.md":
+			fmt.Fprint(w, "## Base\n<!-- viberules:include extra.md -->\n")
+		case "/extra.md":
+			fmt.Fprint(w, "- Extra rule\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	e := setupProject(t, "# Rules\n<!-- viberules:include "+server.URL+"/base.md -->\n")
+	fetcher := NewNetFetcher()
+	fetcher.Client = server.Client()
+
+	content, err := os.ReadFile(e.RulesFile())
+	if err != nil {
+		t.Fatalf("Failed to read rules: %v", err)
+	}
+	expanded, err := e.ExpandIncludes(context.Background(), content, e.RulesFile(), fetcher)
+	if err != nil {
+		t.Fatalf("ExpandIncludes with remote include failed: %v", err)
+	}
+	want := "# Rules\n## Base\n- Extra rule\n"
+	if string(expanded) != want {
+		t.Errorf("Expanded = %q, want %q", expanded, want)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), RemoteRef{URL: server.URL + "/missing.md"}); err == nil {
+		t.Error("Fetching a missing URL should fail")
+	}
+
+	// Without a fetcher remote includes are rejected
+	if _, err := e.ExpandIncludes(context.Background(), content, e.RulesFile(), nil); err == nil {
+		t.Error("ExpandIncludes without a fetcher should fail for remote includes")
+	}
+}
+
+func TestFetchCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	fetcher := NewNetFetcher()
+	fetcher.Client = server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := fetcher.Fetch(ctx, RemoteRef{URL: server.URL + "/slow.md"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch() past the deadline = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestNetFetcherGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	writeTestFile(t, filepath.Join(repo, "go", "style.md"), "v1 rules\n")
+	run("init", "--quiet")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+	run("tag", "v1")
+	writeTestFile(t, filepath.Join(repo, "go", "style.md"), "v2 rules\n")
+	run("commit", "--quiet", "-am", "v2")
+
+	fetcher := NewNetFetcher()
+	content, err := fetcher.Fetch(context.Background(), RemoteRef{URL: "file://" + repo, Git: true, Path: "go/style.md", Ref: "v1"})
+	if err != nil {
+		t.Fatalf("Fetch pinned git ref failed: %v", err)
+	}
+	if string(content) != "v1 rules\n" {
+		t.Errorf("Pinned content = %q, want v1 rules", content)
+	}
+
+	content, commit, err := fetcher.FetchRevision(context.Background(), RemoteRef{URL: "file://" + repo, Git: true, Path: "go/style.md"})
+	if err != nil {
+		t.Fatalf("Fetch default branch failed: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "v2") {
+		t.Errorf("Default branch content = %q, want v2 rules", content)
+	}
+	if len(commit) != 40 {
+		t.Errorf("FetchRevision commit = %q, want a full commit hash", commit)
+	}
+}
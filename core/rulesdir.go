@@ -0,0 +1,75 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RulesDir holds rules split into focused files, concatenated after rules.md
+// in lexical order (e.g. 10-style.md, 20-architecture.md)
+var RulesDir = filepath.Join(".viberules", "rules")
+
+// RulesDirFiles returns the markdown files in RulesDir in concatenation order,
+// followed by the installed packs
+func (e *Engine) RulesDirFiles() ([]string, error) {
+	entries, err := e.fs.ReadDir(RulesDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", RulesDir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		files = append(files, filepath.Join(RulesDir, entry.Name()))
+	}
+	sort.Strings(files)
+	packs, err := e.PackFiles()
+	if err != nil {
+		return nil, err
+	}
+	return append(files, packs...), nil
+}
+
+// ConcatRulesDir returns a transform that appends every file in RulesDir to
+// the content, expanding the includes of each file relative to it. Targets
+// with a PartsDir get the files as separate outputs instead.
+func (e *Engine) ConcatRulesDir(ctx context.Context, fetcher Fetcher) Transform {
+	return func(rc *RenderContext, content []byte) ([]byte, error) {
+		if target, ok := e.FindTarget(rc.Target); ok && target.PartsDir != "" {
+			return content, nil
+		}
+		files, err := e.RulesDirFiles()
+		if err != nil || len(files) == 0 {
+			return content, err
+		}
+
+		var out bytes.Buffer
+		if trimmed := bytes.TrimRight(content, "\n"); len(trimmed) > 0 {
+			out.Write(trimmed)
+			out.WriteString("\n\n")
+		}
+		for i, path := range files {
+			part, err := e.fs.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			part, err = e.ExpandIncludes(ctx, part, path, fetcher)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(bytes.TrimRight(part, "\n"))
+			if i < len(files)-1 {
+				out.WriteString("\n\n")
+			}
+		}
+		out.WriteString("\n")
+		return out.Bytes(), nil
+	}
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConcatRulesDir(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	// Without a rules directory the content is unchanged
+	content, err := e.ConcatRulesDir(context.Background(), nil)(&RenderContext{}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("ConcatRulesDir failed: %v", err)
+	}
+	if string(content) != "# Rules\n" {
+		t.Errorf("Content without rules directory = %q, want unchanged", content)
+	}
+
+	writeTestFile(t, ".viberules/rules/20-architecture.md", "## Architecture\n- Layers\n")
+	writeTestFile(t, ".viberules/rules/10-style.md", "## Style\n<!-- viberules:include ../shared/naming.md -->\n")
+	writeTestFile(t, ".viberules/rules/notes.txt", "ignored")
+	writeTestFile(t, ".viberules/shared/naming.md", "- Descriptive names\n")
+
+	content, err = e.ConcatRulesDir(context.Background(), nil)(&RenderContext{}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("ConcatRulesDir failed: %v", err)
+	}
+	want := "# Rules\n\n## Style\n- Descriptive names\n\n## Architecture\n- Layers\n"
+	if string(content) != want {
+		t.Errorf("Content = %q, want %q", content, want)
+	}
+}
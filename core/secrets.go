@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// secretDetector recognizes one kind of sensitive value
+type secretDetector struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// secretDetectors are checked against every line of files that become tracked.
+// They favor common credential formats over exhaustive coverage.
+var secretDetectors = []secretDetector{
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"API secret key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"URL with credentials", regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`)},
+	{"credential assignment", regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|token|passw(?:or)?d)\b["']?\s*[:=]\s*["']?[A-Za-z0-9/+_.\-]{12,}`)},
+	{"private URL", regexp.MustCompile(`(?i)\bhttps?://(?:localhost|127\.\d+\.\d+\.\d+|10\.\d+\.\d+\.\d+|192\.168\.\d+\.\d+|172\.(?:1[6-9]|2\d|3[01])\.\d+\.\d+|[a-z0-9.-]+\.(?:internal|corp|lan|intranet|local))\b`)},
+}
+
+// SecretFinding is a potential secret found in a file
+type SecretFinding struct {
+	Path     string
+	Line     int
+	Detector string
+	Match    string // redacted excerpt of the matched text
+}
+
+func (f SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.Path, f.Line, f.Detector, f.Match)
+}
+
+// ScanSecrets returns potential secrets in content, reported against path
+func ScanSecrets(path string, content []byte) []SecretFinding {
+	var findings []SecretFinding
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		for _, detector := range secretDetectors {
+			if match := detector.pattern.FindString(text); match != "" {
+				findings = append(findings, SecretFinding{Path: path, Line: line, Detector: detector.name, Match: redact(match)})
+			}
+		}
+	}
+	return findings
+}
+
+// redact keeps the start of a match so users can find it without echoing the secret
+func redact(match string) string {
+	const visible = 6
+	if len(match) <= visible {
+		return match
+	}
+	return match[:visible] + strings.Repeat("*", min(len(match)-visible, 8))
+}
+
+// PublicFiles returns the files under .viberules that git tracks in public mode:
+// everything except the config file and personal *.local.md files
+func (e *Engine) PublicFiles() ([]string, error) {
+	var files []string
+	err := e.walkDir(".viberules", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if d.Name() == ".config.yaml" || strings.HasSuffix(d.Name(), ".local.md") {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list .viberules files: %w", err)
+	}
+	return files, nil
+}
+
+// ScanPublicFiles scans every file that becomes tracked in public mode
+func (e *Engine) ScanPublicFiles() ([]SecretFinding, error) {
+	files, err := e.PublicFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []SecretFinding
+	for _, path := range files {
+		content, err := e.fs.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		findings = append(findings, ScanSecrets(path, content)...)
+	}
+	return findings, nil
+}
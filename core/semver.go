@@ -0,0 +1,220 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semverPattern matches [v]MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD]
+var semverPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// Version is a semantic version. Missing minor and patch numbers count as zero.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string // pre-release, as in 1.0.0-rc.1
+	parts               int    // numbers written, for partial versions in constraints
+}
+
+// ParseVersion parses a semantic version such as 1.2.3, v1.2 or 2.0.0-rc.1
+func ParseVersion(s string) (Version, error) {
+	match := semverPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid version %q (use MAJOR.MINOR.PATCH)", s)
+	}
+	v := Version{Pre: match[4], parts: 1}
+	v.Major, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		v.Minor, _ = strconv.Atoi(match[2])
+		v.parts++
+	}
+	if match[3] != "" {
+		v.Patch, _ = strconv.Atoi(match[3])
+		v.parts++
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than o.
+// A pre-release is lower than its release.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// comparePre orders pre-releases by their dot-separated identifiers, numbers
+// numerically and below words
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Constraint is a set of allowed versions, written as in npm and Cargo:
+// ^1.2 (>=1.2.0 <2.0.0), ~1.2 (>=1.2.0 <1.3.0), 1.2 (1.2.x), * (any),
+// comparisons such as >=1.0 <1.4 that must all hold, and alternatives
+// separated by ||
+type Constraint struct {
+	text string
+	any  [][]comparator // allowed if every comparator of any set holds
+}
+
+type comparator struct {
+	op      string // =, <, <=, > or >=
+	version Version
+}
+
+// ParseConstraint parses a version constraint
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{text: strings.TrimSpace(s)}
+	for _, alternative := range strings.Split(c.text, "||") {
+		var set []comparator
+		for _, term := range strings.Fields(alternative) {
+			comparators, err := parseConstraintTerm(term)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+			set = append(set, comparators...)
+		}
+		if set == nil {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q: empty", s)
+		}
+		c.any = append(c.any, set)
+	}
+	return c, nil
+}
+
+// parseConstraintTerm expands one term of a constraint into comparisons
+func parseConstraintTerm(term string) ([]comparator, error) {
+	if term == "*" || term == "x" {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op, term = prefix, strings.TrimPrefix(term, prefix)
+			break
+		}
+	}
+	v, err := ParseVersion(strings.TrimSuffix(strings.TrimSuffix(term, ".x"), ".*"))
+	if err != nil {
+		return nil, err
+	}
+	low := comparator{op: ">=", version: v}
+
+	switch op {
+	case ">=", ">", "<=", "<":
+		return []comparator{{op: op, version: v}}, nil
+	case "^":
+		// Changes left of the first non-zero number may break compatibility
+		switch {
+		case v.Major > 0 || v.parts == 1:
+			return []comparator{low, {op: "<", version: Version{Major: v.Major + 1}}}, nil
+		case v.Minor > 0 || v.parts == 2:
+			return []comparator{low, {op: "<", version: Version{Minor: v.Minor + 1}}}, nil
+		}
+		return []comparator{low, {op: "<", version: Version{Patch: v.Patch + 1}}}, nil
+	case "~":
+		if v.parts == 1 {
+			return []comparator{low, {op: "<", version: Version{Major: v.Major + 1}}}, nil
+		}
+		return []comparator{low, {op: "<", version: Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+	}
+	// A partial version stands for every version it prefixes
+	switch v.parts {
+	case 1:
+		return []comparator{low, {op: "<", version: Version{Major: v.Major + 1}}}, nil
+	case 2:
+		return []comparator{low, {op: "<", version: Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+	}
+	return []comparator{{op: "=", version: v}}, nil
+}
+
+func (c comparator) holds(v Version) bool {
+	n := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	}
+	return n == 0
+}
+
+// Allows reports whether v satisfies the constraint. Pre-releases are only
+// allowed by constraints that name a pre-release of the same version, so
+// ^1.2 doesn't pick up 1.3.0-rc.1.
+func (c Constraint) Allows(v Version) bool {
+	for _, set := range c.any {
+		allowed := true
+		namesPre := v.Pre == ""
+		for _, comparator := range set {
+			if !comparator.holds(v) {
+				allowed = false
+				break
+			}
+			cv := comparator.version
+			if cv.Pre != "" && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+				namesPre = true
+			}
+		}
+		if allowed && namesPre {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraint) String() string {
+	return c.text
+}
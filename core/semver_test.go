@@ -0,0 +1,72 @@
+package core
+
+import "testing"
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0", "v1.0.1", "1.2", "1.10.0"}
+	for i := 1; i < len(ordered); i++ {
+		lower, err := ParseVersion(ordered[i-1])
+		if err != nil {
+			t.Fatalf("ParseVersion(%s) failed: %v", ordered[i-1], err)
+		}
+		higher, err := ParseVersion(ordered[i])
+		if err != nil {
+			t.Fatalf("ParseVersion(%s) failed: %v", ordered[i], err)
+		}
+		if lower.Compare(higher) != -1 || higher.Compare(lower) != 1 {
+			t.Errorf("%s should be lower than %s", ordered[i-1], ordered[i])
+		}
+	}
+
+	if v, _ := ParseVersion("v2.1.0+build.5"); v.String() != "2.1.0" {
+		t.Errorf("ParseVersion(v2.1.0+build.5) = %s, want 2.1.0", v)
+	}
+	for _, invalid := range []string{"", "latest", "1.2.3.4", "1..2"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestConstraintAllows(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		denied     []string
+	}{
+		{"^1.2", []string{"1.2.0", "1.9.3"}, []string{"1.1.9", "2.0.0", "1.3.0-rc.1"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"1.x", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{">=1.0 <1.4", []string{"1.0.0", "1.3.9"}, []string{"0.9.0", "1.4.0"}},
+		{"^1.0 || ^3.0", []string{"1.5.0", "3.1.0"}, []string{"2.0.0"}},
+		{"*", []string{"0.0.1", "9.9.9"}, []string{"2.0.0-rc.1"}},
+		{"^2.0.0-rc.1", []string{"2.0.0-rc.2", "2.0.0", "2.1.0"}, []string{"2.0.0-alpha", "2.1.0-rc.1"}},
+	}
+	for _, tt := range tests {
+		constraint, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%s) failed: %v", tt.constraint, err)
+		}
+		for _, version := range tt.allowed {
+			if v, _ := ParseVersion(version); !constraint.Allows(v) {
+				t.Errorf("%s should allow %s", tt.constraint, version)
+			}
+		}
+		for _, version := range tt.denied {
+			if v, _ := ParseVersion(version); constraint.Allows(v) {
+				t.Errorf("%s should not allow %s", tt.constraint, version)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"", "^", "^one", "1.0 ||", ">=1.0 <"} {
+		if _, err := ParseConstraint(invalid); err == nil {
+			t.Errorf("ParseConstraint(%q) should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PullResult is the outcome of merging shared rules into a project
+type PullResult struct {
+	Content   []byte // merged rules
+	Commit    string // commit of the shared rules that were merged
+	Conflicts bool   // Content holds sections changed on both sides, with conflict markers
+}
+
+// PullShared fetches the shared rules at ref and merges them into local, the
+// project's rules. The version merged by the previous pull, recorded in lock,
+// is the common ancestor: rules not changed locally since then are replaced,
+// and local changes are merged section by section (see MergeRules). Without a
+// previous pull, sections found on only one side are kept and differing
+// sections conflict. lock records the merged version for the next pull.
+func PullShared(ctx context.Context, fetcher RevisionFetcher, ref RemoteRef, local []byte, lock *Lockfile) (PullResult, error) {
+	theirs, commit, err := fetcher.FetchRevision(ctx, ref)
+	if err != nil {
+		return PullResult{}, err
+	}
+
+	var base []byte
+	pulled := lock.Shared
+	hasBase := pulled != nil && pulled.Ref == ref.String()
+	if hasBase {
+		base = theirs
+		if pulled.Commit != commit {
+			baseRef := ref
+			baseRef.Ref = pulled.Commit
+			if base, _, err = fetcher.FetchRevision(ctx, baseRef); err != nil {
+				return PullResult{}, fmt.Errorf("failed to fetch the shared rules pulled last (%s): %w", pulled.Commit, err)
+			}
+		}
+		if Checksum(base) != pulled.SHA256 {
+			return PullResult{}, fmt.Errorf("the shared rules pulled last (%s) don't match %s", pulled.Commit, LockfilePath)
+		}
+	}
+
+	result := PullResult{Content: local, Commit: commit}
+	switch {
+	case hasBase && bytes.Equal(local, base):
+		result.Content = theirs // fast-forward
+	case bytes.Equal(local, theirs):
+	default:
+		result.Content, result.Conflicts = MergeRules(base, local, theirs)
+	}
+	lock.Shared = &LockedSource{Ref: ref.String(), Commit: commit, SHA256: Checksum(theirs)}
+	return result, nil
+}
+
+// PublishShared commits content as the shared rules file of ref on branch, a
+// new branch started from ref's branch, or the existing branch it updates, and
+// pushes it for review. A signature, when given, is committed next to the
+// rules file (see SignatureSuffix). It returns the pushed commit, or an empty
+// commit when the branch already holds these files. Commits use the git
+// identity of the current user.
+func PublishShared(ctx context.Context, ref RemoteRef, content, signature []byte, branch, message string) (string, error) {
+	if err := ref.checkGit(); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "viberules-publish-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := ref.Ref
+	if start == "" {
+		start = "HEAD"
+	}
+	if _, err := runGitContext(ctx, dir, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	// Continue a branch published before, so repeated publishes add commits
+	if _, err := runGitContext(ctx, dir, "fetch", "--quiet", "--depth", "1", "--", ref.URL, "refs/heads/"+branch); err != nil {
+		if _, err := runGitContext(ctx, dir, "fetch", "--quiet", "--depth", "1", "--", ref.URL, start); err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", ref, err)
+		}
+	}
+	if _, err := runGitContext(ctx, dir, "checkout", "--quiet", "-B", branch, "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+
+	files := []string{ref.Path}
+	if signature != nil {
+		files = append(files, ref.Path+SignatureSuffix)
+	}
+	for i, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		data := content
+		if i > 0 {
+			data = signature
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	if _, err := runGitContext(ctx, dir, append([]string{"add", "--"}, files...)...); err != nil {
+		return "", err
+	}
+	if _, err := runGitContext(ctx, dir, "diff", "--cached", "--quiet"); err == nil {
+		return "", nil
+	}
+	if _, err := runGitContext(ctx, dir, "commit", "--quiet", "-m", message); err != nil {
+		return "", err
+	}
+	if _, err := runGitContext(ctx, dir, "push", "--quiet", "--", ref.URL, "HEAD:refs/heads/"+branch); err != nil {
+		return "", fmt.Errorf("failed to push %s to %s: %w", branch, ref.URL, err)
+	}
+	commit, err := runGitContext(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(commit)), nil
+}
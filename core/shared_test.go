@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPullShared(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	v1 := "# Rules\n\n## Style\n\nUse tabs.\n\n## Testing\n\nRun go test.\n"
+	writeTestFile(t, filepath.Join(repo, "rules.md"), v1)
+	run("init", "--quiet")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+
+	ref := RemoteRef{URL: "file://" + repo, Git: true, Path: "rules.md"}
+	lock := &Lockfile{}
+
+	// The first pull of an empty project takes the shared rules
+	result, err := PullShared(context.Background(), NewNetFetcher(), ref, nil, lock)
+	if err != nil {
+		t.Fatalf("PullShared() failed: %v", err)
+	}
+	if string(result.Content) != v1 || result.Conflicts || lock.Shared == nil || lock.Shared.Commit != result.Commit {
+		t.Fatalf("PullShared() = %+v, lock %+v; want v1 recorded", result, lock.Shared)
+	}
+
+	// Upstream and local changes to different sections are both kept
+	writeTestFile(t, filepath.Join(repo, "rules.md"), "# Rules\n\n## Style\n\nUse gofmt.\n\n## Testing\n\nRun go test.\n")
+	run("commit", "--quiet", "-am", "v2")
+	local := "# Rules\n\n## Style\n\nUse tabs.\n\n## Testing\n\nRun go test -race.\n"
+	result, err = PullShared(context.Background(), NewNetFetcher(), ref, []byte(local), lock)
+	if err != nil {
+		t.Fatalf("PullShared() failed: %v", err)
+	}
+	want := "# Rules\n\n## Style\n\nUse gofmt.\n\n## Testing\n\nRun go test -race.\n"
+	if string(result.Content) != want || result.Conflicts {
+		t.Errorf("PullShared() = %q, %v; want %q", result.Content, result.Conflicts, want)
+	}
+
+	// Both sides changing a section is a conflict
+	writeTestFile(t, filepath.Join(repo, "rules.md"), "# Rules\n\n## Style\n\nUse spaces.\n\n## Testing\n\nRun go test.\n")
+	run("commit", "--quiet", "-am", "v3")
+	local = "# Rules\n\n## Style\n\nUse gofmt -s.\n\n## Testing\n\nRun go test -race.\n"
+	result, err = PullShared(context.Background(), NewNetFetcher(), ref, []byte(local), lock)
+	if err != nil {
+		t.Fatalf("PullShared() failed: %v", err)
+	}
+	if !result.Conflicts {
+		t.Errorf("PullShared() = %q; want a conflict in Style", result.Content)
+	}
+}
+
+func TestPublishShared(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+
+	repo := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	writeTestFile(t, filepath.Join(repo, "go", "rules.md"), "# Rules\n")
+	run("init", "--quiet")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+
+	ref := RemoteRef{URL: "file://" + repo, Git: true, Path: "go/rules.md"}
+	commit, err := PublishShared(context.Background(), ref, []byte("# Rules\n\nUse gofmt.\n"), nil, "viberules/api", "Share gofmt")
+	if err != nil {
+		t.Fatalf("PublishShared() failed: %v", err)
+	}
+	if got := run("show", "viberules/api:go/rules.md"); got != "# Rules\n\nUse gofmt.\n" {
+		t.Errorf("published rules = %q", got)
+	}
+	if got := run("rev-parse", "viberules/api"); len(commit) != 40 || got != commit+"\n" {
+		t.Errorf("PublishShared() = %q, branch at %q", commit, got)
+	}
+
+	// Publishing again continues the branch, and unchanged rules push nothing
+	if commit, err := PublishShared(context.Background(), ref, []byte("# Rules\n\nUse gofmt.\n"), nil, "viberules/api", "Again"); err != nil || commit != "" {
+		t.Errorf("PublishShared(unchanged) = %q, %v; want nothing pushed", commit, err)
+	}
+	if _, err := PublishShared(context.Background(), ref, []byte("# Rules\n\nUse gofmt -s.\n"), []byte("signature\n"), "viberules/api", "Simplify"); err != nil {
+		t.Fatalf("PublishShared() failed: %v", err)
+	}
+	if got := run("show", "viberules/api:go/rules.md.minisig"); got != "signature\n" {
+		t.Errorf("published signature = %q", got)
+	}
+	if got := run("rev-list", "--count", "viberules/api"); got != "3\n" {
+		t.Errorf("viberules/api has %q commits, want 3", got)
+	}
+
+	// A repository git would take as an option is refused
+	marker := filepath.Join(t.TempDir(), "pwned")
+	bad := RemoteRef{URL: "--receive-pack=touch " + marker, Git: true, Path: "go/rules.md"}
+	if _, err := PublishShared(context.Background(), bad, []byte("# Rules\n"), nil, "viberules/api", "Bad"); err == nil {
+		t.Error("PublishShared() with an option as the repository should fail")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("PublishShared() ran the command in the repository URL")
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Projects can keep their rules outside .viberules (e.g. docs/ai-rules.md).
+// Links of every target are then computed relative to the configured file.
+
+// DefaultRulesFile is the rules file of projects that don't configure one
+var DefaultRulesFile = filepath.Join(".viberules", "rules.md")
+
+// SetRulesFile makes path, relative to the scope root, the rules file every
+// target links to. An empty path restores DefaultRulesFile.
+func (e *Engine) SetRulesFile(path string) {
+	if path == "" {
+		e.rulesFile = DefaultRulesFile
+		return
+	}
+	e.rulesFile = filepath.Clean(path)
+}
+
+// RulesFile returns the rules file every target links to
+func (e *Engine) RulesFile() string {
+	return e.rulesFile
+}
+
+// ValidateRulesFile checks that path can hold the rules file: it must stay
+// inside the project and must not be one of the files viberules writes
+func (e *Engine) ValidateRulesFile(path string) error {
+	if path == "" || filepath.IsAbs(path) {
+		return fmt.Errorf("source must be relative to the project root: %q", path)
+	}
+	clean := filepath.Clean(path)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("source must stay inside the project: %q", path)
+	}
+	if clean == LocalRulesFile || clean == NotesFile || strings.HasPrefix(clean, ProfilesDir+string(filepath.Separator)) {
+		return fmt.Errorf("source %q is managed by viberules", path)
+	}
+	for _, target := range e.Targets() {
+		for _, link := range append(target.Links, target.LocalLinks...) {
+			if link.Target == clean {
+				return fmt.Errorf("source %q is the output of %s", path, target.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// withRulesFile returns the target with its links pointing at rulesFile
+func (t Target) withRulesFile(rulesFile string) Target {
+	links := append([]SymlinkDef(nil), t.Links...)
+	for i, link := range links {
+		source, err := filepath.Rel(filepath.Dir(link.Target), rulesFile)
+		if err != nil {
+			return t
+		}
+		links[i].Source = source
+	}
+	t.Links = links
+	return t
+}
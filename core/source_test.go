@@ -0,0 +1,74 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetRulesFile(t *testing.T) {
+	e := setupProject(t, "rules")
+	rulesFile := filepath.Join("docs", "ai-rules.md")
+	writeTestFile(t, rulesFile, "moved rules")
+	e.SetRulesFile(rulesFile)
+
+	target, ok := e.FindTarget("amazonq")
+	if !ok {
+		t.Fatal("FindTarget(amazonq) not found")
+	}
+	link := target.Links[0]
+	if want := filepath.Join("..", "..", "docs", "ai-rules.md"); link.Source != want {
+		t.Errorf("link source = %q, want %q", link.Source, want)
+	}
+	if link.ResolvedSource() != rulesFile {
+		t.Errorf("ResolvedSource() = %q, want %q", link.ResolvedSource(), rulesFile)
+	}
+
+	if err := e.CreateTargetSymlinks("amazonq"); err != nil {
+		t.Fatalf("CreateTargetSymlinks() failed: %v", err)
+	}
+	content, err := os.ReadFile(link.Target)
+	if err != nil || string(content) != "moved rules" {
+		t.Errorf("output = %q, %v; want the configured rules file", content, err)
+	}
+
+	// Profiles are linked relative to the configured file
+	writeTestFile(t, ProfilePath("team"), "team rules")
+	if err := os.Remove(rulesFile); err != nil {
+		t.Fatalf("Failed to remove rules file: %v", err)
+	}
+	if err := e.UseProfile("team"); err != nil {
+		t.Fatalf("UseProfile() failed: %v", err)
+	}
+	if name, ok := e.ActiveProfile(); !ok || name != "team" {
+		t.Errorf("ActiveProfile() = %q, %v; want team", name, ok)
+	}
+
+	e.SetRulesFile("")
+	if e.RulesFile() != DefaultRulesFile {
+		t.Errorf("SetRulesFile(\"\") left RulesFile = %q", e.RulesFile())
+	}
+}
+
+func TestValidateRulesFile(t *testing.T) {
+	e := New(OSFS{})
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"docs/ai-rules.md", false},
+		{".viberules/rules.md", false},
+		{"/tmp/rules.md", true},
+		{"../rules.md", true},
+		{".viberules/rules.local.md", true},
+		{".viberules/profiles/team.md", true},
+		{"CLAUDE.md", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		err := e.ValidateRulesFile(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRulesFile(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// linkWorkers bounds the targets linked or checked at once
+const linkWorkers = 8
+
+// forEachTarget runs fn with the index of every target on at most
+// linkWorkers goroutines. The result holds the error of each target, in the
+// order of targets.
+func forEachTarget(targets []Target, fn func(i int, target Target) error) []error {
+	errs := make([]error, len(targets))
+	slots := make(chan struct{}, linkWorkers)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, target Target) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = fn(i, target)
+		}(i, target)
+	}
+	wg.Wait()
+	return errs
+}
+
+// CreateAllSymlinks creates symlinks for all AI assistant targets of the
+// active scope. Targets are linked concurrently; one failing doesn't stop the
+// others, and the error reports every target that failed.
+func (e *Engine) CreateAllSymlinks() error {
+	targets := e.Targets()
+
+	// Create required directories first
+	for _, dir := range e.GetRequiredDirectories() {
+		if err := e.checkInside(dir); err != nil {
+			return err
+		}
+		if err := e.fs.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	// Create symlinks for each target
+	errs := forEachTarget(targets, func(_ int, target Target) error {
+		for _, link := range target.Links {
+			if err := e.createScopedSymlink(link.Source, link.Target); err != nil {
+				return fmt.Errorf("failed to create symlink for %s: %w", target.Name, err)
+			}
+		}
+		return nil
+	})
+	return errors.Join(errs...)
+}
+
+// RemoveAllSymlinks removes all symlinks created by viberules
+func (e *Engine) RemoveAllSymlinks() error {
+	targets := e.Targets()
+
+	for _, target := range targets {
+		for _, link := range target.Links {
+			if err := e.removeSymlink(link.Target); err != nil {
+				return fmt.Errorf("failed to remove symlink for %s: %w", target.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// createSymlink creates a symlink, removing existing file if necessary
+func (e *Engine) createSymlink(source, target string) error {
+	// Clean paths to prevent path traversal
+	source = filepath.Clean(source)
+	target = filepath.Clean(target)
+
+	// Remove existing file/symlink if it exists
+	if err := e.removeSymlink(target); err != nil {
+		return err
+	}
+
+	// Create parent directory if needed
+	targetDir := filepath.Dir(target)
+	if targetDir != "." {
+		if err := e.fs.MkdirAll(targetDir, 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+	}
+
+	// Create the symlink
+	if err := e.fs.Symlink(source, target); err != nil {
+		if runtime.GOOS == "windows" {
+			// Creating symlinks needs Developer Mode or administrator rights
+			err = WithKind(ErrWindowsUnsupportedStrategy, err)
+		}
+		return fmt.Errorf("failed to create symlink %s -> %s: %w", target, source, err)
+	}
+	e.logger.Debug("created symlink", "path", target, "source", source)
+	e.observerMu.Lock()
+	e.observer.OnSymlinkCreated(target, source)
+	e.observerMu.Unlock()
+
+	return nil
+}
+
+// createScopedSymlink creates a symlink that must stay inside the scope root,
+// the root of the engine's filesystem. A link whose location or
+// destination resolves outside it, through ".." or a symlinked directory, is
+// refused.
+func (e *Engine) createScopedSymlink(source, target string) error {
+	if err := e.CheckLinkInside(source, target); err != nil {
+		return err
+	}
+	return e.createSymlink(source, target)
+}
+
+// CheckLinkInside returns an error unless a symlink at target pointing to
+// source stays inside the scope root. Destinations are resolved through
+// every symlink on the way; the rules file is trusted wherever it resolves,
+// so it can itself be a link to rules kept elsewhere.
+func (e *Engine) CheckLinkInside(source, target string) error {
+	if err := e.checkInside(target); err != nil {
+		return err
+	}
+	root, err := e.fs.Abs(".")
+	if err != nil {
+		return err
+	}
+	location, err := e.fs.Abs(target)
+	if err != nil {
+		return err
+	}
+
+	destination := source
+	if !filepath.IsAbs(destination) {
+		destination = filepath.Join(filepath.Dir(location), source)
+	}
+	destination = e.resolvePath(destination)
+	if isWithin(destination, e.resolvePath(root)) {
+		return nil
+	}
+	if rules, err := e.fs.Abs(e.rulesFile); err == nil && destination == e.resolvePath(rules) {
+		return nil
+	}
+	return fmt.Errorf("refusing to link %s to %s: it resolves outside the project", target, source)
+}
+
+// checkInside returns an error unless path, and the directory holding it
+// once symlinks are resolved, are inside the scope root
+func (e *Engine) checkInside(path string) error {
+	root, err := e.fs.Abs(".")
+	if err != nil {
+		return err
+	}
+	location, err := e.fs.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !isWithin(location, root) || !isWithin(e.resolvePath(filepath.Dir(location)), e.resolvePath(root)) {
+		return fmt.Errorf("refusing to create %s: it is outside the project", path)
+	}
+	return nil
+}
+
+// resolvePath returns path with every symlink resolved. Missing parts are
+// kept as written below the part that exists.
+func (e *Engine) resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := e.fs.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(e.resolvePath(parent), filepath.Base(path))
+}
+
+// isWithin reports whether path is root or below it
+func isWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeSymlink removes a symlink or file if it exists
+func (e *Engine) removeSymlink(path string) error {
+	path = filepath.Clean(path)
+
+	// Check if file exists and get info
+	info, err := e.fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil // File doesn't exist, nothing to remove
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	// SECURITY: Only remove if it's actually a symlink
+	// This prevents accidental deletion of regular files or directories
+	if info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("refusing to remove %s: not a symlink", path)
+	}
+
+	// Safe to remove - it's confirmed to be a symlink
+	if err := e.fs.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove symlink %s: %w", path, err)
+	}
+	e.logger.Debug("removed symlink", "path", path)
+
+	return nil
+}
+
+// IsSymlinkValid checks if a symlink exists and points to the correct target
+func (e *Engine) IsSymlinkValid(linkPath, expectedTarget string) bool {
+	linkPath = filepath.Clean(linkPath)
+	expectedTarget = filepath.Clean(expectedTarget)
+
+	// Check if symlink exists
+	info, err := e.fs.Lstat(linkPath)
+	if err != nil {
+		return false
+	}
+
+	// Check if it's actually a symlink
+	if info.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+
+	// Check if it points to the correct target
+	actualTarget, err := e.fs.Readlink(linkPath)
+	if err != nil {
+		return false
+	}
+
+	// Check if target path matches
+	if filepath.Clean(actualTarget) != expectedTarget {
+		return false
+	}
+
+	// Check if the target actually exists (for broken symlinks)
+	_, err = e.fs.Stat(linkPath) // This will fail for broken symlinks
+	return err == nil
+}
+
+// CheckAllSymlinks verifies all symlinks are properly created, checking
+// targets concurrently. Missing links are listed in target order.
+func (e *Engine) CheckAllSymlinks() (bool, []string) {
+	targets := e.Targets()
+	found := make([][]string, len(targets))
+	forEachTarget(targets, func(i int, target Target) error {
+		for _, link := range target.Links {
+			if !e.IsSymlinkValid(link.Target, link.Source) {
+				found[i] = append(found[i], fmt.Sprintf("%s (%s)", link.Target, target.Name))
+			}
+		}
+		return nil
+	})
+
+	var missing []string
+	for _, links := range found {
+		missing = append(missing, links...)
+	}
+	return len(missing) == 0, missing
+}
+
+// CreateTargetSymlinks creates symlinks for a specific target
+func (e *Engine) CreateTargetSymlinks(targetName string) error {
+	targets := e.Targets()
+
+	for _, target := range targets {
+		if target.Name == targetName {
+			// Create required directories first
+			for _, dir := range e.GetRequiredDirectories() {
+				if err := e.checkInside(dir); err != nil {
+					return err
+				}
+				if err := e.fs.MkdirAll(dir, 0755); err != nil {
+					return fmt.Errorf("failed to create directory %s: %w", dir, err)
+				}
+			}
+
+			links, err := e.OutputLinks(target)
+			if err != nil {
+				return err
+			}
+			if err := e.removeStaleParts(target, linkTargets(links), nil, false); err != nil {
+				return err
+			}
+
+			// Create symlinks for this target
+			for _, link := range links {
+				if err := e.createScopedSymlink(link.Source, link.Target); err != nil {
+					return fmt.Errorf("failed to create symlink: %w", err)
+				}
+			}
+			return nil
+		}
+	}
+
+	return unknownTarget(targetName)
+}
+
+// RemoveTargetSymlinks removes symlinks for a specific target
+func (e *Engine) RemoveTargetSymlinks(targetName string) error {
+	targets := e.Targets()
+
+	for _, target := range targets {
+		if target.Name == targetName {
+			for _, link := range target.Links {
+				if err := e.removeSymlink(link.Target); err != nil {
+					return fmt.Errorf("failed to remove symlink: %w", err)
+				}
+			}
+
+			// Copied parts are left for RemoveTargetCopies
+			parts, err := e.existingParts(target)
+			if err != nil {
+				return err
+			}
+			for _, path := range parts {
+				if info, err := e.fs.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+					if err := e.removeSymlink(path); err != nil {
+						return fmt.Errorf("failed to remove symlink: %w", err)
+					}
+				}
+			}
+			return nil
+		}
+	}
+
+	return unknownTarget(targetName)
+}
@@ -8,6 +8,7 @@ import (
 )
 
 func TestCreateAndRemoveSymlink(t *testing.T) {
+	e := New(OSFS{})
 	// Create temp directory (outside project folder)
 	tempDir := t.TempDir()
 
@@ -22,12 +23,12 @@ func TestCreateAndRemoveSymlink(t *testing.T) {
 	targetFile := filepath.Join(tempDir, "target.txt")
 
 	// Create symlink
-	if err := createSymlink(sourceFile, targetFile); err != nil {
+	if err := e.createSymlink(sourceFile, targetFile); err != nil {
 		t.Fatalf("createSymlink() failed: %v", err)
 	}
 
 	// Check if symlink was created correctly
-	if !IsSymlinkValid(targetFile, sourceFile) {
+	if !e.IsSymlinkValid(targetFile, sourceFile) {
 		t.Error("Created symlink is not valid")
 	}
 
@@ -42,7 +43,7 @@ func TestCreateAndRemoveSymlink(t *testing.T) {
 	}
 
 	// Remove symlink
-	if err := removeSymlink(targetFile); err != nil {
+	if err := e.removeSymlink(targetFile); err != nil {
 		t.Fatalf("removeSymlink() failed: %v", err)
 	}
 
@@ -53,6 +54,7 @@ func TestCreateAndRemoveSymlink(t *testing.T) {
 }
 
 func TestCreateSymlinkWithSubdirectory(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 
 	// Create source file
@@ -65,12 +67,12 @@ func TestCreateSymlinkWithSubdirectory(t *testing.T) {
 	targetFile := filepath.Join(tempDir, "subdir", "target.txt")
 
 	// Create symlink (subdirectory should be auto-created)
-	if err := createSymlink(sourceFile, targetFile); err != nil {
+	if err := e.createSymlink(sourceFile, targetFile); err != nil {
 		t.Fatalf("createSymlink() with subdirectory failed: %v", err)
 	}
 
 	// Check symlink
-	if !IsSymlinkValid(targetFile, sourceFile) {
+	if !e.IsSymlinkValid(targetFile, sourceFile) {
 		t.Error("Symlink in subdirectory is not valid")
 	}
 
@@ -81,6 +83,7 @@ func TestCreateSymlinkWithSubdirectory(t *testing.T) {
 }
 
 func TestCreateTargetSymlinks(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 
 	// Change current directory to temp directory
@@ -106,7 +109,7 @@ func TestCreateTargetSymlinks(t *testing.T) {
 	}
 
 	// Create symlink for claude target
-	if err := CreateTargetSymlinks("claude"); err != nil {
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
 		t.Fatalf("CreateTargetSymlinks(claude) failed: %v", err)
 	}
 
@@ -119,13 +122,14 @@ func TestCreateTargetSymlinks(t *testing.T) {
 	}
 
 	for _, link := range expectedLinks {
-		if !IsSymlinkValid(link.target, link.source) {
+		if !e.IsSymlinkValid(link.target, link.source) {
 			t.Errorf("Symlink %s -> %s is not valid", link.target, link.source)
 		}
 	}
 }
 
 func TestRemoveTargetSymlinks(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 
 	// Change current directory
@@ -151,12 +155,12 @@ func TestRemoveTargetSymlinks(t *testing.T) {
 	}
 
 	// Create symlink
-	if err := CreateTargetSymlinks("claude"); err != nil {
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
 		t.Fatalf("CreateTargetSymlinks(claude) failed: %v", err)
 	}
 
 	// Remove symlink
-	if err := RemoveTargetSymlinks("claude"); err != nil {
+	if err := e.RemoveTargetSymlinks("claude"); err != nil {
 		t.Fatalf("RemoveTargetSymlinks(claude) failed: %v", err)
 	}
 
@@ -170,6 +174,7 @@ func TestRemoveTargetSymlinks(t *testing.T) {
 }
 
 func TestCreateAllSymlinks(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 
 	// Change current directory
@@ -195,18 +200,18 @@ func TestCreateAllSymlinks(t *testing.T) {
 	}
 
 	// Create all symlinks
-	if err := CreateAllSymlinks(); err != nil {
+	if err := e.CreateAllSymlinks(); err != nil {
 		t.Fatalf("CreateAllSymlinks() failed: %v", err)
 	}
 
 	// Check if all symlinks were created correctly
-	valid, missing := CheckAllSymlinks()
+	valid, missing := e.CheckAllSymlinks()
 	if !valid {
 		t.Errorf("Not all symlinks are valid. Missing: %v", missing)
 	}
 
 	// Check if required directories were created
-	requiredDirs := GetRequiredDirectories()
+	requiredDirs := e.GetRequiredDirectories()
 	for _, dir := range requiredDirs {
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			t.Errorf("Required directory %s was not created", dir)
@@ -215,6 +220,7 @@ func TestCreateAllSymlinks(t *testing.T) {
 }
 
 func TestIsSymlinkValid(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 
 	// Create source file
@@ -227,7 +233,7 @@ func TestIsSymlinkValid(t *testing.T) {
 	targetFile := filepath.Join(tempDir, "target.txt")
 
 	// No symlink case
-	if IsSymlinkValid(targetFile, sourceFile) {
+	if e.IsSymlinkValid(targetFile, sourceFile) {
 		t.Error("IsSymlinkValid() should return false for non-existent symlink")
 	}
 
@@ -237,13 +243,13 @@ func TestIsSymlinkValid(t *testing.T) {
 	}
 
 	// Valid symlink
-	if !IsSymlinkValid(targetFile, sourceFile) {
+	if !e.IsSymlinkValid(targetFile, sourceFile) {
 		t.Error("IsSymlinkValid() should return true for valid symlink")
 	}
 
 	// Check with wrong target
 	wrongSource := filepath.Join(tempDir, "wrong.txt")
-	if IsSymlinkValid(targetFile, wrongSource) {
+	if e.IsSymlinkValid(targetFile, wrongSource) {
 		t.Error("IsSymlinkValid() should return false for wrong target")
 	}
 
@@ -254,12 +260,13 @@ func TestIsSymlinkValid(t *testing.T) {
 	}
 
 	// Regular file should return false
-	if IsSymlinkValid(regularFile, sourceFile) {
+	if e.IsSymlinkValid(regularFile, sourceFile) {
 		t.Error("IsSymlinkValid() should return false for regular file")
 	}
 }
 
 func TestSymlinkErrorCases(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 	oldDir, err := os.Getwd()
 	if err != nil {
@@ -277,12 +284,12 @@ func TestSymlinkErrorCases(t *testing.T) {
 	}
 
 	// createSymlink actually creates the parent directory automatically
-	if err := createSymlink("source.txt", "subdir/link.txt"); err != nil {
+	if err := e.createSymlink("source.txt", "subdir/link.txt"); err != nil {
 		t.Errorf("createSymlink should succeed: %v", err)
 	}
 
 	// Test removing non-existent symlink
-	if err := removeSymlink("nonexistent.txt"); err != nil {
+	if err := e.removeSymlink("nonexistent.txt"); err != nil {
 		t.Errorf("removeSymlink should succeed for non-existent file: %v", err)
 	}
 
@@ -293,7 +300,7 @@ func TestSymlinkErrorCases(t *testing.T) {
 	}
 
 	// Try to remove regular file - should fail
-	err = removeSymlink(regularFile)
+	err = e.removeSymlink(regularFile)
 	if err == nil {
 		t.Fatal("SECURITY: removeSymlink should refuse to remove regular files")
 	}
@@ -312,7 +319,7 @@ func TestSymlinkErrorCases(t *testing.T) {
 	}
 
 	// Try to remove directory - should fail
-	err = removeSymlink("testdir")
+	err = e.removeSymlink("testdir")
 	if err == nil {
 		t.Fatal("SECURITY: removeSymlink should refuse to remove directories")
 	}
@@ -327,6 +334,7 @@ func TestSymlinkErrorCases(t *testing.T) {
 }
 
 func TestCreateTargetSymlinksErrors(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 	oldDir, err := os.Getwd()
 	if err != nil {
@@ -339,7 +347,7 @@ func TestCreateTargetSymlinksErrors(t *testing.T) {
 	}
 
 	// Test with invalid target
-	if err := CreateTargetSymlinks("invalid"); err == nil {
+	if err := e.CreateTargetSymlinks("invalid"); err == nil {
 		t.Error("CreateTargetSymlinks should fail for invalid target")
 	}
 
@@ -350,12 +358,13 @@ func TestCreateTargetSymlinksErrors(t *testing.T) {
 
 	// Test without source files - this should actually succeed but symlinks will be broken
 	// The function doesn't validate source file existence before creating symlinks
-	if err := CreateTargetSymlinks("claude"); err != nil {
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
 		t.Logf("CreateTargetSymlinks with missing source files: %v", err)
 	}
 }
 
 func TestCreateSymlinkOverwriteExisting(t *testing.T) {
+	e := New(OSFS{})
 	tempDir := t.TempDir()
 
 	// Create source files
@@ -371,17 +380,17 @@ func TestCreateSymlinkOverwriteExisting(t *testing.T) {
 	}
 
 	// Create first symlink
-	if err := createSymlink(sourceFile1, targetFile); err != nil {
+	if err := e.createSymlink(sourceFile1, targetFile); err != nil {
 		t.Fatalf("Failed to create first symlink: %v", err)
 	}
 
 	// Overwrite with second symlink
-	if err := createSymlink(sourceFile2, targetFile); err != nil {
+	if err := e.createSymlink(sourceFile2, targetFile); err != nil {
 		t.Fatalf("Failed to overwrite symlink: %v", err)
 	}
 
 	// Check if pointing to second source
-	if !IsSymlinkValid(targetFile, sourceFile2) {
+	if !e.IsSymlinkValid(targetFile, sourceFile2) {
 		t.Error("Symlink was not properly overwritten")
 	}
 
@@ -395,3 +404,102 @@ func TestCreateSymlinkOverwriteExisting(t *testing.T) {
 		t.Errorf("Target file content = %s, want content2", string(content))
 	}
 }
+
+func TestCheckLinkInside(t *testing.T) {
+	e := setupProject(t, "rules")
+	outside := t.TempDir()
+
+	if err := e.CheckLinkInside(filepath.Join(".viberules", "rules.md"), "CLAUDE.md"); err != nil {
+		t.Errorf("CheckLinkInside() refused the rules file: %v", err)
+	}
+	for _, link := range []SymlinkDef{
+		{Source: filepath.Join("..", "..", "etc", "passwd"), Target: "CLAUDE.md"},
+		{Source: filepath.Join(outside, "rules.md"), Target: "CLAUDE.md"},
+		{Source: "rules.md", Target: filepath.Join("..", "CLAUDE.md")},
+	} {
+		if err := e.CheckLinkInside(link.Source, link.Target); err == nil {
+			t.Errorf("CheckLinkInside(%s, %s) allowed a link outside the project", link.Source, link.Target)
+		}
+	}
+
+	// A symlinked directory can't carry outputs outside the project
+	if err := os.Symlink(outside, ".amazonq"); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := e.CreateTargetSymlinks("amazonq"); err == nil {
+		t.Error("CreateTargetSymlinks() wrote through a directory linked outside the project")
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("CreateTargetSymlinks() wrote %d entries outside the project", len(entries))
+	}
+
+	// The rules file may itself link to rules kept elsewhere
+	if err := os.Remove(".amazonq"); err != nil {
+		t.Fatalf("Failed to remove .amazonq: %v", err)
+	}
+	shared := filepath.Join(outside, "shared.md")
+	if err := os.WriteFile(shared, []byte("shared rules"), 0644); err != nil {
+		t.Fatalf("Failed to write shared rules: %v", err)
+	}
+	if err := os.Remove(e.RulesFile()); err != nil {
+		t.Fatalf("Failed to remove rules file: %v", err)
+	}
+	if err := os.Symlink(shared, e.RulesFile()); err != nil {
+		t.Fatalf("Failed to link rules file: %v", err)
+	}
+	if err := e.CreateTargetSymlinks("claude"); err != nil {
+		t.Errorf("CreateTargetSymlinks() refused a linked rules file: %v", err)
+	}
+
+	// check reports outputs pointing outside the project
+	if err := os.Remove("GEMINI.md"); err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to remove GEMINI.md: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "other.md"), "GEMINI.md"); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	statuses, err := e.CheckTargetOutputs("gemini", StrategySymlink, nil, nil)
+	if err != nil {
+		t.Fatalf("CheckTargetOutputs() failed: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].State != OutputOutside {
+		t.Errorf("CheckTargetOutputs() = %v, want GEMINI.md outside", statuses)
+	}
+}
+
+func TestCreateAllSymlinksReportsEveryFailure(t *testing.T) {
+	e := New(OSFS{})
+	tempDir := t.TempDir()
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+	writeTestFile(t, ".viberules/rules.md", "test content")
+
+	// Regular files in the way of two targets' links
+	targets := e.Targets()
+	blocked := []Target{targets[0], targets[len(targets)-1]}
+	for _, target := range blocked {
+		writeTestFile(t, target.Links[0].Target, "hand-written")
+	}
+
+	err = e.CreateAllSymlinks()
+	if err == nil {
+		t.Fatal("CreateAllSymlinks() should fail when files are in the way")
+	}
+	for _, target := range blocked {
+		if !strings.Contains(err.Error(), "for "+target.Name+":") {
+			t.Errorf("CreateAllSymlinks() error %q doesn't report %s", err, target.Name)
+		}
+	}
+
+	// The other targets were linked anyway
+	_, missing := e.CheckAllSymlinks()
+	if len(missing) != len(blocked) {
+		t.Errorf("missing links = %v, want only those of %s and %s", missing, blocked[0].Name, blocked[1].Name)
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TargetFilesDir holds per-target additions to the shared rules:
+// <name>.md is appended to the target's output, <name>.prepend.md is prepended.
+var TargetFilesDir = filepath.Join(".viberules", "targets")
+
+// TargetFilePath returns the file appended to a target's output
+func TargetFilePath(targetName string) string {
+	return filepath.Join(TargetFilesDir, targetName+".md")
+}
+
+// TargetPrependFilePath returns the file prepended to a target's output
+func TargetPrependFilePath(targetName string) string {
+	return filepath.Join(TargetFilesDir, targetName+".prepend.md")
+}
+
+// TargetFiles returns the per-target files that exist for targetName
+func (e *Engine) TargetFiles(targetName string) []string {
+	var found []string
+	for _, path := range []string{TargetPrependFilePath(targetName), TargetFilePath(targetName)} {
+		if _, err := e.fs.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// AddTargetFiles returns a transform that wraps the content with the target's
+// prepend and append files, expanding their includes with fetcher
+func (e *Engine) AddTargetFiles(ctx context.Context, fetcher Fetcher) Transform {
+	return func(rc *RenderContext, content []byte) ([]byte, error) {
+		if rc.Part {
+			return content, nil
+		}
+		before, err := e.readTargetFile(ctx, TargetPrependFilePath(rc.Target), fetcher)
+		if err != nil {
+			return nil, err
+		}
+		after, err := e.readTargetFile(ctx, TargetFilePath(rc.Target), fetcher)
+		if err != nil {
+			return nil, err
+		}
+		if before == nil && after == nil {
+			return content, nil
+		}
+
+		var out bytes.Buffer
+		if before != nil {
+			out.Write(bytes.TrimRight(before, "\n"))
+			out.WriteString("\n\n")
+		}
+		out.Write(content)
+		if after != nil {
+			if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+				out.WriteString("\n")
+			}
+			out.WriteString("\n")
+			out.Write(after)
+		}
+		return out.Bytes(), nil
+	}
+}
+
+// readTargetFile returns the expanded content of path, or nil if it doesn't exist
+func (e *Engine) readTargetFile(ctx context.Context, path string, fetcher Fetcher) ([]byte, error) {
+	content, err := e.fs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return e.ExpandIncludes(ctx, content, path, fetcher)
+}
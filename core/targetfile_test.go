@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestAddTargetFiles(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+
+	writeTestFile(t, ".viberules/targets/claude.md", "## Claude\n<!-- viberules:include ../style.md -->\n")
+	writeTestFile(t, ".viberules/targets/claude.prepend.md", "> Read this first\n")
+	writeTestFile(t, ".viberules/style.md", "- Style rule\n")
+
+	content, err := e.AddTargetFiles(context.Background(), nil)(&RenderContext{Target: "claude"}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("AddTargetFiles failed: %v", err)
+	}
+	want := "> Read this first\n\n# Rules\n\n## Claude\n- Style rule\n"
+	if string(content) != want {
+		t.Errorf("Content = %q, want %q", content, want)
+	}
+	if files := e.TargetFiles("claude"); len(files) != 2 {
+		t.Errorf("TargetFiles(claude) = %v, want prepend and append files", files)
+	}
+
+	// Other targets are unaffected
+	content, err = e.AddTargetFiles(context.Background(), nil)(&RenderContext{Target: "gemini"}, []byte("# Rules\n"))
+	if err != nil {
+		t.Fatalf("AddTargetFiles failed: %v", err)
+	}
+	if string(content) != "# Rules\n" {
+		t.Errorf("Content for gemini = %q, want rules unchanged", content)
+	}
+}
+
+func TestGenerateWithTargetFile(t *testing.T) {
+	e := setupProject(t, "# Rules\n")
+	writeTestFile(t, ".viberules/targets/codex.md", "- Codex only\n")
+
+	pipeline := Pipeline{e.AddTargetFiles(context.Background(), nil)}
+	recorded, err := e.GenerateTargetFiles("codex", pipeline, nil, false)
+	if err != nil {
+		t.Fatalf("GenerateTargetFiles failed: %v", err)
+	}
+
+	// Editing the target file makes the output stale
+	if err := os.WriteFile(".viberules/targets/codex.md", []byte("- Changed\n"), 0644); err != nil {
+		t.Fatalf("Failed to update target file: %v", err)
+	}
+	statuses, _ := e.CheckTargetOutputs("codex", StrategyGenerate, pipeline, recorded)
+	if statuses[0].State != OutputStale {
+		t.Errorf("State after target file change = %s, want stale", statuses[0].State)
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import "path/filepath"
+
+// Target represents an AI assistant target with its symlink paths
+type Target struct {
+	Name     string       `yaml:"name" json:"name"`
+	Links    []SymlinkDef `yaml:"links" json:"links"`
+	Nested   bool         `yaml:"nested,omitempty" json:"nested,omitempty"`       // tool also reads its rules file from subdirectories
+	Strategy string       `yaml:"strategy,omitempty" json:"strategy,omitempty"`   // strategy used unless overridden per target, for outputs that need generation
+	PartsDir string       `yaml:"parts_dir,omitempty" json:"parts_dir,omitempty"` // tool loads every file in this directory; RulesDir files are written there separately
+	Imports  bool         `yaml:"imports,omitempty" json:"imports,omitempty"`     // tool expands @path imports in its rules file
+
+	// LocalLinks point the tool's personal rules file at .viberules/rules.local.md
+	LocalLinks []SymlinkDef `yaml:"local_links,omitempty" json:"local_links,omitempty"`
+}
+
+// SymlinkDef defines a symlink mapping
+type SymlinkDef struct {
+	Source string `yaml:"source" json:"source"` // relative path to single rules file
+	Target string `yaml:"target" json:"target"` // destination path for the symlink
+	Part   bool   `yaml:"-" json:"-"`           // a RulesDir file written into the target's PartsDir
+}
+
+// ResolvedSource returns the source path relative to the project root.
+// Source is relative to the directory containing the symlink.
+func (d SymlinkDef) ResolvedSource() string {
+	return filepath.Join(filepath.Dir(d.Target), d.Source)
+}
+
+// Scope selects which set of targets core operations manage
+type Scope int
+
+const (
+	ScopeProject Scope = iota // project files relative to the project root
+	ScopeGlobal               // user-level files relative to the home directory
+)
+
+// SetScope switches the target registry used by the engine.
+// The root of its filesystem is expected to be the root of the selected scope.
+func (e *Engine) SetScope(s Scope) {
+	e.scope = s
+}
+
+// Scope returns the active scope
+func (e *Engine) Scope() Scope {
+	return e.scope
+}
+
+// Targets returns the targets of the active scope, with the configured rules
+// file and output path overrides applied
+func (e *Engine) Targets() []Target {
+	targets := e.defaultTargets()
+	for i, target := range targets {
+		if e.rulesFile != DefaultRulesFile {
+			target = target.withRulesFile(e.rulesFile)
+		}
+		if path, ok := e.outputPaths[target.Name]; ok {
+			target = target.withOutputPath(path)
+		}
+		targets[i] = target
+	}
+	return targets
+}
+
+// defaultTargets returns the targets of the active scope as they are built in
+func (e *Engine) defaultTargets() []Target {
+	if e.scope == ScopeGlobal {
+		return e.GetGlobalTargets()
+	}
+	return e.GetAllTargets()
+}
+
+// TargetNames returns the names of the targets of the active scope
+func (e *Engine) TargetNames() []string {
+	var names []string
+	for _, target := range e.Targets() {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+// FindTarget returns the target of the active scope with the given name
+func (e *Engine) FindTarget(name string) (Target, bool) {
+	for _, target := range e.Targets() {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return Target{}, false
+}
+
+// GetAllTargets returns all supported AI assistant targets, as listed in the
+// project scope of the target registry
+func (e *Engine) GetAllTargets() []Target {
+	return e.registry.Project.clone().Targets
+}
+
+// GetGlobalTargets returns user-level assistant targets, as listed in the
+// global scope of the target registry.
+// Paths are relative to the home directory, where the rules live in ~/.viberules/rules.md.
+func (e *Engine) GetGlobalTargets() []Target {
+	return e.registry.Global.clone().Targets
+}
+
+// GetRequiredDirectories returns directories that need to be created
+func (e *Engine) GetRequiredDirectories() []string {
+	if e.scope == ScopeGlobal {
+		return e.registry.Global.clone().RequiredDirs
+	}
+	return e.registry.Project.clone().RequiredDirs
+}
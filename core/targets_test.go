@@ -7,15 +7,16 @@ import (
 )
 
 func TestGetAllTargets(t *testing.T) {
-	targets := GetAllTargets()
+	e := New(OSFS{})
+	targets := e.GetAllTargets()
 
-	// Should have 4 targets
-	if len(targets) != 4 {
-		t.Errorf("GetAllTargets() = %d targets, want 4", len(targets))
+	// Should have 5 targets
+	if len(targets) != 5 {
+		t.Errorf("GetAllTargets() = %d targets, want 5", len(targets))
 	}
 
 	// Each target should have correct name
-	expectedNames := []string{"claude", "amazonq", "gemini", "codex"}
+	expectedNames := []string{"claude", "amazonq", "gemini", "codex", "cursor"}
 	var actualNames []string
 	for _, target := range targets {
 		actualNames = append(actualNames, target.Name)
@@ -36,7 +37,8 @@ func TestGetAllTargets(t *testing.T) {
 }
 
 func TestTargetStructure(t *testing.T) {
-	targets := GetAllTargets()
+	e := New(OSFS{})
+	targets := e.GetAllTargets()
 
 	tests := []struct {
 		name              string
@@ -73,6 +75,13 @@ func TestTargetStructure(t *testing.T) {
 			expectedSources:   []string{filepath.Join(".viberules", "rules.md")},
 			expectedTargets:   []string{"AGENTS.md"},
 		},
+		{
+			name:              "cursor target",
+			targetName:        "cursor",
+			expectedLinkCount: 1,
+			expectedSources:   []string{filepath.Join("..", "..", ".viberules", "rules.md")},
+			expectedTargets:   []string{filepath.Join(".cursor", "rules", "viberules.mdc")},
+		},
 	}
 
 	for _, tt := range tests {
@@ -112,7 +121,8 @@ func TestTargetStructure(t *testing.T) {
 }
 
 func TestGetRequiredDirectories(t *testing.T) {
-	dirs := GetRequiredDirectories()
+	e := New(OSFS{})
+	dirs := e.GetRequiredDirectories()
 
 	expectedDirs := []string{
 		filepath.Join(".amazonq", "rules"),
@@ -122,3 +132,31 @@ func TestGetRequiredDirectories(t *testing.T) {
 		t.Errorf("GetRequiredDirectories() = %v, want %v", dirs, expectedDirs)
 	}
 }
+
+func TestScopeSelectsTargets(t *testing.T) {
+	e := New(OSFS{})
+
+	if _, ok := e.FindTarget("amazonq"); !ok {
+		t.Error("amazonq should be a project target")
+	}
+
+	e.SetScope(ScopeGlobal)
+	if _, ok := e.FindTarget("amazonq"); ok {
+		t.Error("amazonq should not be a global target")
+	}
+
+	target, ok := e.FindTarget("claude")
+	if !ok {
+		t.Fatal("claude should be a global target")
+	}
+	link := target.Links[0]
+	if link.Target != filepath.Join(".claude", "CLAUDE.md") {
+		t.Errorf("Global claude target = %s, want .claude/CLAUDE.md", link.Target)
+	}
+	if link.ResolvedSource() != filepath.Join(".viberules", "rules.md") {
+		t.Errorf("Global claude source resolves to %s, want .viberules/rules.md", link.ResolvedSource())
+	}
+	if dirs := e.GetRequiredDirectories(); len(dirs) != 0 {
+		t.Errorf("GetRequiredDirectories() in global scope = %v, want none", dirs)
+	}
+}
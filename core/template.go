@@ -0,0 +1,118 @@
+package core
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/*.md
+var builtinTemplates embed.FS
+
+// DefaultTemplate seeds rules.md when no template is requested
+const DefaultTemplate = "default"
+
+// templateDescriptions describe the built-in templates
+var templateDescriptions = map[string]string{
+	"default":    "Generic starting point for any project",
+	"go-backend": "Go services: error handling, context, table-driven tests",
+	"react":      "React with TypeScript: hooks, testing library, accessibility",
+	"python-ml":  "Python machine learning: typing, reproducible experiments, data hygiene",
+}
+
+// RulesTemplate is a starting point for rules.md
+type RulesTemplate struct {
+	Name        string
+	Description string
+	Path        string // file of a user template, empty for built-ins
+}
+
+// Builtin reports whether the template ships with viberules
+func (t RulesTemplate) Builtin() bool {
+	return t.Path == ""
+}
+
+// UserTemplatesDir returns the directory holding user-registered templates
+func UserTemplatesDir(home string) string {
+	return filepath.Join(home, ".viberules", "templates")
+}
+
+// ListTemplates returns built-in and user templates sorted by name.
+// A user template with the name of a built-in one replaces it.
+func (e *Engine) ListTemplates(home string) ([]RulesTemplate, error) {
+	byName := make(map[string]RulesTemplate)
+
+	entries, err := fs.ReadDir(builtinTemplates, "templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in templates: %w", err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".md")
+		byName[name] = RulesTemplate{Name: name, Description: templateDescriptions[name]}
+	}
+
+	dir := UserTemplatesDir(home)
+	entries, err = e.fs.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read user templates: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".md")
+		byName[name] = RulesTemplate{Name: name, Description: "User template", Path: filepath.Join(dir, entry.Name())}
+	}
+
+	templates := make([]RulesTemplate, 0, len(byName))
+	for _, template := range byName {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// LoadTemplate returns the content of the named template.
+// User templates take precedence over built-in ones; with an empty home,
+// only built-in templates are found.
+func (e *Engine) LoadTemplate(home, name string) ([]byte, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, fmt.Errorf("invalid template name: %s", name)
+	}
+
+	if home != "" {
+		content, err := e.fs.ReadFile(filepath.Join(UserTemplatesDir(home), name+".md"))
+		if err == nil {
+			return content, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+
+	content, err := builtinTemplates.ReadFile("templates/" + name + ".md")
+	if err != nil {
+		return nil, fmt.Errorf("template %s not found. Run 'viberules templates list' to see available templates", name)
+	}
+	return content, nil
+}
+
+// AddTemplate registers content as a user template
+func (e *Engine) AddTemplate(home, name string, content []byte) error {
+	if err := ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid template name: %s (use letters, digits, '-' and '_')", name)
+	}
+
+	dir := UserTemplatesDir(home)
+	if err := e.fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	if err := e.fs.WriteFile(filepath.Join(dir, name+".md"), content, 0644); err != nil {
+		return fmt.Errorf("failed to write template %s: %w", name, err)
+	}
+	return nil
+}
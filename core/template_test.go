@@ -0,0 +1,66 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadBuiltinTemplates(t *testing.T) {
+	e := New(OSFS{})
+	home := t.TempDir()
+
+	for _, name := range []string{"default", "go-backend", "react", "python-ml"} {
+		content, err := e.LoadTemplate(home, name)
+		if err != nil {
+			t.Fatalf("LoadTemplate(%s) failed: %v", name, err)
+		}
+		if !strings.HasPrefix(string(content), "# ") {
+			t.Errorf("Template %s should start with a heading", name)
+		}
+	}
+
+	if _, err := e.LoadTemplate(home, "missing"); err == nil {
+		t.Error("LoadTemplate should fail for an unknown template")
+	}
+	if _, err := e.LoadTemplate(home, "../etc/passwd"); err == nil {
+		t.Error("LoadTemplate should reject path-like names")
+	}
+}
+
+func TestUserTemplates(t *testing.T) {
+	e := New(OSFS{})
+	home := t.TempDir()
+
+	if err := e.AddTemplate(home, "team", []byte("# Team rules\n")); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+	// User templates replace built-in ones of the same name
+	if err := os.WriteFile(filepath.Join(UserTemplatesDir(home), "react.md"), []byte("# Our React\n"), 0644); err != nil {
+		t.Fatalf("Failed to write user template: %v", err)
+	}
+
+	content, err := e.LoadTemplate(home, "react")
+	if err != nil || string(content) != "# Our React\n" {
+		t.Errorf("LoadTemplate(react) = %q, %v; want the user template", content, err)
+	}
+
+	templates, err := e.ListTemplates(home)
+	if err != nil {
+		t.Fatalf("ListTemplates failed: %v", err)
+	}
+	found := make(map[string]RulesTemplate)
+	for _, template := range templates {
+		found[template.Name] = template
+	}
+	if team, ok := found["team"]; !ok || team.Builtin() {
+		t.Errorf("ListTemplates should include user template team, got %+v", team)
+	}
+	if found["react"].Builtin() {
+		t.Error("react should be listed as a user template once overridden")
+	}
+	if !found["go-backend"].Builtin() {
+		t.Error("go-backend should be listed as a built-in template")
+	}
+}
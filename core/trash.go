@@ -0,0 +1,66 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// SetTrash makes files edited by hand or not created by viberules go to the
+// platform trash when an output overwrites or removes them, so they can be
+// recovered. Copies identical to what viberules wrote are still deleted.
+func (e *Engine) SetTrash(enabled bool) {
+	e.trash = enabled
+}
+
+// trasher is implemented by filesystems that take over moving files to the
+// trash, such as a PlanFS planning to
+type trasher interface {
+	Trash(name string) error
+}
+
+// moveToTrash moves a file to the platform trash
+func (e *Engine) moveToTrash(path string) error {
+	if t, ok := e.fs.(trasher); ok {
+		return t.Trash(path)
+	}
+	abs, err := e.fs.Abs(path)
+	if err != nil {
+		return err
+	}
+	if err := trashFile(abs); err != nil {
+		return fmt.Errorf("failed to move %s to the trash: %w", path, err)
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying it when they are on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
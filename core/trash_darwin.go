@@ -0,0 +1,32 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// trashFile moves a file to ~/.Trash, numbering its name like Finder when
+// the trash already holds one of the same name
+func trashFile(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(home, ".Trash")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	name := base
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s %d%s", strings.TrimSuffix(base, ext), i, ext)
+	}
+	return moveFile(path, filepath.Join(dir, name))
+}
@@ -0,0 +1,62 @@
+//go:build !darwin
+
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// trashFile moves a file to the home trash of the freedesktop.org trash
+// spec, $XDG_DATA_HOME/Trash, recording where it came from so file managers
+// can restore it
+func trashFile(path string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	trash := filepath.Join(dataHome, "Trash")
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trash, dir), 0700); err != nil {
+			return err
+		}
+	}
+
+	// The info file is created first and exclusively, reserving the name
+	base := filepath.Base(path)
+	name := base
+	var info *os.File
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(filepath.Join(trash, "info", name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			info = f
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+	infoPath := info.Name()
+
+	_, err := fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	if closeErr := info.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = moveFile(path, filepath.Join(trash, "files", name))
+	}
+	if err != nil {
+		os.Remove(infoPath)
+		return err
+	}
+	return nil
+}
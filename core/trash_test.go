@@ -0,0 +1,62 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTrashEditedCopies(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("the freedesktop.org trash is not used on macOS")
+	}
+	e := setupProject(t, "rules v2")
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	e.SetTrash(true)
+
+	// An edited copy is overwritten, and its content kept in the trash
+	if err := os.WriteFile("CLAUDE.md", []byte("edited by hand"), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+	recorded := Checksum([]byte("rules v1"))
+	if _, err := e.WriteManagedFile("CLAUDE.md", []byte("rules v2"), recorded, true); err != nil {
+		t.Fatalf("WriteManagedFile() failed: %v", err)
+	}
+	trashed, err := os.ReadFile(filepath.Join(dataHome, "Trash", "files", "CLAUDE.md"))
+	if err != nil || string(trashed) != "edited by hand" {
+		t.Errorf("trashed CLAUDE.md = %q, %v", trashed, err)
+	}
+	cwd, _ := os.Getwd()
+	info, err := os.ReadFile(filepath.Join(dataHome, "Trash", "info", "CLAUDE.md.trashinfo"))
+	if err != nil || !strings.HasPrefix(string(info), "[Trash Info]\nPath="+filepath.Join(cwd, "CLAUDE.md")+"\n") {
+		t.Errorf("trash info = %q, %v", info, err)
+	}
+
+	// A second file of the same name gets a numbered name
+	if err := os.WriteFile("CLAUDE.md", []byte("edited again"), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+	if err := e.RemoveManagedFile("CLAUDE.md", Checksum([]byte("rules v2")), true); err != nil {
+		t.Fatalf("RemoveManagedFile() failed: %v", err)
+	}
+	if _, err := os.Lstat("CLAUDE.md"); !os.IsNotExist(err) {
+		t.Error("RemoveManagedFile() left CLAUDE.md")
+	}
+	if trashed, err := os.ReadFile(filepath.Join(dataHome, "Trash", "files", "CLAUDE.md.2")); err != nil || string(trashed) != "edited again" {
+		t.Errorf("second trashed CLAUDE.md = %q, %v", trashed, err)
+	}
+
+	// Unchanged copies can be written again and are simply deleted
+	if err := os.WriteFile("GEMINI.md", []byte("rules v2"), 0644); err != nil {
+		t.Fatalf("Failed to write GEMINI.md: %v", err)
+	}
+	if err := e.RemoveManagedFile("GEMINI.md", Checksum([]byte("rules v2")), true); err != nil {
+		t.Fatalf("RemoveManagedFile() failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dataHome, "Trash", "files")); len(entries) != 2 {
+		t.Errorf("trash holds %d files, want 2", len(entries))
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+)
+
+// UserLinkTarget is a tool that only loads rules from a user-level directory.
+// Projects are linked into that directory with one entry per project.
+type UserLinkTarget struct {
+	Name string
+	Dir  string // rules directory relative to the home directory
+}
+
+// GetUserLinkTargets returns tools that read rule files from the home directory
+func GetUserLinkTargets() []UserLinkTarget {
+	return []UserLinkTarget{
+		{Name: "cline", Dir: filepath.Join("Documents", "Cline", "Rules")},
+		{Name: "roo", Dir: filepath.Join(".roo", "rules")},
+		{Name: "continue", Dir: filepath.Join(".continue", "rules")},
+	}
+}
+
+// FindUserLinkTarget returns the user link target with the given name
+func FindUserLinkTarget(name string) (UserLinkTarget, bool) {
+	for _, target := range GetUserLinkTargets() {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return UserLinkTarget{}, false
+}
+
+// UserLinkPath returns the entry path for a project inside the tool's directory.
+// The name combines the project directory name with a hash of its absolute path
+// so projects with the same name don't collide.
+func (t UserLinkTarget) UserLinkPath(home, projectRoot string) string {
+	sum := sha256.Sum256([]byte(projectRoot))
+	name := fmt.Sprintf("%s-%s.md", filepath.Base(projectRoot), hex.EncodeToString(sum[:])[:8])
+	return filepath.Join(home, t.Dir, name)
+}
+
+// CreateUserLink links the project's rules file into the tool's user-level directory
+// and returns the path of the created entry
+func (e *Engine) CreateUserLink(home, targetName, projectRoot string) (string, error) {
+	target, ok := FindUserLinkTarget(targetName)
+	if !ok {
+		return "", WithKind(ErrUnknownTarget, fmt.Errorf("user link target %s not found", targetName))
+	}
+
+	linkPath := target.UserLinkPath(home, projectRoot)
+	source := filepath.Join(projectRoot, e.rulesFile)
+	if err := e.createSymlink(source, linkPath); err != nil {
+		return "", err
+	}
+
+	return linkPath, nil
+}
+
+// RemoveUserLink removes an entry created by CreateUserLink
+func (e *Engine) RemoveUserLink(linkPath string) error {
+	return e.removeSymlink(linkPath)
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateAndRemoveUserLink(t *testing.T) {
+	e := setupProject(t, "rules")
+	home := t.TempDir()
+
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+
+	linkPath, err := e.CreateUserLink(home, "roo", projectRoot)
+	if err != nil {
+		t.Fatalf("CreateUserLink(roo) failed: %v", err)
+	}
+
+	if !strings.HasPrefix(linkPath, filepath.Join(home, ".roo", "rules")) {
+		t.Errorf("User link %s should be inside ~/.roo/rules", linkPath)
+	}
+	if !e.IsSymlinkValid(linkPath, filepath.Join(projectRoot, ".viberules", "rules.md")) {
+		t.Error("User link should point at the project's rules.md")
+	}
+
+	if err := e.RemoveUserLink(linkPath); err != nil {
+		t.Fatalf("RemoveUserLink failed: %v", err)
+	}
+	if _, err := os.Lstat(linkPath); !os.IsNotExist(err) {
+		t.Error("User link was not removed")
+	}
+
+	if _, err := e.CreateUserLink(home, "invalid", projectRoot); err == nil {
+		t.Error("CreateUserLink should fail for unknown targets")
+	}
+}
+
+func TestUserLinkPathIsUniquePerProject(t *testing.T) {
+	target, _ := FindUserLinkTarget("cline")
+
+	a := target.UserLinkPath("/home/u", "/work/a/app")
+	b := target.UserLinkPath("/home/u", "/work/b/app")
+	if a == b {
+		t.Errorf("Projects with the same name should get different entries, both got %s", a)
+	}
+	if !strings.HasPrefix(filepath.Base(a), "app-") {
+		t.Errorf("Entry name %s should start with the project name", filepath.Base(a))
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// variablePattern matches {{.Name}} with optional spaces inside the braces
+var variablePattern = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// ProjectVariables returns the built-in variables of the project rooted at dir:
+// ProjectName, GitRemote (empty outside a repository) and Date (YYYY-MM-DD),
+// the date the rules last changed.
+func (e *Engine) ProjectVariables(dir string) map[string]string {
+	abs, err := e.fs.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+
+	return map[string]string{
+		"ProjectName": filepath.Base(abs),
+		"GitRemote":   gitRemote(abs),
+		"Date":        e.rulesDate(abs),
+	}
+}
+
+// rulesDate returns the date of the last commit of the rules file of the
+// project at dir, or its modification date while it isn't committed. Unlike
+// today's date it only moves with the rules, so outputs using it don't go
+// stale overnight.
+func (e *Engine) rulesDate(dir string) string {
+	path := e.rulesFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if out, err := runGit(dir, "log", "-1", "--format=%cs", "--", path); err == nil {
+		if date := strings.TrimSpace(string(out)); date != "" {
+			return date
+		}
+	}
+	if info, err := e.fs.Stat(path); err == nil {
+		return info.ModTime().Format("2006-01-02")
+	}
+	return time.Now().Format("2006-01-02")
+}
+
+// gitRemote returns the origin URL of the repository containing dir without credentials
+func gitRemote(dir string) string {
+	out, err := runGit(dir, "config", "--get", "remote.origin.url")
+	if err != nil {
+		return ""
+	}
+	remote := strings.TrimSpace(string(out))
+	if u, err := url.Parse(remote); err == nil && u.User != nil {
+		u.User = nil
+		remote = u.String()
+	}
+	return remote
+}
+
+// SubstituteVariables returns a transform that replaces {{.Name}} with the
+// value of vars["Name"]. {{.Target}} is the name of the target being rendered.
+// Unknown variables are left untouched so template syntax in code samples survives.
+func SubstituteVariables(vars map[string]string) Transform {
+	return func(ctx *RenderContext, content []byte) ([]byte, error) {
+		return variablePattern.ReplaceAllFunc(content, func(match []byte) []byte {
+			name := string(variablePattern.FindSubmatch(match)[1])
+			if name == "Target" && ctx.Target != "" {
+				return []byte(ctx.Target)
+			}
+			if value, ok := vars[name]; ok {
+				return []byte(value)
+			}
+			return match
+		}), nil
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// docsCmd generates reference documentation from the command tree, for
+// packagers and teams that ship it. It is hidden and left out of the output.
+var docsCmd = &cobra.Command{
+	Use:    "docs",
+	Short:  "Generate man pages or markdown reference documentation",
+	Hidden: true,
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man [dir]",
+	Short: "Write a man page per command to dir (default: current directory)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := docsDir(args)
+		if err != nil {
+			return err
+		}
+		header := &doc.GenManHeader{Title: "VIBERULES", Section: "1", Source: "viberules " + version}
+		if err := doc.GenManTree(docsRoot(cmd), header, dir); err != nil {
+			return fmt.Errorf("failed to generate man pages: %w", err)
+		}
+		ui.Success("Man pages written to %s\n", dir)
+		return nil
+	},
+}
+
+var docsMarkdownCmd = &cobra.Command{
+	Use:   "markdown [dir]",
+	Short: "Write a markdown page per command to dir (default: current directory)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := docsDir(args)
+		if err != nil {
+			return err
+		}
+		if err := doc.GenMarkdownTree(docsRoot(cmd), dir); err != nil {
+			return fmt.Errorf("failed to generate markdown: %w", err)
+		}
+		ui.Success("Markdown reference written to %s\n", dir)
+		return nil
+	},
+}
+
+// docsRoot returns the root command, without the generation date so
+// generated files only change when the commands do
+func docsRoot(cmd *cobra.Command) *cobra.Command {
+	root := cmd.Root()
+	root.DisableAutoGenTag = true
+	return root
+}
+
+// docsDir returns the directory documentation is written to, creating it
+func docsDir(args []string) (string, error) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find files and filesystems that keep assistants from reading the managed rules",
+	Long: `Look for problems outside the outputs that 'viberules status' reports.
+
+Filesystem: environments known to break symlinks or make outputs churn,
+with the link strategy that avoids them:
+- filesystems without symlinks, such as exFAT and FAT volumes
+- network mounts (NFS, SMB, AFP, WebDAV) and Windows drives under WSL
+- folders synced by Dropbox, OneDrive, iCloud Drive or Google Drive
+- files whose names differ from an output only in case, as claude.md and
+  CLAUDE.md, which are one file on case-insensitive filesystems
+
+Links outside the project: outputs that are symlinks resolving outside the
+project root, so assistants read rules viberules doesn't manage. 'viberules
+sync' replaces them.
+
+Legacy files: rules files of older conventions that assistants read on top
+of, or instead of, the outputs viberules manages, so the tools see rules
+nobody maintains:
+- .cursorrules, deprecated by .cursor/rules
+- .windsurfrules, deprecated by .windsurf/rules
+- a GEMINI.md at the root that no enabled target writes, next to .gemini/
+
+With --adopt, their content is merged into the rules file as 'init --adopt'
+does and they are moved to .viberules/backup/<timestamp>/. With --remove,
+they are only moved to the backup directory. With --json, the problems are
+printed as JSON.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if doctorAdopt && doctorRemove {
+			return fmt.Errorf("--adopt and --remove cannot be used together")
+		}
+		if jsonOutput && (doctorAdopt || doctorRemove) {
+			return fmt.Errorf("--json cannot be used with --adopt or --remove")
+		}
+		return runDoctor()
+	},
+}
+
+// doctorAdopt and doctorRemove are set by doctor --adopt and --remove
+var doctorAdopt, doctorRemove bool
+
+// doctorReport is the JSON form of doctor
+type doctorReport struct {
+	Filesystem []fsProblemReport `json:"filesystem"`
+	Legacy     []core.LegacyFile `json:"legacy"`
+	Outside    []outputReport    `json:"outside"`
+	Issues     int               `json:"issues"`
+}
+
+// fsProblemReport is the JSON form of a filesystem problem
+type fsProblemReport struct {
+	Message  string `json:"message"`
+	Symlinks bool   `json:"symlinks"` // avoided by the generate strategy
+}
+
+func runDoctor() error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	fsProblems, err := filesystemProblems(config)
+	if err != nil {
+		return err
+	}
+	legacy, err := engine.FindLegacyFiles(config.Targets, config.Checksums)
+	if err != nil {
+		return err
+	}
+	outside, err := outsideLinks(config)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		report := doctorReport{Filesystem: []fsProblemReport{}, Legacy: append([]core.LegacyFile{}, legacy...), Outside: outside}
+		for _, problem := range fsProblems {
+			report.Filesystem = append(report.Filesystem, fsProblemReport{Message: problem.Message, Symlinks: problem.Symlinks})
+		}
+		report.Issues = len(fsProblems) + len(legacy) + len(outside)
+		return printJSON(report)
+	}
+	if len(fsProblems) == 0 && len(legacy) == 0 && len(outside) == 0 {
+		ui.Success("No problems found\n")
+		return nil
+	}
+
+	sections := 0
+	section := func(heading string) {
+		if sections > 0 {
+			ui.Print("\n")
+		}
+		sections++
+		ui.Print("%s\n", heading)
+	}
+	if len(fsProblems) > 0 {
+		section("Filesystem:")
+		recommend := false
+		for _, problem := range fsProblems {
+			ui.Print("  ⚠️  %s\n", problem.Message)
+			recommend = recommend || problem.Symlinks
+		}
+		if recommend {
+			ui.Print("\nRun 'viberules strategy generate' so outputs are real files and %s is merged into them instead of linked\n", core.LocalRulesFile)
+		}
+	}
+	if len(outside) > 0 {
+		section("Links outside the project:")
+		for _, link := range outside {
+			ui.Print("  ⚠️  %s (%s) resolves outside the project\n", paint(colorYellow, link.Path), link.Target)
+		}
+		ui.Print("\nRun 'viberules sync' to replace them with links inside the project\n")
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	section("Legacy files:")
+	paths := make([]string, len(legacy))
+	for i, file := range legacy {
+		paths[i] = file.Path
+		ui.Print("  ⚠️  %s: %s\n", paint(colorYellow, file.Path), file.Reason)
+	}
+	if !doctorAdopt && !doctorRemove {
+		ui.Print("\nRun 'viberules doctor --adopt' to merge them into %s, or 'viberules doctor --remove' to move them to %s\n", engine.RulesFile(), core.BackupDir)
+		return nil
+	}
+
+	ok, err := confirm("These files will be moved to "+core.BackupDir+":", paths)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errAborted
+	}
+	if doctorAdopt {
+		return adoptLegacyFiles(paths)
+	}
+	return backupLegacyFiles(paths)
+}
+
+// outsideLinks returns the outputs that are symlinks resolving outside the
+// project, which assistants would read rules from
+func outsideLinks(config *Config) ([]outputReport, error) {
+	statuses, err := collectStatus(config)
+	if err != nil {
+		return nil, err
+	}
+	outside := []outputReport{}
+	for _, status := range statuses {
+		if status.State == core.OutputOutside {
+			outside = append(outside, outputReport{
+				Target:   status.Target,
+				Path:     filepath.ToSlash(status.Path),
+				Strategy: status.Strategy,
+				State:    status.State.String(),
+			})
+		}
+	}
+	return outside, nil
+}
+
+// filesystemProblems returns the problems of the project's filesystem that
+// affect it: those of symlinks only while some target still links
+func filesystemProblems(config *Config) ([]core.FSProblem, error) {
+	problems, err := engine.CheckFilesystem(config.Targets)
+	if err != nil {
+		return nil, err
+	}
+	links := false
+	for _, target := range config.Targets {
+		links = links || config.strategyFor(target) != core.StrategyGenerate
+	}
+	var affecting []core.FSProblem
+	for _, problem := range problems {
+		if links || !problem.Symlinks {
+			affecting = append(affecting, problem)
+		}
+	}
+	return affecting, nil
+}
+
+// adoptLegacyFiles merges legacy files into the rules file, backs them up and
+// regenerates the outputs that hold a copy of the rules
+func adoptLegacyFiles(paths []string) error {
+	rules, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", engine.RulesFile(), err)
+	}
+	merged, adopted, err := engine.AdoptFiles(rules, paths)
+	if err != nil {
+		return err
+	}
+	if err := engine.FS().WriteFile(engine.RulesFile(), merged, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", engine.RulesFile(), err)
+	}
+	if err := backupLegacyFiles(adopted); err != nil {
+		return err
+	}
+	for _, path := range adopted {
+		ui.Info("📥 Imported %s into %s\n", path, engine.RulesFile())
+	}
+	_, err = syncProject()
+	return err
+}
+
+// backupLegacyFiles moves legacy files to a backup directory
+func backupLegacyFiles(paths []string) error {
+	dir := core.NewBackupDir(time.Now())
+	for _, path := range paths {
+		if err := engine.BackupFile(path, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// dryRun is set by --dry-run: the changes commands plan are printed instead
+// of made
+var dryRun bool
+
+// dryRunCommands are the commands --dry-run applies to
+var dryRunCommands = []*cobra.Command{initCmd, addCmd, removeCmd, modeCmd, syncCmd, applyCmd}
+
+// planning is the plan commands are writing to while one runs, so engines
+// made for other projects of a --recursive run write to it too
+var planning *core.PlanFS
+
+// planCommand makes cmd plan its changes before making them: it runs against
+// a plan of the project, and the planned changes are printed with --dry-run
+// or made otherwise, recorded in the journal when cmd is journaled. A
+// command that fails keeps the changes made before the failure, unless it is
+// transactional.
+func planCommand(cmd *cobra.Command) {
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ops, runErr := planRun(func() error { return run(cmd, args) })
+		if dryRun {
+			if runErr != nil {
+				return runErr
+			}
+			printPlan(ops)
+			return nil
+		}
+		if runErr != nil && isTransactional(cmd) {
+			return runErr
+		}
+		if err := applyPlan(cmd, args, ops); err != nil {
+			return err
+		}
+		return runErr
+	}
+}
+
+// supportsDryRun reports whether cmd honors --dry-run
+func supportsDryRun(cmd *cobra.Command) bool {
+	for _, supported := range dryRunCommands {
+		if cmd == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDryRun fails if --dry-run is given to a command that doesn't support it
+func checkDryRun(cmd *cobra.Command) error {
+	if !dryRun {
+		return nil
+	}
+	if globalMode {
+		return fmt.Errorf("--dry-run is not supported with --global")
+	}
+	if supportsDryRun(cmd) {
+		return nil
+	}
+	return fmt.Errorf("--dry-run is not supported by '%s'", cmd.CommandPath())
+}
+
+// planRun runs a command against a plan of the project, with its output
+// discarded for a dry run, and returns the changes it planned
+func planRun(run func() error) ([]core.FileOp, error) {
+	plan, err := core.NewPlanFS(engine.FS())
+	if err != nil {
+		return nil, err
+	}
+	planning = plan
+	restore := useEngine(engine.WithFS(plan))
+	defer func() {
+		restore()
+		planning = nil
+	}()
+
+	if dryRun {
+		err = discardOutput(run)
+	} else {
+		err = run()
+	}
+	ops, planErr := plan.Plan()
+	if planErr != nil {
+		return nil, planErr
+	}
+	if config, configErr := loadConfig(); configErr == nil {
+		engine.LabelOutputs(ops, config.strategyFor)
+	}
+	return ops, err
+}
+
+// discardOutput runs fn with everything but warnings discarded
+func discardOutput(fn func() error) error {
+	previous := ui
+	ui = &terminalUI{out: io.Discard}
+	defer func() { ui = previous }()
+	return fn()
+}
+
+// printPlan prints the changes a dry run planned, with paths relative to
+// the project. Directories created to hold files aren't listed.
+func printPlan(ops []core.FileOp) {
+	var shown []core.FileOp
+	for _, op := range ops {
+		if !(op.Action == core.PlanCreate && op.Mode.IsDir()) {
+			shown = append(shown, op)
+		}
+	}
+	if len(shown) == 0 {
+		ui.Print("Dry run: nothing would change\n")
+		return
+	}
+	ui.Print("Dry run: these changes would be made\n")
+	root := projectDir()
+	for _, op := range shown {
+		line := fmt.Sprintf("  %-7s %s", op.Action, displayPath(root, op.Path))
+		if op.Link != "" {
+			line += " -> " + op.Link
+		}
+		if op.Target != "" {
+			line += fmt.Sprintf(" (%s, %s)", op.Target, op.Strategy)
+		}
+		if op.Trash {
+			line += " [previous content to the trash]"
+		}
+		ui.Print("%s\n", line)
+	}
+}
+
+// displayPath returns path relative to root when it is below it
+func displayPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// Environment variables override .viberules/.config.yaml at runtime, so CI
+// jobs and containers can adjust behavior without changing the repository.
+// Overridden values are never written back to the config file.
+const (
+	envMode         = "VIBERULES_MODE"          // public or local
+	envTargets      = "VIBERULES_TARGETS"       // comma-separated target names
+	envLinkStrategy = "VIBERULES_LINK_STRATEGY" // project link strategy
+	envOffline      = "VIBERULES_OFFLINE"       // true or false; see Config.offline
+)
+
+// envOverrides remembers the config values replaced by environment variables
+type envOverrides struct {
+	vars []string // variables in effect, in the order they were applied
+	file Config   // Mode, Targets and LinkStrategy as read from the file
+	env  Config   // the same fields after the overrides
+}
+
+// applyEnvOverrides replaces config values with those set in the environment
+func applyEnvOverrides(config *Config) error {
+	overrides := &envOverrides{
+		file: Config{Mode: config.Mode, Targets: config.Targets, LinkStrategy: config.LinkStrategy},
+	}
+
+	if value, ok := os.LookupEnv(envMode); ok && value != "" {
+		if value != "public" && value != "local" {
+			return fmt.Errorf("invalid %s: %s (must be 'public' or 'local')", envMode, value)
+		}
+		config.Mode = value
+		overrides.vars = append(overrides.vars, envMode)
+	}
+
+	if value, ok := os.LookupEnv(envTargets); ok && value != "" {
+		var targets []string
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || containsName(targets, name) {
+				continue
+			}
+			if !isValidTarget(name) {
+				if suggestion := suggestName(name, engine.TargetNames()); suggestion != "" {
+					return core.WithKind(core.ErrUnknownTarget, fmt.Errorf("invalid %s: unknown target '%s', did you mean '%s'?", envTargets, name, suggestion))
+				}
+				return core.WithKind(core.ErrUnknownTarget, fmt.Errorf("invalid %s: unknown target %s (available: %s)", envTargets, name, strings.Join(engine.TargetNames(), ", ")))
+			}
+			targets = append(targets, name)
+		}
+		config.Targets = targets
+		overrides.vars = append(overrides.vars, envTargets)
+	}
+
+	if value, ok := os.LookupEnv(envLinkStrategy); ok && value != "" {
+		if !core.IsValidStrategy(value) {
+			return fmt.Errorf("invalid %s: %s", envLinkStrategy, value)
+		}
+		config.LinkStrategy = value
+		overrides.vars = append(overrides.vars, envLinkStrategy)
+	}
+
+	// Read by Config.offline, which also honors --offline
+	if value, ok := os.LookupEnv(envOffline); ok && value != "" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid %s: %s (must be true or false)", envOffline, value)
+		}
+		overrides.vars = append(overrides.vars, envOffline)
+	}
+
+	if len(overrides.vars) > 0 {
+		overrides.env = Config{Mode: config.Mode, Targets: config.Targets, LinkStrategy: config.LinkStrategy}
+		config.env = overrides
+	}
+	return nil
+}
+
+// withoutEnvOverrides returns the config to save: values that still hold what
+// the environment set are replaced by those read from the file. Values a
+// command changed since are saved.
+func withoutEnvOverrides(config *Config) Config {
+	saved := *config
+	overrides := config.env
+	if overrides == nil {
+		return saved
+	}
+
+	if saved.Mode == overrides.env.Mode {
+		saved.Mode = overrides.file.Mode
+	}
+	if equalNames(saved.Targets, overrides.env.Targets) {
+		saved.Targets = overrides.file.Targets
+	}
+	if saved.LinkStrategy == overrides.env.LinkStrategy {
+		saved.LinkStrategy = overrides.file.LinkStrategy
+	}
+	return saved
+}
+
+// envOverrideNames returns the environment variables overriding the config
+func (c *Config) envOverrideNames() []string {
+	if c.env == nil {
+		return nil
+	}
+	return c.env.vars
+}
+
+// equalNames reports whether two name lists hold the same names in order
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// Exit codes are part of the CLI's interface: wrapper scripts and CI branch
+// on them, so existing values must never change meaning.
+const (
+	exitError          = 1  // any failure without a more specific code
+	exitNotInitialized = 3  // no viberules project (run 'viberules init')
+	exitInvalidTarget  = 4  // a target name is unknown in the current scope
+	exitDrift          = 5  // check found outputs or policy needing attention
+	exitPartialFailure = 6  // some targets or projects failed, the others succeeded
+	exitPermission     = 7  // a file or directory could not be read or written
+	exitCanceled       = 8  // interrupted, or stopped by --timeout
+	exitConflict       = 9  // files viberules didn't create are in the way of outputs
+	exitAuth           = 10 // a remote source refused access
+	exitNotFound       = 11 // a remote source doesn't have the requested rules
+	exitOffline        = 12 // remote content is needed but offline mode is on
+)
+
+// kindExitCodes maps the error kinds of the core package to exit codes
+var kindExitCodes = []struct {
+	kind error
+	code int
+}{
+	{core.ErrNotInitialized, exitNotInitialized},
+	{core.ErrUnknownTarget, exitInvalidTarget},
+	{core.ErrDrift, exitDrift},
+	{core.ErrConflict, exitConflict},
+	{core.ErrAuth, exitAuth},
+	{core.ErrNotFound, exitNotFound},
+	{core.ErrOffline, exitOffline},
+}
+
+// codedError is an error that ends the process with a specific exit code
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withExitCode makes err end the process with code
+func withExitCode(code int, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	for _, k := range kindExitCodes {
+		if errors.Is(err, k.kind) {
+			return k.code
+		}
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return exitCanceled
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return exitPermission
+	}
+	return exitError
+}
+
+// invalidTargetError reports a target name that is unknown in the current
+// scope, suggesting the closest target or group
+func invalidTargetError(name string) error {
+	available := strings.Join(engine.TargetNames(), ", ")
+	if suggestion := suggestName(name, targetCandidates()); suggestion != "" {
+		return core.WithKind(core.ErrUnknownTarget, fmt.Errorf("unknown target '%s', did you mean '%s'? (available: %s)", name, suggestion, available))
+	}
+	return core.WithKind(core.ErrUnknownTarget, fmt.Errorf("invalid target: %s (available: %s)", name, available))
+}
+
+// targetCandidates returns the target and group names usable in the current project
+func targetCandidates() []string {
+	config, err := loadConfig()
+	if err != nil {
+		config = &Config{}
+	}
+	return append(engine.TargetNames(), config.groupNames()...)
+}
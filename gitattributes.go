@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// gitattributesPath is the attributes file holding the tracked outputs' section
+const gitattributesPath = ".gitattributes"
+
+// outputMergeDriver is the merge driver tracked outputs are routed to. 'hooks
+// install' registers it to keep the current version, which the post-merge
+// hook then regenerates from the merged rules; elsewhere git merges as usual.
+const outputMergeDriver = "viberules-output"
+
+// gitattributesSection returns the attributes of the project's tracked outputs:
+// generated, so GitHub collapses their diffs, and merged by outputMergeDriver
+func gitattributesSection(config *Config) string {
+	attributes := " linguist-generated=true merge=" + outputMergeDriver
+	var entries []string
+	for _, target := range engine.Targets() {
+		if !containsName(config.Targets, target.Name) || !config.tracksOutput(target.Name) {
+			continue
+		}
+		if target.PartsDir != "" {
+			entries = appendNames(entries, []string{quoteAttributePattern("/"+filepath.ToSlash(target.PartsDir)+"/**") + attributes})
+		}
+		for _, link := range target.Links {
+			if target.PartsDir == "" || filepath.Dir(link.Target) != target.PartsDir {
+				entries = appendNames(entries, []string{quoteAttributePattern("/"+filepath.ToSlash(link.Target)) + attributes})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return "# viberules tracked outputs (generated by viberules sync)\n" + strings.Join(entries, "\n") + "\n"
+}
+
+// updateGitattributes writes the tracked outputs' section of .gitattributes,
+// or removes it when no output is tracked
+func updateGitattributes(config *Config) error {
+	if globalMode || !config.usesGit() {
+		return nil
+	}
+	file := ignoreFile{path: gitattributesPath}
+	section := gitattributesSection(config)
+	if section == "" {
+		content, err := readIgnoreFile(file.path)
+		if err != nil || !file.has(content) {
+			return err
+		}
+		// Don't leave behind a file that only held the section
+		if file.strip(content) == "" {
+			return engine.FS().Remove(file.path)
+		}
+		return file.remove()
+	}
+	_, err := file.write(section)
+	return err
+}
+
+// refreshGitattributes rewrites the .gitattributes section after the enabled targets change
+func refreshGitattributes() {
+	config, err := loadConfig()
+	if err != nil {
+		return
+	}
+	if err := updateGitattributes(config); err != nil {
+		ui.Warn("⚠️  Failed to update %s: %v\n", gitattributesPath, err)
+	}
+}
+
+// gitattributesStale reports whether .gitattributes lacks the section sync
+// would write, or holds one no output needs
+func gitattributesStale(config *Config) bool {
+	if globalMode || !config.usesGit() {
+		return false
+	}
+	file := ignoreFile{path: gitattributesPath}
+	content, err := readIgnoreFile(file.path)
+	if err != nil {
+		return false
+	}
+	section := gitattributesSection(config)
+	if section == "" {
+		return file.has(content)
+	}
+	return !contains(content, file.block(section))
+}
+
+// quoteAttributePattern returns a gitattributes pattern as git reads it: as
+// is, or as a C-style quoted string when it holds whitespace, a double quote
+// or a backslash
+func quoteAttributePattern(pattern string) string {
+	if !strings.ContainsAny(pattern, " \t\r\n\"\\") {
+		return pattern
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range pattern {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
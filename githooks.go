@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// gitHookMarker identifies hooks written by viberules
+const gitHookMarker = "# Installed by 'viberules hooks install'"
+
+// gitHook is a git hook viberules installs
+type gitHook struct {
+	Name    string // file name in the hooks directory
+	Purpose string
+	Guard   string // shell condition under which the hook runs, if not always
+	Command string // viberules command run by the hook
+}
+
+// gitHooks are the hooks installed by 'hooks install'
+var gitHooks = []gitHook{
+	{Name: "pre-commit", Purpose: "verify the rules installation before committing", Command: "viberules check --quiet"},
+	// Checkouts can leave symlinks as plain files or drop them; checking out
+	// single files ($3 = 0) leaves outputs alone
+	{Name: "post-checkout", Purpose: "restore outputs after switching branches", Guard: `[ "$3" = 1 ]`, Command: "viberules sync --quiet"},
+	{Name: "post-merge", Purpose: "restore outputs after merging", Command: "viberules sync --quiet"},
+}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks that keep outputs healthy",
+	Long: `Install or remove git hooks that run viberules for you.
+
+Hooks:
+- pre-commit: runs 'viberules check', blocking the commit when outputs are
+  missing, broken or stale, the .gitignore section is out of date, or, in
+  public mode, tracked rules contain potential secrets
+- post-checkout: runs 'viberules sync' after switching branches, so outputs
+  git left as plain files or removed are restored
+- post-merge: runs 'viberules sync' after merges and pulls
+
+install also registers 'viberules merge-driver' as the merge driver of the
+rules file, in the repository's own config and .git/info/attributes, so
+branches that edit different sections of rules.md merge without conflicts.
+Outputs committed with track_output keep the current branch's version in a
+merge, as .gitattributes asks, and post-merge regenerates them.
+
+Hooks run viberules from PATH and do nothing where it isn't installed.
+Skip them for a single commit with 'git commit --no-verify'.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install git hooks",
+	Long: `Install the viberules git hooks into the repository's hooks directory
+(core.hooksPath if set, else .git/hooks). Hooks not written by viberules
+are left alone unless --force is given, which keeps them as <hook>.orig.`,
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installGitHooks()
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:         "uninstall",
+	Short:       "Remove git hooks installed by viberules",
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return uninstallGitHooks()
+	},
+}
+
+// gitHooksDir returns the hooks directory of the repository containing the project
+func gitHooksDir() (string, error) {
+	if globalMode {
+		return "", fmt.Errorf("hooks are not supported with --global (user-level rules are never tracked by git)")
+	}
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return "", err
+	}
+	if !config.usesGit() {
+		return "", fmt.Errorf("hooks need git integration, which is off for this project (--no-git or git: false)")
+	}
+	dir, err := core.GitHooksDir(projectDir())
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	return dir, nil
+}
+
+// script returns the content of the hook. Git runs hooks from the top of the
+// work tree, so hooks of a project below it change to its directory first and
+// report on stderr when it is gone.
+func (h gitHook) script(prefix string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# viberules: %s.\n", h.Purpose)
+	b.WriteString(gitHookMarker + "; remove with 'viberules hooks uninstall'.\n")
+	b.WriteString("command -v viberules >/dev/null 2>&1 || exit 0\n")
+	if h.Guard != "" {
+		fmt.Fprintf(&b, "%s || exit 0\n", h.Guard)
+	}
+	if prefix != "" {
+		message := fmt.Sprintf("viberules: can't change to %s, skipping the %s hook (run 'viberules hooks install' again)", prefix, h.Name)
+		fmt.Fprintf(&b, "cd %s || { printf '%%s\\n' %s >&2; exit 0; }\n", shellQuote(prefix), shellQuote(message))
+	}
+	fmt.Fprintf(&b, "exec %s\n", h.Command)
+	return b.String()
+}
+
+// shellQuote quotes s as a single sh word: inside single quotes nothing is
+// expanded, and each ' closes the quotes, adds an escaped quote and reopens them
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isViberulesHook reports whether the hook at path was written by viberules
+func isViberulesHook(path string) bool {
+	content, err := engine.FS().ReadFile(path)
+	return err == nil && strings.Contains(string(content), gitHookMarker)
+}
+
+func installGitHooks() error {
+	dir, err := gitHooksDir()
+	if err != nil {
+		return err
+	}
+	prefix, err := core.GitPrefix(projectDir())
+	if err != nil {
+		return err
+	}
+	fs := engine.FS()
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// Check every hook before writing any
+	var foreign []string
+	for _, hook := range gitHooks {
+		path := filepath.Join(dir, hook.Name)
+		if fileExists(path) && !isViberulesHook(path) {
+			foreign = append(foreign, path)
+		}
+	}
+	if len(foreign) > 0 && !force {
+		return fmt.Errorf("hooks not installed by viberules exist: %s (use --force to replace them, keeping them as .orig)", strings.Join(foreign, ", "))
+	}
+	for _, path := range foreign {
+		if fileExists(path + ".orig") {
+			return fmt.Errorf("failed to keep %s: %s.orig already exists", path, path)
+		}
+		if err := fs.Rename(path, path+".orig"); err != nil {
+			return fmt.Errorf("failed to keep %s: %w", path, err)
+		}
+		ui.Warn("⚠️  Moved existing %s to %s.orig\n", path, path)
+	}
+
+	for _, hook := range gitHooks {
+		path := filepath.Join(dir, hook.Name)
+		// WriteFile keeps the mode of an existing file, which may not be executable
+		if err := fs.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+		if err := fs.WriteFile(path, []byte(hook.script(prefix)), 0755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		ui.Detail("   Wrote %s\n", path)
+	}
+	if err := installMergeDriver(); err != nil {
+		return fmt.Errorf("failed to register the merge driver: %w", err)
+	}
+	ui.Success("Installed git hooks: %s\n", strings.Join(gitHookNames(), ", "))
+	ui.Info("🔀 Registered the rules merge driver for %s\n", engine.RulesFile())
+	return nil
+}
+
+func uninstallGitHooks() error {
+	dir, err := gitHooksDir()
+	if err != nil {
+		return err
+	}
+	if err := uninstallMergeDriver(); err != nil {
+		return fmt.Errorf("failed to remove the merge driver: %w", err)
+	}
+	fs := engine.FS()
+	var removed []string
+	for _, hook := range gitHooks {
+		path := filepath.Join(dir, hook.Name)
+		if !isViberulesHook(path) {
+			continue
+		}
+		if err := fs.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		// Put back the hook --force replaced
+		if fileExists(path + ".orig") {
+			if err := fs.Rename(path+".orig", path); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", path, err)
+			}
+			ui.Detail("   Restored %s\n", path)
+		}
+		removed = append(removed, hook.Name)
+	}
+	if len(removed) == 0 {
+		ui.Info("No viberules git hooks installed\n")
+		return nil
+	}
+	ui.Success("Removed git hooks: %s\n", strings.Join(removed, ", "))
+	return nil
+}
+
+// gitHookNames returns the names of the hooks viberules installs
+func gitHookNames() []string {
+	var names []string
+	for _, hook := range gitHooks {
+		names = append(names, hook.Name)
+	}
+	return names
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// globalMode is set by --global to manage user-level rules in the home directory
+var globalMode bool
+
+const globalRulesContent = `# Personal AI Assistant Rules
+
+> ⚠️ IMPORTANT: Edit THIS FILE (~/.viberules/rules.md) to update your personal rules
+> Changes here apply to every project for Claude, Codex, Gemini, OpenCode, etc.
+
+## About Me
+Describe your role, experience, and the languages you work with.
+
+## Preferences
+- Explain trade-offs before making large changes
+- Keep answers concise
+- Match the style of the surrounding code
+
+---
+*This file is automatically linked to all AI assistants via viberules --global*
+`
+
+// enterGlobalScope points the engine at the home directory and its user-level
+// targets
+func enterGlobalScope() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find home directory: %w", err)
+	}
+	engine = newEngine(home)
+	engine.SetScope(core.ScopeGlobal)
+	return nil
+}
+
+// defaultTargets returns the targets enabled by init in the active scope
+func defaultTargets() []string {
+	if engine.Scope() == core.ScopeGlobal {
+		return engine.TargetNames()
+	}
+	return []string{"claude", "amazonq", "gemini", "codex"}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sort"
+)
+
+// Groups name sets of targets, so add, remove and init --targets can enable a
+// team's standard set in one command. A group in the project config hides a
+// user config group of the same name, which hides a built-in one.
+
+// builtinGroups are the groups available without any config
+var builtinGroups = map[string][]string{
+	"cli": {"claude", "codex", "gemini", "opencode"}, // terminal coding agents
+	"ide": {"amazonq", "cursor"},                     // editor assistants
+}
+
+// targetGroup returns the targets of a group that exist in the active scope
+func (c *Config) targetGroup(name string) ([]string, bool) {
+	members, ok := c.Groups[name]
+	if !ok && c.user != nil {
+		members, ok = c.user.Groups[name]
+	}
+	if !ok {
+		members, ok = builtinGroups[name]
+	}
+	if !ok {
+		return nil, false
+	}
+
+	// Groups may list targets of the other scope (opencode is user-level only)
+	var targets []string
+	for _, member := range members {
+		if isValidTarget(member) {
+			targets = append(targets, member)
+		}
+	}
+	return targets, true
+}
+
+// expandTargets replaces group names with their targets, dropping
+// duplicates. Unknown names are kept, to be reported by the caller.
+func (c *Config) expandTargets(names []string) []string {
+	var targets []string
+	for _, name := range names {
+		if !isValidTarget(name) {
+			if members, ok := c.targetGroup(name); ok {
+				targets = appendNames(targets, members)
+				continue
+			}
+		}
+		targets = appendNames(targets, []string{name})
+	}
+	return targets
+}
+
+// groupNames returns the names of every group, sorted
+func (c *Config) groupNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(groups map[string][]string) {
+		for name := range groups {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	add(c.Groups)
+	if c.user != nil {
+		add(c.user.Groups)
+	}
+	add(builtinGroups)
+	sort.Strings(names)
+	return names
+}
+
+// isAnyTarget reports whether name is a target of either scope
+func isAnyTarget(name string) bool {
+	if isProjectTarget(name) {
+		return true
+	}
+	for _, target := range engine.GetGlobalTargets() {
+		if target.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage AI ignore files generated from .viberules/ignore",
+	Long: `Generate each assistant's ignore file from a single .viberules/ignore
+source (.gitignore syntax), so every tool skips the same paths.
+
+Ignore files:
+- cursor: .cursorignore
+- gemini: .aiexclude
+- codeium: .codeiumignore
+- aider: .aiderignore
+
+Files are written for enabled targets automatically. Tools that are not rule
+targets (codeium, aider) are enabled with 'viberules ignore add [tool]'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showIgnoreFiles()
+	},
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:         "add [tool]",
+	Short:       "Generate the ignore file of a tool",
+	Args:        cobra.ExactArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addIgnoreTool(args[0])
+	},
+}
+
+var ignoreRemoveCmd = &cobra.Command{
+	Use:         "remove [tool]",
+	Short:       "Stop generating the ignore file of a tool",
+	Args:        cobra.ExactArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return removeIgnoreTool(args[0])
+	},
+}
+
+func ignoreToolNames() string {
+	var names []string
+	for _, tool := range core.GetIgnoreTools() {
+		names = append(names, tool.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ignoreEnabled reports whether the ignore file of a tool is generated
+func (c *Config) ignoreEnabled(name string) bool {
+	return containsName(c.Targets, name) || containsName(c.IgnoreTools, name)
+}
+
+// containsName reports whether names includes name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIgnoreFile writes the ignore file of a tool, if it has one, and records its checksum
+func writeIgnoreFile(config *Config, name string, overwrite bool) error {
+	tool, ok := core.FindIgnoreTool(name)
+	if !ok || globalMode {
+		return nil
+	}
+
+	sum, err := engine.WriteIgnoreFile(tool, config.Checksums[tool.Path], overwrite)
+	if err != nil {
+		return err
+	}
+	if sum == "" {
+		delete(config.Checksums, tool.Path)
+		return nil
+	}
+	if config.Checksums == nil {
+		config.Checksums = make(map[string]string)
+	}
+	config.Checksums[tool.Path] = sum
+	return nil
+}
+
+// removeIgnoreFile removes the generated ignore file of a tool
+func removeIgnoreFile(config *Config, name string) error {
+	tool, ok := core.FindIgnoreTool(name)
+	if !ok || globalMode {
+		return nil
+	}
+	if err := engine.RemoveManagedFile(tool.Path, config.Checksums[tool.Path], false); err != nil {
+		return err
+	}
+	delete(config.Checksums, tool.Path)
+	return nil
+}
+
+// collectIgnoreStatus reports the state of every generated ignore file
+func collectIgnoreStatus(config *Config) ([]targetOutputStatus, error) {
+	var all []targetOutputStatus
+	for _, tool := range core.GetIgnoreTools() {
+		if !config.ignoreEnabled(tool.Name) || globalMode {
+			continue
+		}
+		status, ok, err := engine.CheckIgnoreFile(tool, config.Checksums[tool.Path])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			all = append(all, targetOutputStatus{OutputStatus: status, Strategy: "ignore"})
+		}
+	}
+	return all, nil
+}
+
+func showIgnoreFiles() error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	if !fileExists(core.IgnoreSource) {
+		ui.Print("No %s found. Create it with .gitignore syntax to generate ignore files.\n\n", core.IgnoreSource)
+	}
+
+	ui.Print("Ignore files:\n")
+	for _, tool := range core.GetIgnoreTools() {
+		if config.ignoreEnabled(tool.Name) {
+			ui.Print("  ✅ %s (%s)\n", tool.Path, tool.Name)
+		} else {
+			ui.Print("  ⬜ %s (%s)\n", tool.Path, tool.Name)
+		}
+	}
+	return nil
+}
+
+func addIgnoreTool(name string) error {
+	if _, ok := core.FindIgnoreTool(name); !ok {
+		return fmt.Errorf("invalid ignore tool: %s (available: %s)", name, ignoreToolNames())
+	}
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	if config.ignoreEnabled(name) {
+		ui.Info("Ignore file for '%s' is already generated\n", name)
+		return nil
+	}
+	config.IgnoreTools = append(config.IgnoreTools, name)
+
+	if err := writeIgnoreFile(config, name, false); err != nil {
+		return err
+	}
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	refreshGitignore()
+
+	ui.Success("Ignore file for '%s' enabled\n", name)
+	return nil
+}
+
+func removeIgnoreTool(name string) error {
+	if _, ok := core.FindIgnoreTool(name); !ok {
+		return fmt.Errorf("invalid ignore tool: %s (available: %s)", name, ignoreToolNames())
+	}
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	if !containsName(config.IgnoreTools, name) {
+		if containsName(config.Targets, name) {
+			return fmt.Errorf("ignore file for '%s' follows the enabled target; remove the target instead", name)
+		}
+		ui.Info("Ignore file for '%s' is not enabled\n", name)
+		return nil
+	}
+
+	var kept []string
+	for _, tool := range config.IgnoreTools {
+		if tool != name {
+			kept = append(kept, tool)
+		}
+	}
+	config.IgnoreTools = kept
+
+	if !config.ignoreEnabled(name) {
+		if err := removeIgnoreFile(config, name); err != nil {
+			return err
+		}
+	}
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	refreshGitignore()
+
+	ui.Success("Ignore file for '%s' disabled\n", name)
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create a viberules project from a rulesync, ruler or ai-rules project",
+	Long: `Convert the project of another rules tool in the current directory into a
+viberules project, as 'viberules init' does with its rules, targets and
+ignore entries filled in.
+
+Tools and what is read:
+- rulesync: rulesync.jsonc (targets), .rulesync/rules/*.md and .rulesyncignore
+- ruler: .ruler/ruler.toml (default_agents, or the enabled [agents.*]) and
+  every .md file below .ruler, AGENTS.md first
+- ai-rules: ai-rules/ai-rules-config.yaml (agents) and ai-rules/*.md
+
+The rules files are joined into rules.md, with the root or AGENTS.md rules
+first and frontmatter removed. Rules a tool only writes for some assistants
+go in viberules:only sections, and rules for some files say which. Assistants
+viberules has no target for are reported and left out; without targets in
+the tool's config, --targets or the usual init defaults apply.
+
+The files the tool generated (CLAUDE.md, AGENTS.md, .cursorignore, ...) are
+moved to .viberules/backup/<timestamp>/ and replaced with viberules outputs.
+The tool's own files are left in place: remove them once the outputs look
+right. MCP servers, commands, subagents and skills are not converted and are
+listed instead.`,
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importProject(importFrom)
+	},
+}
+
+// importFrom is set by import --from
+var importFrom string
+
+// importedRules is set by import to the content init writes to a new rules file
+var importedRules []byte
+
+func importProject(from string) error {
+	if from == "" {
+		return fmt.Errorf("--from is required (available: %s)", strings.Join(core.ImportTools, ", "))
+	}
+	if globalMode {
+		return fmt.Errorf("import is not supported with --global")
+	}
+	if engine.IsProject(".") {
+		return fmt.Errorf("this directory is already a viberules project")
+	}
+	imported, err := engine.ImportProject(from)
+	if err != nil {
+		return err
+	}
+
+	// init does the rest with the imported rules and targets, replacing the
+	// outputs the tool generated
+	previousTargets, previousOverwrite := initTargets, forceOverwrite
+	defer func() {
+		initTargets, forceOverwrite, importedRules = previousTargets, previousOverwrite, nil
+	}()
+	if initTargets == "" {
+		initTargets = strings.Join(imported.Targets, ",")
+	}
+	forceOverwrite = true
+	importedRules = imported.Rules
+	if len(importedRules) == 0 {
+		importedRules = []byte("# Project Rules\n")
+	}
+	for _, name := range imported.Skipped {
+		ui.Warn("⚠️  %s has no viberules target; its rules are imported for the other targets\n", name)
+	}
+	if err := initProject(); err != nil {
+		return err
+	}
+
+	if len(imported.Ignore) > 0 {
+		if err := writeImportedIgnore(imported.Ignore); err != nil {
+			return err
+		}
+	}
+
+	ui.Info("📥 Imported from %s: %s\n", from, strings.Join(imported.Files, ", "))
+	if len(imported.Unconverted) > 0 {
+		ui.Warn("⚠️  Not converted, set these up again by hand: %s\n", strings.Join(imported.Unconverted, ", "))
+	}
+	ui.Info("Check the outputs, then remove the %s files and its generated files from .gitignore\n", from)
+	return nil
+}
+
+// writeImportedIgnore writes the ignore source and the ignore files of the
+// enabled targets, backing up the ones the imported tool generated
+func writeImportedIgnore(patterns []string) error {
+	content := strings.Join(patterns, "\n") + "\n"
+	if err := engine.FS().WriteFile(core.IgnoreSource, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to create %s: %w", core.IgnoreSource, err)
+	}
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	backupDir := core.NewBackupDir(time.Now())
+	for _, target := range config.Targets {
+		tool, ok := core.FindIgnoreTool(target)
+		if !ok {
+			continue
+		}
+		if _, err := engine.FS().Lstat(tool.Path); err == nil && config.Checksums[filepath.Clean(tool.Path)] == "" {
+			if err := engine.BackupFile(tool.Path, backupDir); err != nil {
+				return err
+			}
+		}
+		if err := writeIgnoreFile(config, target, false); err != nil {
+			return fmt.Errorf("failed to write the ignore file of %s: %w", target, err)
+		}
+	}
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+	ui.Info("📝 Created %s\n", core.IgnoreSource)
+	return nil
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Commands that change a project record what they changed in a journal below
+// .viberules/journal: the state of every file their plan created, replaced or
+// deleted, as it was before and after. 'viberules undo' puts the files of the
+// most recent entry back the way they were.
+
+// journalDir holds one directory per recorded command, named after its time
+var journalDir = filepath.Join(".viberules", "journal")
+
+// journalLimit is how many commands the journal remembers
+const journalLimit = 20
+
+// journaledAnnotation marks the commands recorded in the journal: every
+// command that writes to the project
+const journaledAnnotation = "viberules.journaled"
+
+// journaled is the annotations of a command recorded in the journal
+var journaled = map[string]string{journaledAnnotation: "true"}
+
+// isJournaled reports whether cmd is recorded in the journal
+func isJournaled(cmd *cobra.Command) bool {
+	_, ok := cmd.Annotations[journaledAnnotation]
+	return ok
+}
+
+// transactionalCommands leave the project as it was when they fail midway,
+// instead of half-configured. Others keep what succeeded, such as the targets
+// sync updated before one failed.
+var transactionalCommands = []*cobra.Command{initCmd, addCmd}
+
+// isTransactional reports whether cmd is rolled back when it fails
+func isTransactional(cmd *cobra.Command) bool {
+	for _, transactional := range transactionalCommands {
+		if cmd == transactional {
+			return true
+		}
+	}
+	return false
+}
+
+// journalEntry is a recorded command
+type journalEntry struct {
+	Command string          `yaml:"command"`
+	Time    time.Time       `yaml:"time"`
+	Changes []journalChange `yaml:"changes"`
+}
+
+// journalChange is a file a command created, replaced or deleted. The content
+// a replaced or deleted file had is kept next to the entry.
+type journalChange struct {
+	Path   string    `yaml:"path"`
+	Before fileState `yaml:"before"`
+	After  fileState `yaml:"after"`
+}
+
+// fileState describes a path without its content
+type fileState struct {
+	Kind     string `yaml:"kind"` // file, symlink or none
+	Link     string `yaml:"link,omitempty"`
+	Mode     uint32 `yaml:"mode,omitempty"`
+	Checksum string `yaml:"checksum,omitempty"`
+}
+
+// statePath returns the state of a path
+func statePath(path string) fileState {
+	info, err := engine.FS().Lstat(path)
+	if err != nil {
+		return fileState{Kind: "none"}
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, _ := engine.FS().Readlink(path)
+		return fileState{Kind: "symlink", Link: link}
+	}
+	sum, _ := engine.FileChecksum(path)
+	return fileState{Kind: "file", Mode: uint32(info.Mode().Perm()), Checksum: sum}
+}
+
+// journalSnapshot holds the state of the paths a command is about to write,
+// taken before its plan is applied, and the content of their files
+type journalSnapshot struct {
+	paths    []string
+	states   map[string]fileState
+	contents map[string][]byte
+}
+
+// applyPlan makes the changes a command planned, recording them in the
+// journal when the command is journaled. Commands run with --global,
+// --recursive or --submodules are not recorded.
+func applyPlan(cmd *cobra.Command, args []string, ops []core.FileOp) error {
+	if !isJournaled(cmd) || globalMode || multiProject() {
+		return engine.ApplyPlan(ops)
+	}
+	snapshot, err := takeSnapshot(ops)
+	if err != nil {
+		ui.Warn("⚠️  Not recording this command for undo: %v\n", err)
+		return engine.ApplyPlan(ops)
+	}
+
+	applyErr := engine.ApplyPlan(ops)
+	if applyErr != nil && isTransactional(cmd) {
+		if err := snapshot.rollback(); err != nil {
+			ui.Warn("⚠️  Failed to roll back: %v\n", err)
+		}
+		return applyErr
+	}
+	if err := snapshot.save(commandLine(cmd, args)); err != nil {
+		ui.Warn("⚠️  Failed to record this command for undo: %v\n", err)
+	}
+	return applyErr
+}
+
+// commandLine returns how a command was run, without the program name
+func commandLine(cmd *cobra.Command, args []string) string {
+	words := append([]string{cmd.Name()}, args...)
+	if cmd.HasParent() && cmd.Parent() != cmd.Root() {
+		words = append([]string{cmd.Parent().Name()}, words...)
+	}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Value.Type() == "bool" {
+			words = append(words, "--"+flag.Name)
+		} else {
+			words = append(words, "--"+flag.Name+"="+flag.Value.String())
+		}
+	})
+	return strings.Join(words, " ")
+}
+
+// takeSnapshot records the files and symlinks the planned changes write or
+// remove, including those below a directory that is removed or replaced.
+// Paths in the project are recorded relative to it.
+func takeSnapshot(ops []core.FileOp) (*journalSnapshot, error) {
+	snapshot := &journalSnapshot{states: make(map[string]fileState), contents: make(map[string][]byte)}
+	root := projectDir()
+	for _, op := range ops {
+		if op.Action == core.PlanCreate && op.Mode.IsDir() {
+			continue
+		}
+		if err := snapshot.add(displayPath(root, op.Path)); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(snapshot.paths)
+	return snapshot, nil
+}
+
+// add records the state of path, or of what is below it for a directory
+func (s *journalSnapshot) add(path string) error {
+	if _, ok := s.states[path]; ok {
+		return nil
+	}
+	fs := engine.FS()
+	info, err := fs.Lstat(path)
+	if err == nil && info.IsDir() {
+		entries, err := fs.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := s.add(filepath.Join(path, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	state := statePath(path)
+	if state.Kind == "file" {
+		content, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s.contents[path] = content
+	}
+	s.paths = append(s.paths, path)
+	s.states[path] = state
+	return nil
+}
+
+// changes returns the recorded paths that changed since the snapshot was taken
+func (s *journalSnapshot) changes() []journalChange {
+	var changes []journalChange
+	for _, path := range s.paths {
+		if after := statePath(path); after != s.states[path] {
+			changes = append(changes, journalChange{Path: path, Before: s.states[path], After: after})
+		}
+	}
+	return changes
+}
+
+// save writes a journal entry with the files that changed since the
+// snapshot was taken. Nothing is written when nothing changed, or when the
+// project has no .viberules directory left to keep the journal in.
+func (s *journalSnapshot) save(command string) error {
+	if !fileExists(".viberules") {
+		return nil
+	}
+	changes := s.changes()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	entry := journalEntry{Command: command, Time: time.Now(), Changes: changes}
+	dir := filepath.Join(journalDir, entry.Time.Format("20060102-150405.000000000"))
+	fs := engine.FS()
+	if err := fs.MkdirAll(filepath.Join(dir, "files"), 0700); err != nil {
+		return err
+	}
+	for i, change := range changes {
+		if change.Before.Kind == "file" {
+			// Kept private: the file may hold secrets the project kept out of git
+			if err := fs.WriteFile(filepath.Join(dir, "files", strconv.Itoa(i)), s.contents[change.Path], 0600); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := yaml.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+	if err := fs.WriteFile(filepath.Join(dir, "entry.yaml"), content, 0644); err != nil {
+		return err
+	}
+	return pruneJournal()
+}
+
+// rollback puts the files that changed since the snapshot was taken back as
+// they were
+func (s *journalSnapshot) rollback() error {
+	changes := s.changes()
+	if len(changes) == 0 {
+		return nil
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if err := revertChange(changes[i], s.contents[changes[i].Path]); err != nil {
+			return err
+		}
+		ui.Detail("   Reverted %s\n", changes[i].Path)
+	}
+	ui.Warn("↩️  Rolled back the changes made before the failure\n")
+	return nil
+}
+
+// journalEntries returns the directories of recorded commands, oldest first
+func journalEntries() ([]string, error) {
+	entries, err := engine.FS().ReadDir(journalDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(journalDir, entry.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// pruneJournal forgets the oldest commands beyond journalLimit
+func pruneJournal() error {
+	dirs, err := journalEntries()
+	if err != nil {
+		return err
+	}
+	for len(dirs) > journalLimit {
+		if err := engine.FS().RemoveAll(dirs[0]); err != nil {
+			return err
+		}
+		dirs = dirs[1:]
+	}
+	return nil
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the files changed by the last command",
+	Long: `Put back the files the most recent command that changed the project wrote,
+as they were before it ran. Running undo again reverts the command before that;
+the last 20 commands are remembered.
+
+Undo refuses to revert files that were changed after the command, listing
+them, unless --force is given. Commands run with --dry-run, --global or
+--recursive are not recorded.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return undoLast()
+	},
+}
+
+// undoLast reverts the most recent journal entry and forgets it
+func undoLast() error {
+	dirs, err := journalEntries()
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	dir := dirs[len(dirs)-1]
+	content, err := engine.FS().ReadFile(filepath.Join(dir, "entry.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read journal entry: %w", err)
+	}
+	var entry journalEntry
+	if err := yaml.Unmarshal(content, &entry); err != nil {
+		return fmt.Errorf("failed to parse journal entry %s: %w", dir, err)
+	}
+
+	// Files edited since would be lost
+	var edited []string
+	for _, change := range entry.Changes {
+		if statePath(change.Path) != change.After {
+			edited = append(edited, "  - "+change.Path)
+		}
+	}
+	if len(edited) > 0 && !force {
+		return fmt.Errorf("these files changed after '%s' and would be lost:\n%s\nRerun with --force to revert them anyway", entry.Command, strings.Join(edited, "\n"))
+	}
+
+	for i := len(entry.Changes) - 1; i >= 0; i-- {
+		change := entry.Changes[i]
+		var content []byte
+		if change.Before.Kind == "file" {
+			if content, err = engine.FS().ReadFile(filepath.Join(dir, "files", strconv.Itoa(i))); err != nil {
+				return fmt.Errorf("failed to read the saved content of %s: %w", change.Path, err)
+			}
+		}
+		if err := revertChange(change, content); err != nil {
+			return err
+		}
+		ui.Detail("   Reverted %s\n", change.Path)
+	}
+
+	if err := engine.FS().RemoveAll(dir); err != nil {
+		return err
+	}
+	removeEmptyDirs(journalDir)
+	ui.Success("Undid '%s' (%s)\n", entry.Command, entry.Time.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// revertChange puts a path back in its state before a command. content is
+// what a file that existed before held.
+func revertChange(change journalChange, content []byte) error {
+	fs := engine.FS()
+	if info, err := fs.Lstat(change.Path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("failed to revert %s: it is a directory now", change.Path)
+		}
+		if err := fs.Remove(change.Path); err != nil {
+			return fmt.Errorf("failed to revert %s: %w", change.Path, err)
+		}
+	}
+
+	switch change.Before.Kind {
+	case "file":
+		if err := fs.MkdirAll(filepath.Dir(change.Path), 0755); err != nil {
+			return err
+		}
+		if err := fs.WriteFile(change.Path, content, os.FileMode(change.Before.Mode)); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", change.Path, err)
+		}
+		return os.Chmod(projectPath(change.Path), os.FileMode(change.Before.Mode))
+	case "symlink":
+		if err := fs.MkdirAll(filepath.Dir(change.Path), 0755); err != nil {
+			return err
+		}
+		if err := fs.Symlink(change.Before.Link, change.Path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", change.Path, err)
+		}
+	default:
+		removeEmptyDirs(filepath.Dir(change.Path))
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, up to the
+// project root. Directories outside the project are left alone.
+func removeEmptyDirs(dir string) {
+	for dir != "." && !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "..") {
+		if err := engine.FS().Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
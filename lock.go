@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Re-resolve remote rules and move the pins in viberules.lock",
+	Long: `Fetch every remote include again, regenerate outputs and rewrite
+.viberules/viberules.lock with the resolved commits and content hashes.
+
+Generation normally reproduces the content recorded in the lockfile: git
+references are fetched at their pinned commit and any content that no longer
+matches its recorded hash is rejected. Run update to accept upstream changes.
+Installed packs with a version constraint move to the latest version of the
+pack index that it allows.
+
+Remote content fetched within cache_ttl (10 minutes by default) is reused;
+--refresh fetches every source again.
+
+With --recursive, updates every nested viberules project below the current directory.
+With --submodules, also updates the projects of checked-out git submodules.`,
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProjects(updateProject, false)
+	},
+}
+
+// netFetcher retrieves remote content; shared so each source is fetched once per run
+var netFetcher = newNetFetcher()
+
+// offlineFetcher stands in for netFetcher in offline mode
+var offlineFetcher = &core.NetFetcher{Offline: true}
+
+// refreshCache is set by update --refresh: remote content is fetched again
+// even when it was cached within cache_ttl
+var refreshCache bool
+
+// fetcher returns the fetcher of remote content. It reuses what was fetched
+// within the cache TTL, and fails without using the network in offline mode
+// for content that was never fetched.
+func (c *Config) fetcher() core.RevisionFetcher {
+	var inner core.RevisionFetcher = netFetcher
+	if c.offline() {
+		inner = offlineFetcher
+	}
+	return &core.CachingFetcher{Inner: inner, Dir: core.DefaultRefCacheDir(), TTL: c.cacheTTL(), Refresh: refreshCache, Logger: logger}
+}
+
+// requireOnline fails commands that can't work from cached content, such as
+// those re-resolving or publishing remote rules
+func requireOnline(c *Config, command string) error {
+	if c.offline() {
+		return core.WithKind(core.ErrOffline, fmt.Errorf("%s needs network access, but offline mode is on (--offline, %s or offline in the config)", command, envOffline))
+	}
+	return nil
+}
+
+// projectLock holds the lockfile of the project that is currently being generated.
+// It is reloaded whenever the engine moves to another project, as with --recursive.
+var projectLock struct {
+	dir     string
+	fetcher *core.LockedFetcher
+}
+
+// remoteFetcher returns the fetcher for remote includes of the current project,
+// pinned by its lockfile. Locked content is read from the user cache when it
+// is there, so generation works offline for everything fetched before.
+func remoteFetcher(config *Config) core.Fetcher {
+	dir := projectDir()
+	if projectLock.fetcher == nil || projectLock.dir != dir {
+		lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+		if err != nil {
+			return failedFetcher{err}
+		}
+		projectLock.dir = dir
+		projectLock.fetcher = &core.LockedFetcher{Inner: config.fetcher(), Lock: lock, Cache: core.DefaultContentCache(), Logger: logger}
+	}
+	return projectLock.fetcher
+}
+
+// saveLockfile writes pins recorded while generating the current project
+func saveLockfile() error {
+	fetcher := projectLock.fetcher
+	if fetcher == nil || !fetcher.Changed() {
+		return nil
+	}
+	return fetcher.Lock.Save(engine.FS(), core.LockfilePath)
+}
+
+// failedFetcher reports an error for every fetch, e.g. when the lockfile is unreadable
+type failedFetcher struct {
+	err error
+}
+
+func (f failedFetcher) Fetch(ctx context.Context, ref core.RemoteRef) ([]byte, error) {
+	return nil, f.err
+}
+
+// updateProject regenerates the current project with a fresh lock and reports moved pins
+func updateProject() (int, error) {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return 0, err
+	}
+	if err := requireOnline(config, "update"); err != nil {
+		return 0, err
+	}
+	if force {
+		if err := confirmOverwrite(config); err != nil {
+			return 0, err
+		}
+	}
+
+	previous, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+	if err != nil {
+		return 0, err
+	}
+
+	// Packs move within their constraints before outputs include them
+	packs, moved, err := updatePacks(config, previous.Packs)
+	if err != nil {
+		return 0, err
+	}
+
+	dir := projectDir()
+	// Start from an empty lock so sources that are no longer used are dropped
+	projectLock.dir = dir
+	// The shared rules pulled last aren't an include and stay the merge base of
+	// the next pull; installed packs stay installed
+	projectLock.fetcher = &core.LockedFetcher{Inner: config.fetcher(), Lock: &core.Lockfile{Shared: previous.Shared, Packs: packs}, Update: true, Cache: core.DefaultContentCache(), Logger: logger}
+
+	for _, target := range config.Targets {
+		if config.strategyFor(target) != core.StrategyGenerate {
+			continue
+		}
+		if err := createTargetOutputs(config, target, force); err != nil {
+			return 0, fmt.Errorf("failed to regenerate %s: %w", target, err)
+		}
+	}
+	if err := saveConfig(config); err != nil {
+		return 0, err
+	}
+
+	lock := projectLock.fetcher.Lock
+	if lock.Empty() {
+		if err := engine.FS().Remove(core.LockfilePath); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to remove lockfile: %w", err)
+		}
+		ui.Info("No remote rules in use\n")
+		return 0, nil
+	}
+	if err := lock.Save(engine.FS(), core.LockfilePath); err != nil {
+		return 0, err
+	}
+
+	for _, source := range lock.Sources {
+		old, ok := previous.Find(source.Ref)
+		switch {
+		case !ok:
+			ui.Info("📌 %s: pinned %s\n", source.Ref, pinLabel(source))
+		case old != source:
+			ui.Info("📌 %s: %s → %s\n", source.Ref, pinLabel(old), pinLabel(source))
+		default:
+			continue
+		}
+		moved++
+	}
+	if moved == 0 {
+		ui.Success("All remote rules are up to date\n")
+	} else {
+		ui.Success("Updated %d pin(s) and pack(s) in %s\n", moved, core.LockfilePath)
+	}
+	return 0, nil
+}
+
+// pinLabel is a short description of a locked source for output
+func pinLabel(source core.LockedSource) string {
+	if digest, ok := strings.CutPrefix(source.Commit, "sha256:"); ok {
+		return "sha256:" + digest[:min(len(digest), 12)]
+	}
+	if source.Commit != "" {
+		return source.Commit[:min(len(source.Commit), 12)]
+	}
+	return "sha256:" + source.SHA256[:min(len(source.SHA256), 12)]
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// logFile is set by --log-file: debug records are appended to it as JSON lines
+var logFile string
+
+// logOutput is the file opened for --log-file
+var logOutput *os.File
+
+// logger receives debug records of what the command decides; engines and
+// fetchers log what core operations do to it as well
+var logger = quietLogger()
+
+// quietLogger returns a logger dropping debug records
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// useLogger makes the command, its engine and the fetchers log to l
+func useLogger(l *slog.Logger) {
+	logger = l
+	engine.SetLogger(l)
+	netFetcher.Logger = l
+	offlineFetcher.Logger = l
+}
+
+// configureLogging turns on debug records of what the command and core
+// operations attempt and decide: to --log-file, or to stderr as text with
+// --verbose. They help diagnose problems in environments we can't see.
+func configureLogging(cmd *cobra.Command) error {
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch {
+	case logFile != "":
+		file, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		logOutput = file
+		useLogger(slog.New(slog.NewJSONHandler(file, options)))
+	case verbose:
+		useLogger(slog.New(slog.NewTextHandler(os.Stderr, options)))
+	default:
+		return nil
+	}
+	dir, _ := os.Getwd()
+	logger.Debug("running command", "command", cmd.CommandPath(), "args", os.Args[1:],
+		"version", version, "os", runtime.GOOS, "dir", dir)
+	return nil
+}
+
+// finishLogging records how the command ended and closes the log file
+func finishLogging(err error) {
+	if err != nil {
+		logger.Debug("command failed", "error", err, "exit_code", exitCode(err))
+	}
+	if logOutput != nil {
+		logOutput.Close()
+		logOutput = nil
+	}
+	useLogger(quietLogger())
+}
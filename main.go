@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/sky1core/viberules/internal/core"
+	"github.com/sky1core/viberules/core"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -14,21 +20,77 @@ import (
 const version = "0.2.0"
 
 // WARNING: DO NOT CHANGE THESE CONSTANTS!
-// These strings are used to identify viberules sections in existing .gitignore files.
-// Changing them will break gitignore updates for users who already have viberules installed.
+// These strings identify viberules sections written before the begin/end
+// markers, which are removed by their headings. Changing them would leave
+// those sections behind in existing .gitignore files.
 const (
 	gitignoreSectionPrefix = "# viberules"
 	gitignoreLocalMode     = "# viberules (local mode"
 	gitignoreLocalFiles    = "# viberules local files"
 	gitignoreConfigFile    = "# viberules config file"
 	gitignoreOutputFiles   = "# viberules output files"
+	gitignoreIgnoreFiles   = "# viberules AI ignore files"
+	gitignoreMCPFiles      = "# viberules MCP files"
+
+	// The section is written between these markers; everything outside them is left alone
+	gitignoreBegin = "# >>> viberules >>>"
+	gitignoreEnd   = "# <<< viberules <<<"
 )
 
 var (
-	silent bool
-	force  bool
+	silent         bool // set by --quiet
+	force          bool
+	strategyTarget string
+	noGit          bool // set by --no-git
+	offlineFlag    bool // set by --offline
 )
 
+// engine works on the project, or the home directory with --global, that
+// the running command is about
+var engine = newEngine("")
+
+// newEngine returns an engine working on the directory root, or on the working
+// directory when root is empty. It reports its changes to the terminal and
+// knows the targets of the user registry.
+func newEngine(root string) *core.Engine {
+	var f core.FS = core.OSFS{Root: root}
+	if planning != nil && root != "" {
+		f = planning.Within(root)
+	}
+	e := core.New(f)
+	e.SetObserver(outputObserver{})
+	e.SetLogger(logger)
+	e.SetRegistry(core.BuiltinRegistry().Extend(userRegistry))
+	return e
+}
+
+// useEngine makes commands work on e until the returned function puts the
+// previous engine back
+func useEngine(e *core.Engine) (restore func()) {
+	previous := engine
+	engine = e
+	return func() { engine = previous }
+}
+
+// projectDir returns the absolute directory the engine works on, for git and
+// the other tools that don't go through its filesystem
+func projectDir() string {
+	dir, err := engine.FS().Abs(".")
+	if err != nil {
+		return "."
+	}
+	return dir
+}
+
+// projectPath returns path, relative to the directory the engine works on,
+// as a path the os package can use
+func projectPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir(), path)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "viberules",
 	Short: "AI assistant rules management tool using symlinks",
@@ -38,12 +100,42 @@ var rootCmd = &cobra.Command{
 Key features:
 - Manage only 2 files: viberules.md, viberules.local.md
 - Real-time sync via symlinks
-- Individual target management (add/remove)`,
+- Individual target management (add/remove)
+
+Exit codes: 0 success, 1 error, 3 not initialized, 4 invalid target,
+5 drift detected (check), 6 partial failure, 7 permission denied,
+8 interrupted or timed out, 9 files in the way of outputs,
+10 remote access denied, 11 remote content not found, 12 network
+needed while offline.`,
 	Version: version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if runtime.GOOS == "windows" {
 			return fmt.Errorf("Windows is not supported. Please use macOS or Linux")
 		}
+		if err := checkVerbosity(); err != nil {
+			return err
+		}
+		if err := checkDryRun(cmd); err != nil {
+			return err
+		}
+		if err := configureLogging(cmd); err != nil {
+			return err
+		}
+		if err := loadUserRegistry(); err != nil {
+			return err
+		}
+		startCommandContext(cmd)
+		if globalMode {
+			if err := enterGlobalScope(); err != nil {
+				return err
+			}
+		} else if cmd != initCmd && cmd != mergeDriverCmd && cmd.Parent() != docsCmd {
+			enterProjectRoot()
+		}
+		configureOutput()
+		if jsonOutput {
+			ui = &jsonUI{}
+		}
 		return nil
 	},
 }
@@ -54,33 +146,88 @@ var initCmd = &cobra.Command{
 	Long: `Create viberules files and symlinks in the current directory.
 
 Created files:
-- rules.md (single rules file for all AI tools)
+- rules.md (single rules file for all AI tools), seeded from --template
+  (see 'viberules templates list'; defaults to a generic skeleton)
+- rules.local.md (personal rules, never committed; merged into generated
+  outputs and linked as CLAUDE.local.md otherwise)
+- notes.local.md (personal scratchpad, never committed; merged into
+  generated outputs and imported from CLAUDE.local.md otherwise)
 - Symlinks for each AI tool (CLAUDE.md, GEMINI.md, AGENTS.md, etc.)
-- Mode-aware .gitignore configuration`,
+- Mode-aware .gitignore configuration
+
+Targets come from --targets, else from targets in the user config
+(~/.config/viberules/config.yaml), else claude, amazonq, gemini and codex.
+The project starts in local mode unless --mode public is given; like
+'viberules mode public', that refuses to continue if existing files contain
+potential secrets, unless --yes is given. Scripts and scaffolding tools can
+configure a project in one command:
+
+  viberules init --targets claude,codex --mode public --yes
+
+Files viberules didn't create that are in the way of outputs, such as a
+hand-written CLAUDE.md, are listed and nothing is changed. With
+--force-overwrite they are moved to .viberules/backup/<timestamp>/ first.
+With --adopt, the content of existing CLAUDE.md, AGENTS.md, GEMINI.md,
+.cursorrules and .github/copilot-instructions.md files is merged into
+rules.md under a heading per file, leaving out repeated paragraphs. The
+originals are moved to the backup directory and replaced with links.
+
+Outside a git repository, init warns that .gitignore has no effect. With
+--no-git, no .gitignore is written and git: false is saved in the config,
+so later commands skip git as well.
+
+With --global, manages user-level rules instead: ~/.viberules/rules.md is
+linked to ~/.claude/CLAUDE.md, ~/.codex/AGENTS.md, ~/.gemini/GEMINI.md and
+~/.config/opencode/AGENTS.md.`,
+	Annotations: journaled,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return initProject()
 	},
 }
 
 var addCmd = &cobra.Command{
-	Use:   "add [target]",
-	Short: "Add target",
-	Long: `Enable the specified AI assistant target.
-Available targets: claude, amazonq, gemini`,
-	Args: cobra.ExactArgs(1),
+	Use:   "add [target...]",
+	Short: "Add targets",
+	Long: `Enable the specified AI assistant targets, or with --all every target
+that policy allows. Run 'viberules list' to see available targets and groups;
+a group name adds each of its targets (viberules add cli).
+
+Targets are added together: if one can't be added, none are, and the
+config and .gitignore are left as they were. Files not
+created by viberules in the way of outputs are listed and nothing is added,
+unless --force-overwrite moves them to .viberules/backup/<timestamp>/.`,
+	Annotations: journaled,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return addTarget(args[0])
+		targets, err := targetArgs(args, addableTargets)
+		if err != nil {
+			return err
+		}
+		return addTargets(targets...)
 	},
 }
 
 var removeCmd = &cobra.Command{
-	Use:   "remove [target]",
-	Short: "Remove target",
-	Long: `Disable the specified AI assistant target.
-Available targets: claude, amazonq, gemini`,
-	Args: cobra.ExactArgs(1),
+	Use:   "remove [target...]",
+	Short: "Remove targets",
+	Long: `Disable the specified AI assistant targets, or with --all every enabled
+target. Run 'viberules list' to see available targets and groups.
+
+Targets are removed together: if one can't be removed, none are.
+
+With --user, removes an entry created by 'viberules link --user'.`,
+	Annotations: journaled,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return removeTarget(args[0])
+		if userLink {
+			if allTargets || len(args) != 1 {
+				return fmt.Errorf("--user removes one user link at a time")
+			}
+			return unlinkUserTarget(args[0])
+		}
+		targets, err := targetArgs(args, loadEnabledTargets)
+		if err != nil {
+			return err
+		}
+		return removeTargets(targets...)
 	},
 }
 
@@ -89,6 +236,9 @@ var listCmd = &cobra.Command{
 	Short: "List enabled targets",
 	Long:  "Show currently enabled AI assistant targets.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			return listTargetsJSON()
+		}
 		return listTargets()
 	},
 }
@@ -100,12 +250,16 @@ var modeCmd = &cobra.Command{
 	
 Modes:
 - public: .viberules directory is tracked by git (shared rules)
-- local: .viberules directory is ignored by git (personal rules)`,
+- local: .viberules directory is ignored by git (personal rules)
+
+Switching to public scans the files that become tracked for API keys, tokens,
+private keys and private URLs, and refuses to continue without --force.`,
+	Annotations: journaled,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			// Show current mode
 			mode := getProjectMode()
-			fmt.Printf("Current mode: %s\n", mode)
+			ui.Print("Current mode: %s\n", mode)
 			return nil
 		}
 		
@@ -117,216 +271,521 @@ Modes:
 	},
 }
 
+var strategyCmd = &cobra.Command{
+	Use:   "strategy [symlink|copy|generate|pointer|default]",
+	Short: "Get or set link strategy",
+	Long: `Get or set how target outputs are created.
+
+Strategies:
+- symlink: outputs are symlinks to .viberules/rules.md (default)
+- copy: outputs are real files copied from .viberules/rules.md;
+  checksums are recorded so status/sync can detect manual edits
+- generate: like copy, but outputs are rendered through a transformation
+  pipeline (e.g. per-target frontmatter from target_settings)
+- pointer: outputs are tiny files importing .viberules/rules.md with
+  @path lines, which can be committed instead of symlinks (claude and
+  gemini only; set with --target)
+
+Use --target to override the strategy for a single target
+(e.g. copy for a file the team commits). 'default' removes the override.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return showStrategy(strategyTarget)
+		}
+
+		return setStrategyCommand(args[0], strategyTarget)
+	},
+}
+
 func initProject() error {
-	if !silent {
-		fmt.Println("🚀 Initializing viberules project...")
-	}
+	ui.Info("🚀 Initializing viberules project...\n")
 
 	// Check if .viberules directory already exists
-	if stat, err := os.Stat(".viberules"); err == nil && stat.IsDir() {
+	if stat, err := engine.FS().Stat(".viberules"); err == nil && stat.IsDir() {
 		if !force {
 			return fmt.Errorf(".viberules directory already exists. Use --force to reinitialize")
 		}
-		if !silent {
-			fmt.Println("⚠️  Reinitializing existing project...")
-			fmt.Println("   - Existing .viberules/rules.md will be preserved")
-			fmt.Println("   - Missing files will be created")
-			fmt.Println("   - Symlinks will be recreated")
+		ui.Info("⚠️  Reinitializing existing project...\n")
+		ui.Info("   - Existing rules file will be preserved\n")
+		ui.Info("   - Missing files will be created\n")
+		ui.Info("   - Symlinks will be recreated\n")
+		ok, err := confirm("Reinitializing resets these settings:", []string{configFilePath + " (mode and targets)"})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errAborted
 		}
 	}
 
-	// Create .viberules directory
-	if err := os.MkdirAll(".viberules", 0755); err != nil {
-		return fmt.Errorf("failed to create .viberules directory: %w", err)
+	// Resolve the template and targets before creating anything so an unknown name leaves no trace
+	targets, err := initTargetList()
+	if err != nil {
+		return err
+	}
+	mode := "local"
+	if initMode != "" {
+		if globalMode {
+			return fmt.Errorf("--mode is not supported with --global (user-level rules are never tracked by git)")
+		}
+		if initMode != "public" && initMode != "local" {
+			return fmt.Errorf("invalid mode: %s (must be 'public' or 'local')", initMode)
+		}
+		mode = initMode
+	}
+	// A forced reinit keeps the configured rules file; an invalid config is replaced below
+	if _, err := readConfigFile(); err != nil {
+		engine.SetRulesFile("")
+	}
+	rulesFile := engine.RulesFile()
+	rulesExisted := fileExists(rulesFile)
+	if rulesExisted {
+		logger.Debug("skipping existing rules file", "path", rulesFile)
+	}
+	var rulesContent []byte
+	if !rulesExisted && importedRules != nil {
+		rulesContent = importedRules
+	} else if !rulesExisted {
+		content, err := rulesTemplateContent()
+		if err != nil {
+			return err
+		}
+		rulesContent = content
 	}
 
-	// Create single rules.md file only if it doesn't exist
-	rulesFile := ".viberules/rules.md"
-	if !fileExists(rulesFile) {
-		rulesContent := `# AI Assistant Rules
+	// --adopt imports the files assistants already read into the rules file
+	var adopted []string
+	if initAdopt {
+		if globalMode {
+			return fmt.Errorf("--adopt is not supported with --global")
+		}
+		rules := rulesContent
+		if rulesExisted {
+			if rules, err = engine.FS().ReadFile(rulesFile); err != nil {
+				return fmt.Errorf("failed to read %s: %w", rulesFile, err)
+			}
+		} else if initTemplate == "" {
+			rules = nil // imported rules replace the generic skeleton
+		}
+		merged, files, err := engine.AdoptFiles(rules, core.AdoptableFiles)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			if err := requireConfirmation("--adopt will import these files into "+rulesFile+" and move them to "+core.BackupDir+":", files); err != nil {
+				return err
+			}
+			rulesContent, adopted = merged, files
+		}
+	}
+	// Report every file in the way of an output before creating anything
+	existing, err := loadConfig()
+	if err != nil {
+		existing = &Config{}
+	}
+	if err := checkOccupiedOutputs(existing, targets, adopted); err != nil {
+		return err
+	}
 
-> ⚠️ IMPORTANT: Edit THIS FILE (rules.md) to update rules for ALL AI assistants
-> Changes here automatically apply to Claude, Amazon Q, Gemini, Codex, etc.
+	// A reinitialized project may already hold files that public mode tracks
+	if mode == "public" && fileExists(".viberules") {
+		if err := checkPublicFiles(assumeYes, "--yes"); err != nil {
+			return err
+		}
+	}
 
-## Project Overview
-Describe your project, tech stack, and coding standards here.
+	// Create .viberules directory
+	if err := engine.FS().MkdirAll(".viberules", 0755); err != nil {
+		return fmt.Errorf("failed to create .viberules directory: %w", err)
+	}
 
-## Coding Standards
-- Use TypeScript with strict mode
-- Follow ESLint configuration
-- Write unit tests for all functions
-- Use descriptive variable names
+	// Create single rules.md file only if it doesn't exist, or update it with adopted files
+	if rulesContent != nil {
+		if err := engine.FS().MkdirAll(filepath.Dir(rulesFile), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rulesFile, err)
+		}
+		if err := engine.FS().WriteFile(rulesFile, rulesContent, 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", rulesFile, err)
+		}
+		if force && !rulesExisted {
+			ui.Info("📝 Created %s\n", rulesFile)
+		}
+	}
+	if rulesExisted && force && adopted == nil {
+		ui.Info("📋 Preserved existing %s\n", rulesFile)
+		if initTemplate != "" {
+			ui.Info("   (--template only applies when rules.md is created)\n")
+		}
+	}
 
-## Architecture Guidelines
-- Follow clean architecture principles
-- Separate business logic from UI
-- Use dependency injection
+	// Personal rules and notes are ignored by git in every mode (*.local.md)
+	if !globalMode && !fileExists(core.LocalRulesFile) {
+		if err := engine.FS().WriteFile(core.LocalRulesFile, []byte(core.LocalRulesContent), 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", core.LocalRulesFile, err)
+		}
+	}
+	if !globalMode && !fileExists(core.NotesFile) {
+		if err := engine.FS().WriteFile(core.NotesFile, []byte(core.NotesContent), 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", core.NotesFile, err)
+		}
+	}
 
-## Git Workflow
-- Use conventional commits
-- Create feature branches
-- Require code review for main branch
+	// Initialize default config (--mode, else local, and the resolved targets).
+	// Other settings such as the link strategy survive a forced reinit.
+	config, err := loadConfig()
+	if err != nil {
+		config = &Config{}
+	}
+	config.Mode = mode
+	config.Targets = targets
+	if noGit {
+		disabled := false
+		config.Git = &disabled
+	}
 
----
-*This file is automatically linked to all AI assistants via viberules*
-`
+	// Adopted files are kept in the backup directory; their content is in the rules file now
+	now := time.Now()
+	for _, path := range adopted {
+		if err := engine.BackupFile(path, core.NewBackupDir(now)); err != nil {
+			return err
+		}
+		ui.Info("📥 Imported %s into %s\n", path, rulesFile)
+	}
 
-		if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
-			return fmt.Errorf("failed to create .viberules/rules.md: %w", err)
+	// Create outputs for each target, moving aside files that are in the way
+	for _, target := range config.Targets {
+		if err := backupOutputs(config, target, now); err != nil {
+			return fmt.Errorf("failed to create outputs for %s: %w", target, err)
 		}
-		if !silent && force {
-			fmt.Println("📝 Created .viberules/rules.md")
+		if err := createTargetOutputs(config, target, false); err != nil {
+			return fmt.Errorf("failed to create outputs for %s: %w", target, err)
 		}
-	} else if !silent && force {
-		fmt.Println("📋 Preserved existing .viberules/rules.md")
+		reportOutputs(config, target)
 	}
 
-	// Add to .gitignore
-	if err := addToGitignore(); err != nil {
-		if !silent {
-			fmt.Printf("⚠️  Failed to update .gitignore: %v\n", err)
+	if err := saveConfig(config); err != nil {
+		ui.Warn("⚠️  Failed to create config file: %v\n", err)
+	}
+
+	// Adopted files no enabled target writes still point assistants at the rules
+	for _, path := range adopted {
+		if _, err := engine.FS().Lstat(path); os.IsNotExist(err) {
+			if err := engine.LinkToRules(path); err != nil {
+				return fmt.Errorf("failed to link %s: %w", path, err)
+			}
 		}
-	} else if !silent {
-		fmt.Println("📝 Added *.local.md to .gitignore")
 	}
 
-	// Create symlinks
-	if err := core.CreateAllSymlinks(); err != nil {
-		return fmt.Errorf("failed to create symlinks: %w", err)
+	// Add to .gitignore once the enabled targets are saved (user-level rules don't live in a repository)
+	if !globalMode && config.usesGit() {
+		if err := addToGitignore(); err != nil {
+			ui.Warn("⚠️  Failed to update .gitignore: %v\n", err)
+		} else {
+			ui.Info("📝 Added *.local.md to .gitignore\n")
+		}
+		// The ignore rules only take effect inside a repository
+		if _, err := core.GitRoot(projectDir()); err != nil {
+			ui.Warn("⚠️  %s is not inside a git repository, so .gitignore has no effect. Run 'git init' first, or use --no-git for projects without git\n", engine.RulesFile())
+		}
 	}
 
-	// Initialize default config (local mode, all targets)
-	defaultConfig := &Config{
-		Mode:    "local",
-		Targets: []string{"claude", "amazonq", "gemini", "codex"},
+	ui.Success("viberules project initialized successfully!\n")
+	ui.Info("📁 Created files:\n")
+	ui.Info("   - %s (rules shared by all AI tools)\n", rulesFile)
+	ui.Info("   - Symlinks for each AI tool\n")
+	ui.Info("\n")
+	ui.Info("Next steps:\n")
+	ui.Info("1. Edit %s to write your project rules\n", rulesFile)
+	ui.Info("2. Use 'viberules remove [target]' to remove unnecessary targets\n")
+
+	return nil
+}
+
+// initTargets is set by init --targets to choose the enabled targets
+var initTargets string
+
+// initMode is set by init --mode
+var initMode string
+
+// initAdopt is set by init --adopt to import existing assistant rules files
+var initAdopt bool
+
+// initTargetList returns the targets init enables: --targets (where groups
+// are expanded), then the user config, then the built-in defaults. Targets
+// blocked by the user config are refused when asked for and left out of the
+// defaults.
+func initTargetList() ([]string, error) {
+	user, err := loadUserConfig()
+	if err != nil {
+		return nil, err
 	}
-	if err := saveConfig(defaultConfig); err != nil {
-		if !silent {
-			fmt.Printf("⚠️  Failed to create config file: %v\n", err)
-		}
+	policy := &Config{user: user}
+	// A reinitialized project keeps its groups
+	if existing, err := readConfigFile(); err == nil {
+		policy.Groups = existing.Groups
 	}
 
-	if !silent {
-		fmt.Println("✅ viberules project initialized successfully!")
-		fmt.Println("📁 Created files:")
-		fmt.Println("   - .viberules/rules.md (rules shared by all AI tools)")
-		fmt.Println("   - Symlinks for each AI tool")
-		fmt.Println("")
-		fmt.Println("Next steps:")
-		fmt.Println("1. Edit .viberules/rules.md to write your project rules")
-		fmt.Println("2. Use 'viberules remove [target]' to remove unnecessary targets")
+	if initTargets != "" {
+		var names []string
+		for _, name := range strings.Split(initTargets, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		var targets []string
+		for _, name := range policy.expandTargets(names) {
+			if !isValidTarget(name) {
+				return nil, invalidTargetError(name)
+			}
+			if err := policy.checkTargetAllowed(name); err != nil {
+				return nil, err
+			}
+			targets = append(targets, name)
+		}
+		return targets, nil
 	}
 
-	return nil
+	// User targets are project targets; global mode enables every user-level target
+	candidates := defaultTargets()
+	if engine.Scope() == core.ScopeProject && len(user.Targets) > 0 {
+		candidates = user.Targets
+	}
+	var targets []string
+	for _, name := range candidates {
+		if policy.checkTargetAllowed(name) == nil {
+			targets = append(targets, name)
+		}
+	}
+	return targets, nil
 }
 
-func addTarget(target string) error {
-	if !isValidTarget(target) {
-		return fmt.Errorf("invalid target: %s (available: claude, amazonq, gemini, codex)", target)
+// allTargets is set by add --all and remove --all
+var allTargets bool
+
+// addTargets enables targets as one transaction: every name is checked before
+// anything is written, and if creating the outputs of one target fails the
+// outputs already created for the others are removed again
+func addTargets(targets ...string) error {
+	for _, target := range targets {
+		if !isValidTarget(target) {
+			return invalidTargetError(target)
+		}
 	}
 
-	if !fileExists(".viberules/rules.md") {
-		return fmt.Errorf(".viberules/rules.md not found. Run 'viberules init' first")
+	if err := requireRulesFile(); err != nil {
+		return err
 	}
 
 	// Load current targets
-	enabledTargets, err := loadEnabledTargets()
+	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load target settings: %w", err)
 	}
 
-	// Check if already enabled
-	for _, enabled := range enabledTargets {
-		if enabled == target {
-			fmt.Printf("Target '%s' is already enabled\n", target)
-			return nil
+	var added []string
+	for _, target := range targets {
+		if containsName(config.Targets, target) || containsName(added, target) {
+			ui.Info("Target '%s' is already enabled\n", target)
+			continue
+		}
+		if err := config.checkTargetAllowed(target); err != nil {
+			return err
 		}
+		added = append(added, target)
 	}
 
-	// Add target
-	enabledTargets = append(enabledTargets, target)
+	// Create outputs for each target, moving aside files that are in the way
+	if err := checkOccupiedOutputs(config, added, nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	for i, target := range added {
+		err := backupOutputs(config, target, now)
+		if err == nil {
+			err = createTargetOutputs(config, target, false)
+		}
+		if err != nil {
+			for _, done := range added[:i] {
+				if rollbackErr := removeTargetOutputs(config, done); rollbackErr != nil {
+					ui.Warn("⚠️  Failed to roll back target '%s': %v\n", done, rollbackErr)
+				}
+			}
+			return fmt.Errorf("failed to create outputs for target '%s': %w", target, err)
+		}
+		reportOutputs(config, target)
+	}
+	if len(added) == 0 {
+		return nil
+	}
 
-	// Save configuration
-	if err := saveEnabledTargets(enabledTargets); err != nil {
+	// Add targets and save configuration
+	config.Targets = append(config.Targets, added...)
+	if err := saveConfig(config); err != nil {
 		return fmt.Errorf("failed to save target settings: %w", err)
 	}
 
-	// Create symlinks for this target
-	if err := core.CreateTargetSymlinks(target); err != nil {
-		return fmt.Errorf("failed to create symlinks for target '%s': %w", target, err)
-	}
+	refreshGitignore()
+	refreshGitattributes()
 
-	fmt.Printf("✅ Target '%s' added successfully\n", target)
+	for _, target := range added {
+		ui.Success("Target '%s' added successfully\n", target)
+	}
 	return nil
 }
 
-func removeTarget(target string) error {
-	if !isValidTarget(target) {
-		return fmt.Errorf("invalid target: %s (available: claude, amazonq, gemini, codex)", target)
+// removeTargets disables targets as one transaction: if removing the outputs
+// of one target fails, the outputs already removed for the others are recreated
+func removeTargets(targets ...string) error {
+	for _, target := range targets {
+		if !isValidTarget(target) {
+			return invalidTargetError(target)
+		}
 	}
 
 	// Load current targets
-	enabledTargets, err := loadEnabledTargets()
+	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load target settings: %w", err)
 	}
 
-	// Remove target from list
-	newTargets := make([]string, 0)
-	found := false
-	for _, enabled := range enabledTargets {
-		if enabled != target {
-			newTargets = append(newTargets, enabled)
-		} else {
-			found = true
+	var removed []string
+	for _, target := range targets {
+		if !containsName(config.Targets, target) || containsName(removed, target) {
+			ui.Info("Target '%s' is not enabled\n", target)
+			continue
 		}
+		removed = append(removed, target)
 	}
 
-	if !found {
-		fmt.Printf("Target '%s' is not enabled\n", target)
+	// Remove outputs for each target
+	for i, target := range removed {
+		if err := removeTargetOutputs(config, target); err != nil {
+			for _, done := range removed[:i] {
+				if rollbackErr := createTargetOutputs(config, done, false); rollbackErr != nil {
+					ui.Warn("⚠️  Failed to roll back target '%s': %v\n", done, rollbackErr)
+				}
+			}
+			return fmt.Errorf("failed to remove outputs for target '%s': %w", target, err)
+		}
+	}
+	if len(removed) == 0 {
 		return nil
 	}
 
 	// Save configuration
-	if err := saveEnabledTargets(newTargets); err != nil {
+	newTargets := make([]string, 0)
+	for _, enabled := range config.Targets {
+		if !containsName(removed, enabled) {
+			newTargets = append(newTargets, enabled)
+		}
+	}
+	config.Targets = newTargets
+	if err := saveConfig(config); err != nil {
 		return fmt.Errorf("failed to save target settings: %w", err)
 	}
 
-	// Remove symlinks for this target
-	if err := core.RemoveTargetSymlinks(target); err != nil {
-		return fmt.Errorf("failed to remove symlinks for target '%s': %w", target, err)
-	}
+	refreshGitignore()
+	refreshGitattributes()
 
-	fmt.Printf("✅ Target '%s' removed successfully\n", target)
+	for _, target := range removed {
+		ui.Success("Target '%s' removed successfully\n", target)
+	}
 	return nil
 }
 
+// targetArgs returns the targets named on the command line, with groups
+// expanded, or with --all the ones all returns
+func targetArgs(args []string, all func() ([]string, error)) ([]string, error) {
+	if !allTargets {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("requires at least one target, or --all")
+		}
+		config, err := loadConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load target settings: %w", err)
+		}
+		return config.expandTargets(args), nil
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("--all can't be combined with target names")
+	}
+	return all()
+}
+
+// addableTargets returns every target of the current scope that isn't
+// enabled yet and that policy allows
+func addableTargets() ([]string, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load target settings: %w", err)
+	}
+	var targets []string
+	for _, name := range engine.TargetNames() {
+		if !containsName(config.Targets, name) && config.checkTargetAllowed(name) == nil {
+			targets = append(targets, name)
+		}
+	}
+	return targets, nil
+}
+
 func listTargets() error {
 	enabledTargets, err := loadEnabledTargets()
 	if err != nil {
 		return fmt.Errorf("failed to load target settings: %w", err)
 	}
 
-	fmt.Println("Enabled targets:")
+	ui.Print("Enabled targets:\n")
 	if len(enabledTargets) == 0 {
-		fmt.Println("  (none)")
+		ui.Print("  (none)\n")
 	} else {
 		for _, target := range enabledTargets {
-			fmt.Printf("  - %s\n", target)
+			ui.Print("  - %s\n", target)
 		}
 	}
 
-	fmt.Println("\nAvailable targets:")
-	for _, target := range []string{"claude", "amazonq", "gemini", "codex"} {
-		fmt.Printf("  - %s\n", target)
+	ui.Print("\nAvailable targets:\n")
+	for _, target := range engine.TargetNames() {
+		ui.Print("  - %s\n", target)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	ui.Print("\nGroups:\n")
+	for _, name := range config.groupNames() {
+		members, _ := config.targetGroup(name)
+		ui.Print("  - %s: %s\n", name, strings.Join(members, ", "))
+	}
+
+	if len(config.UserLinks) > 0 {
+		ui.Print("\nUser links:\n")
+		for _, target := range core.GetUserLinkTargets() {
+			if path, ok := config.UserLinks[target.Name]; ok {
+				ui.Print("  - %s: %s\n", target.Name, path)
+			}
+		}
 	}
 
 	return nil
 }
 
 func setModeCommand(mode string) error {
-	if !fileExists(".viberules/rules.md") {
-		return fmt.Errorf(".viberules/rules.md not found. Run 'viberules init' first")
+	if globalMode {
+		return fmt.Errorf("mode is not supported with --global (user-level rules are never tracked by git)")
+	}
+
+	if err := requireRulesFile(); err != nil {
+		return err
+	}
+
+	// Files that become tracked by git must not leak credentials
+	if mode == "public" {
+		if err := checkPublicFiles(force, "--force"); err != nil {
+			return err
+		}
 	}
 	
 	if err := setProjectMode(mode); err != nil {
@@ -335,46 +794,374 @@ func setModeCommand(mode string) error {
 	
 	// Update gitignore based on new mode
 	if err := addToGitignore(); err != nil {
-		fmt.Printf("⚠️  Failed to update .gitignore: %v\n", err)
+		ui.Warn("⚠️  Failed to update .gitignore: %v\n", err)
 	}
 	
-	fmt.Printf("✅ Project mode set to '%s'\n", mode)
+	ui.Success("Project mode set to '%s'\n", mode)
 	if mode == "public" {
-		fmt.Println("📁 .viberules/rules.md will be tracked by git")
-		fmt.Println("🔒 .viberules/.config.yaml will be ignored by git")
+		ui.Info("📁 %s will be tracked by git\n", engine.RulesFile())
+		ui.Info("🔒 .viberules/.config.yaml will be ignored by git\n")
 	} else {
-		fmt.Println("🔒 .viberules directory will be ignored by git")
+		ui.Info("🔒 .viberules directory will be ignored by git\n")
 	}
 	
 	return nil
 }
 
-func isValidTarget(target string) bool {
-	for _, valid := range []string{"claude", "amazonq", "gemini", "codex"} {
-		if target == valid {
-			return true
+func showStrategy(target string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if target != "" {
+		if !isValidTarget(target) {
+			return invalidTargetError(target)
 		}
+		ui.Print("Link strategy for '%s': %s\n", target, config.strategyFor(target))
+		return nil
 	}
-	return false
+
+	ui.Print("Current link strategy: %s", config.linkStrategy())
+	if config.LinkStrategy == "" && config.user != nil && config.user.LinkStrategy != "" {
+		ui.Print(" (from user config)")
+	}
+	ui.Print("\n")
+	for _, name := range config.Targets {
+		if config.strategyFor(name) == config.linkStrategy() {
+			continue
+		}
+		if config.TargetSettings[name].LinkStrategy != "" {
+			ui.Print("  - %s: %s (override)\n", name, config.strategyFor(name))
+		} else {
+			ui.Print("  - %s: %s (target default)\n", name, config.strategyFor(name))
+		}
+	}
+	return nil
+}
+
+func setStrategyCommand(strategy, target string) error {
+	if !core.IsValidStrategy(strategy) && !(target != "" && strategy == "default") {
+		return fmt.Errorf("invalid link strategy: %s (must be 'symlink', 'copy', 'generate' or 'pointer')", strategy)
+	}
+	if target != "" && !isValidTarget(target) {
+		return invalidTargetError(target)
+	}
+	if strategy == core.StrategyPointer {
+		if target == "" {
+			return fmt.Errorf("the pointer strategy is set per target (use --target)")
+		}
+		if t, _ := engine.FindTarget(target); !t.Imports {
+			return fmt.Errorf("%s does not support @imports; the pointer strategy is not available", target)
+		}
+	}
+
+	if err := requireRulesFile(); err != nil {
+		return err
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	affected := config.Targets
+	if target == "" {
+		config.LinkStrategy = strategy
+	} else {
+		setTargetStrategy(config, target, strategy)
+		affected = nil
+		for _, enabled := range config.Targets {
+			if enabled == target {
+				affected = []string{target}
+			}
+		}
+	}
+
+	// Recreate outputs of affected targets with the new strategy
+	for _, name := range affected {
+		if err := createTargetOutputs(config, name, false); err != nil {
+			return fmt.Errorf("failed to switch outputs for target '%s': %w", name, err)
+		}
+	}
+
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	// Pointer files are committed, so they leave the ignored outputs
+	if !globalMode {
+		if err := addToGitignore(); err != nil {
+			ui.Warn("⚠️  Failed to update .gitignore: %v\n", err)
+		}
+	}
+
+	if target != "" {
+		ui.Success("Link strategy for '%s' set to '%s'\n", target, config.strategyFor(target))
+		if strategy == core.StrategyPointer && config.Mode == "local" && !globalMode {
+			ui.Info("ℹ️  Local mode keeps .viberules/ out of git; use 'viberules mode public' so committed pointer files resolve for everyone\n")
+		}
+	} else {
+		ui.Success("Link strategy set to '%s'\n", strategy)
+	}
+	return nil
 }
 
+// setTargetStrategy sets or clears ("default") the strategy override of a target
+func setTargetStrategy(config *Config, target, strategy string) {
+	settings := config.TargetSettings[target]
+	if strategy == "default" {
+		settings.LinkStrategy = ""
+	} else {
+		settings.LinkStrategy = strategy
+	}
+
+	if settings.isEmpty() {
+		delete(config.TargetSettings, target)
+		return
+	}
+	if config.TargetSettings == nil {
+		config.TargetSettings = make(map[string]TargetSettings)
+	}
+	config.TargetSettings[target] = settings
+}
+
+func isValidTarget(target string) bool {
+	_, ok := engine.FindTarget(target)
+	return ok
+}
+
+// configFilePath is the project config, relative to the project root
+const configFilePath = ".viberules/.config.yaml"
+
 type Config struct {
-	Mode    string   `yaml:"mode"`
-	Targets []string `yaml:"targets"`
+	Mode         string            `yaml:"mode"`
+	Targets      []string          `yaml:"targets"`
+	LinkStrategy string            `yaml:"link_strategy,omitempty"`
+	Source       string            `yaml:"source,omitempty"`    // rules file every target links to (default .viberules/rules.md)
+	Checksums    map[string]string `yaml:"checksums,omitempty"` // output path -> checksum of copied content
+
+	TargetSettings map[string]TargetSettings `yaml:"target_settings,omitempty"`
+	UserLinks      map[string]string         `yaml:"user_links,omitempty"`   // user link target -> entry path
+	ProfileDirs    map[string]string         `yaml:"profile_dirs,omitempty"` // subdirectory -> profile name
+	Variables      map[string]string         `yaml:"variables,omitempty"`    // {{.Name}} values for generated outputs
+	IgnoreTools    []string                  `yaml:"ignore_tools,omitempty"` // ignore files generated for tools that aren't targets
+	Banner         string                    `yaml:"banner,omitempty"`       // comment put atop copied and generated outputs ("default" or custom text)
+
+	// Inherit merges rules of enclosing projects (monorepo root first) into
+	// generated outputs. Defaults to true.
+	Inherit *bool `yaml:"inherit,omitempty"`
+
+	// GitExclude writes local mode ignore rules to .git/info/exclude, leaving
+	// the shared .gitignore untouched. Defaults to the user config's.
+	GitExclude *bool `yaml:"git_exclude,omitempty"`
+
+	// Git keeps the .gitignore section up to date and allows git hooks.
+	// init --no-git sets it to false for projects that don't live in git.
+	Git *bool `yaml:"git,omitempty"`
+
+	// Target policy, combined with the user config's (see policy.go)
+	BlockedTargets  []string `yaml:"blocked_targets,omitempty"`  // targets add refuses to enable
+	RequiredTargets []string `yaml:"required_targets,omitempty"` // targets check requires
+	Policy          Policy   `yaml:"policy,omitempty"`           // rules sections every project must contain
+
+	// Shared is the team's central rules repository, merged in by pull
+	Shared SharedRules `yaml:"shared,omitempty"`
+
+	// PackIndex lists the rule packs pack search and install offer. Defaults to the user config's.
+	PackIndex string `yaml:"pack_index,omitempty"`
+
+	// Groups name sets of targets usable in add, remove and init --targets (see groups.go)
+	Groups map[string][]string `yaml:"groups,omitempty"`
+
+	// Output is auto, plain or rich; see configureOutput. Defaults to the user config's.
+	Output string `yaml:"output,omitempty"`
+
+	// Trash moves files edited by hand that outputs overwrite or remove to the
+	// platform trash instead of deleting them. Defaults to the user config's.
+	Trash *bool `yaml:"trash,omitempty"`
+
+	// Offline forbids network access: remote includes come from the cache of
+	// content fetched before, and commands that need the network refuse to
+	// run. Defaults to the user config's.
+	Offline *bool `yaml:"offline,omitempty"`
+
+	// CacheTTL is how long fetched remote content is reused without fetching
+	// it again, as a duration such as 30m; 0 always fetches. Defaults to the
+	// user config's, then to defaultCacheTTL.
+	CacheTTL string `yaml:"cache_ttl,omitempty"`
+
+	env  *envOverrides // values replaced by environment variables, restored on save
+	user *UserConfig   // personal defaults under the project config
+}
+
+// TargetSettings holds per-target overrides of project settings
+type TargetSettings struct {
+	LinkStrategy string                 `yaml:"link_strategy,omitempty"`
+	Frontmatter  map[string]interface{} `yaml:"frontmatter,omitempty"` // injected in generate mode
+	TokenBudget  int                    `yaml:"token_budget,omitempty"` // warn when the target's rules exceed this many tokens
+	Overflow     string                 `yaml:"overflow,omitempty"`     // warn, truncate or split rules over the token budget (generate mode)
+	Output       string                 `yaml:"output,omitempty"`       // rules file path replacing the target's default (e.g. docs/CLAUDE.md)
+	TrackOutput  bool                   `yaml:"track_output,omitempty"` // commit outputs instead of ignoring them (symlinks become copies)
+}
+
+// isEmpty reports whether no setting of the target is overridden
+func (s TargetSettings) isEmpty() bool {
+	return s.LinkStrategy == "" && len(s.Frontmatter) == 0 && s.TokenBudget == 0 && s.Overflow == "" && s.Output == "" && !s.TrackOutput
+}
+
+// gitExclude reports whether local mode ignore rules go to .git/info/exclude
+func (c *Config) gitExclude() bool {
+	if c.GitExclude != nil {
+		return *c.GitExclude
+	}
+	return c.user != nil && c.user.GitExclude
+}
+
+// usesGit reports whether git integration is on: not turned off by --no-git
+// or by git: false
+func (c *Config) usesGit() bool {
+	if noGit {
+		return false
+	}
+	return c.Git == nil || *c.Git
+}
+
+// trash reports whether overwritten and removed files go to the platform trash
+func (c *Config) trash() bool {
+	if c.Trash != nil {
+		return *c.Trash
+	}
+	return c.user != nil && c.user.Trash
+}
+
+// offline reports whether network access is off: by --offline, then
+// VIBERULES_OFFLINE, then the project and user configs
+func (c *Config) offline() bool {
+	if offlineFlag {
+		return true
+	}
+	if value, err := strconv.ParseBool(os.Getenv(envOffline)); err == nil {
+		return value
+	}
+	if c.Offline != nil {
+		return *c.Offline
+	}
+	return c.user != nil && c.user.Offline
+}
+
+// defaultCacheTTL is how long fetched remote content is reused unless the
+// config says otherwise
+const defaultCacheTTL = 10 * time.Minute
+
+// cacheTTL returns how long fetched remote content is reused. Invalid values,
+// which validation reports, fall back to the default.
+func (c *Config) cacheTTL() time.Duration {
+	value := c.CacheTTL
+	if value == "" && c.user != nil {
+		value = c.user.CacheTTL
+	}
+	if ttl, err := parseCacheTTL(value); err == nil {
+		return ttl
+	}
+	return defaultCacheTTL
 }
 
+// parseCacheTTL parses a cache_ttl value: a duration that isn't negative
+func parseCacheTTL(value string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err == nil && ttl < 0 {
+		err = fmt.Errorf("negative duration")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache_ttl: %s (a duration such as 30m, or 0 to always fetch)", value)
+	}
+	return ttl, nil
+}
+
+// linkStrategy returns the configured link strategy, defaulting to symlinks
+func (c *Config) linkStrategy() string {
+	if c.LinkStrategy != "" {
+		return c.LinkStrategy
+	}
+	if c.user != nil && c.user.LinkStrategy != "" {
+		return c.user.LinkStrategy
+	}
+	return core.StrategySymlink
+}
+
+// outputPaths returns the valid output path overrides of targets.
+// Invalid paths fall back to the target's default output.
+func (c *Config) outputPaths() map[string]string {
+	paths := make(map[string]string)
+	for name, settings := range c.TargetSettings {
+		if settings.Output != "" && engine.ValidateOutputPath(name, settings.Output) == nil {
+			paths[name] = filepath.Clean(settings.Output)
+		}
+	}
+	return paths
+}
+
+// strategyFor returns the link strategy for a target, honoring per-target overrides
+func (c *Config) strategyFor(target string) string {
+	strategy := c.linkStrategy()
+	if settings, ok := c.TargetSettings[target]; ok && settings.LinkStrategy != "" {
+		strategy = settings.LinkStrategy
+	} else if t, ok := engine.FindTarget(target); ok && t.Strategy != "" {
+		strategy = t.Strategy
+	}
+	// A committed output has to be a real file
+	if strategy == core.StrategySymlink && c.tracksOutput(target) {
+		return core.StrategyCopy
+	}
+	return strategy
+}
+
+// tracksOutput reports whether the outputs of a target are committed to git
+func (c *Config) tracksOutput(target string) bool {
+	return c.TargetSettings[target].TrackOutput
+}
+
+// loadConfig reads the project config layered over the user config, with
+// environment variable overrides applied
 func loadConfig() (*Config, error) {
-	configPath := ".viberules/.config.yaml"
+	// Held until the config is saved, so concurrent commands don't interleave
+	if err := lockConfig(); err != nil {
+		return nil, err
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	user, err := loadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	applyUserConfig(config, user, fileExists(configFilePath))
+	if err := applyEnvOverrides(config); err != nil {
+		return nil, err
+	}
+	logger.Debug("loaded config", "path", configFilePath, "exists", fileExists(configFilePath),
+		"mode", config.Mode, "targets", config.Targets, "env_overrides", config.envOverrideNames())
+	engine.SetTrash(config.trash())
+	return config, nil
+}
+
+// readConfigFile reads .viberules/.config.yaml, or the default config if it doesn't exist
+func readConfigFile() (*Config, error) {
+	configPath := configFilePath
 	if !fileExists(configPath) {
+		engine.SetOutputPaths(nil)
+		engine.SetRulesFile("")
 		// Return default config if no config file exists
 		return &Config{
 			Mode:    "local", // Default mode changed to local
-			Targets: []string{"claude", "amazonq", "gemini", "codex"},
+			Targets: defaultTargets(),
 		}, nil
 	}
 
 	// Security: Limit config file size to prevent YAML bomb attacks
-	info, err := os.Stat(configPath)
+	info, err := engine.FS().Stat(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat config file: %w", err)
 	}
@@ -383,33 +1170,50 @@ func loadConfig() (*Config, error) {
 		return nil, fmt.Errorf("config file too large: %d bytes (max %d)", info.Size(), maxConfigSize)
 	}
 
-	content, err := os.ReadFile(configPath)
+	content, err := engine.FS().ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(content, &config); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	var config Config
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
 
-	// Validate mode
-	if config.Mode != "local" && config.Mode != "public" {
-		config.Mode = "local" // Default value
+	// A missing mode means the default; anything else invalid is reported with its line
+	if config.Mode == "" {
+		config.Mode = "local"
+	}
+	if err := checkConfigFile(configPath, &doc, &config); err != nil {
+		return nil, err
 	}
 
+	engine.SetOutputPaths(config.outputPaths())
+	engine.SetRulesFile(config.Source)
+
 	return &config, nil
 }
 
 func saveConfig(config *Config) error {
-	configPath := ".viberules/.config.yaml"
+	configPath := configFilePath
 	
-	content, err := yaml.Marshal(config)
+	saved := withoutEnvOverrides(config)
+	content, err := yaml.Marshal(&saved)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, content, 0644); err != nil {
+	if err := lockConfig(); err != nil {
+		return err
+	}
+	defer unlockConfig()
+	if err := core.WriteFileAtomic(engine.FS(), configPath, content, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -434,112 +1238,440 @@ func saveEnabledTargets(targets []string) error {
 }
 
 func fileExists(filename string) bool {
-	_, err := os.Stat(filename)
+	_, err := engine.FS().Stat(filename)
 	return err == nil
 }
 
 func addToGitignore() error {
-	gitignorePath := ".gitignore"
-	mode := getProjectMode()
-
-	// Create gitignore content based on mode
-	var viberulesSection string
-	if mode == "local" {
-		// Local mode: ignore entire .viberules directory
-		viberulesSection = fmt.Sprintf(`
-%s - entire directory ignored)
-.viberules/
-
-%s (always ignored)
-.viberules/.config.yaml
-
-%s (personal files only)
-*.local.md
-
-%s (symlinked)
-.amazonq/
-CLAUDE.md
-GEMINI.md
-AGENTS.md
-`, gitignoreLocalMode, gitignoreConfigFile, gitignoreLocalFiles, gitignoreOutputFiles)
+	_, err := updateGitignore()
+	return err
+}
+
+// updateGitignore writes the project's ignore section and returns the
+// entries of the previous section it no longer lists, such as outputs of
+// removed targets or of a target whose file name changed
+func updateGitignore() ([]string, error) {
+	config, err := loadConfig()
+	if err != nil {
+		config = &Config{Mode: "local", Targets: defaultTargets()} // fallback to default
+	}
+	if !config.usesGit() {
+		return nil, nil
+	}
+	mode := config.Mode
+
+	// Local mode can keep the section out of the shared .gitignore
+	target, other := ignoreFile{path: ".gitignore"}, ignoreFile{}
+	exclude, excludeErr := gitExcludeFile()
+	if mode == "local" && config.gitExclude() {
+		if excludeErr != nil {
+			return nil, fmt.Errorf("git_exclude needs a git repository: %w", excludeErr)
+		}
+		target, other = exclude, target
+	} else if excludeErr == nil {
+		other = exclude
+	}
+
+	stale, err := target.write(gitignoreSection(config))
+	if err != nil {
+		return nil, err
+	}
+	// Only one file holds the section
+	if other.path != "" {
+		return stale, other.remove()
+	}
+	return stale, nil
+}
+
+// ignoreFile is a file holding the viberules section of the current project:
+// its .gitignore, or the repository's exclude file, which is shared by every
+// project of the repository
+type ignoreFile struct {
+	path   string
+	prefix string // project directory relative to the repository root, for entries of the exclude file
+}
+
+// gitExcludeFile returns the exclude file of the repository containing the project
+func gitExcludeFile() (ignoreFile, error) {
+	path, err := core.GitExcludePath(projectDir())
+	if err != nil {
+		return ignoreFile{}, err
+	}
+	prefix, err := core.GitPrefix(projectDir())
+	if err != nil {
+		return ignoreFile{}, err
+	}
+	return ignoreFile{path: path, prefix: strings.TrimSuffix(filepath.ToSlash(prefix), "/")}, nil
+}
+
+// markers returns the lines the section is written between. Projects below
+// the repository root name their directory, so the sections of a monorepo's
+// packages can share the exclude file.
+func (f ignoreFile) markers() (string, string) {
+	if f.prefix == "" {
+		return gitignoreBegin, gitignoreEnd
+	}
+	return "# >>> viberules " + f.prefix + " >>>", "# <<< viberules " + f.prefix + " <<<"
+}
+
+// entry returns a gitignore entry of the project as written in the file.
+// Entries of a project below the repository root are rooted at its directory;
+// those without a slash keep matching at any depth below it.
+func (f ignoreFile) entry(line string) string {
+	if f.prefix == "" || line == "" || strings.HasPrefix(line, "#") {
+		return line
+	}
+	if strings.Contains(strings.TrimSuffix(line, "/"), "/") {
+		return "/" + f.prefix + "/" + strings.TrimPrefix(line, "/")
+	}
+	return "/" + f.prefix + "/**/" + line
+}
+
+// write replaces the viberules section of the file with section and returns
+// the entries of the replaced section that section doesn't list
+func (f ignoreFile) write(section string) ([]string, error) {
+	contentStr, err := readIgnoreFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	previous := removedEntries(contentStr, f.strip(contentStr))
+
+	beginMarker, endMarker := f.markers()
+	lines := f.entries(section)
+	block := f.block(section)
+
+	if begin, end, ok := ignoreBlock(contentStr, beginMarker, endMarker); ok {
+		// Replace the block in place
+		contentStr = contentStr[:begin] + block + contentStr[end:]
 	} else {
-		// Public mode: track .viberules/rules.md but ignore config
-		viberulesSection = fmt.Sprintf(`
-%s (always ignored)
-.viberules/.config.yaml
-
-%s (personal files only)
-*.local.md
-
-%s (symlinked)
-.amazonq/
-CLAUDE.md
-GEMINI.md
-AGENTS.md
-`, gitignoreConfigFile, gitignoreLocalFiles, gitignoreOutputFiles)
-	}
-
-	// Read existing .gitignore
-	var content []byte
-	var err error
-	if fileExists(gitignorePath) {
-		content, err = os.ReadFile(gitignorePath)
-		if err != nil {
-			return fmt.Errorf("failed to read .gitignore: %w", err)
+		contentStr = f.strip(contentStr)
+
+		// Add viberules section
+		if len(contentStr) > 0 && contentStr[len(contentStr)-1] != '\n' {
+			contentStr += "\n"
 		}
+		contentStr += "\n" + block + "\n"
 	}
 
-	contentStr := string(content)
+	// Write back
+	if err := engine.FS().MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", f.path, err)
+	}
+	if err := engine.FS().WriteFile(f.path, []byte(contentStr), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	engine.Observer().OnGitignoreUpdated(f.path)
 
-	// Remove existing viberules section if present
-	if contains(contentStr, gitignoreLocalFiles) || contains(contentStr, gitignoreLocalMode) {
-		// Simple approach: split by lines and rebuild without viberules section
-		lines := strings.Split(contentStr, "\n")
-		var newLines []string
-		skipSection := false
-		
-		for _, line := range lines {
-			if strings.HasPrefix(line, gitignoreSectionPrefix) {
-				skipSection = true
-				continue
-			}
-			
-			// End of section when we hit another comment section (not viberules)
-			if skipSection {
-				if strings.HasPrefix(line, "#") && !strings.Contains(line, "viberules") {
-					skipSection = false
-					newLines = append(newLines, line)
+	var stale []string
+	for _, entry := range previous {
+		if !containsName(lines, entry) {
+			stale = append(stale, entry)
+		}
+	}
+	return stale, nil
+}
+
+// entries returns the lines of section as written in the file
+func (f ignoreFile) entries(section string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(section, "\n"), "\n") {
+		lines = append(lines, f.entry(line))
+	}
+	return lines
+}
+
+// block returns section as written in the file, between the project's markers
+func (f ignoreFile) block(section string) string {
+	beginMarker, endMarker := f.markers()
+	return beginMarker + "\n" + strings.Join(f.entries(section), "\n") + "\n" + endMarker
+}
+
+// removedEntries returns the entries of an ignore file's content that are
+// missing from stripped, the content without the viberules section
+func removedEntries(content, stripped string) []string {
+	kept := make(map[string]int)
+	for _, line := range strings.Split(stripped, "\n") {
+		kept[strings.TrimSpace(line)]++
+	}
+	var removed []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if kept[line] > 0 {
+			kept[line]--
+			continue
+		}
+		removed = appendNames(removed, []string{line})
+	}
+	return removed
+}
+
+// remove removes the viberules section from the file, if it has one
+func (f ignoreFile) remove() error {
+	contentStr, err := readIgnoreFile(f.path)
+	if err != nil {
+		return err
+	}
+	if !f.has(contentStr) {
+		return nil
+	}
+	stripped := f.strip(contentStr)
+	if stripped != "" {
+		stripped += "\n"
+	}
+	if err := engine.FS().WriteFile(f.path, []byte(stripped), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	engine.Observer().OnGitignoreUpdated(f.path)
+	return nil
+}
+
+// has reports whether content holds the project's viberules section
+func (f ignoreFile) has(content string) bool {
+	beginMarker, _ := f.markers()
+	if f.prefix != "" {
+		return contains(content, beginMarker)
+	}
+	return hasIgnoreSection(content)
+}
+
+// strip returns content without the project's viberules section: the lines
+// between its markers, or, in files written before the markers existed, each
+// "# viberules" heading with its entries up to the next blank line. Lines
+// outside the section, including sections of other projects, are kept as
+// they are.
+func (f ignoreFile) strip(contentStr string) string {
+	if !f.has(contentStr) {
+		return contentStr
+	}
+	beginMarker, endMarker := f.markers()
+
+	lines := strings.Split(contentStr, "\n")
+	var newLines []string
+	inLegacy, inOther := false, false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == beginMarker:
+			// Without an end marker only the headings below are recognized
+			for j := i + 1; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) == endMarker {
+					i = j
+					break
 				}
-				// Skip all lines until we hit another section or end of file
-				continue
 			}
-			
-			newLines = append(newLines, line)
+			continue
+		case trimmed == endMarker:
+			continue
+		case strings.HasPrefix(trimmed, "# >>> viberules "):
+			inOther = true
+		case strings.HasPrefix(trimmed, "# <<< viberules "):
+			inOther = false
+		case inOther:
+		case f.prefix == "" && strings.HasPrefix(line, gitignoreSectionPrefix):
+			inLegacy = true
+			continue
+		case inLegacy:
+			if trimmed == "" {
+				inLegacy = false
+			}
+			continue
 		}
-		
-		contentStr = strings.Join(newLines, "\n")
-		// Remove trailing empty lines
-		contentStr = strings.TrimRight(contentStr, "\n")
+		newLines = append(newLines, line)
 	}
 
-	// Add viberules section
-	if len(contentStr) > 0 && contentStr[len(contentStr)-1] != '\n' {
-		contentStr += "\n"
+	// Remove trailing empty lines
+	return strings.TrimRight(strings.Join(newLines, "\n"), "\n")
+}
+
+// readIgnoreFile returns the content of the ignore file at path, empty if it doesn't exist
+func readIgnoreFile(path string) (string, error) {
+	if !fileExists(path) {
+		return "", nil
 	}
-	contentStr += viberulesSection
+	content, err := engine.FS().ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(content), nil
+}
 
-	// Write back
-	if err := os.WriteFile(gitignorePath, []byte(contentStr), 0644); err != nil {
-		return fmt.Errorf("failed to write .gitignore: %w", err)
+// ignoreBlock returns the byte range of a marked viberules block in an
+// ignore file's content, from its begin marker to the end of its end marker
+func ignoreBlock(content, beginMarker, endMarker string) (int, int, bool) {
+	begin := strings.Index(content, beginMarker+"\n")
+	if begin < 0 || (begin > 0 && content[begin-1] != '\n') {
+		return 0, 0, false
 	}
+	end := strings.Index(content[begin:], "\n"+endMarker)
+	if end < 0 {
+		return 0, 0, false
+	}
+	return begin, begin + end + 1 + len(endMarker), true
+}
 
-	return nil
+// hasIgnoreSection reports whether an ignore file's content has a viberules section
+func hasIgnoreSection(content string) bool {
+	if contains(content, gitignoreBegin) {
+		return true
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, gitignoreSectionPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshGitignore rewrites the gitignore section after the enabled targets
+// change. Projects whose section was removed by hand are left alone.
+func refreshGitignore() {
+	if globalMode || !hasGitignoreSection() {
+		return
+	}
+	if err := addToGitignore(); err != nil {
+		ui.Warn("⚠️  Failed to update .gitignore: %v\n", err)
+	}
+}
+
+// hasGitignoreSection reports whether the project's .gitignore, or the
+// repository's exclude file, has its viberules section
+func hasGitignoreSection() bool {
+	files := []ignoreFile{{path: ".gitignore"}}
+	if exclude, err := gitExcludeFile(); err == nil {
+		files = append(files, exclude)
+	}
+	for _, file := range files {
+		content, err := engine.FS().ReadFile(file.path)
+		if err == nil && file.has(string(content)) {
+			return true
+		}
+	}
+	return false
+}
+
+// staleIgnoreFiles returns the ignore files holding a viberules section that
+// differs from the one sync would write, and .gitattributes when its section
+// doesn't match the tracked outputs
+func staleIgnoreFiles(config *Config) []string {
+	if globalMode || !config.usesGit() {
+		return nil
+	}
+	files := []ignoreFile{{path: ".gitignore"}}
+	if exclude, err := gitExcludeFile(); err == nil {
+		files = append(files, exclude)
+	}
+	section := gitignoreSection(config)
+	var stale []string
+	for _, file := range files {
+		content, err := engine.FS().ReadFile(file.path)
+		if err != nil || !file.has(string(content)) {
+			continue
+		}
+		if !contains(string(content), file.block(section)) {
+			stale = append(stale, file.path)
+		}
+	}
+	if gitattributesStale(config) {
+		stale = append(stale, gitattributesPath)
+	}
+	return stale
 }
 
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
 
+// gitignoreSection returns the ignore rules of the project: personal files,
+// and the outputs of enabled targets. Pointer files and outputs with
+// track_output are meant to be committed, so they are left out.
+func gitignoreSection(config *Config) string {
+	var b strings.Builder
+	group := func(heading string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(heading + "\n")
+		for _, entry := range entries {
+			b.WriteString(entry + "\n")
+		}
+	}
+
+	if config.Mode == "local" {
+		// Local mode: ignore entire .viberules directory, and a rules file kept outside it
+		private := []string{".viberules/"}
+		if !strings.HasPrefix(engine.RulesFile(), ".viberules"+string(filepath.Separator)) {
+			private = append(private, filepath.ToSlash(engine.RulesFile()))
+		}
+		group(gitignoreLocalMode+" - entire directory ignored)", private)
+	}
+	group(gitignoreConfigFile+" (always ignored)", []string{".viberules/.config.yaml"})
+	group(gitignoreLocalFiles+" (personal files only)", []string{"*.local.md", filepath.ToSlash(core.BackupDir) + "/", filepath.ToSlash(journalDir) + "/"})
+	group(gitignoreOutputFiles+" (symlinked)", gitignoreOutputs(config))
+	group(gitignoreIgnoreFiles+" (generated from .viberules/ignore)", gitignoreToolFiles(config))
+	group(gitignoreMCPFiles+" (hold values of environment variables)", gitignoreResolvedMCPFiles(config))
+	return b.String()
+}
+
+// gitignoreOutputs returns the ignore entries of enabled targets' outputs,
+// in target order
+func gitignoreOutputs(config *Config) []string {
+	var entries []string
+	for _, target := range engine.Targets() {
+		if !containsName(config.Targets, target.Name) || config.strategyFor(target.Name) == core.StrategyPointer || config.tracksOutput(target.Name) {
+			continue
+		}
+		// Parts share their directory with the main output
+		if target.PartsDir != "" {
+			entries = appendNames(entries, []string{filepath.ToSlash(target.PartsDir) + "/"})
+		}
+		for _, link := range target.Links {
+			if target.PartsDir == "" || filepath.Dir(link.Target) != target.PartsDir {
+				entries = appendNames(entries, []string{filepath.ToSlash(link.Target)})
+			}
+		}
+	}
+	return entries
+}
+
+// gitignoreToolFiles returns the generated ignore files of enabled targets
+// and ignore_tools. Without .viberules/ignore nothing is generated, and a
+// hand-written .aiexclude stays tracked.
+func gitignoreToolFiles(config *Config) []string {
+	if !fileExists(core.IgnoreSource) {
+		return nil
+	}
+	var entries []string
+	for _, tool := range core.GetIgnoreTools() {
+		if config.ignoreEnabled(tool.Name) {
+			entries = append(entries, tool.Path)
+		}
+	}
+	return entries
+}
+
+// gitignoreResolvedMCPFiles returns the MCP files of enabled targets that hold
+// environment variables resolved at generation time, often secrets
+func gitignoreResolvedMCPFiles(config *Config) []string {
+	servers, err := engine.LoadMCPConfig()
+	if err != nil || servers == nil {
+		return nil
+	}
+	var entries []string
+	for _, target := range core.GetMCPTargets() {
+		if containsName(config.Targets, target.Name) && len(target.ResolvedVariables(servers)) > 0 {
+			entries = append(entries, filepath.ToSlash(target.Path))
+		}
+	}
+	return entries
+}
+
 // getProjectMode returns the current project mode (public or local)
 func getProjectMode() string {
 	config, err := loadConfig()
@@ -550,6 +1682,27 @@ func getProjectMode() string {
 }
 
 // setProjectMode sets the project mode (public or local)
+// checkPublicFiles scans the files public mode tracks for potential secrets
+// and fails if any are found, unless proceed is set by the given flag
+func checkPublicFiles(proceed bool, flag string) error {
+	findings, err := engine.ScanPublicFiles()
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	ui.Print("🔍 Potential secrets in files that will be tracked by git:\n")
+	for _, finding := range findings {
+		ui.Print("  - %s\n", finding)
+	}
+	if !proceed {
+		return fmt.Errorf("found %d potential secret(s). Remove them or use %s to switch to public mode anyway", len(findings), flag)
+	}
+	ui.Warn("⚠️  Continuing because of %s\n", flag)
+	return nil
+}
+
 func setProjectMode(mode string) error {
 	if mode != "public" && mode != "local" {
 		return fmt.Errorf("invalid mode: %s (must be 'public' or 'local')", mode)
@@ -566,17 +1719,120 @@ func setProjectMode(mode string) error {
 
 func init() {
 	initCmd.Flags().BoolVarP(&force, "force", "f", false, "Force reinitialize existing project")
+	initCmd.Flags().StringVarP(&initTemplate, "template", "t", "", "Seed rules.md from a template (see 'viberules templates list')")
+	initCmd.Flags().StringVar(&initTargets, "targets", "", "Comma-separated targets to enable (default from the user config)")
+	initCmd.Flags().StringVar(&initMode, "mode", "", "Project mode: public or local (default local)")
+	for _, cmd := range []*cobra.Command{initCmd, addCmd} {
+		cmd.Flags().BoolVar(&forceOverwrite, "force-overwrite", false, "Back up files not created by viberules that are in the way of outputs to .viberules/backup/ and replace them")
+	}
+	initCmd.Flags().BoolVar(&initAdopt, "adopt", false, "Import existing CLAUDE.md, AGENTS.md, GEMINI.md, .cursorrules and copilot-instructions.md into rules.md")
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Tool to import from: rulesync, ruler or ai-rules")
+	importCmd.Flags().StringVar(&initTargets, "targets", "", "Comma-separated targets to enable instead of the tool's")
+	importCmd.Flags().StringVar(&initMode, "mode", "", "Project mode: public or local (default local)")
+	doctorCmd.Flags().BoolVar(&doctorAdopt, "adopt", false, "Merge legacy files into rules.md and move them to .viberules/backup/")
+	doctorCmd.Flags().BoolVar(&doctorRemove, "remove", false, "Move legacy files to .viberules/backup/")
+	syncCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite copied files that were edited by hand")
+	applyCmd.Flags().BoolVarP(&force, "force", "f", false, "Switch to public mode even if potential secrets are found")
+	modeCmd.Flags().BoolVarP(&force, "force", "f", false, "Switch to public mode even if potential secrets are found")
+	updateCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite generated files that were edited by hand")
+	updateCmd.Flags().BoolVar(&refreshCache, "refresh", false, "Fetch every remote source again instead of reusing what was fetched within cache_ttl")
+	undoCmd.Flags().BoolVarP(&force, "force", "f", false, "Revert files that were changed after the command")
+	hooksInstallCmd.Flags().BoolVarP(&force, "force", "f", false, "Replace hooks not installed by viberules, keeping them as <hook>.orig")
+	publishCmd.Flags().StringVarP(&publishBranch, "branch", "b", "", "Branch to push to (default viberules/<project>)")
+	publishCmd.Flags().StringVarP(&publishMessage, "message", "m", "", "Commit message")
+	publishCmd.Flags().StringVar(&publishBump, "bump", "patch", "Part of the rules version to bump: major, minor or patch")
+	publishCmd.Flags().BoolVar(&publishSign, "sign", false, "Sign the rules with minisign (default when signing.key is set)")
+	proposeCmd.Flags().StringVar(&proposeRules, "rules", "", "Rules file to propose in place of the current one")
+	proposeCmd.Flags().StringVar(&proposeSection, "section", "", "Section to put in the rules")
+	proposeCmd.Flags().StringVarP(&proposeBranch, "branch", "b", "", "Branch to create (default viberules/rules-update)")
+	proposeCmd.Flags().StringVarP(&proposeMessage, "message", "m", "", "Commit message, also the pull request title")
+	proposeCmd.Flags().StringVar(&proposeWorkspace, "workspace", "", "Propose in every viberules project directly under this directory")
+	proposeCmd.Flags().BoolVar(&proposePush, "push", false, "Push the branch to origin")
+	proposeCmd.Flags().BoolVar(&proposePR, "pr", false, "Push and open a pull request (gh) or merge request (glab)")
+	for _, cmd := range []*cobra.Command{statusCmd, syncCmd, checkCmd, updateCmd} {
+		cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Run in every nested viberules project below the current directory")
+		cmd.Flags().BoolVar(&submodules, "submodules", false, "Also run in the viberules projects of checked-out git submodules")
+	}
+	for _, cmd := range []*cobra.Command{listCmd, statusCmd, targetsCmd, doctorCmd} {
+		cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print machine-readable JSON")
+	}
+	linkCmd.Flags().BoolVar(&userLink, "user", false, "Link into the tool's user-level directory")
+	removeCmd.Flags().BoolVar(&userLink, "user", false, "Remove a user-level link created by 'link --user'")
+	addCmd.Flags().BoolVar(&allTargets, "all", false, "Add every available target")
+	removeCmd.Flags().BoolVar(&allTargets, "all", false, "Remove every enabled target")
+	strategyCmd.Flags().StringVarP(&strategyTarget, "target", "t", "", "Get or set the strategy of a single target")
 	
+	rootCmd.PersistentFlags().BoolVarP(&globalMode, "global", "g", false, "Manage user-level rules in the home directory")
+	rootCmd.PersistentFlags().BoolVarP(&silent, "quiet", "q", false, "Only print errors, warnings and the output a command was asked for")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Also print the files each command writes, and debug records to stderr")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append debug records of what the command does to this file, as JSON lines")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to confirmation prompts (init: continue past potential secrets)")
+	rootCmd.PersistentFlags().BoolVar(&plainFlag, "plain", false, "Print plain text without emoji or colors (the default when output is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR or when not writing to a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the files init, add, remove, mode, sync or apply would change without changing them")
+	rootCmd.PersistentFlags().BoolVar(&noGit, "no-git", false, "Skip .gitignore updates and git hooks, for projects outside git (init saves it as git: false)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false, "Use only cached remote content and refuse commands that need the network")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "Stop fetching remote includes and processing projects after this long, e.g. 30s (0 for no limit)")
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(targetsCmd)
 	rootCmd.AddCommand(modeCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd, profileCreateCmd, profileUseCmd, profileMapCmd, profileUnmapCmd)
+	rootCmd.AddCommand(strategyCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(publishCmd)
+	rootCmd.AddCommand(changelogCmd)
+	rootCmd.AddCommand(proposeCmd)
+	rootCmd.AddCommand(packCmd)
+	packCmd.AddCommand(packSearchCmd, packInstallCmd, packRemoveCmd, packListCmd, packPushCmd)
+	rootCmd.AddCommand(templatesCmd)
+	rootCmd.AddCommand(ignoreCmd)
+	ignoreCmd.AddCommand(ignoreAddCmd, ignoreRemoveCmd)
+	rootCmd.AddCommand(mcpCmd)
+	rootCmd.AddCommand(commandsCmd)
+	rootCmd.AddCommand(agentsCmd)
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstallCmd, hooksUninstallCmd)
+	rootCmd.AddCommand(mergeDriverCmd)
+	rootCmd.AddCommand(docsCmd)
+	docsCmd.AddCommand(docsManCmd, docsMarkdownCmd)
+	templatesCmd.AddCommand(templatesListCmd, templatesAddCmd)
+
+	// Commands that write plan their changes first
+	var plan func(cmd *cobra.Command)
+	plan = func(cmd *cobra.Command) {
+		if cmd.RunE != nil && (supportsDryRun(cmd) || isJournaled(cmd)) {
+			planCommand(cmd)
+		}
+		for _, sub := range cmd.Commands() {
+			plan(sub)
+		}
+	}
+	plan(rootCmd)
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	finishLogging(err)
+	cancelCommand()
+	stop()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
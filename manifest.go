@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// manifestFile declares the desired state of a project, committed at its root
+const manifestFile = "viberules.yaml"
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Bring the project in line with viberules.yaml",
+	Long: `Read viberules.yaml at the project root and change the project until it
+matches: initialize it if needed, enable and disable targets, and set the
+mode, link strategies, ignore files, shared rules, pack index and packs.
+
+  mode: public
+  targets: [claude, codex, cursor]
+  link_strategy: copy
+  strategies:              # per-target overrides; targets left out use link_strategy
+    claude: pointer
+  ignore_tools: [aider]
+  shared:
+    repo: git@github.com:acme/ai-rules.git
+  pack_index: https://example.com/packs.yaml
+  packs: [security-baseline, go-style@^1.2]
+
+The file is committed, so changes to the setup are reviewed like code
+instead of made by commands on each machine. Keys left out are not managed
+and keep their current values; an empty list, as in 'packs: []', means none.
+'viberules check' reports where the project differs from the file.
+
+Each change is made by the command that would make it by hand (add, remove,
+mode, strategy, ignore, pack), so the same checks apply. With --dry-run, the
+files apply would change are printed instead.`,
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return applyManifest()
+	},
+}
+
+// Manifest is the desired state of a project declared in viberules.yaml.
+// Nil lists and maps and empty strings leave a setting unmanaged.
+type Manifest struct {
+	Mode         string            `yaml:"mode,omitempty"`
+	Targets      []string          `yaml:"targets,omitempty"`
+	LinkStrategy string            `yaml:"link_strategy,omitempty"`
+	Strategies   map[string]string `yaml:"strategies,omitempty"` // target -> link strategy override
+	IgnoreTools  []string          `yaml:"ignore_tools,omitempty"`
+	Shared       *SharedRules      `yaml:"shared,omitempty"`
+	PackIndex    string            `yaml:"pack_index,omitempty"`
+	Packs        []string          `yaml:"packs,omitempty"` // name, or name@constraint
+}
+
+// loadManifest reads viberules.yaml, or returns nil when there is none
+func loadManifest() (*Manifest, error) {
+	content, err := engine.FS().ReadFile(manifestFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", manifestFile, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestFile, err)
+	}
+	var manifest Manifest
+	if len(doc.Content) == 0 {
+		return &manifest, nil
+	}
+	if err := doc.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestFile, err)
+	}
+	if err := checkYAMLFile(manifestFile, doc.Content[0], reflect.TypeOf(Manifest{}), manifest.problems()); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// problems reports every value of the manifest viberules can't use
+func (m *Manifest) problems() []configProblem {
+	var problems []configProblem
+	add := func(message string, path ...string) {
+		problems = append(problems, configProblem{path: path, message: message})
+	}
+
+	if m.Mode != "" && m.Mode != "public" && m.Mode != "local" {
+		add(fmt.Sprintf("invalid mode: %s (must be 'public' or 'local')", m.Mode), "mode")
+	}
+	for i, name := range m.Targets {
+		if !isValidTarget(name) {
+			add(fmt.Sprintf("invalid target: %s (available: %s)", name, strings.Join(engine.TargetNames(), ", ")), "targets", strconv.Itoa(i))
+		} else if containsName(m.Targets[:i], name) {
+			add(fmt.Sprintf("duplicate target: %s", name), "targets", strconv.Itoa(i))
+		}
+	}
+	switch {
+	case m.LinkStrategy == core.StrategyPointer:
+		add("the pointer strategy is set per target (strategies.<target>)", "link_strategy")
+	case m.LinkStrategy != "" && !core.IsValidStrategy(m.LinkStrategy):
+		add(fmt.Sprintf("invalid link_strategy: %s (must be 'symlink', 'copy' or 'generate')", m.LinkStrategy), "link_strategy")
+	}
+	for _, name := range sortedKeys(m.Strategies) {
+		strategy := m.Strategies[name]
+		switch t, ok := engine.FindTarget(name); {
+		case !ok:
+			add(fmt.Sprintf("invalid target in strategies: %s (available: %s)", name, strings.Join(engine.TargetNames(), ", ")), "strategies", name)
+		case !core.IsValidStrategy(strategy):
+			add(fmt.Sprintf("invalid link strategy for %s: %s (must be 'symlink', 'copy', 'generate' or 'pointer')", name, strategy), "strategies", name)
+		case strategy == core.StrategyPointer && !t.Imports:
+			add(fmt.Sprintf("%s does not support @imports; the pointer strategy is not available", name), "strategies", name)
+		}
+	}
+	for i, tool := range m.IgnoreTools {
+		if _, ok := core.FindIgnoreTool(tool); !ok {
+			add(fmt.Sprintf("invalid ignore tool: %s (available: %s)", tool, ignoreToolNames()), "ignore_tools", strconv.Itoa(i))
+		}
+	}
+	for i, pack := range m.Packs {
+		name, constraint, pinned := strings.Cut(pack, "@")
+		if name == "" {
+			add(fmt.Sprintf("invalid pack: %s", pack), "packs", strconv.Itoa(i))
+		} else if _, err := core.ParseConstraint(constraint); pinned && err != nil {
+			add(fmt.Sprintf("invalid version constraint for %s: %v", name, err), "packs", strconv.Itoa(i))
+		}
+	}
+	return problems
+}
+
+// manifestChange is a difference between the project and the manifest, with
+// the command that removes it
+type manifestChange struct {
+	description string
+	apply       func() error
+}
+
+// manifestChanges returns what apply changes to make the project match m, in
+// the order it changes them: pack sources come before packs
+func manifestChanges(m *Manifest, config *Config) ([]manifestChange, error) {
+	var changes []manifestChange
+	change := func(apply func() error, format string, args ...interface{}) {
+		changes = append(changes, manifestChange{description: fmt.Sprintf(format, args...), apply: apply})
+	}
+
+	if m.Mode != "" && m.Mode != config.Mode {
+		mode := m.Mode
+		change(func() error { return setModeCommand(mode) }, "mode: %s → %s", config.Mode, mode)
+	}
+
+	if m.Targets != nil {
+		var removed, added []string
+		for _, target := range config.Targets {
+			if !containsName(m.Targets, target) {
+				removed = append(removed, target)
+			}
+		}
+		for _, target := range m.Targets {
+			if !containsName(config.Targets, target) {
+				added = append(added, target)
+			}
+		}
+		if len(removed) > 0 {
+			change(func() error { return removeTargets(removed...) }, "targets: remove %s", strings.Join(removed, ", "))
+		}
+		if len(added) > 0 {
+			change(func() error { return addTargets(added...) }, "targets: add %s", strings.Join(added, ", "))
+		}
+	}
+
+	if m.LinkStrategy != "" && m.LinkStrategy != config.LinkStrategy {
+		strategy := m.LinkStrategy
+		change(func() error { return setStrategyCommand(strategy, "") }, "link_strategy: %s → %s", config.linkStrategy(), strategy)
+	}
+	if m.Strategies != nil {
+		names := sortedKeys(m.Strategies)
+		for name, settings := range config.TargetSettings {
+			if settings.LinkStrategy != "" && !containsName(names, name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			current, desired := config.TargetSettings[name].LinkStrategy, m.Strategies[name]
+			switch {
+			case current == desired:
+			case desired == "":
+				target := name
+				change(func() error { return setStrategyCommand("default", target) }, "strategies: %s: %s → default", name, current)
+			default:
+				target := name
+				change(func() error { return setStrategyCommand(desired, target) }, "strategies: %s: %s", name, desired)
+			}
+		}
+	}
+
+	if m.IgnoreTools != nil {
+		for _, tool := range config.IgnoreTools {
+			if !containsName(m.IgnoreTools, tool) {
+				name := tool
+				change(func() error { return removeIgnoreTool(name) }, "ignore_tools: remove %s", name)
+			}
+		}
+		for _, tool := range m.IgnoreTools {
+			if !containsName(config.IgnoreTools, tool) {
+				name := tool
+				change(func() error { return addIgnoreTool(name) }, "ignore_tools: add %s", name)
+			}
+		}
+	}
+
+	if m.Shared != nil && *m.Shared != config.Shared {
+		shared := *m.Shared
+		change(func() error {
+			return updateConfig(func(c *Config) { c.Shared = shared })
+		}, "shared: %s", shared.Repo)
+	}
+	if m.PackIndex != "" && m.PackIndex != config.PackIndex {
+		index := m.PackIndex
+		change(func() error {
+			return updateConfig(func(c *Config) { c.PackIndex = index })
+		}, "pack_index: %s", index)
+	}
+
+	if m.Packs != nil {
+		lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+		if err != nil {
+			return nil, err
+		}
+		var install, remove []string
+		for _, pack := range m.Packs {
+			name, constraint, pinned := strings.Cut(pack, "@")
+			locked, ok := lock.FindPack(name)
+			if !ok || (pinned && constraint != locked.Constraint) {
+				install = append(install, pack)
+			}
+		}
+		for _, locked := range lock.Packs {
+			if !containsPack(m.Packs, locked.Name) {
+				remove = append(remove, locked.Name)
+			}
+		}
+		if len(remove) > 0 {
+			change(func() error { return removePacks(remove) }, "packs: remove %s", strings.Join(remove, ", "))
+		}
+		if len(install) > 0 {
+			change(func() error { return installPacks(install) }, "packs: install %s", strings.Join(install, ", "))
+		}
+	}
+	return changes, nil
+}
+
+// containsPack reports whether packs, as listed in a manifest, name a pack
+func containsPack(packs []string, name string) bool {
+	for _, pack := range packs {
+		if listed, _, _ := strings.Cut(pack, "@"); listed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateConfig changes the project config with fn and saves it
+func updateConfig(fn func(c *Config)) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	fn(config)
+	return saveConfig(config)
+}
+
+func applyManifest() error {
+	if globalMode {
+		return fmt.Errorf("apply is not supported with --global")
+	}
+	manifest, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return fmt.Errorf("no %s in the current directory (see 'viberules apply --help' for its format)", manifestFile)
+	}
+
+	// A fresh checkout starts with the manifest's targets and mode
+	if !engine.IsProject(".") {
+		previousTargets, previousMode := initTargets, initMode
+		defer func() { initTargets, initMode = previousTargets, previousMode }()
+		initTargets, initMode = strings.Join(manifest.Targets, ","), manifest.Mode
+		if err := initProject(); err != nil {
+			return err
+		}
+	}
+
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	changes, err := manifestChanges(manifest, config)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		ui.Success("Project matches %s\n", manifestFile)
+		return nil
+	}
+	for _, change := range changes {
+		ui.Info("📋 %s\n", change.description)
+		if err := change.apply(); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", change.description, err)
+		}
+	}
+	ui.Success("Applied %d change(s) from %s\n", len(changes), manifestFile)
+	return nil
+}
+
+// manifestDrift returns how the project differs from viberules.yaml, if it has one
+func manifestDrift(config *Config) ([]string, error) {
+	manifest, err := loadManifest()
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+	changes, err := manifestChanges(manifest, config)
+	if err != nil {
+		return nil, err
+	}
+	drift := make([]string, len(changes))
+	for i, change := range changes {
+		drift[i] = change.description
+	}
+	return drift, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
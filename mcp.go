@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Show MCP servers generated from .viberules/mcp.yaml",
+	Long: `Generate each assistant's project MCP configuration from a single
+.viberules/mcp.yaml source.
+
+MCP files:
+- claude: .mcp.json
+- cursor: .cursor/mcp.json
+- amazonq: .amazonq/mcp.json
+- gemini: .gemini/settings.json (only the mcpServers key is managed)
+
+A server listing 'targets' is only written for those targets; 'disabled: true'
+keeps a server in the source without generating it.
+
+${VAR} placeholders keep secrets out of the source. They are passed through
+in each tool's syntax where the tool reads the environment itself (claude,
+cursor, gemini) and resolved at generation time otherwise (amazonq).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showMCPServers()
+	},
+}
+
+// writeMCPFile writes the MCP servers of a target, if it reads any, and records their checksum
+func writeMCPFile(config *Config, name string, overwrite bool) error {
+	target, ok := core.FindMCPTarget(name)
+	if !ok || globalMode {
+		return nil
+	}
+	servers, err := engine.LoadMCPConfig()
+	if err != nil {
+		return err
+	}
+
+	warnResolvedVariables(target, servers)
+
+	key := target.ChecksumKey()
+	sum, err := engine.WriteMCPServers(target, servers, config.Checksums[key], overwrite)
+	if err != nil {
+		return err
+	}
+	if sum == "" {
+		delete(config.Checksums, key)
+		return nil
+	}
+	if config.Checksums == nil {
+		config.Checksums = make(map[string]string)
+	}
+	config.Checksums[key] = sum
+	return nil
+}
+
+// warnResolvedVariables warns when secrets from the environment are written
+// into a target's MCP file, which gitignoreResolvedMCPFiles keeps out of git
+func warnResolvedVariables(target core.MCPTarget, servers *core.MCPConfig) {
+	names := target.ResolvedVariables(servers)
+	if len(names) == 0 {
+		return
+	}
+
+	var unset []string
+	for _, name := range names {
+		if _, ok := os.LookupEnv(name); !ok {
+			unset = append(unset, name)
+		}
+	}
+	ui.Warn("⚠️  %s can't read environment variables; %s resolved at generation time, so it is ignored by git.\n",
+		target.Path, strings.Join(names, ", "))
+	if len(unset) > 0 {
+		ui.Warn("⚠️  Not set, left as placeholders in %s: %s\n", target.Path, strings.Join(unset, ", "))
+	}
+}
+
+// removeMCPFile removes the generated MCP servers of a target
+func removeMCPFile(config *Config, name string) error {
+	target, ok := core.FindMCPTarget(name)
+	if !ok || globalMode {
+		return nil
+	}
+	key := target.ChecksumKey()
+	if err := engine.RemoveMCPServers(target, config.Checksums[key]); err != nil {
+		return err
+	}
+	delete(config.Checksums, key)
+	return nil
+}
+
+// collectMCPStatus reports the state of the MCP servers of every enabled target
+func collectMCPStatus(config *Config) ([]targetOutputStatus, error) {
+	if globalMode {
+		return nil, nil
+	}
+	servers, err := engine.LoadMCPConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []targetOutputStatus
+	for _, target := range core.GetMCPTargets() {
+		if !containsName(config.Targets, target.Name) {
+			continue
+		}
+		if status, ok := engine.CheckMCPServers(target, servers, config.Checksums[target.ChecksumKey()]); ok {
+			all = append(all, targetOutputStatus{OutputStatus: status, Strategy: "mcp"})
+		}
+	}
+	return all, nil
+}
+
+func showMCPServers() error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	servers, err := engine.LoadMCPConfig()
+	if err != nil {
+		return err
+	}
+	if servers == nil {
+		ui.Print("No %s found. Declare servers there to generate MCP configs.\n", core.MCPSource)
+		return nil
+	}
+
+	ui.Print("MCP servers:\n")
+	for _, target := range core.GetMCPTargets() {
+		names := servers.ServersFor(target.Name)
+		if len(names) == 0 {
+			names = []string{"(none)"}
+		}
+		if containsName(config.Targets, target.Name) {
+			ui.Print("  ✅ %s (%s): %s\n", target.Path, target.Name, strings.Join(names, ", "))
+		} else {
+			ui.Print("  ⬜ %s (%s): %s\n", target.Path, target.Name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// mergeDriverName is the name the merge driver is registered under in git config
+const mergeDriverName = "viberules"
+
+var mergeDriverCmd = &cobra.Command{
+	Use:   "merge-driver [ancestor] [current] [other]",
+	Short: "Merge rules.md section by section (run by git)",
+	Long: `Merge two versions of a rules file section by section. Git runs this
+as the merge driver registered by 'viberules hooks install', with the common
+ancestor, the current branch's version and the other branch's version.
+
+Sections changed on one side take that side's version, sections added on
+either side are kept, and lines both sides appended to the same section
+are both kept. Sections both sides changed differently get conflict
+markers, and the command fails so git reports the conflict.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMergeDriver(args[0], args[1], args[2])
+	},
+}
+
+// runMergeDriver merges other into current, writing the result to current as git expects
+func runMergeDriver(ancestor, current, other string) error {
+	var versions [3][]byte
+	for i, path := range []string{ancestor, current, other} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		versions[i] = content
+	}
+	merged, conflicts := core.MergeRules(versions[0], versions[1], versions[2])
+	if err := os.WriteFile(current, merged, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", current, err)
+	}
+	if conflicts {
+		return fmt.Errorf("sections changed on both sides are marked with conflict markers")
+	}
+	return nil
+}
+
+// mergeAttribute returns the attributes line routing the project's rules
+// file to the merge driver, with the path rooted at the repository root
+func mergeAttribute() (string, error) {
+	prefix, err := core.GitPrefix(projectDir())
+	if err != nil {
+		return "", err
+	}
+	return quoteAttributePattern("/"+prefix+filepath.ToSlash(engine.RulesFile())) + " merge=" + mergeDriverName, nil
+}
+
+// installMergeDriver registers the merge drivers in the repository's config and
+// routes the rules file to its driver in .git/info/attributes, which isn't
+// shared, so clones without viberules keep git's own merge. Tracked outputs are
+// routed to outputMergeDriver by the .gitattributes section sync writes.
+func installMergeDriver() error {
+	if err := core.GitConfigSet(projectDir(), "merge."+mergeDriverName+".name", "viberules section merge for rules.md"); err != nil {
+		return err
+	}
+	if err := core.GitConfigSet(projectDir(), "merge."+mergeDriverName+".driver", "viberules merge-driver %O %A %B"); err != nil {
+		return err
+	}
+	// Tracked outputs keep our version; the post-merge hook regenerates them
+	if err := core.GitConfigSet(projectDir(), "merge."+outputMergeDriver+".name", "keep generated outputs for viberules sync"); err != nil {
+		return err
+	}
+	if err := core.GitConfigSet(projectDir(), "merge."+outputMergeDriver+".driver", "true"); err != nil {
+		return err
+	}
+	path, line, err := mergeAttributesFile()
+	if err != nil {
+		return err
+	}
+	content, err := readIgnoreFile(path)
+	if err != nil {
+		return err
+	}
+	if containsName(strings.Split(content, "\n"), line) {
+		return nil
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if err := engine.FS().MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := engine.FS().WriteFile(path, []byte(content+line+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	ui.Detail("   Updated %s\n", path)
+	return nil
+}
+
+// uninstallMergeDriver undoes installMergeDriver
+func uninstallMergeDriver() error {
+	if err := core.GitConfigRemoveSection(projectDir(), "merge."+mergeDriverName); err != nil {
+		return err
+	}
+	if err := core.GitConfigRemoveSection(projectDir(), "merge."+outputMergeDriver); err != nil {
+		return err
+	}
+	path, line, err := mergeAttributesFile()
+	if err != nil {
+		return err
+	}
+	content, err := readIgnoreFile(path)
+	if err != nil || content == "" {
+		return err
+	}
+	var kept []string
+	for _, l := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		if l != line {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == 0 {
+		return engine.FS().Remove(path)
+	}
+	if err := engine.FS().WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// mergeAttributesFile returns the repository's personal attributes file and
+// the line of the merge driver in it
+func mergeAttributesFile() (string, string, error) {
+	path, err := core.GitAttributesPath(projectDir())
+	if err != nil {
+		return "", "", err
+	}
+	line, err := mergeAttribute()
+	if err != nil {
+		return "", "", err
+	}
+	return path, line, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+// enterProjectRoot points the engine at the nearest enclosing viberules project
+// so commands work from any subdirectory of a package. Nothing changes if no
+// project is found; commands then report that the project is not initialized.
+func enterProjectRoot() {
+	if engine.IsProject(".") {
+		return
+	}
+
+	root, ok := engine.FindProjectRoot(".")
+	if !ok {
+		return
+	}
+	engine = newEngine(root)
+	logger.Debug("entered project root", "dir", root)
+}
+
+// inherits reports whether rules of enclosing projects are merged into generated outputs
+func (c *Config) inherits() bool {
+	return c.Inherit == nil || *c.Inherit
+}
+
+// ancestorRules returns the rules files inherited by the current project
+func ancestorRules() []string {
+	if globalMode {
+		return nil
+	}
+	return engine.FindAncestorRules(".")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// UI is where commands send everything they print, so every command honors
+// --quiet, --verbose, --json and plain output the same way, and tests can
+// capture what a command says by replacing ui.
+type UI interface {
+	// Info prints progress and result messages, suppressed by --quiet
+	Info(format string, args ...interface{})
+	// Success prints a completed action, marked as such; suppressed by --quiet
+	Success(format string, args ...interface{})
+	// Warn reports something the user has to act on. Warnings go to stderr
+	// and are shown even with --quiet.
+	Warn(format string, args ...interface{})
+	// Detail prints the files a command touches, shown only with --verbose
+	Detail(format string, args ...interface{})
+	// Print prints output a command was asked for (list, status, config
+	// list), which is never suppressed
+	Print(format string, args ...interface{})
+	// Data prints a document exactly as given, such as JSON or a config value
+	Data(data string)
+	// Prompt asks the user something on stderr, even with --quiet or --json
+	Prompt(format string, args ...interface{})
+}
+
+// ui is the UI of the running command
+var ui UI = &terminalUI{}
+
+// verbose is set by --verbose to print the files each command touches
+var verbose bool
+
+// terminalUI prints text for people, in plain text if requested. A nil
+// writer means the process's standard output or standard error.
+type terminalUI struct {
+	out io.Writer
+	err io.Writer
+}
+
+func (t *terminalUI) stdout() io.Writer {
+	if t.out != nil {
+		return t.out
+	}
+	return os.Stdout
+}
+
+func (t *terminalUI) stderr() io.Writer {
+	if t.err != nil {
+		return t.err
+	}
+	return os.Stderr
+}
+
+func (t *terminalUI) Info(format string, args ...interface{}) {
+	if silent {
+		return
+	}
+	t.Print(format, args...)
+}
+
+func (t *terminalUI) Success(format string, args ...interface{}) {
+	t.Info("✅ "+format, args...)
+}
+
+func (t *terminalUI) Warn(format string, args ...interface{}) {
+	fmt.Fprint(t.stderr(), decorate(fmt.Sprintf(format, args...)))
+}
+
+func (t *terminalUI) Detail(format string, args ...interface{}) {
+	if !verbose || silent {
+		return
+	}
+	t.Print(format, args...)
+}
+
+func (t *terminalUI) Print(format string, args ...interface{}) {
+	fmt.Fprint(t.stdout(), decorate(fmt.Sprintf(format, args...)))
+}
+
+func (t *terminalUI) Data(data string) {
+	fmt.Fprintln(t.stdout(), data)
+}
+
+func (t *terminalUI) Prompt(format string, args ...interface{}) {
+	fmt.Fprintf(t.stderr(), format, args...)
+}
+
+// jsonUI keeps standard output for the document of --json: messages are
+// left out and warnings go to stderr as JSON lines
+type jsonUI struct {
+	terminalUI
+}
+
+func (j *jsonUI) Info(format string, args ...interface{})    {}
+func (j *jsonUI) Success(format string, args ...interface{}) {}
+func (j *jsonUI) Detail(format string, args ...interface{})  {}
+
+func (j *jsonUI) Warn(format string, args ...interface{}) {
+	message := strings.TrimSpace(stripEmoji(fmt.Sprintf(format, args...)))
+	json.NewEncoder(j.stderr()).Encode(map[string]string{"level": "warning", "message": message})
+}
+
+// checkVerbosity fails if --quiet and --verbose are both given
+func checkVerbosity() error {
+	if silent && verbose {
+		return fmt.Errorf("--quiet and --verbose can't be used together")
+	}
+	return nil
+}
+
+// outputObserver prints the events of core operations the user should know about
+type outputObserver struct {
+	core.NopObserver
+}
+
+// OnFileBackedUp warns: the file is no longer where the user left it
+func (outputObserver) OnFileBackedUp(path, backup string) {
+	ui.Warn("📦 Moved existing %s to %s\n", path, backup)
+}
+
+func (outputObserver) OnGitignoreUpdated(path string) {
+	ui.Detail("   Updated %s\n", path)
+}
+
+// noColor is set by --no-color. Color is also off when NO_COLOR is set,
+// TERM is dumb or standard output is not a terminal.
+var noColor bool
+
+const (
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// colorEnabled reports whether output may use ANSI colors
+func colorEnabled() bool {
+	if noColor || plainOutput || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// paint wraps s in color when colors are enabled
+func paint(color, s string) string {
+	if !colorEnabled() {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// paintState colors the state of an output by how much it needs attention:
+// missing and broken outputs are red, drifted ones yellow
+func paintState(state core.OutputState) string {
+	switch state {
+	case core.OutputOK:
+		return paint(colorGreen, state.String())
+	case core.OutputMissing, core.OutputBroken, core.OutputOutside:
+		return paint(colorRed, state.String())
+	}
+	return paint(colorYellow, state.String())
+}
+
+// Output styles, set by output in the project or user config
+const (
+	outputAuto  = "auto"  // plain unless standard output is a terminal (default)
+	outputPlain = "plain" // no emoji or colors, for CI logs and limited terminals
+	outputRich  = "rich"  // emoji even when output is redirected
+)
+
+// plainFlag is set by --plain; plainOutput is the style in effect
+var (
+	plainFlag   bool
+	plainOutput bool
+)
+
+// plainMarkers replace status emoji with words in plain output, so lines
+// stay greppable; other emoji are dropped
+var plainMarkers = strings.NewReplacer(
+	"✅ ", "[ok] ",
+	"⚠️  ", "[warn] ",
+	"❌ ", "[error] ",
+	"⛔ ", "[blocked] ",
+	"⬜ ", "[ ] ",
+	"ℹ️  ", "[info] ",
+	"✂️  ", "[truncated] ",
+)
+
+// configureOutput picks the output style: --plain, then the output setting,
+// then plain whenever standard output isn't a terminal
+func configureOutput() {
+	style := outputAuto
+	if config, err := loadConfig(); err == nil {
+		style = config.outputStyle()
+	}
+	switch {
+	case plainFlag || style == outputPlain:
+		plainOutput = true
+	case style == outputRich:
+		plainOutput = false
+	default:
+		plainOutput = !isTerminal(os.Stdout)
+	}
+}
+
+// outputStyle returns the output setting of the project, else the user's
+func (c *Config) outputStyle() string {
+	if c.Output != "" {
+		return c.Output
+	}
+	if c.user != nil && c.user.Output != "" {
+		return c.user.Output
+	}
+	return outputAuto
+}
+
+// isValidOutputStyle reports whether style is a known output setting
+func isValidOutputStyle(style string) bool {
+	return style == outputAuto || style == outputPlain || style == outputRich
+}
+
+// decorate returns s as it should be printed in the current output style
+func decorate(s string) string {
+	if !plainOutput {
+		return s
+	}
+	return plainText(s)
+}
+
+// plainText replaces status emoji with words and drops other emoji
+func plainText(s string) string {
+	return stripEmoji(plainMarkers.Replace(s))
+}
+
+// stripEmoji drops emoji and the space that follows each
+func stripEmoji(s string) string {
+	var b strings.Builder
+	skipSpace := false
+	for _, r := range s {
+		if isEmoji(r) {
+			skipSpace = true
+			continue
+		}
+		if skipSpace && r == ' ' {
+			skipSpace = false
+			continue
+		}
+		skipSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isEmoji reports whether r is a pictograph, dingbat or emoji variation selector
+func isEmoji(r rune) bool {
+	return r >= 0x1F000 || (r >= 0x2600 && r <= 0x27BF) || (r >= 0x2B00 && r <= 0x2BFF) || r == 0x2139 || r == 0xFE0F
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// createTargetOutputs creates the outputs of a target using its link strategy.
+// Checksums of copied files are recorded in config; the caller is responsible for saving it.
+// When overwrite is set, copies edited by hand are replaced.
+func createTargetOutputs(config *Config, target string, overwrite bool) error {
+	if err := createProjectOutputs(config, target, overwrite); err != nil {
+		return err
+	}
+	if err := writeIgnoreFile(config, target, overwrite); err != nil {
+		return err
+	}
+	if err := writeMCPFile(config, target, overwrite); err != nil {
+		return err
+	}
+	if err := writeCommands(config, target, overwrite); err != nil {
+		return err
+	}
+	if err := writeAgents(config, target, overwrite); err != nil {
+		return err
+	}
+	if err := writeClaudeSettings(config, target, overwrite); err != nil {
+		return err
+	}
+
+	// Directories mapped to profiles get their own symlinks
+	for _, dir := range sortedProfileDirs(config) {
+		if err := engine.CreateNestedSymlinks(target, dir, core.ProfilePath(config.ProfileDirs[dir])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forceOverwrite is set by --force-overwrite: files viberules didn't create
+// that are in the way of outputs are backed up and replaced
+var forceOverwrite bool
+
+// checkOccupiedOutputs fails with every output of targets that a file
+// viberules didn't create is in the way of, before anything is written.
+// Paths in skip are moved aside by the caller. With --force-overwrite the
+// files are backed up by backupOutputs instead.
+func checkOccupiedOutputs(config *Config, targets []string, skip []string) error {
+	if forceOverwrite {
+		return nil
+	}
+	if err := engine.CheckOccupiedOutputs(targets, config.Checksums, skip); err != nil {
+		if !errors.Is(err, core.ErrConflict) {
+			return err
+		}
+		return fmt.Errorf("%w\nMove them, import them with 'viberules init --adopt', or rerun with --force-overwrite to back them up to %s and replace them",
+			err, core.BackupDir)
+	}
+	return nil
+}
+
+// backupOutputs moves files viberules didn't create out of the way of a
+// target's outputs with --force-overwrite, into a backup directory named
+// after the time of the command. outputObserver tells the user where they went.
+func backupOutputs(config *Config, target string, now time.Time) error {
+	if !forceOverwrite {
+		return nil
+	}
+	_, err := engine.BackupUnmanagedOutputs(target, config.Checksums, core.NewBackupDir(now))
+	return err
+}
+
+// reportOutputs lists the outputs of a target with --verbose
+func reportOutputs(config *Config, target string) {
+	if !verbose || silent {
+		return
+	}
+	strategy := config.strategyFor(target)
+	statuses, err := engine.CheckTargetOutputs(target, strategy, config.pipelineFor(target, strategy), config.Checksums)
+	if err != nil {
+		return
+	}
+	for _, status := range statuses {
+		ui.Detail("   %s: %s (%s)\n", target, status.Path, strategy)
+	}
+}
+
+// createProjectOutputs creates the outputs of a target at the project root
+func createProjectOutputs(config *Config, target string, overwrite bool) error {
+	// Clean up the default path of a target whose output was moved
+	if err := engine.RemoveRelocatedOutput(target, config.Checksums, overwrite); err != nil {
+		return err
+	}
+
+	if strategy := config.strategyFor(target); core.WritesFiles(strategy) {
+		written, err := engine.GenerateTargetFiles(target, config.pipelineFor(target, strategy), config.Checksums, overwrite)
+		if err != nil {
+			return err
+		}
+		if err := saveLockfile(); err != nil {
+			return err
+		}
+		if config.Checksums == nil {
+			config.Checksums = make(map[string]string)
+		}
+		forgetChecksums(config, target) // drops parts whose source was deleted
+		for path, sum := range written {
+			config.Checksums[path] = sum
+		}
+
+		// Generated outputs merge personal rules; copies stay identical and link them instead
+		if strategy == core.StrategyGenerate {
+			return engine.RemoveLocalSymlinks(target)
+		}
+		return engine.CreateLocalSymlinks(target)
+	}
+
+	// Drop copies left over from the copy strategy before linking
+	if err := engine.RemoveTargetCopies(target, config.Checksums, overwrite); err != nil {
+		return err
+	}
+	forgetChecksums(config, target)
+
+	if err := engine.CreateLocalSymlinks(target); err != nil {
+		return err
+	}
+	return engine.CreateTargetSymlinks(target)
+}
+
+// removeTargetOutputs removes the outputs of a target regardless of how they were created
+func removeTargetOutputs(config *Config, target string) error {
+	for _, dir := range sortedProfileDirs(config) {
+		if err := engine.RemoveNestedSymlinks(target, dir); err != nil {
+			return err
+		}
+	}
+
+	if err := engine.RemoveTargetCopies(target, config.Checksums, false); err != nil {
+		return err
+	}
+	if err := engine.RemoveRelocatedOutput(target, config.Checksums, false); err != nil {
+		return err
+	}
+	forgetChecksums(config, target)
+
+	if !containsName(config.IgnoreTools, target) {
+		if err := removeIgnoreFile(config, target); err != nil {
+			return err
+		}
+	}
+	if err := removeMCPFile(config, target); err != nil {
+		return err
+	}
+	if err := removeCommands(config, target); err != nil {
+		return err
+	}
+	if err := removeAgents(config, target); err != nil {
+		return err
+	}
+	if err := removeClaudeSettings(config, target); err != nil {
+		return err
+	}
+	if err := engine.RemoveLocalSymlinks(target); err != nil {
+		return err
+	}
+	return engine.RemoveTargetSymlinks(target)
+}
+
+// pipelineFor builds the generation pipeline of a target.
+// The copy strategy uses an empty pipeline so outputs stay byte-identical,
+// unless a banner is configured or the outputs are tracked.
+func (c *Config) pipelineFor(target, strategy string) core.Pipeline {
+	return c.buildPipeline(target, strategy, true)
+}
+
+// buildPipeline builds the generation pipeline of a target. Without output,
+// the stages that only shape the written file (budget fitting, banner,
+// provenance) are left out, so the pipeline measures the effective rules.
+func (c *Config) buildPipeline(target, strategy string, output bool) core.Pipeline {
+	if strategy == core.StrategyPointer {
+		return core.Pipeline{engine.PointerFile()}
+	}
+	if strategy == core.StrategyCopy && output {
+		var pipeline core.Pipeline
+		if c.Banner != "" {
+			pipeline = append(pipeline, engine.AddBanner(c.Banner))
+		}
+		// Committed outputs carry their provenance, as the checksums of the
+		// personal config don't travel with them
+		if c.tracksOutput(target) {
+			pipeline = append(pipeline, engine.AddProvenance())
+		}
+		return pipeline
+	}
+	if strategy != core.StrategyGenerate {
+		return nil
+	}
+
+	var inherited []string
+	if c.inherits() {
+		inherited = ancestorRules()
+	}
+
+	settings := c.TargetSettings[target]
+	fetcher := remoteFetcher(c)
+	pipeline := core.Pipeline{
+		engine.IncludeRules(commandContext, fetcher),
+		engine.ConcatRulesDir(commandContext, fetcher),
+		engine.InheritRules(commandContext, inherited, fetcher),
+		engine.AddTargetFiles(commandContext, fetcher),
+	}
+	// Personal rules and notes never go into outputs that are committed
+	if !c.tracksOutput(target) {
+		pipeline = append(pipeline, engine.MergeLocalRules(commandContext, fetcher))
+	}
+	pipeline = append(pipeline, engine.FilterSections(), core.SubstituteVariables(c.variables()))
+	if output {
+		pipeline = append(pipeline, engine.FitBudget(settings.Overflow, settings.TokenBudget))
+	}
+	pipeline = append(pipeline, core.FrontmatterFor(target, settings.Frontmatter))
+	if output {
+		pipeline = append(pipeline, engine.AddBanner(c.Banner))
+		if c.tracksOutput(target) {
+			pipeline = append(pipeline, engine.AddProvenance())
+		}
+	}
+	return pipeline
+}
+
+// variables returns the built-in project variables overridden by those in config
+func (c *Config) variables() map[string]string {
+	vars := engine.ProjectVariables(".")
+	for name, value := range c.Variables {
+		vars[name] = value
+	}
+	return vars
+}
+
+// forgetChecksums drops the recorded checksums of a target's outputs
+func forgetChecksums(config *Config, target string) {
+	t, ok := engine.FindTarget(target)
+	if !ok {
+		return
+	}
+	for _, link := range t.Links {
+		delete(config.Checksums, link.Target)
+	}
+	if path, ok := engine.DefaultOutputPath(target); ok {
+		delete(config.Checksums, path) // left behind by a moved output
+	}
+	for path := range config.Checksums {
+		if t.IsPartPath(path) {
+			delete(config.Checksums, path)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack",
+	Short: "Install reusable rule packs from an index",
+	Long: `Rule packs are reusable rules, such as a style guide or a security
+baseline, listed in an index: a YAML file at pack_index, a remote reference
+in include syntax or a local path, set in the project or user config.
+
+  packs:
+    - name: go-style
+      description: Go style guide
+      version: 1.2.0
+      source: git::https://github.com/acme/packs//go-style.md?ref=v1.2.0
+
+Sources are remote references or paths relative to the index. A pack may be
+listed once per version; installs take the latest version their constraint
+allows (see 'viberules pack install --help'). Installed packs live in
+.viberules/packs/<name>.md and are recorded in .viberules/viberules.lock;
+outputs include them after the rules file and .viberules/rules/ files, in
+name order.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPacks()
+	},
+}
+
+var packSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "List the packs of the index matching a query",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := ""
+		if len(args) == 1 {
+			query = args[0]
+		}
+		return searchPacks(query)
+	},
+}
+
+var packInstallCmd = &cobra.Command{
+	Use:   "install [name[@constraint]...]",
+	Short: "Install packs from the index, or reinstall them",
+	Long: `Install the latest version of each pack that its constraint allows, as
+in go-style@^1.2 or go-style@~1.2.3. Without a constraint, an installed pack
+keeps the one it has and a new pack gets ^<latest version>, so it stays on
+that major version.
+
+The constraint is recorded in .viberules/viberules.lock; 'viberules update'
+moves packs to newer versions within it, and nothing else does.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installPacks(args)
+	},
+}
+
+var packRemoveCmd = &cobra.Command{
+	Use:         "remove [name...]",
+	Short:       "Remove installed packs",
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return removePacks(args)
+	},
+}
+
+var packListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed packs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPacks()
+	},
+}
+
+var packPushCmd = &cobra.Command{
+	Use:   "push [file] [oci-ref]",
+	Short: "Push a rules file to an OCI registry as an artifact",
+	Long: `Push a rules file, such as a pack or the shared rules, to a container
+registry as an OCI artifact (the way ORAS pushes files), for indexes, includes
+and shared.repo to reference as oci://<registry>/<repository>:<tag>.
+
+The tag defaults to latest. Files with potential secrets are refused unless
+--yes is given. The manifest digest is printed; references with @<digest>
+never change, and viberules.lock pins tags to the digest they resolved to.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return pushPack(args[0], args[1])
+	},
+}
+
+// packIndex returns the location of the pack index
+func (c *Config) packIndex() string {
+	if c.PackIndex == "" && c.user != nil {
+		return c.user.PackIndex
+	}
+	return c.PackIndex
+}
+
+// loadPackIndex reads the configured pack index
+func loadPackIndex(config *Config) (core.PackIndex, error) {
+	location := config.packIndex()
+	if location == "" {
+		return core.PackIndex{}, fmt.Errorf("no pack index configured (run 'viberules config set pack_index <url>')")
+	}
+	// A local index is found from the project root
+	if !core.IsRemoteRef(location) {
+		location = projectPath(location)
+	}
+	return core.LoadPackIndex(commandContext, location, config.fetcher())
+}
+
+func searchPacks(query string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	index, err := loadPackIndex(config)
+	if err != nil {
+		return err
+	}
+	packs := index.Search(query)
+	if len(packs) == 0 {
+		ui.Info("No packs match '%s'\n", query)
+		return nil
+	}
+	lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+	if err != nil {
+		return err
+	}
+	for _, pack := range packs {
+		mark := " "
+		if _, ok := lock.FindPack(pack.Name); ok {
+			mark = "✓"
+		}
+		ui.Print("%s %-20s %-10s %s\n", mark, pack.Name, pack.Version, pack.Description)
+	}
+	return nil
+}
+
+func installPacks(names []string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	index, err := loadPackIndex(config)
+	if err != nil {
+		return err
+	}
+	lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range names {
+		name, constraint, pinned := strings.Cut(arg, "@")
+		if _, ok := index.Find(name); !ok {
+			return fmt.Errorf("no pack named '%s' in %s (see 'viberules pack search')", name, config.packIndex())
+		}
+		if previous, ok := lock.FindPack(name); ok && !pinned {
+			constraint = previous.Constraint
+		}
+		pack, constraint, err := resolvePack(index, name, constraint)
+		if err != nil {
+			return err
+		}
+		locked, err := installPack(config, pack, constraint)
+		if err != nil {
+			return err
+		}
+		lock.SetPack(locked)
+		ui.Success("Installed pack %s%s\n", name, packVersionLabel(pack.Version))
+	}
+	if err := savePackLock(lock); err != nil {
+		return err
+	}
+	_, err = syncProject()
+	return err
+}
+
+// resolvePack picks the version of a pack to install: the latest that
+// constraint allows, or the latest when there is none. New constraints keep
+// packs on the major version they start with.
+func resolvePack(index core.PackIndex, name, constraint string) (core.Pack, string, error) {
+	if constraint == "" {
+		pack, _ := index.Find(name)
+		if v, err := core.ParseVersion(pack.Version); err == nil {
+			constraint = "^" + v.String()
+		}
+		return pack, constraint, nil
+	}
+	parsed, err := core.ParseConstraint(constraint)
+	if err != nil {
+		return core.Pack{}, "", err
+	}
+	pack, err := index.Resolve(name, parsed)
+	return pack, constraint, err
+}
+
+// installPack writes pack into PacksDir and returns its lock record
+func installPack(config *Config, pack core.Pack, constraint string) (core.LockedPack, error) {
+	content, commit, err := core.FetchPack(commandContext, pack, config.fetcher())
+	if err != nil {
+		return core.LockedPack{}, err
+	}
+	fs := engine.FS()
+	if err := fs.MkdirAll(core.PacksDir, 0755); err != nil {
+		return core.LockedPack{}, fmt.Errorf("failed to create %s: %w", core.PacksDir, err)
+	}
+	if err := fs.WriteFile(core.PackPath(pack.Name), content, 0644); err != nil {
+		return core.LockedPack{}, fmt.Errorf("failed to write %s: %w", core.PackPath(pack.Name), err)
+	}
+	return core.LockedPack{
+		Name:       pack.Name,
+		Version:    pack.Version,
+		Constraint: constraint,
+		Source:     pack.Source,
+		Commit:     commit,
+		SHA256:     core.Checksum(content),
+	}, nil
+}
+
+// updatePacks moves installed packs to the latest version their constraint
+// allows and returns their new records with the number that moved
+func updatePacks(config *Config, packs []core.LockedPack) ([]core.LockedPack, int, error) {
+	var index *core.PackIndex
+	updated := make([]core.LockedPack, 0, len(packs))
+	moved := 0
+	for _, locked := range packs {
+		if locked.Constraint == "" {
+			updated = append(updated, locked)
+			continue
+		}
+		if index == nil {
+			loaded, err := loadPackIndex(config)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to update packs: %w", err)
+			}
+			index = &loaded
+		}
+		pack, constraint, err := resolvePack(*index, locked.Name, locked.Constraint)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to update pack %s: %w", locked.Name, err)
+		}
+		if pack.Version == locked.Version && pack.Source == locked.Source {
+			updated = append(updated, locked)
+			continue
+		}
+		installed, err := installPack(config, pack, constraint)
+		if err != nil {
+			return nil, 0, err
+		}
+		ui.Info("📦 %s: %s → %s (%s)\n", locked.Name, locked.Version, pack.Version, constraint)
+		updated = append(updated, installed)
+		moved++
+	}
+	return updated, moved, nil
+}
+
+func removePacks(names []string) error {
+	if _, err := loadInitializedConfig(); err != nil {
+		return err
+	}
+	lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+	if err != nil {
+		return err
+	}
+
+	fs := engine.FS()
+	for _, name := range names {
+		_, locked := lock.FindPack(name)
+		err := fs.Remove(core.PackPath(name))
+		if os.IsNotExist(err) && !locked {
+			return fmt.Errorf("pack '%s' is not installed", name)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", core.PackPath(name), err)
+		}
+		lock.RemovePack(name)
+		ui.Success("Removed pack %s\n", name)
+	}
+	if err := savePackLock(lock); err != nil {
+		return err
+	}
+	_, err = syncProject()
+	return err
+}
+
+func pushPack(file, target string) error {
+	ref, err := core.ParseRemoteRef(target)
+	if err != nil {
+		return err
+	}
+	if !ref.OCI {
+		return fmt.Errorf("%s is not an oci:// reference", target)
+	}
+	user, err := loadUserConfig()
+	if err != nil {
+		return err
+	}
+	// Pushing needs no project, so only the user config can turn offline mode on
+	if err := requireOnline(&Config{user: user}, "pack push"); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	// Registries are often readable by everyone who can pull images
+	if findings := core.ScanSecrets(file, content); len(findings) > 0 {
+		ui.Print("🔍 Potential secrets in %s:\n", file)
+		for _, finding := range findings {
+			ui.Print("  - %s\n", finding)
+		}
+		if !assumeYes {
+			return fmt.Errorf("found %d potential secret(s). Remove them or use --yes to push anyway", len(findings))
+		}
+		ui.Warn("⚠️  Continuing because of --yes\n")
+	}
+
+	digest, err := core.PushOCI(commandContext, ref, filepath.Base(file), content, credentials)
+	if err != nil {
+		return err
+	}
+	pinned := ref
+	pinned.Ref = digest
+	ui.Success("Pushed %s to %s\n", file, ref)
+	ui.Info("   Pinned reference: %s\n", pinned)
+	return nil
+}
+
+// savePackLock writes lock, or removes the lockfile once it records nothing
+func savePackLock(lock *core.Lockfile) error {
+	// Generation reloads the lock, keeping the packs recorded here
+	projectLock.fetcher = nil
+	if !lock.Empty() {
+		return lock.Save(engine.FS(), core.LockfilePath)
+	}
+	if err := engine.FS().Remove(core.LockfilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lockfile: %w", err)
+	}
+	return nil
+}
+
+func listPacks() error {
+	if _, err := loadInitializedConfig(); err != nil {
+		return err
+	}
+	files, err := engine.PackFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		ui.Info("No packs installed (see 'viberules pack search')\n")
+		return nil
+	}
+	lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".md")
+		pack, ok := lock.FindPack(name)
+		if !ok {
+			ui.Print("  - %s (not installed from an index)\n", name)
+			continue
+		}
+		status := ""
+		if pack.Constraint != "" {
+			status = " within " + pack.Constraint
+		}
+		if content, err := engine.FS().ReadFile(file); err == nil && core.Checksum(content) != pack.SHA256 {
+			status += " (edited since install)"
+		}
+		ui.Print("  - %s%s from %s%s\n", name, packVersionLabel(pack.Version), pack.Source, status)
+	}
+	return nil
+}
+
+// packVersionLabel is " <version>" for output, or nothing for unversioned packs
+func packVersionLabel(version string) string {
+	if version == "" {
+		return ""
+	}
+	return " " + version
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// Organizations can restrict which assistants read a codebase: blocked
+// targets can't be enabled and required targets must be. They can also
+// mandate sections every rules file must contain word for word. Each list is
+// read from the user config (where a shared policy is usually installed) and
+// the project config, and combined.
+
+// Policy holds the rules sections a project must contain
+type Policy struct {
+	// Sections are rules fragments, files relative to the project root or
+	// remote references, that must appear verbatim in the rules file
+	Sections []string `yaml:"sections,omitempty"`
+}
+
+// blockedTargets returns the targets no project may enable
+func (c *Config) blockedTargets() []string {
+	var names []string
+	if c.user != nil {
+		names = appendNames(names, c.user.BlockedTargets)
+	}
+	return appendNames(names, c.BlockedTargets)
+}
+
+// requiredTargets returns the targets every project must enable
+func (c *Config) requiredTargets() []string {
+	var names []string
+	if c.user != nil {
+		names = appendNames(names, c.user.RequiredTargets)
+	}
+	return appendNames(names, c.RequiredTargets)
+}
+
+// policySections returns the references of the sections every project must contain
+func (c *Config) policySections() []string {
+	var refs []string
+	if c.user != nil {
+		refs = appendNames(refs, c.user.Policy.Sections)
+	}
+	return appendNames(refs, c.Policy.Sections)
+}
+
+// checkTargetAllowed fails if target is blocked by policy
+func (c *Config) checkTargetAllowed(target string) error {
+	if containsName(c.blockedTargets(), target) {
+		return fmt.Errorf("target '%s' is blocked by policy (blocked_targets: %s)", target, strings.Join(c.blockedTargets(), ", "))
+	}
+	return nil
+}
+
+// policyViolations describes how the enabled targets break the policy
+func (c *Config) policyViolations() []string {
+	var violations []string
+	for _, name := range c.requiredTargets() {
+		if !containsName(c.Targets, name) {
+			violations = append(violations, fmt.Sprintf("required target '%s' is not enabled (run 'viberules add %s')", name, name))
+		}
+	}
+	for _, name := range c.Targets {
+		if containsName(c.blockedTargets(), name) {
+			violations = append(violations, fmt.Sprintf("target '%s' is blocked by policy (run 'viberules remove %s')", name, name))
+		}
+	}
+	return violations
+}
+
+// policySectionViolations describes the policy sections missing from the rules
+// file or edited in it
+func (c *Config) policySectionViolations() []string {
+	refs := c.policySections()
+	if len(refs) == 0 || globalMode {
+		return nil
+	}
+	rules, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return []string{fmt.Sprintf("failed to read %s: %v", engine.RulesFile(), err)}
+	}
+	var violations []string
+	for _, ref := range refs {
+		section, err := engine.ReadPolicySection(commandContext, ref, remoteFetcher(c))
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("failed to read policy section %s: %v", ref, err))
+			continue
+		}
+		if !core.HasSection(rules, section) {
+			violations = append(violations, fmt.Sprintf("policy section %s is missing from %s or was edited (run 'viberules sync')", ref, engine.RulesFile()))
+		}
+	}
+	return violations
+}
+
+// enforcePolicySections puts policy sections missing from the rules file, or
+// edited in it, back in place
+func enforcePolicySections(config *Config) error {
+	refs := config.policySections()
+	if len(refs) == 0 || globalMode {
+		return nil
+	}
+	rules, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", engine.RulesFile(), err)
+	}
+	var restored []string
+	for _, ref := range refs {
+		section, err := engine.ReadPolicySection(commandContext, ref, remoteFetcher(config))
+		if err != nil {
+			return fmt.Errorf("failed to read policy section %s: %w", ref, err)
+		}
+		var changed bool
+		if rules, changed = core.RequireSection(rules, section); changed {
+			restored = append(restored, ref)
+		}
+	}
+	if err := saveLockfile(); err != nil {
+		return err
+	}
+	if len(restored) == 0 {
+		return nil
+	}
+	if err := engine.FS().WriteFile(engine.RulesFile(), rules, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", engine.RulesFile(), err)
+	}
+	ui.Info("📜 Restored policy sections in %s: %s\n", engine.RulesFile(), strings.Join(restored, ", "))
+	return nil
+}
+
+// appendNames appends the names of add missing from names
+func appendNames(names, add []string) []string {
+	for _, name := range add {
+		if !containsName(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
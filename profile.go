@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named rule sets",
+	Long: `Manage named rule sets stored in .viberules/profiles/<name>.md.
+
+'profile use' makes .viberules/rules.md point at a profile, so every target
+follows it. 'profile map' gives a subdirectory its own CLAUDE.md, AGENTS.md
+and GEMINI.md symlinks pointing at a profile, so assistants working in that
+directory get focused rules.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles and directory mappings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listProfiles()
+	},
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:         "create [name]",
+	Short:       "Create a profile from the current rules",
+	Args:        cobra.ExactArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return createProfile(args[0])
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:         "use [name]",
+	Short:       "Use a profile as the project rules",
+	Args:        cobra.ExactArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return useProfile(args[0])
+	},
+}
+
+var profileMapCmd = &cobra.Command{
+	Use:         "map [dir] [name]",
+	Short:       "Map a subdirectory to a profile",
+	Args:        cobra.ExactArgs(2),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return mapProfileDir(args[0], args[1])
+	},
+}
+
+var profileUnmapCmd = &cobra.Command{
+	Use:         "unmap [dir]",
+	Short:       "Remove a subdirectory mapping",
+	Args:        cobra.ExactArgs(1),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return unmapProfileDir(args[0])
+	},
+}
+
+func listProfiles() error {
+	if _, err := loadInitializedConfig(); err != nil {
+		return err
+	}
+
+	profiles, err := engine.ListProfiles()
+	if err != nil {
+		return err
+	}
+	active, _ := engine.ActiveProfile()
+
+	ui.Print("Profiles:\n")
+	if len(profiles) == 0 {
+		ui.Print("  (none)\n")
+	}
+	for _, name := range profiles {
+		if name == active {
+			ui.Print("  * %s (active)\n", name)
+		} else {
+			ui.Print("  - %s\n", name)
+		}
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.ProfileDirs) > 0 {
+		ui.Print("\nDirectory mappings:\n")
+		for _, dir := range sortedProfileDirs(config) {
+			ui.Print("  - %s: %s\n", dir, config.ProfileDirs[dir])
+		}
+	}
+	return nil
+}
+
+func createProfile(name string) error {
+	if _, err := loadInitializedConfig(); err != nil {
+		return err
+	}
+
+	content, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return fmt.Errorf("failed to read rules file: %w", err)
+	}
+	if err := engine.CreateProfile(name, content); err != nil {
+		return err
+	}
+
+	ui.Success("Profile '%s' created: %s\n", name, core.ProfilePath(name))
+	return nil
+}
+
+func useProfile(name string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := engine.UseProfile(name); err != nil {
+		return err
+	}
+
+	// Symlinks follow rules.md automatically; copies have to be refreshed
+	for _, target := range config.Targets {
+		if core.WritesFiles(config.strategyFor(target)) {
+			if err := createTargetOutputs(config, target, false); err != nil {
+				return fmt.Errorf("failed to refresh outputs for target '%s': %w", target, err)
+			}
+		}
+	}
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	ui.Success("Using profile '%s'\n", name)
+	return nil
+}
+
+// cleanProfileDir validates a mapped directory and returns it relative to the project root
+func cleanProfileDir(dir string) (string, error) {
+	cleaned := filepath.Clean(dir)
+	if filepath.IsAbs(cleaned) || cleaned == "." || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("invalid directory: %s (must be a subdirectory of the project)", dir)
+	}
+	if info, err := engine.FS().Stat(cleaned); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("directory not found: %s", dir)
+	}
+	return cleaned, nil
+}
+
+func mapProfileDir(dir, name string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	dir, err = cleanProfileDir(dir)
+	if err != nil {
+		return err
+	}
+	if _, err := engine.FS().Stat(core.ProfilePath(name)); err != nil {
+		return fmt.Errorf("profile %s not found", name)
+	}
+
+	for _, target := range config.Targets {
+		if err := engine.CreateNestedSymlinks(target, dir, core.ProfilePath(name)); err != nil {
+			return fmt.Errorf("failed to create outputs for target '%s' in %s: %w", target, dir, err)
+		}
+	}
+
+	if config.ProfileDirs == nil {
+		config.ProfileDirs = make(map[string]string)
+	}
+	config.ProfileDirs[dir] = name
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	ui.Success("Mapped %s to profile '%s'\n", dir, name)
+	return nil
+}
+
+func unmapProfileDir(dir string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	dir = filepath.Clean(dir)
+	if _, ok := config.ProfileDirs[dir]; !ok {
+		ui.Info("Directory '%s' is not mapped\n", dir)
+		return nil
+	}
+
+	for _, target := range config.Targets {
+		if err := engine.RemoveNestedSymlinks(target, dir); err != nil {
+			return fmt.Errorf("failed to remove outputs for target '%s' in %s: %w", target, dir, err)
+		}
+	}
+
+	delete(config.ProfileDirs, dir)
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	ui.Success("Unmapped %s\n", dir)
+	return nil
+}
+
+// sortedProfileDirs returns mapped directories in lexical order
+func sortedProfileDirs(config *Config) []string {
+	var dirs []string
+	for dir := range config.ProfileDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// assumeYes is set by --yes: confirmation prompts are answered yes, and init
+// continues past potential secrets in files public mode tracks
+var assumeYes bool
+
+// promptInput is where answers to confirmation prompts are read from
+var promptInput io.Reader = os.Stdin
+
+// stdinIsTerminal reports whether a user can answer prompts. Without a
+// terminal, as in scripts and CI, operations go ahead as they always did.
+var stdinIsTerminal = func() bool {
+	return isTerminal(os.Stdin)
+}
+
+// confirm shows what an operation is about to overwrite or delete and asks
+// whether to continue. It answers yes by itself with --yes, --dry-run or
+// when no one can answer.
+func confirm(question string, affected []string) (bool, error) {
+	if assumeYes || dryRun || !stdinIsTerminal() {
+		return true, nil
+	}
+	ui.Prompt("%s\n", question)
+	for _, path := range affected {
+		ui.Prompt("  - %s\n", path)
+	}
+	ui.Prompt("Continue? [y/N] ")
+
+	answer, err := bufio.NewReader(promptInput).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// errAborted is returned when the user declines a confirmation prompt
+var errAborted = fmt.Errorf("aborted")
+
+// requireConfirmation asks like confirm, but doesn't go ahead unattended:
+// when no one can answer, --yes is needed
+func requireConfirmation(question string, affected []string) error {
+	if !assumeYes && !dryRun && !stdinIsTerminal() {
+		return fmt.Errorf("%s %s\nRerun with --yes to continue", question, strings.Join(affected, ", "))
+	}
+	ok, err := confirm(question, affected)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errAborted
+	}
+	return nil
+}
+
+// confirmOverwrite asks before --force replaces outputs edited by hand or
+// files viberules didn't create
+func confirmOverwrite(config *Config) error {
+	statuses, err := collectStatus(config)
+	if err != nil {
+		return err
+	}
+	var affected []string
+	for _, status := range statuses {
+		if status.State == core.OutputModified || status.State == core.OutputUnmanaged {
+			affected = append(affected, fmt.Sprintf("%s (%s)", status.Path, status.State))
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+	ok, err := confirm("--force will overwrite these files:", affected)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errAborted
+	}
+	return nil
+}
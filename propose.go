@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	proposeRules     string
+	proposeSection   string
+	proposeBranch    string
+	proposeMessage   string
+	proposeWorkspace string
+	proposePush      bool
+	proposePR        bool
+)
+
+var proposeCmd = &cobra.Command{
+	Use:   "propose",
+	Short: "Commit a rules change on a new branch, ready for review",
+	Long: `Apply a rules change, regenerate outputs, and commit the result on a new
+branch, in this project or in every project of a workspace, so a rollout
+across many repositories is one command.
+
+The change is a whole rules file (--rules) or a section (--section) put in
+place the way policy sections are: a section starting with a heading
+replaces the part of the rules under the same heading, and any other
+section is appended. Each project must be in public mode, so its rules are
+committed, and have no uncommitted changes; it is left on the new branch.
+
+--workspace runs in every directory directly under the given one that is a
+viberules project; a failing project doesn't stop the others. --push pushes
+the branch to origin, and --pr also opens a pull request with the gh CLI,
+or a merge request with the glab CLI for GitLab remotes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return proposeChange()
+	},
+}
+
+// rulesChange is the change propose applies to a rules file
+type rulesChange struct {
+	rules   []byte // replaces the whole file when set
+	section []byte // put in place after that when set
+}
+
+// apply returns current with the change made
+func (c rulesChange) apply(current []byte) []byte {
+	if c.rules != nil {
+		current = c.rules
+	}
+	if c.section != nil {
+		current, _ = core.RequireSection(current, c.section)
+	}
+	return current
+}
+
+func proposeChange() error {
+	if proposeRules == "" && proposeSection == "" {
+		return fmt.Errorf("nothing to propose: give --rules or --section")
+	}
+	var change rulesChange
+	var err error
+	// The paths are relative to the working directory, not to the projects
+	if proposeRules != "" {
+		if change.rules, err = os.ReadFile(proposeRules); err != nil {
+			return fmt.Errorf("failed to read %s: %w", proposeRules, err)
+		}
+	}
+	if proposeSection != "" {
+		if change.section, err = os.ReadFile(proposeSection); err != nil {
+			return fmt.Errorf("failed to read %s: %w", proposeSection, err)
+		}
+	}
+	branch := proposeBranch
+	if branch == "" {
+		branch = "viberules/rules-update"
+	}
+	message := proposeMessage
+	if message == "" {
+		message = "Update AI assistant rules"
+	}
+
+	if proposeWorkspace == "" {
+		return proposeIn(change, branch, message)
+	}
+	workspace, err := filepath.Abs(proposeWorkspace)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(workspace)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace %s: %w", proposeWorkspace, err)
+	}
+	var projects, failed []string
+	for _, entry := range entries {
+		dir := filepath.Join(proposeWorkspace, entry.Name())
+		if entry.IsDir() && engine.IsProject(filepath.Join(workspace, entry.Name())) {
+			projects = append(projects, dir)
+		}
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("no viberules projects in %s", proposeWorkspace)
+	}
+	for _, dir := range projects {
+		ui.Info("📂 %s\n", dir)
+		restore := useEngine(newEngine(dir))
+		err := proposeIn(change, branch, message)
+		restore()
+		if err != nil {
+			ui.Warn("⚠️  %s: %v\n", dir, err)
+			failed = append(failed, filepath.Base(dir))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("propose failed in %d of %d project(s): %s", len(failed), len(projects), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// proposeIn commits change on branch in the project the engine works on
+func proposeIn(change rulesChange, branch, message string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	if config.Mode == "local" {
+		return fmt.Errorf("local mode keeps the rules out of git; run 'viberules mode public' to commit them")
+	}
+	if proposePush || proposePR {
+		if err := requireOnline(config, "propose --push"); err != nil {
+			return err
+		}
+	}
+	changes, err := core.GitChanges(projectDir())
+	if err != nil {
+		return fmt.Errorf("propose needs a git repository: %w", err)
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("there are uncommitted changes; commit or stash them first")
+	}
+	current, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", engine.RulesFile(), err)
+	}
+	proposed := change.apply(current)
+	if bytes.Equal(proposed, current) {
+		ui.Success("Rules already include this change\n")
+		return nil
+	}
+
+	if err := core.GitCreateBranch(projectDir(), branch); err != nil {
+		return err
+	}
+	if err := engine.FS().WriteFile(engine.RulesFile(), proposed, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", engine.RulesFile(), err)
+	}
+	if _, err := syncProject(); err != nil {
+		return err
+	}
+	commit, err := core.GitCommitAll(projectDir(), message)
+	if err != nil {
+		return err
+	}
+	ui.Success("Committed the rules change on branch %s (%s)\n", branch, pinLabel(core.LockedSource{Commit: commit}))
+
+	if !proposePush && !proposePR {
+		return nil
+	}
+	if err := core.GitPush(commandContext, projectDir(), branch); err != nil {
+		return err
+	}
+	ui.Success("Pushed %s to origin\n", branch)
+	if !proposePR {
+		return nil
+	}
+	url, err := core.OpenPullRequest(commandContext, projectDir(), branch, message, "Rules change prepared with `viberules propose`.")
+	if err != nil {
+		return err
+	}
+	ui.Success("Opened %s\n", url)
+	return nil
+}
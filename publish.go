@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	publishBranch  string
+	publishMessage string
+	publishBump    string
+	publishSign    bool
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Push this project's rules to the shared rules repository",
+	Long: `Commit this project's rules file as the rules file of the shared rules
+repository (shared.repo, shared.path) on a branch and push it, so an
+improvement made here can be reviewed and merged for every project.
+
+The branch starts from shared.branch and is named viberules/<project>
+unless --branch is given; publishing again adds a commit to it. Rules with
+conflict markers are refused, and so are rules with potential secrets unless
+--yes is given. Commits use your git identity.
+
+When the rules frontmatter has a version: field, it is bumped (patch level
+unless --bump says major or minor) in the published rules and in this
+project's rules file; see 'viberules changelog'.
+
+With --sign, or when signing.key is set in the user config, the rules are
+signed with minisign and the signature is committed next to them as
+<path>.minisig, for projects that trust the key to verify on pull.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return publishProject()
+	},
+}
+
+func publishProject() error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	if err := requireOnline(config, "publish"); err != nil {
+		return err
+	}
+	ref, err := config.Shared.ref()
+	if err != nil {
+		return err
+	}
+	if ref.OCI {
+		return fmt.Errorf("shared rules in an OCI registry have no branches to review; push them with 'viberules pack push %s %s'", engine.RulesFile(), ref)
+	}
+	rules, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", engine.RulesFile(), err)
+	}
+	if core.HasConflictMarkers(rules) {
+		return fmt.Errorf("%s has conflict markers; resolve them before publishing", engine.RulesFile())
+	}
+	// The shared repository is read by every team, so it must not leak credentials
+	if findings := core.ScanSecrets(engine.RulesFile(), rules); len(findings) > 0 {
+		ui.Print("🔍 Potential secrets in the rules to publish:\n")
+		for _, finding := range findings {
+			ui.Print("  - %s\n", finding)
+		}
+		if !assumeYes {
+			return fmt.Errorf("found %d potential secret(s). Remove them or use --yes to publish anyway", len(findings))
+		}
+		ui.Warn("⚠️  Continuing because of --yes\n")
+	}
+
+	project := filepath.Base(projectDir())
+	branch := publishBranch
+	if branch == "" {
+		branch = "viberules/" + project
+	}
+	message := publishMessage
+	if message == "" {
+		message = "Update shared rules from " + project
+	}
+
+	published := rules
+	version := core.RulesVersion(rules)
+	if version != "" {
+		// Rules published before are still on the branch with the bumped version
+		branchRef := ref
+		branchRef.Ref = branch
+		if current, err := newNetFetcher().Fetch(commandContext, branchRef); err == nil && bytes.Equal(current, rules) {
+			ui.Success("Branch %s of %s already has these rules\n", branch, ref.URL)
+			return nil
+		}
+		bumped, err := core.BumpVersion(version, publishBump)
+		if err != nil {
+			return err
+		}
+		published = core.SetRulesVersion(rules, bumped)
+		version = bumped
+	}
+
+	signature, err := signRules(config, published)
+	if err != nil {
+		return err
+	}
+	commit, err := core.PublishShared(commandContext, ref, published, signature, branch, message)
+	if err != nil {
+		return err
+	}
+	if commit == "" {
+		ui.Success("Branch %s of %s already has these rules\n", branch, ref.URL)
+		return nil
+	}
+	ui.Success("Published %s to branch %s of %s (%s)\n", engine.RulesFile(), branch, ref.URL, pinLabel(core.LockedSource{Commit: commit}))
+	ui.Info("Open a pull request from %s to share it with every project\n", branch)
+	if bytes.Equal(published, rules) {
+		return nil
+	}
+
+	if err := engine.FS().WriteFile(engine.RulesFile(), published, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", engine.RulesFile(), err)
+	}
+	ui.Info("🏷️  Bumped the rules version to %s\n", version)
+	_, err = syncProject()
+	return err
+}
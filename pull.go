@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// SharedRules is the team's central rules repository
+type SharedRules struct {
+	Repo   string `yaml:"repo,omitempty"`   // git repository URL, or oci:// reference of an artifact
+	Branch string `yaml:"branch,omitempty"` // branch to follow; the default branch if empty
+	Path   string `yaml:"path,omitempty"`   // rules file inside the repository (default rules.md)
+}
+
+// ref returns the reference of the shared rules file
+func (s SharedRules) ref() (core.RemoteRef, error) {
+	if s.Repo == "" {
+		return core.RemoteRef{}, fmt.Errorf("no shared rules repository configured (run 'viberules config set shared.repo <url>')")
+	}
+	if strings.HasPrefix(s.Repo, "oci://") {
+		return core.ParseRemoteRef(s.Repo)
+	}
+	if err := core.CheckGitURL(s.Repo); err != nil {
+		return core.RemoteRef{}, fmt.Errorf("invalid shared.repo: %w", err)
+	}
+	if strings.HasPrefix(s.Branch, "-") {
+		return core.RemoteRef{}, fmt.Errorf("invalid shared.branch: %s starts with '-'", s.Branch)
+	}
+	file := "rules.md"
+	if s.Path != "" {
+		file = path.Clean("/" + s.Path)[1:]
+	}
+	return core.RemoteRef{URL: s.Repo, Git: true, Path: file, Ref: s.Branch}, nil
+}
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Merge the team's shared rules into this project",
+	Long: `Fetch the rules file of the shared rules repository configured with
+shared.repo (and optionally shared.branch and shared.path) and merge it into
+this project's rules file, then regenerate outputs.
+
+Rules not changed here since the last pull are replaced by the shared
+version. Otherwise the two are merged section by section, keyed by heading:
+sections changed on only one side take that side's version, and sections
+changed on both sides get conflict markers to resolve before running sync.
+The commit merged last is recorded in .viberules/viberules.lock.
+
+shared.repo may also be an OCI artifact (oci://<registry>/<repository>:<tag>,
+see 'viberules pack push'); shared.branch and shared.path don't apply, and
+the manifest digest is recorded instead of a commit.
+
+When the user config lists signing.trusted_keys, the shared rules must carry
+a minisign signature (<path>.minisig, see 'viberules publish --sign') by one
+of those keys, or nothing is merged.`,
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return pullProject()
+	},
+}
+
+func pullProject() error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+	if err := requireOnline(config, "pull"); err != nil {
+		return err
+	}
+	ref, err := config.Shared.ref()
+	if err != nil {
+		return err
+	}
+	local, err := engine.FS().ReadFile(engine.RulesFile())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", engine.RulesFile(), err)
+	}
+	lock, err := core.LoadLockfile(engine.FS(), core.LockfilePath)
+	if err != nil {
+		return err
+	}
+
+	// Not netFetcher: a branch fetched earlier in the run may have moved since
+	fetcher := newNetFetcher()
+	keys, err := config.signing().publicKeys()
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		// The fetcher keeps what it fetched, so the verified rules are the ones merged
+		commit, err := core.VerifyShared(commandContext, fetcher, ref, keys)
+		if err != nil {
+			return fmt.Errorf("refusing to pull unverified rules: %w", err)
+		}
+		ui.Info("🔏 Verified the signature of %s (%s)\n", ref, pinLabel(core.LockedSource{Commit: commit}))
+	}
+	result, err := core.PullShared(commandContext, fetcher, ref, local, lock)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	if err := lock.Save(engine.FS(), core.LockfilePath); err != nil {
+		return err
+	}
+	if bytes.Equal(result.Content, local) {
+		ui.Success("Already up to date with %s\n", ref)
+		return nil
+	}
+	if err := engine.FS().WriteFile(engine.RulesFile(), result.Content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", engine.RulesFile(), err)
+	}
+	if result.Conflicts {
+		return fmt.Errorf("sections changed both here and in %s are marked with conflict markers in %s; resolve them and run 'viberules sync'", ref, engine.RulesFile())
+	}
+	ui.Success("Pulled shared rules from %s (%s)\n", ref, pinLabel(core.LockedSource{Commit: result.Commit}))
+
+	_, err = syncProject()
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// userRegistry holds the targets read from the user's registry file
+var userRegistry core.Registry
+
+var targetsCmd = &cobra.Command{
+	Use:   "targets",
+	Short: "Show the target registry",
+	Long: `Show every target viberules knows and the files it manages for it.
+
+Targets come from the built-in registry and from the user registry in
+~/.config/viberules/targets.yaml, written in the same format. A user target
+with the name of a built-in one replaces it. With --json, the whole registry
+is printed in that format, for both scopes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			return printJSON(engine.Registry().ToSlash())
+		}
+		return listRegistry()
+	},
+}
+
+// userRegistryPath returns the path of the user registry, next to the user config
+func userRegistryPath() (string, error) {
+	path, err := userConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "targets.yaml"), nil
+}
+
+// loadUserRegistry adds the targets of the user registry, if there is one,
+// to the built-in registry
+func loadUserRegistry() error {
+	path, err := userRegistryPath()
+	if err != nil {
+		return nil // no home directory, no user targets
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat user registry: %w", err)
+	}
+	const maxRegistrySize = 1 * 1024 * 1024 // 1MB
+	if info.Size() > maxRegistrySize {
+		return fmt.Errorf("user registry too large: %d bytes (max %d)", info.Size(), maxRegistrySize)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read user registry: %w", err)
+	}
+	registry, err := core.ParseRegistry(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	userRegistry = registry
+	engine.SetRegistry(core.BuiltinRegistry().Extend(registry))
+	return nil
+}
+
+// listRegistry prints the targets of the active scope with their outputs
+func listRegistry() error {
+	userTargets := userRegistry.Project.Targets
+	if engine.Scope() == core.ScopeGlobal {
+		userTargets = userRegistry.Global.Targets
+	}
+
+	ui.Print("Targets:\n")
+	for _, target := range engine.Targets() {
+		var outputs []string
+		for _, link := range target.Links {
+			outputs = append(outputs, filepath.ToSlash(link.Target))
+		}
+		source := "built-in"
+		for _, user := range userTargets {
+			if user.Name == target.Name {
+				source = "user"
+			}
+		}
+		ui.Print("  - %-10s %s (%s)\n", target.Name, strings.Join(outputs, ", "), source)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// jsonOutput is set by --json: list, status, targets and doctor print a stable JSON document
+// for editor plugins, scripts and CI instead of text
+var jsonOutput bool
+
+// listReport is the JSON form of list
+type listReport struct {
+	Enabled   []string          `json:"enabled"`
+	Available []string          `json:"available"`
+	UserLinks map[string]string `json:"user_links,omitempty"`
+
+	Groups map[string][]string `json:"groups"`
+}
+
+// statusReport is the JSON form of status for one project
+type statusReport struct {
+	Path         string         `json:"path,omitempty"` // relative to where status ran, with --recursive or --submodules
+	Mode         string         `json:"mode,omitempty"`
+	LinkStrategy string         `json:"link_strategy,omitempty"`
+	EnvOverrides []string       `json:"env_overrides,omitempty"`
+	Outputs      []outputReport `json:"outputs"`
+	Issues       int            `json:"issues"`
+	Inherited    []string       `json:"inherited,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// outputReport is the JSON form of one target output
+type outputReport struct {
+	Target   string `json:"target"`
+	Path     string `json:"path"`
+	Strategy string `json:"strategy"`
+	State    string `json:"state"`
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	ui.Data(string(data))
+	return nil
+}
+
+func listTargetsJSON() error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	report := listReport{
+		Enabled:   append([]string{}, config.Targets...),
+		Available: engine.TargetNames(),
+		UserLinks: config.UserLinks,
+		Groups:    make(map[string][]string),
+	}
+	for _, name := range config.groupNames() {
+		members, _ := config.targetGroup(name)
+		report.Groups[name] = append([]string{}, members...)
+	}
+	return printJSON(report)
+}
+
+// projectStatus returns the status report of the current project
+func projectStatus() (statusReport, error) {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return statusReport{}, err
+	}
+	statuses, err := collectStatus(config)
+	if err != nil {
+		return statusReport{}, err
+	}
+
+	report := statusReport{
+		Mode:         config.Mode,
+		LinkStrategy: config.linkStrategy(),
+		EnvOverrides: config.envOverrideNames(),
+		Outputs:      []outputReport{},
+		Inherited:    ancestorRules(),
+	}
+	if globalMode {
+		report.Mode = ""
+	}
+	for _, status := range statuses {
+		report.Outputs = append(report.Outputs, outputReport{
+			Target:   status.Target,
+			Path:     filepath.ToSlash(status.Path),
+			Strategy: status.Strategy,
+			State:    status.State.String(),
+		})
+		if status.State != core.OutputOK {
+			report.Issues++
+		}
+	}
+	return report, nil
+}
+
+// showStatusJSON prints the status of the current project, or with
+// --recursive or --submodules an array with every project
+func showStatusJSON() error {
+	if !multiProject() {
+		report, err := projectStatus()
+		if err != nil {
+			return err
+		}
+		return printJSON(report)
+	}
+
+	root := projectDir()
+	projects, _, err := projectDirs(root)
+	if err != nil {
+		return err
+	}
+	reports := []statusReport{}
+	for _, project := range projects {
+		rel, _ := filepath.Rel(root, project)
+		report := statusReport{Outputs: []outputReport{}}
+		if err := commandContext.Err(); err != nil {
+			report.Error = err.Error()
+		} else {
+			restore := useEngine(newEngine(project))
+			if report, err = projectStatus(); err != nil {
+				report = statusReport{Outputs: []outputReport{}, Error: err.Error()}
+			}
+			restore()
+		}
+		report.Path = filepath.ToSlash(rel)
+		reports = append(reports, report)
+	}
+	if err := printJSON(reports); err != nil {
+		return err
+	}
+	return commandContext.Err()
+}
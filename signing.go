@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// Signing configures minisign signatures of shared rules. It is read from the
+// user config only, so a project can't change which keys are trusted.
+type Signing struct {
+	Key         string   `yaml:"key,omitempty"`          // minisign secret key publish signs with
+	TrustedKeys []string `yaml:"trusted_keys,omitempty"` // minisign public keys pull accepts
+}
+
+// publicKeys parses the trusted keys
+func (s Signing) publicKeys() ([]core.PublicKey, error) {
+	var keys []core.PublicKey
+	for _, text := range s.TrustedKeys {
+		key, err := core.ParsePublicKey(text)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keyPath returns the secret key path with a leading ~ expanded
+func (s Signing) keyPath() string {
+	if rest, ok := strings.CutPrefix(s.Key, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return s.Key
+}
+
+// signing returns the signing settings of the user config
+func (c *Config) signing() Signing {
+	if c.user == nil {
+		return Signing{}
+	}
+	return c.user.Signing
+}
+
+// signRules signs the rules to publish when --sign is given or a signing key
+// is configured, and returns nil otherwise
+func signRules(config *Config, content []byte) ([]byte, error) {
+	signing := config.signing()
+	if !publishSign && signing.Key == "" {
+		return nil, nil
+	}
+	comment := fmt.Sprintf("viberules rules sha256:%s", core.Checksum(content))
+	signature, err := core.SignMinisign(commandContext, signing.keyPath(), content, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the rules: %w", err)
+	}
+	return signature, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sky1core/viberules/core"
+)
+
+// setRulesSource makes path the rules file every target links to, or restores
+// .viberules/rules.md when path is empty. The current rules file is moved there
+// unless the new path already exists, and outputs are recreated.
+func setRulesSource(path string) error {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return err
+	}
+
+	dest := core.DefaultRulesFile
+	if path != "" {
+		dest = filepath.Clean(path)
+	}
+	if err := engine.ValidateRulesFile(dest); err != nil {
+		return err
+	}
+	if dest == configFilePath {
+		return fmt.Errorf("source %q is managed by viberules", path)
+	}
+	current := engine.RulesFile()
+	if dest == current {
+		ui.Info("ℹ️  Source is already %s\n", dest)
+		return nil
+	}
+
+	// An active profile is a link, recreated below relative to the new location
+	profile, hasProfile := engine.ActiveProfile()
+	moved := !fileExists(dest)
+	if moved {
+		if err := engine.FS().MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", dest, err)
+		}
+		if hasProfile {
+			err = engine.FS().Remove(current)
+		} else {
+			err = engine.FS().Rename(current, dest)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", current, dest, err)
+		}
+	}
+
+	if dest == core.DefaultRulesFile {
+		config.Source = ""
+	} else {
+		config.Source = filepath.ToSlash(dest)
+	}
+	engine.SetRulesFile(config.Source)
+	if moved && hasProfile {
+		if err := engine.UseProfile(profile); err != nil {
+			return fmt.Errorf("failed to link profile %s: %w", profile, err)
+		}
+	}
+
+	for _, name := range config.Targets {
+		if err := createTargetOutputs(config, name, false); err != nil {
+			return fmt.Errorf("failed to relink outputs for target '%s': %w", name, err)
+		}
+	}
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+	if !globalMode {
+		if err := addToGitignore(); err != nil {
+			ui.Warn("⚠️  Failed to update .gitignore: %v\n", err)
+		}
+	}
+
+	if moved {
+		ui.Success("Moved %s to %s\n", current, dest)
+	} else {
+		ui.Success("Source set to existing %s (%s left untouched)\n", dest, current)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+// suggestName returns the candidate closest to a mistyped name, or "" if
+// none is close enough to be what the user meant
+func suggestName(name string, candidates []string) string {
+	best, bestDistance := "", 0
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if best == "" || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	// Allow one edit per three characters, so short names need a near match
+	if best == "" || bestDistance == 0 || bestDistance > max(1, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// swaps of adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	// rows[i][j] is the distance between the first i runes of a and j of b
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
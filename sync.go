@@ -0,0 +1,514 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// recursive is set by --recursive to run a command in every nested project
+var recursive bool
+
+// submodules is set by --submodules to also run a command in the projects of git submodules
+var submodules bool
+
+// multiProject reports whether the command runs in more than the current project
+func multiProject() bool {
+	return recursive || submodules
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of target outputs",
+	Long: `Show whether each enabled target's output is up to date.
+
+States:
+- ok: output matches .viberules/rules.md (or its generated form)
+- missing: output does not exist
+- broken: symlink is broken or points elsewhere
+- modified: copied file was edited by hand
+- stale: copied or generated file is out of date with .viberules/rules.md
+- unmanaged: a file exists that viberules did not create
+
+With --recursive, reports every nested viberules project below the current directory.
+With --submodules, also reports the projects of checked-out git submodules.
+With --json, prints the mode, strategy and outputs as JSON.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			return showStatusJSON()
+		}
+		return runProjects(showStatus, false)
+	},
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify target outputs (non-zero exit on problems)",
+	Long: `Verify that every enabled target's output is up to date.
+Only problems are printed; the command fails if any output needs attention.
+
+With --recursive, checks every nested viberules project below the current directory.
+With --submodules, also checks the projects of checked-out git submodules.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProjects(checkProject, true)
+	},
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Recreate outputs for enabled targets",
+	Long: `Recreate symlinks and re-copy or regenerate files for every enabled target.
+
+Copied files that were edited by hand are left untouched unless --force is given.
+With --recursive, repairs every nested viberules project below the current directory.
+With --submodules, also repairs the projects of checked-out git submodules;
+submodules without .viberules are skipped.`,
+	Args:        cobra.NoArgs,
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProjects(syncProject, false)
+	},
+}
+
+// projectOp runs a command in the current project and returns the number of issues found
+type projectOp func() (int, error)
+
+// runProjects runs op in the current project, or in every project found with
+// --recursive or --submodules. When failOnIssues is set, issues reported by
+// op make the command fail.
+func runProjects(op projectOp, failOnIssues bool) error {
+	if !multiProject() {
+		issues, err := op()
+		if err != nil {
+			return err
+		}
+		if failOnIssues && issues > 0 {
+			return core.WithKind(core.ErrDrift, fmt.Errorf("%d output(s) need attention. Run 'viberules sync' to repair", issues))
+		}
+		return nil
+	}
+
+	root := projectDir()
+	projects, skipped, err := projectDirs(root)
+	if err != nil {
+		return err
+	}
+
+	type result struct {
+		path   string
+		issues int
+		err    error
+	}
+	var results []result
+	for _, project := range projects {
+		rel, _ := filepath.Rel(root, project)
+		if err := commandContext.Err(); err != nil {
+			results = append(results, result{path: rel, err: err})
+			continue
+		}
+		ui.Info("📦 %s\n", rel)
+
+		restore := useEngine(newEngine(project))
+		issues, err := op()
+		restore()
+		results = append(results, result{path: rel, issues: issues, err: err})
+		ui.Info("\n")
+	}
+
+	ui.Info("Summary:\n")
+	var failed []string
+	code := exitDrift // when every project failed, the code of its first error instead
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			ui.Print("  ❌ %s: %v\n", paint(colorRed, r.path), r.err)
+			if code == exitDrift {
+				code = exitCode(r.err)
+			}
+			failed = append(failed, r.path)
+		case r.issues > 0:
+			ui.Print("  ⚠️  %s: %d issue(s)\n", paint(colorYellow, r.path), r.issues)
+			if failOnIssues {
+				failed = append(failed, r.path)
+			}
+		default:
+			ui.Info("  ✅ %s\n", paint(colorGreen, r.path))
+		}
+	}
+	for _, path := range skipped {
+		rel, _ := filepath.Rel(root, path)
+		ui.Info("  - %s: no viberules project, skipped\n", rel)
+	}
+
+	if len(failed) > 0 {
+		if len(failed) < len(results) {
+			code = exitPartialFailure
+		}
+		if commandContext.Err() != nil {
+			code = exitCanceled
+		}
+		return withExitCode(code, fmt.Errorf("%d of %d project(s) failed: %s", len(failed), len(results), strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+// projectDirs returns the projects below root a command runs in: every
+// nested project with --recursive, else root itself, and with --submodules
+// the projects of checked-out submodules. Submodules with no project are
+// returned as skipped.
+func projectDirs(root string) ([]string, []string, error) {
+	var projects []string
+	if recursive {
+		found, err := engine.FindNestedProjects(commandContext, root)
+		if err != nil {
+			return nil, nil, err
+		}
+		projects = found
+	} else if engine.IsProject(root) {
+		projects = []string{root}
+	}
+
+	var skipped []string
+	if submodules {
+		found, err := core.FindSubmodules(commandContext, root)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, dir := range found {
+			switch {
+			case engine.IsProject(dir):
+				if !containsName(projects, dir) {
+					projects = append(projects, dir)
+				}
+			case !containsProjectBelow(projects, dir):
+				skipped = append(skipped, dir)
+			}
+		}
+	}
+
+	if len(projects) == 0 {
+		return nil, nil, core.WithKind(core.ErrNotInitialized, fmt.Errorf("no viberules projects found below %s", root))
+	}
+	return projects, skipped, nil
+}
+
+// containsProjectBelow reports whether a project lies below dir, as nested
+// projects of a submodule found by --recursive do
+func containsProjectBelow(projects []string, dir string) bool {
+	for _, project := range projects {
+		if strings.HasPrefix(project, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// targetOutputStatus pairs an output status with the strategy that produced it
+type targetOutputStatus struct {
+	core.OutputStatus
+	Strategy string
+}
+
+// collectStatus checks the outputs of every enabled target
+func collectStatus(config *Config) ([]targetOutputStatus, error) {
+	var all []targetOutputStatus
+	for _, target := range config.Targets {
+		strategy := config.strategyFor(target)
+		statuses, err := engine.CheckTargetOutputs(target, strategy, config.pipelineFor(target, strategy), config.Checksums)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			all = append(all, targetOutputStatus{OutputStatus: status, Strategy: strategy})
+		}
+
+		// Personal rules are linked unless they are merged into generated outputs
+		if strategy != core.StrategyGenerate {
+			local, err := engine.CheckLocalSymlinks(target)
+			if err != nil {
+				return nil, err
+			}
+			for _, status := range local {
+				all = append(all, targetOutputStatus{OutputStatus: status, Strategy: core.StrategySymlink})
+			}
+		}
+
+		// Outputs in directories mapped to profiles are always symlinks
+		for _, dir := range sortedProfileDirs(config) {
+			nested, err := engine.CheckNestedSymlinks(target, dir, core.ProfilePath(config.ProfileDirs[dir]))
+			if err != nil {
+				return nil, err
+			}
+			for _, status := range nested {
+				all = append(all, targetOutputStatus{OutputStatus: status, Strategy: core.StrategySymlink})
+			}
+		}
+	}
+
+	ignored, err := collectIgnoreStatus(config)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := collectMCPStatus(config)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := collectPromptDirStatus(config, core.GetCommandTargets(), "command")
+	if err != nil {
+		return nil, err
+	}
+	agents, err := collectPromptDirStatus(config, core.GetAgentTargets(), "agent")
+	if err != nil {
+		return nil, err
+	}
+	settings, err := collectClaudeSettingsStatus(config)
+	if err != nil {
+		return nil, err
+	}
+	all = append(all, ignored...)
+	all = append(all, servers...)
+	all = append(all, commands...)
+	all = append(all, agents...)
+	return append(all, settings...), nil
+}
+
+// requireRulesFile fails unless the project's rules file exists
+func requireRulesFile() error {
+	_, err := loadInitializedConfig()
+	return err
+}
+
+func loadInitializedConfig() (*Config, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if !fileExists(engine.RulesFile()) {
+		return nil, core.WithKind(core.ErrNotInitialized, fmt.Errorf("%s not found. Run 'viberules init' first", engine.RulesFile()))
+	}
+	return config, nil
+}
+
+func showStatus() (int, error) {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	statuses, err := collectStatus(config)
+	if err != nil {
+		return 0, err
+	}
+
+	ui.Print("Link strategy: %s\n", config.linkStrategy())
+	if vars := config.envOverrideNames(); len(vars) > 0 {
+		ui.Print("Overridden by environment: %s\n", strings.Join(vars, ", "))
+	}
+	ui.Print("\n")
+
+	issues := 0
+	for _, status := range statuses {
+		if status.State == core.OutputOK {
+			ui.Print("  ✅ %s (%s, %s)\n", paint(colorGreen, status.Path), status.Target, status.Strategy)
+			continue
+		}
+		issues++
+		ui.Print("  ⚠️  %s (%s, %s): %s\n", status.Path, status.Target, status.Strategy, paintState(status.State))
+	}
+
+	if content, err := engine.FS().ReadFile(engine.RulesFile()); err == nil {
+		for _, target := range config.Targets {
+			if config.strategyFor(target) == core.StrategyGenerate {
+				continue
+			}
+			if core.HasIncludes(content) {
+				ui.Print("\nℹ️  %s uses include directives; they are only expanded by the generate strategy\n", engine.RulesFile())
+			}
+			if core.HasConditionals(content) {
+				ui.Print("\nℹ️  %s has per-target sections; they are only filtered by the generate strategy\n", engine.RulesFile())
+			}
+			break
+		}
+	}
+	if notes, _ := engine.Notes(); notes != nil {
+		// Personal rules files that import the notes make up for the strategy
+		var unseen []string
+		for _, target := range config.Targets {
+			t, ok := engine.FindTarget(target)
+			if config.strategyFor(target) != core.StrategyGenerate && (!ok || !t.Imports || len(t.LocalLinks) == 0) {
+				unseen = append(unseen, target)
+			}
+		}
+		if len(unseen) > 0 {
+			ui.Print("\nℹ️  %s is only merged by the generate strategy; %s don't see it\n", core.NotesFile, strings.Join(unseen, ", "))
+		}
+	}
+
+	// Targets that load a whole directory get rules directory files as links of their own
+	if files, _ := engine.RulesDirFiles(); len(files) > 0 {
+		for _, target := range config.Targets {
+			t, ok := engine.FindTarget(target)
+			if !ok || t.PartsDir != "" || config.strategyFor(target) == core.StrategyGenerate {
+				continue
+			}
+			ui.Print("\nℹ️  %s/*.md and rule packs are only concatenated by the generate strategy\n", core.RulesDir)
+			break
+		}
+	}
+
+	for _, target := range config.Targets {
+		if strategy := config.strategyFor(target); strategy != core.StrategyGenerate {
+			for _, path := range engine.TargetFiles(target) {
+				ui.Print("\nℹ️  %s is only applied by the generate strategy (%s uses %s)\n", path, target, strategy)
+			}
+		}
+	}
+
+	for _, warning := range budgetWarnings(config) {
+		ui.Print("\n%s", warning)
+	}
+
+	if inherited := ancestorRules(); len(inherited) > 0 {
+		ui.Print("\nInherited rules:\n")
+		for _, path := range inherited {
+			ui.Print("  - %s\n", path)
+		}
+		if !config.inherits() {
+			ui.Print("  (disabled by 'inherit: false')\n")
+		} else {
+			ui.Print("  (merged into outputs that use the generate strategy)\n")
+		}
+	}
+
+	if issues > 0 && !multiProject() {
+		ui.Print("\nRun 'viberules sync' to repair outputs\n")
+	}
+
+	return issues, nil
+}
+
+func checkProject() (int, error) {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	statuses, err := collectStatus(config)
+	if err != nil {
+		return 0, err
+	}
+
+	issues := 0
+	for _, status := range statuses {
+		if status.State != core.OutputOK {
+			issues++
+			ui.Print("  ⚠️  %s (%s, %s): %s\n", status.Path, status.Target, status.Strategy, paintState(status.State))
+		}
+	}
+	for _, violation := range append(config.policyViolations(), config.policySectionViolations()...) {
+		issues++
+		ui.Print("  ⛔ %s\n", paint(colorRed, violation))
+	}
+	for _, path := range staleIgnoreFiles(config) {
+		issues++
+		ui.Print("  ⚠️  %s (viberules section): %s\n", path, paintState(core.OutputStale))
+	}
+	// The committed manifest is the setup the project should have
+	drift, err := manifestDrift(config)
+	if err != nil {
+		return issues, err
+	}
+	for _, change := range drift {
+		issues++
+		ui.Print("  ⚠️  %s differs: %s (run 'viberules apply')\n", manifestFile, change)
+	}
+	// Public mode commits the rules, so they must not leak credentials
+	if config.Mode == "public" && !globalMode {
+		findings, err := engine.ScanPublicFiles()
+		if err != nil {
+			return issues, err
+		}
+		for _, finding := range findings {
+			issues++
+			ui.Print("  ⛔ %s\n", paint(colorRed, "potential secret: "+finding.String()))
+		}
+	}
+
+	if issues == 0 {
+		ui.Success("All outputs are up to date\n")
+	}
+	return issues, nil
+}
+
+func syncProject() (int, error) {
+	config, err := loadInitializedConfig()
+	if err != nil {
+		return 0, err
+	}
+	if force {
+		if err := confirmOverwrite(config); err != nil {
+			return 0, err
+		}
+	}
+
+	// Outputs are generated from rules that hold the mandated sections
+	if err := enforcePolicySections(config); err != nil {
+		ui.Warn("⚠️  %v\n", err)
+	}
+
+	var failed []string
+	offline := false // a target needs remote content that isn't cached
+	for _, target := range config.Targets {
+		if err := createTargetOutputs(config, target, force); err != nil {
+			ui.Warn("⚠️  %s: %v\n", target, err)
+			failed = append(failed, target)
+			offline = offline || errors.Is(err, core.ErrOffline)
+			continue
+		}
+		reportOutputs(config, target)
+	}
+	for _, tool := range config.IgnoreTools {
+		if err := writeIgnoreFile(config, tool, force); err != nil {
+			ui.Warn("⚠️  %s: %v\n", tool, err)
+			failed = append(failed, tool)
+		}
+	}
+
+	if err := saveConfig(config); err != nil {
+		return len(failed), err
+	}
+
+	// Keep the gitignore section in step with moved outputs, dropping entries
+	// left by targets or file names that changed since it was written
+	if !globalMode && hasGitignoreSection() {
+		stale, err := updateGitignore()
+		if err != nil {
+			ui.Warn("⚠️  Failed to update .gitignore: %v\n", err)
+		} else if len(stale) > 0 {
+			ui.Info("🧹 Removed obsolete ignore entries: %s\n", strings.Join(stale, ", "))
+		}
+	}
+	if err := updateGitattributes(config); err != nil {
+		ui.Warn("⚠️  Failed to update %s: %v\n", gitattributesPath, err)
+	}
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("failed to sync targets: %s (use --force to overwrite modified files)", strings.Join(failed, ", "))
+		if offline {
+			err = core.WithKind(core.ErrOffline, fmt.Errorf("failed to sync targets: %s (sync once with network access to cache their remote content)", strings.Join(failed, ", ")))
+		}
+		if len(failed) < len(config.Targets)+len(config.IgnoreTools) {
+			err = withExitCode(exitPartialFailure, err)
+		}
+		return len(failed), err
+	}
+
+	ui.Success("Synced %d target(s)\n", len(config.Targets))
+	return 0, nil
+}
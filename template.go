@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// initTemplate is set by init --template to seed rules.md from a template
+var initTemplate string
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage templates for 'viberules init --template'",
+	Long: `Manage the templates 'viberules init --template <name>' seeds rules.md from.
+
+Built-in templates ship with viberules. User templates live in
+~/.viberules/templates/<name>.md and replace built-in templates of the same name.`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listTemplates()
+	},
+}
+
+var templatesAddCmd = &cobra.Command{
+	Use:         "add [name] [file]",
+	Short:       "Register a file as a user template",
+	Args:        cobra.ExactArgs(2),
+	Annotations: journaled,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addTemplate(args[0], args[1])
+	},
+}
+
+// rulesTemplateContent returns the initial content of rules.md
+func rulesTemplateContent() ([]byte, error) {
+	if initTemplate == "" && globalMode {
+		return []byte(globalRulesContent), nil
+	}
+
+	name := initTemplate
+	if name == "" {
+		name = core.DefaultTemplate
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// Only a named template may be a user template; the default is built in
+		if initTemplate != "" {
+			return nil, fmt.Errorf("failed to find home directory: %w", err)
+		}
+		home = ""
+	}
+	return engine.LoadTemplate(home, name)
+}
+
+func listTemplates() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find home directory: %w", err)
+	}
+	templates, err := engine.ListTemplates(home)
+	if err != nil {
+		return err
+	}
+
+	ui.Print("Templates:\n")
+	for _, template := range templates {
+		source := "built-in"
+		if !template.Builtin() {
+			source = template.Path
+		}
+		ui.Print("  - %-12s %s (%s)\n", template.Name, template.Description, source)
+	}
+	return nil
+}
+
+func addTemplate(name, file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	// A generated output makes a fine template, minus its banner and provenance
+	banner := ""
+	if config, err := loadConfig(); err == nil {
+		banner = config.Banner
+	}
+	content = engine.StripBanner(core.StripProvenance(content), banner)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find home directory: %w", err)
+	}
+	if err := engine.AddTemplate(home, name, content); err != nil {
+		return err
+	}
+
+	ui.Success("Template '%s' registered. Use it with 'viberules init --template %s'\n", name, name)
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sky1core/viberules/core"
+	"gopkg.in/yaml.v3"
+)
+
+// UserConfig holds personal defaults shared by every project, read from
+// $XDG_CONFIG_HOME/viberules/config.yaml (~/.config/viberules/config.yaml).
+// Project config takes precedence; the user config only fills what it leaves unset.
+type UserConfig struct {
+	Targets      []string `yaml:"targets,omitempty"`       // targets init enables
+	LinkStrategy string   `yaml:"link_strategy,omitempty"` // strategy of projects that don't set one
+	GitExclude   bool     `yaml:"git_exclude,omitempty"`   // keep local mode ignore rules in .git/info/exclude
+	Trash        bool     `yaml:"trash,omitempty"`         // move files edited by hand to the trash instead of deleting them
+	Offline      bool     `yaml:"offline,omitempty"`       // use only cached remote content
+	CacheTTL     string   `yaml:"cache_ttl,omitempty"`     // how long fetched remote content is reused, such as 30m
+
+	BlockedTargets  []string `yaml:"blocked_targets,omitempty"`  // targets no project may enable
+	RequiredTargets []string `yaml:"required_targets,omitempty"` // targets check requires every project to enable
+	Policy          Policy   `yaml:"policy,omitempty"`           // rules sections every project must contain
+	Signing         Signing  `yaml:"signing,omitempty"`          // keys that sign and verify shared rules
+
+	Auth map[string]HostAuth `yaml:"auth,omitempty"` // credentials of private hosts, by host name
+
+	Groups    map[string][]string `yaml:"groups,omitempty"`     // named target sets, available in every project
+	PackIndex string              `yaml:"pack_index,omitempty"` // index of rule packs for projects that don't set one
+	Output    string              `yaml:"output,omitempty"`     // auto, plain or rich
+}
+
+// userConfigPath returns the path of the user config, following the XDG base directory spec
+func userConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "viberules", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "viberules", "config.yaml"), nil
+}
+
+// loadUserConfig reads the user config, returning an empty one if it doesn't exist
+func loadUserConfig() (*UserConfig, error) {
+	path, err := userConfigPath()
+	if err != nil {
+		return &UserConfig{}, nil // no home directory, no user defaults
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return &UserConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat user config: %w", err)
+	}
+	const maxConfigSize = 1 * 1024 * 1024 // 1MB
+	if info.Size() > maxConfigSize {
+		return nil, fmt.Errorf("user config too large: %d bytes (max %d)", info.Size(), maxConfigSize)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user config: %w", err)
+	}
+	var user UserConfig
+	if err := yaml.Unmarshal(content, &user); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if user.LinkStrategy != "" && !core.IsValidStrategy(user.LinkStrategy) {
+		return nil, fmt.Errorf("invalid link_strategy in %s: %s", path, user.LinkStrategy)
+	}
+	if user.Output != "" && !isValidOutputStyle(user.Output) {
+		return nil, fmt.Errorf("invalid output in %s: %s (must be 'auto', 'plain' or 'rich')", path, user.Output)
+	}
+	for _, names := range [][]string{user.Targets, user.BlockedTargets, user.RequiredTargets} {
+		for _, name := range names {
+			if !isProjectTarget(name) {
+				return nil, fmt.Errorf("invalid target in %s: %s", path, name)
+			}
+		}
+	}
+	for _, name := range user.RequiredTargets {
+		if containsName(user.BlockedTargets, name) {
+			return nil, fmt.Errorf("target in %s is both blocked and required: %s", path, name)
+		}
+	}
+	if user.CacheTTL != "" {
+		if _, err := parseCacheTTL(user.CacheTTL); err != nil {
+			return nil, fmt.Errorf("%w in %s", err, path)
+		}
+	}
+	for host, auth := range user.Auth {
+		if err := auth.validate(); err != nil {
+			return nil, fmt.Errorf("invalid auth.%s in %s: %w", host, path, err)
+		}
+	}
+	if _, err := user.Signing.publicKeys(); err != nil {
+		return nil, fmt.Errorf("invalid signing.trusted_keys in %s: %w", path, err)
+	}
+	for group, members := range user.Groups {
+		if isAnyTarget(group) {
+			return nil, fmt.Errorf("group in %s has the name of a target: %s", path, group)
+		}
+		for _, name := range members {
+			if !isAnyTarget(name) {
+				return nil, fmt.Errorf("invalid target in group %s in %s: %s", group, path, name)
+			}
+		}
+	}
+	return &user, nil
+}
+
+// isProjectTarget reports whether name is a project-level target, whatever the active scope
+func isProjectTarget(name string) bool {
+	for _, target := range engine.GetAllTargets() {
+		if target.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyUserConfig fills what the project config leaves unset with the user's defaults
+func applyUserConfig(config *Config, user *UserConfig, fromFile bool) {
+	config.user = user
+	// User targets are project targets; global mode keeps its own defaults
+	if !fromFile && len(user.Targets) > 0 && engine.Scope() == core.ScopeProject {
+		config.Targets = append([]string(nil), user.Targets...)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"github.com/spf13/cobra"
+)
+
+// userLink is set by --user on link/remove to manage user-level entries
+var userLink bool
+
+var linkCmd = &cobra.Command{
+	Use:   "link --user [target]",
+	Short: "Link project rules into a user-level tool directory",
+	Long: `Link this project's rules into the user-level directory of a tool that
+only reads global configuration. The entry points back at .viberules/rules.md
+and is removed again with 'viberules remove --user [target]'.
+
+User link targets:
+- cline: ~/Documents/Cline/Rules/
+- roo: ~/.roo/rules/
+- continue: ~/.continue/rules/`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !userLink {
+			return fmt.Errorf("link requires --user")
+		}
+		return linkUserTarget(args[0])
+	},
+}
+
+func userLinkTargetNames() string {
+	var names []string
+	for _, target := range core.GetUserLinkTargets() {
+		names = append(names, target.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func linkUserTarget(target string) error {
+	if globalMode {
+		return fmt.Errorf("link --user is not supported with --global")
+	}
+	if _, ok := core.FindUserLinkTarget(target); !ok {
+		return core.WithKind(core.ErrUnknownTarget, fmt.Errorf("invalid user link target: %s (available: %s)", target, userLinkTargetNames()))
+	}
+
+	if err := requireRulesFile(); err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find home directory: %w", err)
+	}
+	projectRoot := projectDir()
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	linkPath, err := engine.CreateUserLink(home, target, projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to link rules for '%s': %w", target, err)
+	}
+
+	if config.UserLinks == nil {
+		config.UserLinks = make(map[string]string)
+	}
+	config.UserLinks[target] = linkPath
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	ui.Success("Linked rules for '%s': %s\n", target, linkPath)
+	return nil
+}
+
+func unlinkUserTarget(target string) error {
+	if _, ok := core.FindUserLinkTarget(target); !ok {
+		return core.WithKind(core.ErrUnknownTarget, fmt.Errorf("invalid user link target: %s (available: %s)", target, userLinkTargetNames()))
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	linkPath, ok := config.UserLinks[target]
+	if !ok {
+		ui.Info("User link '%s' is not enabled\n", target)
+		return nil
+	}
+
+	if err := engine.RemoveUserLink(linkPath); err != nil {
+		return fmt.Errorf("failed to remove user link for '%s': %w", target, err)
+	}
+
+	delete(config.UserLinks, target)
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	ui.Success("User link '%s' removed successfully\n", target)
+	return nil
+}
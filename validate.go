@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sky1core/viberules/core"
+	"gopkg.in/yaml.v3"
+)
+
+// configProblem is a config value viberules can't use
+type configProblem struct {
+	path    []string // YAML path of the offending key or list item
+	message string
+}
+
+// problems reports every config value viberules can't use
+func (c *Config) problems() []configProblem {
+	var problems []configProblem
+	add := func(message string, path ...string) {
+		problems = append(problems, configProblem{path: path, message: message})
+	}
+
+	if c.Mode != "public" && c.Mode != "local" {
+		add(fmt.Sprintf("invalid mode: %s (must be 'public' or 'local')", c.Mode), "mode")
+	}
+	switch {
+	case c.LinkStrategy == core.StrategyPointer:
+		add("the pointer strategy is set per target (target_settings.<target>.link_strategy)", "link_strategy")
+	case c.LinkStrategy != "" && !core.IsValidStrategy(c.LinkStrategy):
+		add(fmt.Sprintf("invalid link_strategy: %s (must be 'symlink', 'copy' or 'generate')", c.LinkStrategy), "link_strategy")
+	}
+
+	if c.Output != "" && !isValidOutputStyle(c.Output) {
+		add(fmt.Sprintf("invalid output: %s (must be 'auto', 'plain' or 'rich')", c.Output), "output")
+	}
+
+	if c.CacheTTL != "" {
+		if _, err := parseCacheTTL(c.CacheTTL); err != nil {
+			add(err.Error(), "cache_ttl")
+		}
+	}
+
+	if c.Source != "" {
+		if err := engine.ValidateRulesFile(c.Source); err != nil {
+			add(err.Error(), "source")
+		} else if filepath.Clean(c.Source) == configFilePath {
+			add(fmt.Sprintf("source %q is managed by viberules", c.Source), "source")
+		}
+	}
+
+	for i, name := range c.Targets {
+		item := strconv.Itoa(i)
+		if !isValidTarget(name) {
+			add(fmt.Sprintf("invalid target: %s (available: %s)", name, strings.Join(engine.TargetNames(), ", ")), "targets", item)
+		} else if containsName(c.Targets[:i], name) {
+			add(fmt.Sprintf("duplicate target: %s", name), "targets", item)
+		}
+	}
+
+	for _, key := range []string{"blocked_targets", "required_targets"} {
+		list := c.BlockedTargets
+		if key == "required_targets" {
+			list = c.RequiredTargets
+		}
+		for i, name := range list {
+			switch {
+			case !isValidTarget(name):
+				add(fmt.Sprintf("invalid target: %s (available: %s)", name, strings.Join(engine.TargetNames(), ", ")), key, strconv.Itoa(i))
+			case key == "required_targets" && containsName(c.blockedTargets(), name):
+				add(fmt.Sprintf("target %s is both blocked and required", name), key, strconv.Itoa(i))
+			}
+		}
+	}
+
+	var groups []string
+	for name := range c.Groups {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if isAnyTarget(group) {
+			add(fmt.Sprintf("group %s has the name of a target", group), "groups", group)
+			continue
+		}
+		for i, name := range c.Groups[group] {
+			if !isAnyTarget(name) {
+				add(fmt.Sprintf("invalid target in group %s: %s (available: %s)", group, name, strings.Join(engine.TargetNames(), ", ")), "groups", group, strconv.Itoa(i))
+			}
+		}
+	}
+
+	var names []string
+	for name := range c.TargetSettings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		settings := c.TargetSettings[name]
+		if !isValidTarget(name) {
+			add(fmt.Sprintf("invalid target in target_settings: %s (available: %s)", name, strings.Join(engine.TargetNames(), ", ")), "target_settings", name)
+			continue
+		}
+		if settings.LinkStrategy != "" && !core.IsValidStrategy(settings.LinkStrategy) {
+			add(fmt.Sprintf("invalid link_strategy for %s: %s (must be 'symlink', 'copy', 'generate' or 'pointer')", name, settings.LinkStrategy), "target_settings", name, "link_strategy")
+		}
+		if settings.LinkStrategy == core.StrategyPointer {
+			if t, _ := engine.FindTarget(name); !t.Imports {
+				add(fmt.Sprintf("%s does not support @imports; the pointer strategy is not available", name), "target_settings", name, "link_strategy")
+			}
+		}
+		if settings.TrackOutput && settings.LinkStrategy == core.StrategySymlink {
+			add(fmt.Sprintf("track_output needs a file, not a symlink, for %s (use the copy, generate or pointer strategy)", name), "target_settings", name, "track_output")
+		}
+		if settings.Overflow != "" && !core.IsValidOverflow(settings.Overflow) {
+			add(fmt.Sprintf("invalid overflow for %s: %s (must be 'warn', 'truncate' or 'split')", name, settings.Overflow), "target_settings", name, "overflow")
+		}
+		if settings.TokenBudget < 0 {
+			add(fmt.Sprintf("invalid token_budget for %s: %d (must be positive)", name, settings.TokenBudget), "target_settings", name, "token_budget")
+		}
+		if settings.Output != "" {
+			if err := engine.ValidateOutputPath(name, settings.Output); err != nil {
+				add(err.Error(), "target_settings", name, "output")
+			}
+		}
+	}
+
+	for i, tool := range c.IgnoreTools {
+		if _, ok := core.FindIgnoreTool(tool); !ok {
+			add(fmt.Sprintf("invalid ignore tool: %s (available: %s)", tool, ignoreToolNames()), "ignore_tools", strconv.Itoa(i))
+		}
+	}
+	return problems
+}
+
+// validate reports the first config value viberules can't use
+func (c *Config) validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return errors.New(problems[0].message)
+	}
+	return nil
+}
+
+// checkConfigFile reports unknown keys and invalid values of a parsed config
+// file, each with the line it appears on
+func checkConfigFile(path string, doc *yaml.Node, config *Config) error {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	return checkYAMLFile(path, doc.Content[0], reflect.TypeOf(Config{}), config.problems())
+}
+
+// checkYAMLFile reports the keys of root that typ has no field for and
+// problems, each with the line it appears on
+func checkYAMLFile(path string, root *yaml.Node, typ reflect.Type, problems []configProblem) error {
+	found := unknownConfigKeys(root, typ, "")
+	for _, problem := range problems {
+		found = append(found, lineProblem{line: nodeLine(root, problem.path), message: problem.message})
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].line < found[j].line })
+	var lines []string
+	for _, problem := range found {
+		lines = append(lines, fmt.Sprintf("line %d: %s", problem.line, problem.message))
+	}
+	return fmt.Errorf("invalid %s:\n  %s\nFix the file and run the command again", path, strings.Join(lines, "\n  "))
+}
+
+// lineProblem is a problem found at a line of the config file
+type lineProblem struct {
+	line    int
+	message string
+}
+
+// unknownConfigKeys reports keys of a mapping node that typ has no field for,
+// recursing into known sections
+func unknownConfigKeys(node *yaml.Node, typ reflect.Type, prefix string) []lineProblem {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var problems []lineProblem
+	switch typ.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		var known []string
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if field.IsExported() && tag != "" && tag != "-" {
+				fields[tag] = field.Type
+				known = append(known, tag)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, lineProblem{
+					line:    key.Line,
+					message: fmt.Sprintf("unknown key: %s%s (known keys: %s)", prefix, key.Value, strings.Join(known, ", ")),
+				})
+				continue
+			}
+			problems = append(problems, unknownConfigKeys(value, fieldType, prefix+key.Value+".")...)
+		}
+	case reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			problems = append(problems, unknownConfigKeys(value, typ.Elem(), prefix+key.Value+".")...)
+		}
+	}
+	return problems
+}
+
+// nodeLine returns the line of the key or item at path, or of its closest
+// enclosing key when it can't be found
+func nodeLine(node *yaml.Node, path []string) int {
+	line := node.Line
+	for _, name := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == name {
+					line = node.Content[i].Line
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(name); err == nil && i < len(node.Content) {
+				next = node.Content[i]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}